@@ -0,0 +1,93 @@
+package calendar
+
+import (
+	"fmt"
+	"time"
+)
+
+const (
+	dateLayout   = "2006-01-02"
+	annualLayout = "01-02"
+)
+
+type Calendar struct {
+	loc    *time.Location
+	dates  map[string]bool
+	annual map[string]bool
+}
+
+func New(timeZone string, holidays []string) (*Calendar, error) {
+	loc := time.UTC
+	if timeZone != "" {
+		l, err := time.LoadLocation(timeZone)
+		if err != nil {
+			return nil, fmt.Errorf("unknown time_zone %q", timeZone)
+		}
+		loc = l
+	}
+
+	c := &Calendar{loc: loc, dates: make(map[string]bool), annual: make(map[string]bool)}
+	for _, h := range holidays {
+		if _, err := time.Parse(dateLayout, h); err == nil {
+			if c.dates[h] {
+				return nil, fmt.Errorf("holiday %s is listed more than once", h)
+			}
+			c.dates[h] = true
+			continue
+		}
+		if _, err := time.Parse(annualLayout, h); err == nil {
+			if c.annual[h] {
+				return nil, fmt.Errorf("holiday %s is listed more than once", h)
+			}
+			c.annual[h] = true
+			continue
+		}
+		return nil, fmt.Errorf("holiday %q is neither YYYY-MM-DD nor MM-DD", h)
+	}
+	return c, nil
+}
+
+func (c *Calendar) Location() *time.Location {
+	if c == nil {
+		return time.UTC
+	}
+	return c.loc
+}
+
+func (c *Calendar) IsHoliday(t time.Time) bool {
+	if c == nil {
+		return false
+	}
+	local := t.In(c.loc)
+	return c.dates[local.Format(dateLayout)] || c.annual[local.Format(annualLayout)]
+}
+
+func (c *Calendar) IsBusinessDay(t time.Time) bool {
+	if c == nil {
+		return true
+	}
+	switch t.In(c.loc).Weekday() {
+	case time.Saturday, time.Sunday:
+		return false
+	}
+	return !c.IsHoliday(t)
+}
+
+func (c *Calendar) AddBusinessDays(from time.Time, days int) time.Time {
+	if c == nil {
+		return from.AddDate(0, 0, days)
+	}
+	if days <= 0 {
+		return from
+	}
+
+	local := from.In(c.loc)
+	day := local
+	for counted := 0; counted < days; {
+		day = time.Date(day.Year(), day.Month(), day.Day()+1, local.Hour(), local.Minute(), local.Second(), local.Nanosecond(), c.loc)
+		if c.IsBusinessDay(day) {
+			counted++
+		}
+	}
+	return day.In(from.Location())
+}
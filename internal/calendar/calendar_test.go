@@ -0,0 +1,97 @@
+package calendar
+
+import (
+	"testing"
+	"time"
+)
+
+func brazil(t *testing.T) *Calendar {
+	t.Helper()
+	c, err := New("America/Sao_Paulo", []string{"01-01", "04-21", "2026-02-16", "2026-02-17", "2026-04-03"})
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	return c
+}
+
+func TestNew_Errors(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name     string
+		timeZone string
+		holidays []string
+	}{
+		{"unknown time zone", "Mars/Olympus", nil},
+		{"malformed holiday", "", []string{"16/02/2026"}},
+		{"impossible date", "", []string{"2026-02-30"}},
+		{"duplicate date", "", []string{"2026-02-16", "2026-02-16"}},
+		{"duplicate annual", "", []string{"12-25", "12-25"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			if _, err := New(tt.timeZone, tt.holidays); err == nil {
+				t.Errorf("New(%q, %v) error = nil, want error", tt.timeZone, tt.holidays)
+			}
+		})
+	}
+}
+
+func TestIsBusinessDay(t *testing.T) {
+	t.Parallel()
+
+	c := brazil(t)
+	tests := []struct {
+		name string
+		at   time.Time
+		want bool
+	}{
+		{"weekday", time.Date(2026, 2, 12, 15, 0, 0, 0, time.UTC), true},
+		{"saturday", time.Date(2026, 2, 14, 15, 0, 0, 0, time.UTC), false},
+		{"carnaval monday", time.Date(2026, 2, 16, 15, 0, 0, 0, time.UTC), false},
+		{"annual holiday any year", time.Date(2027, 4, 21, 15, 0, 0, 0, time.UTC), false},
+		{"local date differs from UTC", time.Date(2026, 2, 18, 2, 0, 0, 0, time.UTC), false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			if got := c.IsBusinessDay(tt.at); got != tt.want {
+				t.Errorf("IsBusinessDay(%v) = %v, want %v", tt.at, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestAddBusinessDays(t *testing.T) {
+	t.Parallel()
+
+	c := brazil(t)
+	friday := time.Date(2026, 2, 13, 15, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name string
+		cal  *Calendar
+		from time.Time
+		days int
+		want time.Time
+	}{
+		{"instant", c, friday, 0, friday},
+		{"skips weekend and carnaval", c, friday, 1, time.Date(2026, 2, 18, 15, 0, 0, 0, time.UTC)},
+		{"two business days", c, friday, 2, time.Date(2026, 2, 19, 15, 0, 0, 0, time.UTC)},
+		{"starting on a weekend", c, time.Date(2026, 2, 7, 15, 0, 0, 0, time.UTC), 1, time.Date(2026, 2, 9, 15, 0, 0, 0, time.UTC)},
+		{"skips good friday", c, time.Date(2026, 4, 2, 15, 0, 0, 0, time.UTC), 1, time.Date(2026, 4, 6, 15, 0, 0, 0, time.UTC)},
+		{"nil calendar counts calendar days", nil, friday, 2, friday.AddDate(0, 0, 2)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			if got := tt.cal.AddBusinessDays(tt.from, tt.days); !got.Equal(tt.want) {
+				t.Errorf("AddBusinessDays(%v, %d) = %v, want %v", tt.from, tt.days, got, tt.want)
+			}
+		})
+	}
+}
@@ -1,20 +1,35 @@
 package config
 
 import (
+	"crypto/sha256"
+	"encoding/hex"
 	"encoding/json"
 	"fmt"
 	"os"
+	"slices"
+	"time"
 
+	"github.com/ivanjtm/YunoChallenge/internal/calendar"
 	"github.com/ivanjtm/YunoChallenge/internal/model"
+	"github.com/ivanjtm/YunoChallenge/internal/money"
+	"github.com/ivanjtm/YunoChallenge/internal/quota"
+	"github.com/ivanjtm/YunoChallenge/internal/rules"
 )
 
 type AppConfig struct {
 	Processors   []model.Processor
 	Rules        []model.CompatibilityRule
+	Markets      model.MarketConfig
 	Transactions []model.Transaction
+	Version      string
 }
 
-func Load(processorsPath, rulesPath string) (*AppConfig, error) {
+func Load(processorsPath, rulesPath, marketsPath string) (*AppConfig, error) {
+	markets, err := loadMarkets(marketsPath)
+	if err != nil {
+		return nil, fmt.Errorf("loading markets: %w", err)
+	}
+
 	processors, err := loadProcessors(processorsPath)
 	if err != nil {
 		return nil, fmt.Errorf("loading processors: %w", err)
@@ -28,28 +43,53 @@ func Load(processorsPath, rulesPath string) (*AppConfig, error) {
 	cfg := &AppConfig{
 		Processors: processors,
 		Rules:      rules,
+		Markets:    markets,
 	}
 
 	if err := validate(cfg); err != nil {
 		return nil, fmt.Errorf("validation error: %w", err)
 	}
 
+	cfg.Version, err = digestFiles(processorsPath, rulesPath, marketsPath)
+	if err != nil {
+		return nil, fmt.Errorf("hashing config: %w", err)
+	}
+
 	return cfg, nil
 }
 
-func LoadWithTransactions(processorsPath, rulesPath, transactionsPath string) (*AppConfig, error) {
-	cfg, err := Load(processorsPath, rulesPath)
+func digestFiles(paths ...string) (string, error) {
+	h := sha256.New()
+	for _, path := range paths {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return "", err
+		}
+		h.Write(data)
+	}
+	return hex.EncodeToString(h.Sum(nil))[:12], nil
+}
+
+func LoadWithTransactions(processorsPath, rulesPath, marketsPath, transactionsPath string) (*AppConfig, error) {
+	cfg, err := Load(processorsPath, rulesPath, marketsPath)
 	if err != nil {
 		return nil, err
 	}
 
-	transactions, err := loadTransactions(transactionsPath)
+	if err := cfg.LoadTransactions(transactionsPath); err != nil {
+		return nil, err
+	}
+	return cfg, nil
+}
+
+func (c *AppConfig) LoadTransactions(path string) error {
+	transactions, err := loadTransactions(path)
 	if err != nil {
-		return nil, fmt.Errorf("loading transactions: %w", err)
+		return fmt.Errorf("loading transactions: %w", err)
 	}
 
-	cfg.Transactions = transactions
-	return cfg, nil
+	c.Transactions = transactions
+	return nil
 }
 
 func (c *AppConfig) ProcessorByID(id string) (model.Processor, bool) {
@@ -61,6 +101,80 @@ func (c *AppConfig) ProcessorByID(id string) (model.Processor, bool) {
 	return model.Processor{}, false
 }
 
+func (c *AppConfig) CountryByCode(code model.Country) (model.CountryConfig, bool) {
+	for _, country := range c.Markets.Countries {
+		if country.Code == code {
+			return country, true
+		}
+	}
+	return model.CountryConfig{}, false
+}
+
+func (c *AppConfig) DayValues() map[model.Currency]float64 {
+	values := make(map[model.Currency]float64)
+	for _, cur := range c.Markets.Currencies {
+		if cur.DayValue > 0 {
+			values[cur.Code] = cur.DayValue
+		}
+	}
+	return values
+}
+
+func (c *AppConfig) Taxes() map[model.Country][]model.TaxRule {
+	taxes := make(map[model.Country][]model.TaxRule)
+	for _, c := range c.Markets.Countries {
+		if len(c.Taxes) > 0 {
+			taxes[c.Code] = c.Taxes
+		}
+	}
+	return taxes
+}
+
+func (c *AppConfig) ReversalPolicies() map[model.Country]*model.ReversalPolicy {
+	policies := make(map[model.Country]*model.ReversalPolicy)
+	for _, c := range c.Markets.Countries {
+		if c.ReversalWindow != nil {
+			policies[c.Code] = c.ReversalWindow
+		}
+	}
+	return policies
+}
+
+func (c *AppConfig) Calendars() map[model.Country]*calendar.Calendar {
+	calendars := make(map[model.Country]*calendar.Calendar)
+	for _, c := range c.Markets.Countries {
+		if c.Calendar == nil {
+			continue
+		}
+		if cal, err := calendar.New(c.Calendar.TimeZone, c.Calendar.Holidays); err == nil {
+			calendars[c.Code] = cal
+		}
+	}
+	return calendars
+}
+
+func (c *AppConfig) MinorUnits() map[string]int {
+	units := make(map[string]int, len(c.Markets.Currencies))
+	for _, cur := range c.Markets.Currencies {
+		units[string(cur.Code)] = cur.MinorUnits
+	}
+	return units
+}
+
+func loadMarkets(path string) (model.MarketConfig, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return model.MarketConfig{}, err
+	}
+	defer f.Close()
+
+	var markets model.MarketConfig
+	if err := json.NewDecoder(f).Decode(&markets); err != nil {
+		return model.MarketConfig{}, err
+	}
+	return markets, nil
+}
+
 func loadProcessors(path string) ([]model.Processor, error) {
 	f, err := os.Open(path)
 	if err != nil {
@@ -104,6 +218,28 @@ func loadTransactions(path string) ([]model.Transaction, error) {
 }
 
 func validate(cfg *AppConfig) error {
+	if err := validateMarkets(cfg.Markets); err != nil {
+		return err
+	}
+
+	currencies := make(map[model.Currency]bool)
+	for _, c := range cfg.Markets.Currencies {
+		currencies[c.Code] = true
+	}
+	countryCurrency := make(map[model.Country]model.Currency)
+	countryReversal := make(map[model.Country]*model.ReversalPolicy)
+	for _, c := range cfg.Markets.Countries {
+		countryCurrency[c.Code] = c.Currency
+		countryReversal[c.Code] = c.ReversalWindow
+	}
+	methodCountries := make(map[model.PaymentMethod]map[model.Country]bool)
+	for _, pm := range cfg.Markets.PaymentMethods {
+		methodCountries[pm.Code] = make(map[model.Country]bool)
+		for _, c := range pm.Countries {
+			methodCountries[pm.Code][c] = true
+		}
+	}
+
 	for i, p := range cfg.Processors {
 		if p.ID == "" {
 			return fmt.Errorf("processor at index %d has empty ID", i)
@@ -117,31 +253,347 @@ func validate(cfg *AppConfig) error {
 		if len(p.RefundFees) == 0 {
 			return fmt.Errorf("processor %q has no refund fees", p.ID)
 		}
+		for _, country := range p.SupportedCountries {
+			if _, ok := countryCurrency[country]; !ok {
+				return fmt.Errorf("processor %q supports unknown country %q", p.ID, country)
+			}
+		}
+		for _, cur := range p.SupportedCurrencies {
+			if !currencies[cur] {
+				return fmt.Errorf("processor %q supports unknown currency %q", p.ID, cur)
+			}
+		}
+
+		if !validWindow(p.EffectiveFrom, p.EffectiveTo) {
+			return fmt.Errorf("processor %q has effective_to before effective_from", p.ID)
+		}
+		if err := quota.ValidateLimits(p); err != nil {
+			return fmt.Errorf("processor %q %w", p.ID, err)
+		}
+		for _, l := range p.QuotaLimits {
+			if l.Method != "" && (!knownRefundMethod(l.Method) || l.Method == model.RefundAccountCredit) {
+				return fmt.Errorf("processor %q has a quota limit for unknown refund method %q", p.ID, l.Method)
+			}
+			if l.Currency != "" && !slices.Contains(p.SupportedCurrencies, l.Currency) {
+				return fmt.Errorf("processor %q has a quota limit in unsupported currency %q", p.ID, l.Currency)
+			}
+		}
+		if m := p.FXMarkup; m != nil {
+			if m.Rate < 0 || m.Rate >= 1 {
+				return fmt.Errorf("processor %q has fx_markup rate %v outside [0, 1)", p.ID, m.Rate)
+			}
+			if !currencies[m.SettlementCurrency] {
+				return fmt.Errorf("processor %q has fx_markup in unknown settlement currency %q", p.ID, m.SettlementCurrency)
+			}
+		}
+		if rw := p.ReversalWindow; rw != nil {
+			if err := rules.ValidateReversalPolicy(*rw); err != nil {
+				return fmt.Errorf("processor %q reversal_window: %w", p.ID, err)
+			}
+			for _, country := range p.SupportedCountries {
+				if err := rules.ValidateReversalPolicy(rules.ResolveReversalPolicy(countryReversal[country], rw)); err != nil {
+					return fmt.Errorf("processor %q reversal_window in %s: %w", p.ID, country, err)
+				}
+			}
+		}
+		if err := validateFeeSchedules(p); err != nil {
+			return err
+		}
 
 		feeCurrencies := make(map[model.Currency]bool)
 		for _, fee := range p.RefundFees {
+			if fee.Currency != "" && !currencies[fee.Currency] {
+				return fmt.Errorf("processor %q has a %s fee in unknown currency %q", p.ID, fee.Method, fee.Currency)
+			}
+			for _, pm := range fee.PaymentMethods {
+				if _, ok := methodCountries[pm]; !ok {
+					return fmt.Errorf("processor %q has a %s fee for unknown payment method %q", p.ID, fee.Method, pm)
+				}
+			}
 			feeCurrencies[fee.Currency] = true
 		}
 
-		countryCurrency := map[model.Country]model.Currency{
-			model.CountryBR: model.CurrencyBRL,
-			model.CountryMX: model.CurrencyMXN,
-			model.CountryCO: model.CurrencyCOP,
-		}
 		for _, country := range p.SupportedCountries {
-			if cur, ok := countryCurrency[country]; ok {
-				if !feeCurrencies[cur] {
-					fmt.Printf("[WARNING] processor %q supports country %s but has no fees for currency %s\n", p.ID, country, cur)
+			if cur := countryCurrency[country]; !feeCurrencies[cur] {
+				fmt.Printf("[WARNING] processor %q supports country %s but has no fees for currency %s\n", p.ID, country, cur)
+			}
+		}
+	}
+
+	for _, c := range cfg.Markets.Countries {
+		for _, t := range c.Taxes {
+			for _, id := range t.Processors {
+				if _, ok := cfg.ProcessorByID(id); !ok {
+					return fmt.Errorf("country %q tax %q applies to unknown processor %q", c.Code, t.Name, id)
 				}
 			}
 		}
+		if c.TestData == nil {
+			continue
+		}
+		for _, wp := range c.TestData.Processors {
+			p, ok := cfg.ProcessorByID(wp.ProcessorID)
+			if !ok {
+				return fmt.Errorf("country %q test_data uses unknown processor %q", c.Code, wp.ProcessorID)
+			}
+			if !supportsCountry(p, c.Code) {
+				return fmt.Errorf("country %q test_data uses processor %q which does not support it", c.Code, wp.ProcessorID)
+			}
+		}
 	}
 
+	knownProcessor := func(id string) bool {
+		_, ok := cfg.ProcessorByID(id)
+		return ok
+	}
+	seen := make(map[string]bool)
 	for i, r := range cfg.Rules {
 		if r.OriginalMethod == "" {
 			return fmt.Errorf("rule at index %d has empty original_method", i)
 		}
+		countries, ok := methodCountries[r.OriginalMethod]
+		if !ok {
+			return fmt.Errorf("rule at index %d has unknown original_method %q", i, r.OriginalMethod)
+		}
+		if _, ok := countryCurrency[r.Country]; !ok {
+			return fmt.Errorf("rule at index %d has unknown country %q", i, r.Country)
+		}
+		if !countries[r.Country] {
+			return fmt.Errorf("rule at index %d: payment method %s is not offered in %s", i, r.OriginalMethod, r.Country)
+		}
+		ruleKey := fmt.Sprintf("%s %s", r.OriginalMethod, r.Country)
+		if r.ProcessorID != "" {
+			ruleKey += " for " + r.ProcessorID
+			p, ok := cfg.ProcessorByID(r.ProcessorID)
+			if !ok {
+				return fmt.Errorf("rule at index %d is scoped to unknown processor %q", i, r.ProcessorID)
+			}
+			if !supportsCountry(p, r.Country) {
+				return fmt.Errorf("rule at index %d is scoped to processor %q which does not support %s", i, r.ProcessorID, r.Country)
+			}
+		}
+		if seen[ruleKey] {
+			return fmt.Errorf("rule at index %d duplicates the rule for %s", i, ruleKey)
+		}
+		seen[ruleKey] = true
+		for _, ar := range r.AllowedRefunds {
+			if r.ProcessorID != "" && ar.Method == model.RefundAccountCredit {
+				return fmt.Errorf("rule at index %d (%s): account credit cannot be scoped to a processor", i, ruleKey)
+			}
+			if ar.When == nil {
+				continue
+			}
+			if err := rules.ValidateCondition(*ar.When, knownProcessor); err != nil {
+				return fmt.Errorf("rule at index %d (%s) has an invalid %s condition: %w", i, ruleKey, ar.Method, err)
+			}
+		}
+	}
+
+	return nil
+}
+
+func validWindow(from, to *time.Time) bool {
+	return from == nil || to == nil || to.After(*from)
+}
+
+func validateFeeSchedules(p model.Processor) error {
+	for i, a := range p.RefundFees {
+		if !validWindow(a.EffectiveFrom, a.EffectiveTo) {
+			return fmt.Errorf("processor %q %s fee at index %d has effective_to before effective_from", p.ID, a.Method, i)
+		}
+		if err := validateTiers(a); err != nil {
+			return fmt.Errorf("processor %q %s fee at index %d: %w", p.ID, a.Method, i, err)
+		}
+		for j := i + 1; j < len(p.RefundFees); j++ {
+			b := p.RefundFees[j]
+			if a.Method != b.Method || a.Currency != b.Currency || !sameStart(a.EffectiveFrom, b.EffectiveFrom) {
+				continue
+			}
+			if !sharesPaymentMethod(a.PaymentMethods, b.PaymentMethods) || !windowsOverlap(a, b) {
+				continue
+			}
+			return fmt.Errorf("processor %q has overlapping %s %s fees at index %d and %d with the same effective_from", p.ID, a.Method, a.Currency, i, j)
+		}
+	}
+	return nil
+}
+
+func validateTiers(fee model.RefundMethodFee) error {
+	var prevAbove money.Amount
+	for i, t := range fee.AmountTiers {
+		if t.Above <= prevAbove {
+			return fmt.Errorf("amount_tiers[%d].above must be positive and ascending", i)
+		}
+		if t.PercentFee < 0 {
+			return fmt.Errorf("amount_tiers[%d].percent_fee must not be negative", i)
+		}
+		prevAbove = t.Above
 	}
 
+	prevMin := 0
+	for i, t := range fee.VolumeTiers {
+		if t.MinMonthlyRefunds <= prevMin {
+			return fmt.Errorf("volume_tiers[%d].min_monthly_refunds must be positive and ascending", i)
+		}
+		if t.Discount < 0 || t.Discount >= 1 {
+			return fmt.Errorf("volume_tiers[%d].discount must be in [0, 1)", i)
+		}
+		prevMin = t.MinMonthlyRefunds
+	}
 	return nil
 }
+
+func sameStart(a, b *time.Time) bool {
+	if a == nil || b == nil {
+		return a == nil && b == nil
+	}
+	return a.Equal(*b)
+}
+
+func windowsOverlap(a, b model.RefundMethodFee) bool {
+	if a.EffectiveTo != nil && b.EffectiveFrom != nil && !a.EffectiveTo.After(*b.EffectiveFrom) {
+		return false
+	}
+	if b.EffectiveTo != nil && a.EffectiveFrom != nil && !b.EffectiveTo.After(*a.EffectiveFrom) {
+		return false
+	}
+	return true
+}
+
+func sharesPaymentMethod(a, b []model.PaymentMethod) bool {
+	for _, x := range a {
+		for _, y := range b {
+			if x == y {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func supportsCountry(p model.Processor, country model.Country) bool {
+	for _, c := range p.SupportedCountries {
+		if c == country {
+			return true
+		}
+	}
+	return false
+}
+
+func validateMarkets(m model.MarketConfig) error {
+	if len(m.Countries) == 0 {
+		return fmt.Errorf("markets define no countries")
+	}
+
+	currencies := make(map[model.Currency]bool)
+	for i, c := range m.Currencies {
+		if c.Code == "" {
+			return fmt.Errorf("currency at index %d has empty code", i)
+		}
+		if currencies[c.Code] {
+			return fmt.Errorf("currency %q is defined more than once", c.Code)
+		}
+		if c.MinorUnits < 0 || c.MinorUnits > 4 {
+			return fmt.Errorf("currency %q has invalid minor_units %d", c.Code, c.MinorUnits)
+		}
+		if c.DayValue < 0 {
+			return fmt.Errorf("currency %q has negative day_value", c.Code)
+		}
+		if td := c.TestData; td != nil && (td.MinAmount <= 0 || td.MaxAmount < td.MinAmount || td.TypicalAmount <= 0) {
+			return fmt.Errorf("currency %q has invalid test_data amounts", c.Code)
+		}
+		currencies[c.Code] = true
+	}
+
+	countries := make(map[model.Country]bool)
+	for i, c := range m.Countries {
+		if c.Code == "" {
+			return fmt.Errorf("country at index %d has empty code", i)
+		}
+		if countries[c.Code] {
+			return fmt.Errorf("country %q is defined more than once", c.Code)
+		}
+		if !currencies[c.Currency] {
+			return fmt.Errorf("country %q uses unknown currency %q", c.Code, c.Currency)
+		}
+		countries[c.Code] = true
+	}
+
+	methods := make(map[model.PaymentMethod]map[model.Country]bool)
+	for i, pm := range m.PaymentMethods {
+		if pm.Code == "" {
+			return fmt.Errorf("payment method at index %d has empty code", i)
+		}
+		if _, ok := methods[pm.Code]; ok {
+			return fmt.Errorf("payment method %q is defined more than once", pm.Code)
+		}
+		if len(pm.Countries) == 0 {
+			return fmt.Errorf("payment method %q is not offered in any country", pm.Code)
+		}
+		methods[pm.Code] = make(map[model.Country]bool)
+		for _, c := range pm.Countries {
+			if !countries[c] {
+				return fmt.Errorf("payment method %q references unknown country %q", pm.Code, c)
+			}
+			methods[pm.Code][c] = true
+		}
+	}
+
+	for _, c := range m.Countries {
+		if cal := c.Calendar; cal != nil {
+			if _, err := calendar.New(cal.TimeZone, cal.Holidays); err != nil {
+				return fmt.Errorf("country %q calendar: %w", c.Code, err)
+			}
+		}
+		if rw := c.ReversalWindow; rw != nil {
+			if err := rules.ValidateReversalPolicy(*rw); err != nil {
+				return fmt.Errorf("country %q reversal_window: %w", c.Code, err)
+			}
+			if err := rules.ValidateReversalPolicy(rules.ResolveReversalPolicy(rw)); err != nil {
+				return fmt.Errorf("country %q reversal_window: %w", c.Code, err)
+			}
+		}
+		for i, t := range c.Taxes {
+			if t.Name == "" {
+				return fmt.Errorf("country %q tax at index %d has empty name", c.Code, i)
+			}
+			if t.Rate < 0 || t.Rate >= 1 {
+				return fmt.Errorf("country %q tax %q has rate %v outside [0, 1)", c.Code, t.Name, t.Rate)
+			}
+			if t.Base != "" && t.Base != model.TaxBaseAmount && t.Base != model.TaxBaseFee {
+				return fmt.Errorf("country %q tax %q has unknown base %q", c.Code, t.Name, t.Base)
+			}
+			for _, rm := range t.RefundMethods {
+				if !knownRefundMethod(rm) {
+					return fmt.Errorf("country %q tax %q has unknown refund method %q", c.Code, t.Name, rm)
+				}
+			}
+			for _, pm := range t.PaymentMethods {
+				if !methods[pm][c.Code] {
+					return fmt.Errorf("country %q tax %q uses payment method %q not offered there", c.Code, t.Name, pm)
+				}
+			}
+		}
+		if c.TestData == nil {
+			continue
+		}
+		if c.TestData.Weight < 0 {
+			return fmt.Errorf("country %q has negative test_data weight", c.Code)
+		}
+		for _, wm := range c.TestData.PaymentMethods {
+			if !methods[wm.Method][c.Code] {
+				return fmt.Errorf("country %q test_data uses payment method %q not offered there", c.Code, wm.Method)
+			}
+		}
+	}
+
+	return nil
+}
+
+func knownRefundMethod(m model.RefundMethod) bool {
+	switch m {
+	case model.RefundReversal, model.RefundSameMethod, model.RefundBankTransfer, model.RefundAccountCredit:
+		return true
+	}
+	return false
+}
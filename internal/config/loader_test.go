@@ -0,0 +1,211 @@
+package config
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ivanjtm/YunoChallenge/internal/model"
+	"github.com/ivanjtm/YunoChallenge/internal/money"
+)
+
+func loadFixture(t *testing.T) *AppConfig {
+	t.Helper()
+	cfg, err := Load("testdata/processors.json", "testdata/rules.json", "testdata/markets.json")
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	return cfg
+}
+
+func TestLoad_RepoConfig(t *testing.T) {
+	t.Parallel()
+
+	cfg, err := Load("../../config/processors.json", "../../config/rules.json", "../../config/markets.json")
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	for _, code := range []model.Country{model.CountryBR, model.CountryMX, model.CountryCO} {
+		if _, ok := cfg.CountryByCode(code); !ok {
+			t.Errorf("CountryByCode(%s) missing", code)
+		}
+	}
+	if len(cfg.Version) != 12 {
+		t.Errorf("Version = %q, want a 12 character digest", cfg.Version)
+	}
+}
+
+func TestLoad_MarketsAddCountry(t *testing.T) {
+	t.Parallel()
+
+	cfg := loadFixture(t)
+	cl, ok := cfg.CountryByCode("CL")
+	if !ok {
+		t.Fatal("CountryByCode(CL) missing")
+	}
+	if cl.Currency != "CLP" || cl.Name != "Chile" {
+		t.Errorf("CL = %+v, want Chile in CLP", cl)
+	}
+	if got := cfg.DayValues()["CLP"]; got != 180 {
+		t.Errorf("DayValues()[CLP] = %v, want 180", got)
+	}
+	if _, ok := cfg.DayValues()["USD"]; ok {
+		t.Error("DayValues() has USD, want only currencies with a day_value")
+	}
+	if got := cfg.MinorUnits()["CLP"]; got != 0 {
+		t.Errorf("MinorUnits()[CLP] = %d, want 0", got)
+	}
+	if taxes := cfg.Taxes()["CL"]; len(taxes) != 1 || taxes[0].Name != "IVA" || taxes[0].Base != model.TaxBaseFee {
+		t.Errorf("Taxes()[CL] = %+v, want IVA on the fee", taxes)
+	}
+	if rw := cfg.ReversalPolicies()["CL"]; rw == nil || rw.Hours != 48 {
+		t.Errorf("ReversalPolicies()[CL] = %+v, want 48 hours", rw)
+	}
+	if _, ok := cfg.ReversalPolicies()["BR"]; ok {
+		t.Error("ReversalPolicies() has BR, want only countries that set one")
+	}
+	cal := cfg.Calendars()["CL"]
+	if cal == nil || cal.Location().String() != "America/Santiago" {
+		t.Fatalf("Calendars()[CL] = %v, want America/Santiago", cal)
+	}
+	if !cal.IsHoliday(time.Date(2025, 9, 18, 12, 0, 0, 0, cal.Location())) {
+		t.Error("Calendars()[CL] misses Fiestas Patrias on 09-18")
+	}
+	if p, ok := cfg.ProcessorByID("chilepay"); !ok || !supportsCountry(p, "CL") {
+		t.Errorf("ProcessorByID(chilepay) = %+v, %v, want a processor for CL", p, ok)
+	}
+}
+
+func TestValidate_Rejects(t *testing.T) {
+	t.Parallel()
+
+	country := func(cfg *AppConfig, code model.Country) *model.CountryConfig {
+		for i := range cfg.Markets.Countries {
+			if cfg.Markets.Countries[i].Code == code {
+				return &cfg.Markets.Countries[i]
+			}
+		}
+		t.Fatalf("fixture has no country %s", code)
+		return nil
+	}
+	processor := func(cfg *AppConfig, id string) *model.Processor {
+		for i := range cfg.Processors {
+			if cfg.Processors[i].ID == id {
+				return &cfg.Processors[i]
+			}
+		}
+		t.Fatalf("fixture has no processor %s", id)
+		return nil
+	}
+	at := func(s string) *time.Time {
+		ts, err := time.Parse(time.DateOnly, s)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return &ts
+	}
+
+	tests := []struct {
+		name    string
+		edit    func(*AppConfig)
+		wantErr string
+	}{
+		// markets
+		{"no countries", func(c *AppConfig) { c.Markets.Countries = nil }, "markets define no countries"},
+		{"empty currency code", func(c *AppConfig) { c.Markets.Currencies[2].Code = "" }, "currency at index 2 has empty code"},
+		{"duplicate currency", func(c *AppConfig) { c.Markets.Currencies[2].Code = "CLP" }, `currency "CLP" is defined more than once`},
+		{"minor units above 4", func(c *AppConfig) { c.Markets.Currencies[1].MinorUnits = 5 }, `currency "CLP" has invalid minor_units 5`},
+		{"negative minor units", func(c *AppConfig) { c.Markets.Currencies[1].MinorUnits = -1 }, `currency "CLP" has invalid minor_units -1`},
+		{"negative day value", func(c *AppConfig) { c.Markets.Currencies[1].DayValue = -1 }, `currency "CLP" has negative day_value`},
+		{"test data max below min", func(c *AppConfig) { c.Markets.Currencies[1].TestData.MaxAmount = money.FromFloat(1000) }, `currency "CLP" has invalid test_data amounts`},
+		{"test data without a typical amount", func(c *AppConfig) { c.Markets.Currencies[1].TestData.TypicalAmount = 0 }, `currency "CLP" has invalid test_data amounts`},
+		{"empty country code", func(c *AppConfig) { c.Markets.Countries[1].Code = "" }, "country at index 1 has empty code"},
+		{"duplicate country", func(c *AppConfig) { c.Markets.Countries[1].Code = "BR" }, `country "BR" is defined more than once`},
+		{"country in unknown currency", func(c *AppConfig) { country(c, "CL").Currency = "ARS" }, `country "CL" uses unknown currency "ARS"`},
+		{"empty payment method code", func(c *AppConfig) { c.Markets.PaymentMethods[2].Code = "" }, "payment method at index 2 has empty code"},
+		{"duplicate payment method", func(c *AppConfig) { c.Markets.PaymentMethods[2].Code = "PIX" }, `payment method "PIX" is defined more than once`},
+		{"payment method without countries", func(c *AppConfig) { c.Markets.PaymentMethods[2].Countries = nil }, `payment method "WEBPAY" is not offered in any country`},
+		{"payment method in unknown country", func(c *AppConfig) { c.Markets.PaymentMethods[2].Countries = []model.Country{"CL", "PE"} }, `payment method "WEBPAY" references unknown country "PE"`},
+		{"unknown time zone", func(c *AppConfig) { country(c, "CL").Calendar.TimeZone = "America/Nowhere" }, `country "CL" calendar`},
+		{"bad holiday", func(c *AppConfig) { country(c, "CL").Calendar.Holidays = []string{"18-09"} }, `country "CL" calendar`},
+		{"bad country reversal window", func(c *AppConfig) { country(c, "CL").ReversalWindow.Cutoff = "25:00" }, `country "CL" reversal_window`},
+		{"empty tax name", func(c *AppConfig) { country(c, "CL").Taxes[0].Name = "" }, `country "CL" tax at index 0 has empty name`},
+		{"tax rate of 100%", func(c *AppConfig) { country(c, "CL").Taxes[0].Rate = 1 }, `country "CL" tax "IVA" has rate 1 outside [0, 1)`},
+		{"unknown tax base", func(c *AppConfig) { country(c, "CL").Taxes[0].Base = "total" }, `country "CL" tax "IVA" has unknown base "total"`},
+		{"tax for unknown refund method", func(c *AppConfig) { country(c, "CL").Taxes[0].RefundMethods = []model.RefundMethod{"CASH"} }, `country "CL" tax "IVA" has unknown refund method "CASH"`},
+		{"tax for payment method not offered", func(c *AppConfig) { country(c, "CL").Taxes[0].PaymentMethods = []model.PaymentMethod{"PIX"} }, `country "CL" tax "IVA" uses payment method "PIX" not offered there`},
+		{"negative test data weight", func(c *AppConfig) { country(c, "CL").TestData.Weight = -1 }, `country "CL" has negative test_data weight`},
+		{"test data payment method not offered", func(c *AppConfig) { country(c, "CL").TestData.PaymentMethods[0].Method = "PIX" }, `country "CL" test_data uses payment method "PIX" not offered there`},
+
+		// processors
+		{"empty processor ID", func(c *AppConfig) { c.Processors[1].ID = "" }, "processor at index 1 has empty ID"},
+		{"empty processor name", func(c *AppConfig) { processor(c, "chilepay").Name = "" }, `processor "chilepay" has empty Name`},
+		{"no supported countries", func(c *AppConfig) { processor(c, "chilepay").SupportedCountries = nil }, `processor "chilepay" has no supported countries`},
+		{"no refund fees", func(c *AppConfig) { processor(c, "chilepay").RefundFees = nil }, `processor "chilepay" has no refund fees`},
+		{"unknown country", func(c *AppConfig) { processor(c, "chilepay").SupportedCountries = []model.Country{"CL", "PE"} }, `processor "chilepay" supports unknown country "PE"`},
+		{"unknown currency", func(c *AppConfig) { processor(c, "chilepay").SupportedCurrencies = []model.Currency{"CLP", "PEN"} }, `processor "chilepay" supports unknown currency "PEN"`},
+		{"inverted effective window", func(c *AppConfig) {
+			p := processor(c, "chilepay")
+			p.EffectiveFrom, p.EffectiveTo = at("2025-06-01"), at("2025-01-01")
+		}, `processor "chilepay" has effective_to before effective_from`},
+		{"negative daily quota", func(c *AppConfig) { processor(c, "chilepay").DailyQuota = -1 }, `processor "chilepay" daily_quota -1 is negative`},
+		{"quota limit for unknown method", func(c *AppConfig) { processor(c, "chilepay").QuotaLimits[0].Method = "CASH" }, `processor "chilepay" has a quota limit for unknown refund method "CASH"`},
+		{"quota limit for account credit", func(c *AppConfig) { processor(c, "chilepay").QuotaLimits[0].Method = model.RefundAccountCredit }, `processor "chilepay" has a quota limit for unknown refund method "ACCOUNT_CREDIT"`},
+		{"quota limit in unsupported currency", func(c *AppConfig) { processor(c, "chilepay").QuotaLimits[0].Currency = "BRL" }, `processor "chilepay" has a quota limit in unsupported currency "BRL"`},
+		{"fx markup of 100%", func(c *AppConfig) { processor(c, "globalpay").FXMarkup.Rate = 1 }, `processor "globalpay" has fx_markup rate 1 outside [0, 1)`},
+		{"fx markup in unknown currency", func(c *AppConfig) { processor(c, "globalpay").FXMarkup.SettlementCurrency = "EUR" }, `processor "globalpay" has fx_markup in unknown settlement currency "EUR"`},
+		{"bad processor reversal window", func(c *AppConfig) {
+			processor(c, "chilepay").ReversalWindow = &model.ReversalPolicy{Cutoff: "noon"}
+		}, `processor "chilepay" reversal_window`},
+		{"inverted fee window", func(c *AppConfig) {
+			f := &processor(c, "chilepay").RefundFees[0]
+			f.EffectiveFrom, f.EffectiveTo = at("2025-06-01"), at("2025-01-01")
+		}, `processor "chilepay" SAME_METHOD fee at index 0 has effective_to before effective_from`},
+		{"descending amount tiers", func(c *AppConfig) {
+			processor(c, "chilepay").RefundFees[0].AmountTiers = []model.AmountTier{{Above: money.FromFloat(50000)}, {Above: money.FromFloat(10000)}}
+		}, "amount_tiers[1].above must be positive and ascending"},
+		{"volume discount of 100%", func(c *AppConfig) {
+			processor(c, "chilepay").RefundFees[0].VolumeTiers = []model.VolumeTier{{MinMonthlyRefunds: 100, Discount: 1}}
+		}, "volume_tiers[0].discount must be in [0, 1)"},
+		{"overlapping fees", func(c *AppConfig) {
+			p := processor(c, "chilepay")
+			p.RefundFees = append(p.RefundFees, p.RefundFees[0])
+		}, `processor "chilepay" has overlapping SAME_METHOD CLP fees at index 0 and 2`},
+		{"fee in unknown currency", func(c *AppConfig) { processor(c, "chilepay").RefundFees[1].Currency = "PEN" }, `processor "chilepay" has a BANK_TRANSFER fee in unknown currency "PEN"`},
+		{"fee for unknown payment method", func(c *AppConfig) {
+			processor(c, "chilepay").RefundFees[1].PaymentMethods = []model.PaymentMethod{"KHIPU"}
+		}, `processor "chilepay" has a BANK_TRANSFER fee for unknown payment method "KHIPU"`},
+
+		// cross references
+		{"tax for unknown processor", func(c *AppConfig) { country(c, "CL").Taxes[0].Processors = []string{"andespay"} }, `country "CL" tax "IVA" applies to unknown processor "andespay"`},
+		{"test data for unknown processor", func(c *AppConfig) { country(c, "CL").TestData.Processors[0].ProcessorID = "andespay" }, `country "CL" test_data uses unknown processor "andespay"`},
+		{"test data for processor outside the country", func(c *AppConfig) { country(c, "CL").TestData.Processors[0].ProcessorID = "paybr" }, `country "CL" test_data uses processor "paybr" which does not support it`},
+
+		// rules
+		{"rule without original method", func(c *AppConfig) { c.Rules[1].OriginalMethod = "" }, "rule at index 1 has empty original_method"},
+		{"rule for unknown method", func(c *AppConfig) { c.Rules[1].OriginalMethod = "KHIPU" }, `rule at index 1 has unknown original_method "KHIPU"`},
+		{"rule for unknown country", func(c *AppConfig) { c.Rules[1].Country = "PE" }, `rule at index 1 has unknown country "PE"`},
+		{"rule for method not offered", func(c *AppConfig) { c.Rules[1].Country = "BR" }, "rule at index 1: payment method WEBPAY is not offered in BR"},
+		{"rule scoped to unknown processor", func(c *AppConfig) { c.Rules[2].ProcessorID = "andespay" }, `rule at index 2 is scoped to unknown processor "andespay"`},
+		{"rule scoped to processor outside the country", func(c *AppConfig) { c.Rules[2].ProcessorID = "paybr" }, `rule at index 2 is scoped to processor "paybr" which does not support CL`},
+		{"duplicate rule", func(c *AppConfig) { c.Rules = append(c.Rules, c.Rules[1]) }, "rule at index 3 duplicates the rule for WEBPAY CL"},
+		{"scoped account credit", func(c *AppConfig) {
+			c.Rules[2].AllowedRefunds = append(c.Rules[2].AllowedRefunds, model.AllowedRefund{Method: model.RefundAccountCredit})
+		}, "rule at index 2 (CREDIT_CARD CL for chilepay): account credit cannot be scoped to a processor"},
+		{"condition on unknown processor", func(c *AppConfig) {
+			c.Rules[1].AllowedRefunds[1].When = &model.RuleCondition{Processors: []string{"andespay"}}
+		}, "rule at index 1 (WEBPAY CL) has an invalid BANK_TRANSFER condition"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			cfg := loadFixture(t)
+			tt.edit(cfg)
+			err := validate(cfg)
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("validate() error = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
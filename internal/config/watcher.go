@@ -0,0 +1,147 @@
+package config
+
+import (
+	"context"
+	"os"
+	"sync"
+	"time"
+)
+
+type ReloadStatus struct {
+	Version     string     `json:"version"`
+	LoadedAt    time.Time  `json:"loaded_at"`
+	Reloads     int        `json:"reloads"`
+	LastError   string     `json:"last_error,omitempty"`
+	LastErrorAt *time.Time `json:"last_error_at,omitempty"`
+}
+
+type Watcher struct {
+	ProcessorsPath string
+	RulesPath      string
+	MarketsPath    string
+	Validate       func(*AppConfig) error
+	OnReload       func(*AppConfig)
+	OnError        func(error)
+
+	reloadMu sync.Mutex
+	mu       sync.RWMutex
+	current  *AppConfig
+	status   ReloadStatus
+	mtimes   map[string]time.Time
+}
+
+func NewWatcher(cfg *AppConfig, processorsPath, rulesPath, marketsPath string) *Watcher {
+	w := &Watcher{
+		ProcessorsPath: processorsPath,
+		RulesPath:      rulesPath,
+		MarketsPath:    marketsPath,
+		current:        cfg,
+		status:         ReloadStatus{Version: cfg.Version, LoadedAt: time.Now()},
+	}
+	w.mtimes = w.modTimes()
+	return w
+}
+
+func (w *Watcher) Current() *AppConfig {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+	return w.current
+}
+
+func (w *Watcher) Status() ReloadStatus {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+	return w.status
+}
+
+func (w *Watcher) Reload() error {
+	w.reloadMu.Lock()
+	defer w.reloadMu.Unlock()
+
+	w.mu.Lock()
+	next, err := w.reloadLocked()
+	w.mu.Unlock()
+
+	w.notify(next, err)
+	return err
+}
+
+func (w *Watcher) Poll(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			w.reloadMu.Lock()
+			w.mu.Lock()
+			var next *AppConfig
+			var err error
+			if w.changedLocked() {
+				next, err = w.reloadLocked()
+			}
+			w.mu.Unlock()
+
+			w.notify(next, err)
+			w.reloadMu.Unlock()
+		}
+	}
+}
+
+func (w *Watcher) reloadLocked() (*AppConfig, error) {
+	w.mtimes = w.modTimes()
+
+	next, err := Load(w.ProcessorsPath, w.RulesPath, w.MarketsPath)
+	if err == nil && next.Version == w.current.Version {
+		return nil, nil
+	}
+	if err == nil && w.Validate != nil {
+		err = w.Validate(next)
+	}
+	if err != nil {
+		now := time.Now()
+		w.status.LastError = err.Error()
+		w.status.LastErrorAt = &now
+		return nil, err
+	}
+
+	next.Transactions = w.current.Transactions
+
+	w.current = next
+	w.status.Version = next.Version
+	w.status.LoadedAt = time.Now()
+	w.status.Reloads++
+	w.status.LastError = ""
+	w.status.LastErrorAt = nil
+	return next, nil
+}
+
+func (w *Watcher) notify(next *AppConfig, err error) {
+	if err != nil && w.OnError != nil {
+		w.OnError(err)
+	}
+	if next != nil && w.OnReload != nil {
+		w.OnReload(next)
+	}
+}
+
+func (w *Watcher) changedLocked() bool {
+	for path, mtime := range w.modTimes() {
+		if !mtime.Equal(w.mtimes[path]) {
+			return true
+		}
+	}
+	return false
+}
+
+func (w *Watcher) modTimes() map[string]time.Time {
+	mtimes := make(map[string]time.Time, 3)
+	for _, path := range []string{w.ProcessorsPath, w.RulesPath, w.MarketsPath} {
+		if info, err := os.Stat(path); err == nil {
+			mtimes[path] = info.ModTime()
+		}
+	}
+	return mtimes
+}
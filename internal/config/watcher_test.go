@@ -0,0 +1,263 @@
+package config
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+const (
+	watchMarkets    = `{"currencies":[{"code":"BRL","minor_units":2,"day_value":1}],"countries":[{"code":"BR","name":"Brazil","currency":"BRL"}],"payment_methods":[{"code":"PIX","countries":["BR"]}]}`
+	watchProcessors = `[{"id":"paybr","name":"PayBR","supported_countries":["BR"],"supported_currencies":["BRL"],"refund_fees":[{"method":"SAME_METHOD","payment_methods":["PIX"],"currency":"BRL","base_fee":0.5}],"daily_quota":100}]`
+	watchRules      = `[]`
+)
+
+type watcherFixture struct {
+	w        *Watcher
+	dir      string
+	reloads  chan *AppConfig
+	failures chan error
+	mtime    time.Time
+}
+
+func newWatcherFixture(t *testing.T) *watcherFixture {
+	t.Helper()
+	f := &watcherFixture{
+		dir:      t.TempDir(),
+		reloads:  make(chan *AppConfig, 10),
+		failures: make(chan error, 10),
+		mtime:    time.Date(2025, 6, 15, 12, 0, 0, 0, time.UTC),
+	}
+	f.write(t, "markets.json", watchMarkets)
+	f.write(t, "processors.json", watchProcessors)
+	f.write(t, "rules.json", watchRules)
+
+	cfg, err := Load(f.path("processors.json"), f.path("rules.json"), f.path("markets.json"))
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	f.w = NewWatcher(cfg, f.path("processors.json"), f.path("rules.json"), f.path("markets.json"))
+	f.w.OnReload = func(c *AppConfig) { f.reloads <- c }
+	f.w.OnError = func(err error) { f.failures <- err }
+	return f
+}
+
+func (f *watcherFixture) path(name string) string {
+	return filepath.Join(f.dir, name)
+}
+
+func (f *watcherFixture) write(t *testing.T, name, content string) {
+	t.Helper()
+	tmp := f.path(name + ".tmp")
+	if err := os.WriteFile(tmp, []byte(content), 0o644); err != nil {
+		t.Fatalf("write %s: %v", name, err)
+	}
+	f.touch(t, name+".tmp")
+	if err := os.Rename(tmp, f.path(name)); err != nil {
+		t.Fatalf("rename %s: %v", name, err)
+	}
+}
+
+func (f *watcherFixture) touch(t *testing.T, name string) {
+	t.Helper()
+	f.mtime = f.mtime.Add(time.Second)
+	if err := os.Chtimes(f.path(name), f.mtime, f.mtime); err != nil {
+		t.Fatalf("chtimes %s: %v", name, err)
+	}
+}
+
+func (f *watcherFixture) callbacks() (reloads, failures int) {
+	return len(f.reloads), len(f.failures)
+}
+
+func TestWatcher_Reload(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name      string
+		edit      func(*testing.T, *watcherFixture)
+		wantErr   string
+		reloaded  bool
+		wantQuota int
+	}{
+		{"unchanged files", func(*testing.T, *watcherFixture) {}, "", false, 100},
+		{"touched but identical", func(t *testing.T, f *watcherFixture) { f.touch(t, "processors.json") }, "", false, 100},
+		{"edited processor", func(t *testing.T, f *watcherFixture) {
+			f.write(t, "processors.json", strings.Replace(watchProcessors, `"daily_quota":100`, `"daily_quota":250`, 1))
+		}, "", true, 250},
+		{"validation failure keeps the old config", func(t *testing.T, f *watcherFixture) {
+			f.write(t, "processors.json", `[{"id":"","name":"Nameless"}]`)
+		}, "processor at index 0 has empty ID", false, 100},
+		{"unknown currency keeps the old config", func(t *testing.T, f *watcherFixture) {
+			f.write(t, "markets.json", strings.Replace(watchMarkets, `"currency":"BRL"`, `"currency":"ARS"`, 1))
+		}, `country "BR" uses unknown currency "ARS"`, false, 100},
+		{"malformed JSON keeps the old config", func(t *testing.T, f *watcherFixture) {
+			f.write(t, "rules.json", `[{`)
+		}, "loading rules", false, 100},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			f := newWatcherFixture(t)
+			before := f.w.Status()
+			tt.edit(t, f)
+
+			err := f.w.Reload()
+			if tt.wantErr == "" && err != nil {
+				t.Fatalf("Reload() error = %v", err)
+			}
+			if tt.wantErr != "" && (err == nil || !strings.Contains(err.Error(), tt.wantErr)) {
+				t.Fatalf("Reload() error = %v, want %q", err, tt.wantErr)
+			}
+
+			reloads, failures := f.callbacks()
+			if want := map[bool]int{true: 1}[tt.reloaded]; reloads != want {
+				t.Errorf("OnReload calls = %d, want %d", reloads, want)
+			}
+			if want := map[bool]int{true: 1}[tt.wantErr != ""]; failures != want {
+				t.Errorf("OnError calls = %d, want %d", failures, want)
+			}
+			if got := f.w.Current().Processors[0].DailyQuota; got != tt.wantQuota {
+				t.Errorf("Current() daily quota = %d, want %d", got, tt.wantQuota)
+			}
+
+			st := f.w.Status()
+			if versionChanged := st.Version != before.Version; versionChanged != tt.reloaded {
+				t.Errorf("Status().Version %s -> %s, changed = %v, want %v", before.Version, st.Version, versionChanged, tt.reloaded)
+			}
+			if st.Version != f.w.Current().Version {
+				t.Errorf("Status().Version = %s, want the current config's %s", st.Version, f.w.Current().Version)
+			}
+			if want := map[bool]int{true: 1}[tt.reloaded]; st.Reloads != want {
+				t.Errorf("Status().Reloads = %d, want %d", st.Reloads, want)
+			}
+			if (st.LastError != "") != (tt.wantErr != "") || (st.LastErrorAt != nil) != (tt.wantErr != "") {
+				t.Errorf("Status() last error = %q at %v, want an error %v", st.LastError, st.LastErrorAt, tt.wantErr != "")
+			}
+		})
+	}
+}
+
+func TestWatcher_RecoversAfterFailedReload(t *testing.T) {
+	t.Parallel()
+
+	f := newWatcherFixture(t)
+	f.write(t, "processors.json", `[{`)
+	if err := f.w.Reload(); err == nil {
+		t.Fatal("Reload() of a broken file error = nil")
+	}
+	first := f.w.Status().Version
+
+	f.write(t, "processors.json", strings.Replace(watchProcessors, `"PayBR"`, `"PayBR Prime"`, 1))
+	if err := f.w.Reload(); err != nil {
+		t.Fatalf("Reload() after the fix error = %v", err)
+	}
+	st := f.w.Status()
+	if st.Version == first || st.Reloads != 1 {
+		t.Errorf("Status() = version %s, %d reloads, want a new version and 1 reload", st.Version, st.Reloads)
+	}
+	if st.LastError != "" || st.LastErrorAt != nil {
+		t.Errorf("Status() last error = %q, want it cleared by the good reload", st.LastError)
+	}
+	if got := f.w.Current().Processors[0].Name; got != "PayBR Prime" {
+		t.Errorf("Current() processor name = %q, want PayBR Prime", got)
+	}
+	if reloads, failures := f.callbacks(); reloads != 1 || failures != 1 {
+		t.Errorf("callbacks = %d reloads, %d errors, want 1 and 1", reloads, failures)
+	}
+}
+
+func TestWatcher_PollReloadsOnlyOnChange(t *testing.T) {
+	t.Parallel()
+
+	const interval = 5 * time.Millisecond
+	f := newWatcherFixture(t)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go f.w.Poll(ctx, interval)
+
+	settle := func() { time.Sleep(20 * interval) }
+
+	settle()
+	if reloads, failures := f.callbacks(); reloads != 0 || failures != 0 {
+		t.Fatalf("callbacks without changes = %d reloads, %d errors, want none", reloads, failures)
+	}
+
+	f.write(t, "processors.json", `[{"id":"paybr"}]`)
+	select {
+	case err := <-f.failures:
+		if !strings.Contains(err.Error(), `processor "paybr" has empty Name`) {
+			t.Errorf("OnError(%v), want the validation failure", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("timed out waiting for OnError")
+	}
+	settle()
+	if reloads, failures := f.callbacks(); reloads != 0 || failures != 0 {
+		t.Fatalf("after a bad edit: %d reloads, %d more errors, want the error reported once", reloads, failures)
+	}
+	if got := f.w.Current().Processors[0].Name; got != "PayBR" {
+		t.Errorf("Current() after a failed poll = %q, want the old config", got)
+	}
+
+	before := f.w.Status().Version
+	f.write(t, "processors.json", strings.Replace(watchProcessors, `"daily_quota":100`, `"daily_quota":300`, 1))
+	var next *AppConfig
+	select {
+	case next = <-f.reloads:
+	case <-time.After(5 * time.Second):
+		t.Fatal("timed out waiting for OnReload")
+	}
+	settle()
+	if reloads, failures := f.callbacks(); reloads != 0 || failures != 0 {
+		t.Errorf("after a good edit: %d more reloads, %d errors, want the reload reported once", reloads, failures)
+	}
+	if next.Processors[0].DailyQuota != 300 || f.w.Current() != next {
+		t.Errorf("OnReload got daily quota %d, want 300 and the watcher's current config", next.Processors[0].DailyQuota)
+	}
+	st := f.w.Status()
+	if st.Version == before || st.Version != next.Version || st.Reloads != 1 || st.LastError != "" {
+		t.Errorf("Status() = %+v, want version %s after 1 reload and no error", st, next.Version)
+	}
+}
+
+func TestWatcher_ValidateRejectsReload(t *testing.T) {
+	t.Parallel()
+
+	f := newWatcherFixture(t)
+	f.w.Validate = func(c *AppConfig) error {
+		if c.Processors[0].DailyQuota > 200 {
+			return errors.New("daily quota too high")
+		}
+		return nil
+	}
+	before := f.w.Status().Version
+
+	f.write(t, "processors.json", strings.Replace(watchProcessors, `"daily_quota":100`, `"daily_quota":250`, 1))
+	if err := f.w.Reload(); err == nil || err.Error() != "daily quota too high" {
+		t.Fatalf("Reload() error = %v, want the Validate error", err)
+	}
+	st := f.w.Status()
+	if st.Version != before || st.Reloads != 0 || st.LastError != "daily quota too high" {
+		t.Errorf("Status() = %+v, want version %s kept and the Validate error recorded", st, before)
+	}
+	if got := f.w.Current().Processors[0].DailyQuota; got != 100 {
+		t.Errorf("Current() daily quota = %d, want the old 100", got)
+	}
+
+	f.write(t, "processors.json", strings.Replace(watchProcessors, `"daily_quota":100`, `"daily_quota":150`, 1))
+	if err := f.w.Reload(); err != nil {
+		t.Fatalf("Reload() error = %v", err)
+	}
+	if got := f.w.Current().Processors[0].DailyQuota; got != 150 {
+		t.Errorf("Current() daily quota = %d, want 150", got)
+	}
+	if reloads, failures := f.callbacks(); reloads != 1 || failures != 1 {
+		t.Errorf("callbacks = %d reloads, %d errors, want 1 and 1", reloads, failures)
+	}
+}
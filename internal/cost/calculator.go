@@ -1,34 +1,81 @@
 package cost
 
 import (
-	"math"
+	"time"
 
 	"github.com/ivanjtm/YunoChallenge/internal/model"
+	"github.com/ivanjtm/YunoChallenge/internal/money"
 )
 
-func Calculate(amount float64, fee model.RefundMethodFee) float64 {
+type Pricing struct {
+	Cost       money.Amount
+	Base       money.Amount
+	Variable   money.Amount
+	Discount   money.Amount
+	Clamp      model.FeeClamp
+	AmountTier *model.AmountTier
+	VolumeTier *model.VolumeTier
+}
+
+func Calculate(amount money.Amount, fee model.RefundMethodFee, monthlyVolume int) money.Amount {
+	return Price(amount, fee, monthlyVolume).Cost
+}
+
+func Price(amount money.Amount, fee model.RefundMethodFee, monthlyVolume int) Pricing {
 	if fee.Method == model.RefundReversal {
-		return 0
+		return Pricing{}
 	}
 	if fee.Method == model.RefundAccountCredit {
-		return 0
+		return Pricing{}
 	}
 
-	cost := fee.BaseFee + (amount * fee.PercentFee)
+	var p Pricing
+	var variable, lower money.Amount
+	percent := fee.PercentFee
+	for i, tier := range fee.AmountTiers {
+		if amount <= tier.Above {
+			break
+		}
+		variable += (tier.Above - lower).MulRate(percent)
+		lower, percent = tier.Above, tier.PercentFee
+		p.AmountTier = &fee.AmountTiers[i]
+	}
+	variable += (amount - lower).MulRate(percent)
+
+	p.Base, p.Variable = fee.BaseFee, variable
+	cost := fee.BaseFee + variable
+
+	for i, tier := range fee.VolumeTiers {
+		if monthlyVolume >= tier.MinMonthlyRefunds {
+			p.VolumeTier = &fee.VolumeTiers[i]
+		}
+	}
+	if p.VolumeTier != nil {
+		discounted := cost.MulRate(1 - p.VolumeTier.Discount)
+		p.Discount = cost - discounted
+		cost = discounted
+	}
 
 	if cost < fee.MinFee {
 		cost = fee.MinFee
+		p.Clamp = model.ClampMin
 	}
 	if fee.MaxFee > 0 && cost > fee.MaxFee {
 		cost = fee.MaxFee
+		p.Clamp = model.ClampMax
 	}
 
-	cost = math.Round(cost*100) / 100
+	p.Cost = cost.Round(string(fee.Currency))
 
-	return cost
+	return p
 }
 
 func FindMatchingFee(proc model.Processor, refundMethod model.RefundMethod, originalMethod model.PaymentMethod, currency model.Currency) *model.RefundMethodFee {
+	return FindMatchingFeeAt(proc, refundMethod, originalMethod, currency, time.Now())
+}
+
+func FindMatchingFeeAt(proc model.Processor, refundMethod model.RefundMethod, originalMethod model.PaymentMethod, currency model.Currency, at time.Time) *model.RefundMethodFee {
+	var match *model.RefundMethodFee
 	for i, fee := range proc.RefundFees {
 		if fee.Method != refundMethod {
 			continue
@@ -36,16 +83,51 @@ func FindMatchingFee(proc model.Processor, refundMethod model.RefundMethod, orig
 		if fee.Currency != currency && fee.Currency != "" {
 			continue
 		}
+		if !InEffect(fee.EffectiveFrom, fee.EffectiveTo, at) {
+			continue
+		}
 		for _, pm := range fee.PaymentMethods {
 			if pm == originalMethod {
-				return &proc.RefundFees[i]
+				if match == nil || startsLater(fee.EffectiveFrom, match.EffectiveFrom) {
+					match = &proc.RefundFees[i]
+				}
+				break
 			}
 		}
 	}
-	return nil
+	return match
 }
 
-func CalculateNaive(tx model.Transaction, processors []model.Processor) float64 {
+func InEffect(from, to *time.Time, at time.Time) bool {
+	if from != nil && at.Before(*from) {
+		return false
+	}
+	if to != nil && !at.Before(*to) {
+		return false
+	}
+	return true
+}
+
+func ProcessorActive(proc model.Processor, at time.Time) bool {
+	return InEffect(proc.EffectiveFrom, proc.EffectiveTo, at)
+}
+
+func startsLater(a, b *time.Time) bool {
+	if a == nil {
+		return false
+	}
+	return b == nil || a.After(*b)
+}
+
+func CalculateNaive(tx model.Transaction, processors []model.Processor) money.Amount {
+	return CalculateNaiveAt(tx, processors, time.Now(), 0)
+}
+
+func CalculateNaiveAt(tx model.Transaction, processors []model.Processor, at time.Time, monthlyVolume int) money.Amount {
+	return NaiveBreakdownAt(tx, processors, nil, at, monthlyVolume).Total
+}
+
+func NaiveBreakdownAt(tx model.Transaction, processors []model.Processor, taxes []model.TaxRule, at time.Time, monthlyVolume int) model.CostBreakdown {
 	var origProc *model.Processor
 	for i, p := range processors {
 		if p.ID == tx.ProcessorID {
@@ -54,18 +136,22 @@ func CalculateNaive(tx model.Transaction, processors []model.Processor) float64
 		}
 	}
 	if origProc == nil {
-		return math.Round(tx.Amount*0.035*100) / 100
+		return naiveFallback(tx)
 	}
 
-	if fee := FindMatchingFee(*origProc, model.RefundSameMethod, tx.PaymentMethod, tx.Currency); fee != nil {
-		return Calculate(tx.Amount, *fee)
+	for _, method := range []model.RefundMethod{model.RefundSameMethod, model.RefundBankTransfer} {
+		if fee := FindMatchingFeeAt(*origProc, method, tx.PaymentMethod, tx.Currency, at); fee != nil {
+			return Landed(tx, *origProc, method, Price(tx.Amount, *fee, monthlyVolume), taxes)
+		}
 	}
 
-	if fee := FindMatchingFee(*origProc, model.RefundBankTransfer, tx.PaymentMethod, tx.Currency); fee != nil {
-		return Calculate(tx.Amount, *fee)
-	}
+	return naiveFallback(tx)
+}
 
-	return math.Round(tx.Amount*0.035*100) / 100
+func naiveFallback(tx model.Transaction) model.CostBreakdown {
+	variable := tx.Amount.MulRate(0.035)
+	fee := variable.Round(string(tx.Currency))
+	return model.CostBreakdown{PercentComponent: variable, Fee: fee, Total: fee}
 }
 
 func SupportsCountryAndCurrency(proc model.Processor, country model.Country, currency model.Currency) bool {
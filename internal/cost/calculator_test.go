@@ -1,10 +1,11 @@
 package cost
 
 import (
-	"math"
 	"testing"
+	"time"
 
 	"github.com/ivanjtm/YunoChallenge/internal/model"
+	"github.com/ivanjtm/YunoChallenge/internal/money"
 )
 
 func testProcessorPayBR() model.Processor {
@@ -27,28 +28,28 @@ func testProcessorPayBR() model.Processor {
 				Method:         model.RefundSameMethod,
 				PaymentMethods: []model.PaymentMethod{model.MethodPIX},
 				Currency:       model.CurrencyBRL,
-				BaseFee:        0.5,
+				BaseFee:        money.FromFloat(0.5),
 				PercentFee:     0.005,
-				MinFee:         0.75,
+				MinFee:         money.FromFloat(0.75),
 				MaxFee:         0,
 			},
 			{
 				Method:         model.RefundSameMethod,
 				PaymentMethods: []model.PaymentMethod{model.MethodCreditCard},
 				Currency:       model.CurrencyBRL,
-				BaseFee:        1.5,
+				BaseFee:        money.FromFloat(1.5),
 				PercentFee:     0.025,
-				MinFee:         2.0,
-				MaxFee:         150.0,
+				MinFee:         money.FromFloat(2.0),
+				MaxFee:         money.FromFloat(150.0),
 			},
 			{
 				Method:         model.RefundBankTransfer,
 				PaymentMethods: []model.PaymentMethod{model.MethodPIX, model.MethodBoleto, model.MethodCreditCard},
 				Currency:       model.CurrencyBRL,
-				BaseFee:        1.0,
+				BaseFee:        money.FromFloat(1.0),
 				PercentFee:     0.015,
-				MinFee:         1.5,
-				MaxFee:         100.0,
+				MinFee:         money.FromFloat(1.5),
+				MaxFee:         money.FromFloat(100.0),
 			},
 		},
 		DailyQuota: 1000,
@@ -80,28 +81,28 @@ func testProcessorMexPay() model.Processor {
 				Method:         model.RefundSameMethod,
 				PaymentMethods: []model.PaymentMethod{model.MethodSPEI},
 				Currency:       model.CurrencyMXN,
-				BaseFee:        5.0,
+				BaseFee:        money.FromFloat(5.0),
 				PercentFee:     0.008,
-				MinFee:         8.0,
+				MinFee:         money.FromFloat(8.0),
 				MaxFee:         0,
 			},
 			{
 				Method:         model.RefundSameMethod,
 				PaymentMethods: []model.PaymentMethod{model.MethodCreditCard},
 				Currency:       model.CurrencyMXN,
-				BaseFee:        15.0,
+				BaseFee:        money.FromFloat(15.0),
 				PercentFee:     0.02,
-				MinFee:         20.0,
-				MaxFee:         2500.0,
+				MinFee:         money.FromFloat(20.0),
+				MaxFee:         money.FromFloat(2500.0),
 			},
 			{
 				Method:         model.RefundBankTransfer,
 				PaymentMethods: []model.PaymentMethod{model.MethodSPEI, model.MethodOXXO, model.MethodCreditCard},
 				Currency:       model.CurrencyMXN,
-				BaseFee:        10.0,
+				BaseFee:        money.FromFloat(10.0),
 				PercentFee:     0.012,
-				MinFee:         15.0,
-				MaxFee:         1800.0,
+				MinFee:         money.FromFloat(15.0),
+				MaxFee:         money.FromFloat(1800.0),
 			},
 		},
 		DailyQuota: 800,
@@ -133,19 +134,19 @@ func testProcessorGlobalPay() model.Processor {
 				Method:         model.RefundSameMethod,
 				PaymentMethods: []model.PaymentMethod{model.MethodCreditCard},
 				Currency:       model.CurrencyBRL,
-				BaseFee:        2.0,
+				BaseFee:        money.FromFloat(2.0),
 				PercentFee:     0.02,
-				MinFee:         3.0,
-				MaxFee:         200.0,
+				MinFee:         money.FromFloat(3.0),
+				MaxFee:         money.FromFloat(200.0),
 			},
 			{
 				Method:         model.RefundBankTransfer,
 				PaymentMethods: []model.PaymentMethod{model.MethodPIX, model.MethodBoleto, model.MethodCreditCard},
 				Currency:       model.CurrencyBRL,
-				BaseFee:        2.0,
+				BaseFee:        money.FromFloat(2.0),
 				PercentFee:     0.02,
-				MinFee:         3.0,
-				MaxFee:         200.0,
+				MinFee:         money.FromFloat(3.0),
+				MaxFee:         money.FromFloat(200.0),
 			},
 		},
 		DailyQuota: 2000,
@@ -162,57 +163,57 @@ func TestCalculate(t *testing.T) {
 
 	tests := []struct {
 		name   string
-		amount float64
+		amount money.Amount
 		fee    model.RefundMethodFee
-		want   float64
+		want   money.Amount
 	}{
 		{
 			name:   "normal fee calculation",
-			amount: 200.0,
+			amount: money.FromFloat(200.0),
 			fee: model.RefundMethodFee{
 				Method:     model.RefundSameMethod,
-				BaseFee:    1.5,
+				BaseFee:    money.FromFloat(1.5),
 				PercentFee: 0.025,
-				MinFee:     2.0,
-				MaxFee:     150.0,
+				MinFee:     money.FromFloat(2.0),
+				MaxFee:     money.FromFloat(150.0),
 			},
-			want: 6.5,
+			want: money.FromFloat(6.5),
 		},
 		{
 			name:   "min fee floor applied",
-			amount: 10.0,
+			amount: money.FromFloat(10.0),
 			fee: model.RefundMethodFee{
 				Method:     model.RefundSameMethod,
-				BaseFee:    0.5,
+				BaseFee:    money.FromFloat(0.5),
 				PercentFee: 0.005,
-				MinFee:     0.75,
+				MinFee:     money.FromFloat(0.75),
 				MaxFee:     0,
 			},
-			want: 0.75,
+			want: money.FromFloat(0.75),
 		},
 		{
 			name:   "max fee cap applied",
-			amount: 50000.0,
+			amount: money.FromFloat(50000.0),
 			fee: model.RefundMethodFee{
 				Method:     model.RefundSameMethod,
-				BaseFee:    1.5,
+				BaseFee:    money.FromFloat(1.5),
 				PercentFee: 0.025,
-				MinFee:     2.0,
-				MaxFee:     150.0,
+				MinFee:     money.FromFloat(2.0),
+				MaxFee:     money.FromFloat(150.0),
 			},
-			want: 150.0,
+			want: money.FromFloat(150.0),
 		},
 		{
 			name:   "zero amount uses min fee",
 			amount: 0.0,
 			fee: model.RefundMethodFee{
 				Method:     model.RefundSameMethod,
-				BaseFee:    1.5,
+				BaseFee:    money.FromFloat(1.5),
 				PercentFee: 0.025,
-				MinFee:     2.0,
-				MaxFee:     150.0,
+				MinFee:     money.FromFloat(2.0),
+				MaxFee:     money.FromFloat(150.0),
 			},
-			want: 2.0,
+			want: money.FromFloat(2.0),
 		},
 		{
 			name:   "zero amount with zero base and min fee",
@@ -222,19 +223,19 @@ func TestCalculate(t *testing.T) {
 				BaseFee:    0.0,
 				PercentFee: 0.01,
 				MinFee:     0.0,
-				MaxFee:     100.0,
+				MaxFee:     money.FromFloat(100.0),
 			},
 			want: 0.0,
 		},
 		{
 			name:   "reversal is always free",
-			amount: 5000.0,
+			amount: money.FromFloat(5000.0),
 			fee: model.RefundMethodFee{
 				Method:     model.RefundReversal,
-				BaseFee:    10.0,
+				BaseFee:    money.FromFloat(10.0),
 				PercentFee: 0.05,
-				MinFee:     5.0,
-				MaxFee:     500.0,
+				MinFee:     money.FromFloat(5.0),
+				MaxFee:     money.FromFloat(500.0),
 			},
 			want: 0.0,
 		},
@@ -252,106 +253,106 @@ func TestCalculate(t *testing.T) {
 		},
 		{
 			name:   "account credit is always free",
-			amount: 3000.0,
+			amount: money.FromFloat(3000.0),
 			fee: model.RefundMethodFee{
 				Method:     model.RefundAccountCredit,
-				BaseFee:    5.0,
+				BaseFee:    money.FromFloat(5.0),
 				PercentFee: 0.02,
-				MinFee:     3.0,
-				MaxFee:     200.0,
+				MinFee:     money.FromFloat(3.0),
+				MaxFee:     money.FromFloat(200.0),
 			},
 			want: 0.0,
 		},
 		{
 			name:   "max fee zero means no cap",
-			amount: 100000.0,
+			amount: money.FromFloat(100000.0),
 			fee: model.RefundMethodFee{
 				Method:     model.RefundSameMethod,
-				BaseFee:    5.0,
+				BaseFee:    money.FromFloat(5.0),
 				PercentFee: 0.008,
-				MinFee:     8.0,
+				MinFee:     money.FromFloat(8.0),
 				MaxFee:     0,
 			},
-			want: 805.0,
+			want: money.FromFloat(805.0),
 		},
 		{
 			name:   "cost exactly at min fee boundary",
-			amount: 50.0,
+			amount: money.FromFloat(50.0),
 			fee: model.RefundMethodFee{
 				Method:     model.RefundSameMethod,
-				BaseFee:    0.5,
+				BaseFee:    money.FromFloat(0.5),
 				PercentFee: 0.005,
-				MinFee:     0.75,
+				MinFee:     money.FromFloat(0.75),
 				MaxFee:     0,
 			},
-			want: 0.75,
+			want: money.FromFloat(0.75),
 		},
 		{
 			name:   "cost exactly at max fee boundary",
-			amount: 5920.0,
+			amount: money.FromFloat(5920.0),
 			fee: model.RefundMethodFee{
 				Method:     model.RefundSameMethod,
-				BaseFee:    1.5,
+				BaseFee:    money.FromFloat(1.5),
 				PercentFee: 0.025,
-				MinFee:     2.0,
-				MaxFee:     150.0,
+				MinFee:     money.FromFloat(2.0),
+				MaxFee:     money.FromFloat(150.0),
 			},
-			want: 149.5,
+			want: money.FromFloat(149.5),
 		},
 		{
 			name:   "result is rounded to two decimals",
-			amount: 33.33,
+			amount: money.FromFloat(33.33),
 			fee: model.RefundMethodFee{
 				Method:     model.RefundBankTransfer,
-				BaseFee:    1.0,
+				BaseFee:    money.FromFloat(1.0),
 				PercentFee: 0.015,
-				MinFee:     1.5,
-				MaxFee:     100.0,
+				MinFee:     money.FromFloat(1.5),
+				MaxFee:     money.FromFloat(100.0),
 			},
-			want: 1.5,
+			want: money.FromFloat(1.5),
 		},
 		{
 			name:   "large COP amount below cap",
-			amount: 15000000.0,
+			amount: money.FromFloat(15000000.0),
 			fee: model.RefundMethodFee{
 				Method:     model.RefundSameMethod,
-				BaseFee:    3500.0,
+				BaseFee:    money.FromFloat(3500.0),
 				PercentFee: 0.022,
-				MinFee:     5000.0,
-				MaxFee:     350000.0,
+				MinFee:     money.FromFloat(5000.0),
+				MaxFee:     money.FromFloat(350000.0),
 			},
-			want: 333500.0,
+			want: money.FromFloat(333500.0),
 		},
 		{
 			name:   "large COP amount hits cap",
-			amount: 20000000.0,
+			amount: money.FromFloat(20000000.0),
 			fee: model.RefundMethodFee{
 				Method:     model.RefundSameMethod,
-				BaseFee:    3500.0,
+				BaseFee:    money.FromFloat(3500.0),
 				PercentFee: 0.022,
-				MinFee:     5000.0,
-				MaxFee:     350000.0,
+				MinFee:     money.FromFloat(5000.0),
+				MaxFee:     money.FromFloat(350000.0),
 			},
-			want: 350000.0,
+			want: money.FromFloat(350000.0),
 		},
 		{
 			name:   "bank transfer mid range",
-			amount: 1000.0,
+			amount: money.FromFloat(1000.0),
 			fee: model.RefundMethodFee{
 				Method:     model.RefundBankTransfer,
-				BaseFee:    10.0,
+				BaseFee:    money.FromFloat(10.0),
 				PercentFee: 0.012,
-				MinFee:     15.0,
-				MaxFee:     1800.0,
+				MinFee:     money.FromFloat(15.0),
+				MaxFee:     money.FromFloat(1800.0),
 			},
-			want: 22.0,
+			want: money.FromFloat(22.0),
 		},
 	}
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			t.Parallel()
-			got := Calculate(tt.amount, tt.fee)
+			got := Calculate(tt.amount, tt.fee, 0)
 			if got != tt.want {
 				t.Errorf("Calculate(%v, %+v) = %v, want %v", tt.amount, tt.fee.Method, got, tt.want)
 			}
@@ -487,10 +488,10 @@ func TestFindMatchingFee(t *testing.T) {
 						Method:         model.RefundSameMethod,
 						PaymentMethods: []model.PaymentMethod{model.MethodCreditCard},
 						Currency:       "",
-						BaseFee:        1.0,
+						BaseFee:        money.FromFloat(1.0),
 						PercentFee:     0.01,
-						MinFee:         0.5,
-						MaxFee:         50.0,
+						MinFee:         money.FromFloat(0.5),
+						MaxFee:         money.FromFloat(50.0),
 					},
 				},
 			},
@@ -545,7 +546,7 @@ func TestCalculateNaive(t *testing.T) {
 	tests := []struct {
 		name string
 		tx   model.Transaction
-		want float64
+		want money.Amount
 	}{
 		{
 			name: "uses same method fee for credit card via paybr",
@@ -554,9 +555,9 @@ func TestCalculateNaive(t *testing.T) {
 				ProcessorID:   "paybr",
 				PaymentMethod: model.MethodCreditCard,
 				Currency:      model.CurrencyBRL,
-				Amount:        500.0,
+				Amount:        money.FromFloat(500.0),
 			},
-			want: 14.0,
+			want: money.FromFloat(14.0),
 		},
 		{
 			name: "uses same method fee for PIX via paybr",
@@ -565,9 +566,9 @@ func TestCalculateNaive(t *testing.T) {
 				ProcessorID:   "paybr",
 				PaymentMethod: model.MethodPIX,
 				Currency:      model.CurrencyBRL,
-				Amount:        200.0,
+				Amount:        money.FromFloat(200.0),
 			},
-			want: 1.5,
+			want: money.FromFloat(1.5),
 		},
 		{
 			name: "falls back to bank transfer when same method not available",
@@ -576,9 +577,9 @@ func TestCalculateNaive(t *testing.T) {
 				ProcessorID:   "paybr",
 				PaymentMethod: model.MethodBoleto,
 				Currency:      model.CurrencyBRL,
-				Amount:        1000.0,
+				Amount:        money.FromFloat(1000.0),
 			},
-			want: 16.0,
+			want: money.FromFloat(16.0),
 		},
 		{
 			name: "unknown processor falls back to 3.5 percent",
@@ -587,9 +588,9 @@ func TestCalculateNaive(t *testing.T) {
 				ProcessorID:   "unknown",
 				PaymentMethod: model.MethodCreditCard,
 				Currency:      model.CurrencyBRL,
-				Amount:        1000.0,
+				Amount:        money.FromFloat(1000.0),
 			},
-			want: 35.0,
+			want: money.FromFloat(35.0),
 		},
 		{
 			name: "mexpay credit card same method",
@@ -598,9 +599,9 @@ func TestCalculateNaive(t *testing.T) {
 				ProcessorID:   "mexpay",
 				PaymentMethod: model.MethodCreditCard,
 				Currency:      model.CurrencyMXN,
-				Amount:        2000.0,
+				Amount:        money.FromFloat(2000.0),
 			},
-			want: 55.0,
+			want: money.FromFloat(55.0),
 		},
 		{
 			name: "mexpay OXXO falls back to bank transfer",
@@ -609,9 +610,9 @@ func TestCalculateNaive(t *testing.T) {
 				ProcessorID:   "mexpay",
 				PaymentMethod: model.MethodOXXO,
 				Currency:      model.CurrencyMXN,
-				Amount:        500.0,
+				Amount:        money.FromFloat(500.0),
 			},
-			want: 16.0,
+			want: money.FromFloat(16.0),
 		},
 		{
 			name: "unknown processor with zero amount",
@@ -631,9 +632,9 @@ func TestCalculateNaive(t *testing.T) {
 				ProcessorID:   "paybr",
 				PaymentMethod: model.MethodCreditCard,
 				Currency:      model.CurrencyBRL,
-				Amount:        50000.0,
+				Amount:        money.FromFloat(50000.0),
 			},
-			want: 150.0,
+			want: money.FromFloat(150.0),
 		},
 		{
 			name: "no matching payment method or bank transfer falls back to 3.5 percent",
@@ -642,9 +643,9 @@ func TestCalculateNaive(t *testing.T) {
 				ProcessorID:   "mexpay",
 				PaymentMethod: model.MethodPSE,
 				Currency:      model.CurrencyMXN,
-				Amount:        1000.0,
+				Amount:        money.FromFloat(1000.0),
 			},
-			want: 35.0,
+			want: money.FromFloat(35.0),
 		},
 	}
 
@@ -667,10 +668,10 @@ func TestCalculateNaive_EmptyProcessorList(t *testing.T) {
 		ProcessorID:   "paybr",
 		PaymentMethod: model.MethodCreditCard,
 		Currency:      model.CurrencyBRL,
-		Amount:        1000.0,
+		Amount:        money.FromFloat(1000.0),
 	}
 	got := CalculateNaive(tx, nil)
-	want := math.Round(1000.0*0.035*100) / 100
+	want := money.FromFloat(1000.0).MulRate(0.035)
 	if got != want {
 		t.Errorf("CalculateNaive() with nil processors = %v, want %v", got, want)
 	}
@@ -783,3 +784,165 @@ func TestSupportsCountryAndCurrency(t *testing.T) {
 		})
 	}
 }
+
+func timePtr(t time.Time) *time.Time { return &t }
+
+func TestFindMatchingFeeAt_EffectiveDates(t *testing.T) {
+	t.Parallel()
+
+	jul := time.Date(2025, 7, 1, 0, 0, 0, 0, time.UTC)
+	oct := time.Date(2025, 10, 1, 0, 0, 0, 0, time.UTC)
+	pix := []model.PaymentMethod{model.MethodPIX}
+	proc := model.Processor{
+		ID: "paybr",
+		RefundFees: []model.RefundMethodFee{
+			{Method: model.RefundSameMethod, PaymentMethods: pix, Currency: model.CurrencyBRL, BaseFee: money.FromFloat(1.0)},
+			{Method: model.RefundSameMethod, PaymentMethods: pix, Currency: model.CurrencyBRL, BaseFee: money.FromFloat(0.5), EffectiveFrom: timePtr(jul), EffectiveTo: timePtr(oct)},
+			{Method: model.RefundSameMethod, PaymentMethods: pix, Currency: model.CurrencyBRL, BaseFee: money.FromFloat(0.25), EffectiveFrom: timePtr(oct)},
+		},
+	}
+
+	tests := []struct {
+		name    string
+		at      time.Time
+		wantFee money.Amount
+	}{
+		{"before any dated schedule uses undated fee", time.Date(2025, 3, 1, 0, 0, 0, 0, time.UTC), money.FromFloat(1.0)},
+		{"dated schedule overrides undated", time.Date(2025, 8, 1, 0, 0, 0, 0, time.UTC), money.FromFloat(0.5)},
+		{"effective_from is inclusive", jul, money.FromFloat(0.5)},
+		{"effective_to is exclusive", oct, money.FromFloat(0.25)},
+		{"open-ended future schedule", time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC), money.FromFloat(0.25)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			fee := FindMatchingFeeAt(proc, model.RefundSameMethod, model.MethodPIX, model.CurrencyBRL, tt.at)
+			if fee == nil {
+				t.Fatal("FindMatchingFeeAt() = nil")
+			}
+			if fee.BaseFee != tt.wantFee {
+				t.Errorf("BaseFee = %s, want %s", fee.BaseFee, tt.wantFee)
+			}
+		})
+	}
+}
+
+func TestFindMatchingFeeAt_ExpiredScheduleOnly(t *testing.T) {
+	t.Parallel()
+
+	proc := model.Processor{
+		ID: "paybr",
+		RefundFees: []model.RefundMethodFee{{
+			Method: model.RefundSameMethod, PaymentMethods: []model.PaymentMethod{model.MethodPIX}, Currency: model.CurrencyBRL,
+			EffectiveTo: timePtr(time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)),
+		}},
+	}
+	if fee := FindMatchingFeeAt(proc, model.RefundSameMethod, model.MethodPIX, model.CurrencyBRL, time.Date(2025, 6, 1, 0, 0, 0, 0, time.UTC)); fee != nil {
+		t.Errorf("FindMatchingFeeAt() = %+v, want nil for expired schedule", fee)
+	}
+}
+
+func TestProcessorActive(t *testing.T) {
+	t.Parallel()
+
+	start := time.Date(2025, 7, 1, 0, 0, 0, 0, time.UTC)
+	proc := model.Processor{ID: "newproc", EffectiveFrom: timePtr(start)}
+
+	if ProcessorActive(proc, start.Add(-time.Hour)) {
+		t.Error("ProcessorActive() before effective_from = true")
+	}
+	if !ProcessorActive(proc, start) {
+		t.Error("ProcessorActive() at effective_from = false")
+	}
+	if !ProcessorActive(model.Processor{ID: "undated"}, start) {
+		t.Error("ProcessorActive() for undated processor = false")
+	}
+}
+
+func TestPrice_Tiers(t *testing.T) {
+	t.Parallel()
+
+	fee := model.RefundMethodFee{
+		Method:      model.RefundSameMethod,
+		BaseFee:     money.FromFloat(1.0),
+		PercentFee:  0.03,
+		MinFee:      money.FromFloat(2.0),
+		MaxFee:      money.FromFloat(500.0),
+		AmountTiers: []model.AmountTier{{Above: money.FromFloat(10000), PercentFee: 0.02}, {Above: money.FromFloat(50000), PercentFee: 0.01}},
+		VolumeTiers: []model.VolumeTier{{MinMonthlyRefunds: 100, Discount: 0.10}, {MinMonthlyRefunds: 1000, Discount: 0.25}},
+	}
+
+	tests := []struct {
+		name           string
+		amount         money.Amount
+		volume         int
+		want           money.Amount
+		wantAmountTier money.Amount
+		wantVolumeTier int
+	}{
+		{"below first band", money.FromFloat(5000), 0, money.FromFloat(151.0), 0, 0},
+		{"portion above band at lower percent", money.FromFloat(15000), 0, money.FromFloat(401.0), money.FromFloat(10000), 0},
+		{"two bands clamped to max", money.FromFloat(60000), 0, money.FromFloat(500.0), money.FromFloat(50000), 0},
+		{"volume discount", money.FromFloat(5000), 150, money.FromFloat(135.9), 0, 100},
+		{"highest volume tier reached", money.FromFloat(5000), 2000, money.FromFloat(113.25), 0, 1000},
+		{"discount still floored by min fee", money.FromFloat(10), 2000, money.FromFloat(2.0), 0, 1000},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			p := Price(tt.amount, fee, tt.volume)
+			if p.Cost != tt.want {
+				t.Errorf("Cost = %s, want %s", p.Cost, tt.want)
+			}
+			if got := Calculate(tt.amount, fee, tt.volume); got != p.Cost {
+				t.Errorf("Calculate() = %s, Price().Cost = %s", got, p.Cost)
+			}
+			if tt.wantAmountTier == 0 && p.AmountTier != nil || tt.wantAmountTier != 0 && (p.AmountTier == nil || p.AmountTier.Above != tt.wantAmountTier) {
+				t.Errorf("AmountTier = %+v, want above %s", p.AmountTier, tt.wantAmountTier)
+			}
+			if tt.wantVolumeTier == 0 && p.VolumeTier != nil || tt.wantVolumeTier != 0 && (p.VolumeTier == nil || p.VolumeTier.MinMonthlyRefunds != tt.wantVolumeTier) {
+				t.Errorf("VolumeTier = %+v, want min %d", p.VolumeTier, tt.wantVolumeTier)
+			}
+		})
+	}
+}
+
+func TestPrice_Components(t *testing.T) {
+	t.Parallel()
+
+	fee := model.RefundMethodFee{
+		Method:      model.RefundSameMethod,
+		BaseFee:     money.FromFloat(1.0),
+		PercentFee:  0.03,
+		MinFee:      money.FromFloat(2.0),
+		MaxFee:      money.FromFloat(500.0),
+		VolumeTiers: []model.VolumeTier{{MinMonthlyRefunds: 100, Discount: 0.10}},
+	}
+
+	tests := []struct {
+		name         string
+		amount       money.Amount
+		volume       int
+		wantVariable money.Amount
+		wantDiscount money.Amount
+		wantClamp    model.FeeClamp
+	}{
+		{"unclamped", money.FromFloat(5000), 0, money.FromFloat(150), 0, ""},
+		{"volume discount", money.FromFloat(5000), 150, money.FromFloat(150), money.FromFloat(15.1), ""},
+		{"raised to min fee", money.FromFloat(10), 0, money.FromFloat(0.3), 0, model.ClampMin},
+		{"capped at max fee", money.FromFloat(20000), 0, money.FromFloat(600), 0, model.ClampMax},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			p := Price(tt.amount, fee, tt.volume)
+			if p.Base != fee.BaseFee || p.Variable != tt.wantVariable || p.Discount != tt.wantDiscount || p.Clamp != tt.wantClamp {
+				t.Errorf("Price() = base %s variable %s discount %s clamp %q, want base %s variable %s discount %s clamp %q",
+					p.Base, p.Variable, p.Discount, p.Clamp, fee.BaseFee, tt.wantVariable, tt.wantDiscount, tt.wantClamp)
+			}
+		})
+	}
+}
@@ -0,0 +1,68 @@
+package cost
+
+import (
+	"slices"
+
+	"github.com/ivanjtm/YunoChallenge/internal/model"
+	"github.com/ivanjtm/YunoChallenge/internal/money"
+)
+
+func Landed(tx model.Transaction, proc model.Processor, method model.RefundMethod, pricing Pricing, taxes []model.TaxRule) model.CostBreakdown {
+	fee := pricing.Cost
+	b := model.CostBreakdown{
+		BaseFee:          pricing.Base,
+		PercentComponent: pricing.Variable,
+		VolumeDiscount:   pricing.Discount,
+		Clamp:            pricing.Clamp,
+		AmountTier:       pricing.AmountTier,
+		VolumeTier:       pricing.VolumeTier,
+		Fee:              fee,
+		Total:            fee,
+	}
+	if method == model.RefundReversal || method == model.RefundAccountCredit {
+		return b
+	}
+
+	cur := string(tx.Currency)
+	b.FXMarkup = FXMarkupFor(tx, proc)
+
+	for _, t := range taxes {
+		if !TaxApplies(t, proc.ID, method, tx.PaymentMethod) {
+			continue
+		}
+		base := tx.Amount
+		if t.Base == model.TaxBaseFee {
+			base = fee
+		}
+		amount := base.MulRate(t.Rate).Round(cur)
+		if amount == 0 {
+			continue
+		}
+		b.Tax += amount
+		b.Taxes = append(b.Taxes, model.TaxLine{Name: t.Name, Amount: amount})
+	}
+
+	b.Total = b.Fee + b.Tax + b.FXMarkup
+	return b
+}
+
+func FXMarkupFor(tx model.Transaction, proc model.Processor) money.Amount {
+	m := proc.FXMarkup
+	if m == nil || m.Rate <= 0 || m.SettlementCurrency == tx.Currency {
+		return 0
+	}
+	return tx.Amount.MulRate(m.Rate).Round(string(tx.Currency))
+}
+
+func TaxApplies(t model.TaxRule, processorID string, method model.RefundMethod, paymentMethod model.PaymentMethod) bool {
+	if len(t.RefundMethods) > 0 && !slices.Contains(t.RefundMethods, method) {
+		return false
+	}
+	if len(t.PaymentMethods) > 0 && !slices.Contains(t.PaymentMethods, paymentMethod) {
+		return false
+	}
+	if len(t.Processors) > 0 && !slices.Contains(t.Processors, processorID) {
+		return false
+	}
+	return true
+}
@@ -0,0 +1,84 @@
+package cost
+
+import (
+	"testing"
+	"time"
+
+	"github.com/ivanjtm/YunoChallenge/internal/model"
+	"github.com/ivanjtm/YunoChallenge/internal/money"
+)
+
+func TestLanded(t *testing.T) {
+	t.Parallel()
+
+	crossBorder := model.Processor{ID: "globalpay", FXMarkup: &model.FXMarkup{Rate: 0.015, SettlementCurrency: model.CurrencyUSD}}
+	local := model.Processor{ID: "paybr"}
+	taxes := []model.TaxRule{
+		{Name: "IOF", Rate: 0.0038, Processors: []string{"globalpay"}},
+		{Name: "ISS", Rate: 0.05, Base: model.TaxBaseFee, RefundMethods: []model.RefundMethod{model.RefundBankTransfer}},
+	}
+	tx := model.Transaction{Currency: model.CurrencyBRL, PaymentMethod: model.MethodCreditCard, Amount: money.FromFloat(1000)}
+
+	tests := []struct {
+		name       string
+		proc       model.Processor
+		method     model.RefundMethod
+		fee        money.Amount
+		wantTax    money.Amount
+		wantMarkup money.Amount
+		wantTotal  money.Amount
+		wantLines  int
+	}{
+		{"cross-border same method", crossBorder, model.RefundSameMethod, money.FromFloat(22), money.FromFloat(3.8), money.FromFloat(15), money.FromFloat(40.8), 1},
+		{"cross-border bank transfer adds fee-based tax", crossBorder, model.RefundBankTransfer, money.FromFloat(20), money.FromFloat(4.8), money.FromFloat(15), money.FromFloat(39.8), 2},
+		{"local processor", local, model.RefundSameMethod, money.FromFloat(26.5), 0, 0, money.FromFloat(26.5), 0},
+		{"reversal has no extras", crossBorder, model.RefundReversal, 0, 0, 0, 0, 0},
+		{"account credit has no extras", crossBorder, model.RefundAccountCredit, 0, 0, 0, 0, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			b := Landed(tx, tt.proc, tt.method, Pricing{Cost: tt.fee}, taxes)
+			if b.Fee != tt.fee || b.Tax != tt.wantTax || b.FXMarkup != tt.wantMarkup || b.Total != tt.wantTotal {
+				t.Errorf("Landed() = %+v, want fee %s tax %s markup %s total %s", b, tt.fee, tt.wantTax, tt.wantMarkup, tt.wantTotal)
+			}
+			if len(b.Taxes) != tt.wantLines {
+				t.Errorf("len(Taxes) = %d, want %d", len(b.Taxes), tt.wantLines)
+			}
+		})
+	}
+}
+
+func TestFXMarkupFor_SettlementCurrency(t *testing.T) {
+	t.Parallel()
+
+	proc := model.Processor{ID: "globalpay", FXMarkup: &model.FXMarkup{Rate: 0.015, SettlementCurrency: model.CurrencyUSD}}
+	usd := model.Transaction{Currency: model.CurrencyUSD, Amount: money.FromFloat(100)}
+	if got := FXMarkupFor(usd, proc); got != 0 {
+		t.Errorf("FXMarkupFor(settlement currency) = %s, want 0", got)
+	}
+	cop := model.Transaction{Currency: model.CurrencyCOP, Amount: money.FromFloat(150033)}
+	if got := FXMarkupFor(cop, proc); got != money.FromFloat(2250) {
+		t.Errorf("FXMarkupFor(COP) = %s, want 2250 rounded to whole pesos", got)
+	}
+}
+
+func TestNaiveBreakdownAt_IncludesLandedCost(t *testing.T) {
+	t.Parallel()
+
+	proc := testProcessorPayBR()
+	proc.FXMarkup = &model.FXMarkup{Rate: 0.01, SettlementCurrency: model.CurrencyUSD}
+	tx := model.Transaction{
+		ProcessorID: "paybr", Currency: model.CurrencyBRL, PaymentMethod: model.MethodPIX, Amount: money.FromFloat(200),
+	}
+	taxes := []model.TaxRule{{Name: "IOF", Rate: 0.0038}}
+
+	b := NaiveBreakdownAt(tx, []model.Processor{proc}, taxes, time.Now(), 0)
+	if b.Fee != money.FromFloat(1.5) || b.FXMarkup != money.FromFloat(2) || b.Tax != money.FromFloat(0.76) || b.Total != money.FromFloat(4.26) {
+		t.Errorf("NaiveBreakdownAt() = %+v, want fee 1.50 + markup 2.00 + tax 0.76", b)
+	}
+	if got := CalculateNaiveAt(tx, []model.Processor{proc}, time.Now(), 0); got != money.FromFloat(3.5) {
+		t.Errorf("CalculateNaiveAt() = %s, want 3.50 without taxes", got)
+	}
+}
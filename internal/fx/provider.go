@@ -0,0 +1,112 @@
+package fx
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"time"
+
+	"github.com/ivanjtm/YunoChallenge/internal/model"
+	"github.com/ivanjtm/YunoChallenge/internal/money"
+)
+
+const dateLayout = "2006-01-02"
+
+var ErrNoRate = errors.New("no FX rate available")
+
+type Provider interface {
+	Rate(from, to model.Currency, on time.Time) (model.FXRate, error)
+}
+
+type RateTable struct {
+	Date  string                     `json:"date"`
+	Base  model.Currency             `json:"base"`
+	Rates map[model.Currency]float64 `json:"rates"`
+}
+
+type datedTable struct {
+	date  time.Time
+	table RateTable
+}
+
+type TableProvider struct {
+	tables []datedTable
+}
+
+func NewTableProvider(tables []RateTable) (*TableProvider, error) {
+	p := &TableProvider{}
+	for i, t := range tables {
+		d, err := time.Parse(dateLayout, t.Date)
+		if err != nil {
+			return nil, fmt.Errorf("rate table at index %d: invalid date %q", i, t.Date)
+		}
+		if t.Base == "" {
+			return nil, fmt.Errorf("rate table %s has empty base currency", t.Date)
+		}
+		for cur, rate := range t.Rates {
+			if rate <= 0 {
+				return nil, fmt.Errorf("rate table %s: rate for %s must be positive", t.Date, cur)
+			}
+		}
+		p.tables = append(p.tables, datedTable{date: d, table: t})
+	}
+	sort.Slice(p.tables, func(i, j int) bool {
+		return p.tables[i].date.Before(p.tables[j].date)
+	})
+	return p, nil
+}
+
+func NewFileProvider(path string) (*TableProvider, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var tables []RateTable
+	if err := json.NewDecoder(f).Decode(&tables); err != nil {
+		return nil, err
+	}
+	return NewTableProvider(tables)
+}
+
+func (p *TableProvider) Rate(from, to model.Currency, on time.Time) (model.FXRate, error) {
+	if from == to {
+		return model.FXRate{From: from, To: to, Rate: 1, Date: on.Format(dateLayout)}, nil
+	}
+
+	for i := len(p.tables) - 1; i >= 0; i-- {
+		dt := p.tables[i]
+		if dt.date.After(on) {
+			continue
+		}
+		fromRate, ok := rateFor(dt.table, from)
+		if !ok {
+			continue
+		}
+		toRate, ok := rateFor(dt.table, to)
+		if !ok {
+			continue
+		}
+		return model.FXRate{From: from, To: to, Rate: toRate / fromRate, Date: dt.table.Date}, nil
+	}
+	return model.FXRate{}, fmt.Errorf("%w: %s to %s on %s", ErrNoRate, from, to, on.Format(dateLayout))
+}
+
+func rateFor(t RateTable, cur model.Currency) (float64, bool) {
+	if cur == t.Base {
+		return 1, true
+	}
+	r, ok := t.Rates[cur]
+	return r, ok
+}
+
+func Convert(p Provider, amount money.Amount, from, to model.Currency, on time.Time) (money.Amount, model.FXRate, error) {
+	rate, err := p.Rate(from, to, on)
+	if err != nil {
+		return 0, model.FXRate{}, err
+	}
+	return amount.MulRate(rate.Rate).Round(string(to)), rate, nil
+}
@@ -0,0 +1,143 @@
+package fx
+
+import (
+	"errors"
+	"math"
+	"testing"
+	"time"
+
+	"github.com/ivanjtm/YunoChallenge/internal/model"
+	"github.com/ivanjtm/YunoChallenge/internal/money"
+)
+
+func testProvider(t *testing.T) *TableProvider {
+	t.Helper()
+	p, err := NewTableProvider([]RateTable{
+		{Date: "2025-07-01", Base: model.CurrencyUSD, Rates: map[model.Currency]float64{model.CurrencyBRL: 5.0, model.CurrencyMXN: 20.0}},
+		{Date: "2025-01-01", Base: model.CurrencyUSD, Rates: map[model.Currency]float64{model.CurrencyBRL: 6.0, model.CurrencyMXN: 20.0, model.CurrencyCOP: 4000.0}},
+	})
+	if err != nil {
+		t.Fatalf("NewTableProvider() error = %v", err)
+	}
+	return p
+}
+
+func TestTableProvider_Rate(t *testing.T) {
+	t.Parallel()
+
+	p := testProvider(t)
+
+	tests := []struct {
+		name     string
+		from, to model.Currency
+		on       time.Time
+		wantRate float64
+		wantDate string
+	}{
+		{"latest table on or before date", model.CurrencyBRL, model.CurrencyUSD, time.Date(2025, 8, 1, 0, 0, 0, 0, time.UTC), 0.2, "2025-07-01"},
+		{"earlier table", model.CurrencyBRL, model.CurrencyUSD, time.Date(2025, 3, 1, 0, 0, 0, 0, time.UTC), 1.0 / 6.0, "2025-01-01"},
+		{"cross rate", model.CurrencyMXN, model.CurrencyBRL, time.Date(2025, 8, 1, 0, 0, 0, 0, time.UTC), 0.25, "2025-07-01"},
+		{"falls back to table with currency", model.CurrencyCOP, model.CurrencyUSD, time.Date(2025, 8, 1, 0, 0, 0, 0, time.UTC), 1.0 / 4000.0, "2025-01-01"},
+		{"identity", model.CurrencyUSD, model.CurrencyUSD, time.Date(2025, 8, 1, 0, 0, 0, 0, time.UTC), 1, "2025-08-01"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			got, err := p.Rate(tt.from, tt.to, tt.on)
+			if err != nil {
+				t.Fatalf("Rate() error = %v", err)
+			}
+			if math.Abs(got.Rate-tt.wantRate) > 1e-9 {
+				t.Errorf("Rate = %v, want %v", got.Rate, tt.wantRate)
+			}
+			if got.Date != tt.wantDate {
+				t.Errorf("Date = %s, want %s", got.Date, tt.wantDate)
+			}
+		})
+	}
+}
+
+func TestTableProvider_NoRate(t *testing.T) {
+	t.Parallel()
+
+	p := testProvider(t)
+	_, err := p.Rate(model.CurrencyBRL, model.CurrencyUSD, time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC))
+	if !errors.Is(err, ErrNoRate) {
+		t.Errorf("Rate() before first table error = %v, want ErrNoRate", err)
+	}
+	_, err = p.Rate("EUR", model.CurrencyUSD, time.Date(2025, 8, 1, 0, 0, 0, 0, time.UTC))
+	if !errors.Is(err, ErrNoRate) {
+		t.Errorf("Rate(EUR) error = %v, want ErrNoRate", err)
+	}
+}
+
+func TestNewTableProvider_Invalid(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name  string
+		table RateTable
+	}{
+		{"bad date", RateTable{Date: "July", Base: model.CurrencyUSD}},
+		{"missing base", RateTable{Date: "2025-01-01"}},
+		{"non-positive rate", RateTable{Date: "2025-01-01", Base: model.CurrencyUSD, Rates: map[model.Currency]float64{model.CurrencyBRL: 0}}},
+	}
+	for _, tt := range tests {
+		if _, err := NewTableProvider([]RateTable{tt.table}); err == nil {
+			t.Errorf("%s: NewTableProvider() error = nil, want error", tt.name)
+		}
+	}
+}
+
+func TestNormalize(t *testing.T) {
+	t.Parallel()
+
+	p := testProvider(t)
+	totals := make(map[string]model.CurrencyTotals)
+	AddToCurrency(totals, model.CurrencyBRL, money.FromFloat(50), money.FromFloat(10))
+	AddToCurrency(totals, model.CurrencyMXN, money.FromFloat(200), money.FromFloat(100))
+	AddToCurrency(totals, "EUR", money.FromFloat(5), money.FromFloat(5))
+
+	report := Normalize(p, model.CurrencyUSD, time.Date(2025, 8, 1, 0, 0, 0, 0, time.UTC), totals)
+
+	if report.NaiveCost != money.FromFloat(20) || report.SmartCost != money.FromFloat(7) || report.Savings != money.FromFloat(13) {
+		t.Errorf("report = %+v, want naive 20, smart 7, savings 13", report)
+	}
+	if len(report.Rates) != 2 || report.Rates[0].From != model.CurrencyBRL {
+		t.Errorf("Rates = %+v, want BRL and MXN in order", report.Rates)
+	}
+	if len(report.MissingRates) != 1 || report.MissingRates[0] != "EUR" {
+		t.Errorf("MissingRates = %v, want [EUR]", report.MissingRates)
+	}
+	if Normalize(nil, model.CurrencyUSD, time.Now(), totals) != nil {
+		t.Error("Normalize(nil provider) != nil")
+	}
+}
+
+func TestLedger_ConvertsAtTransactionDate(t *testing.T) {
+	t.Parallel()
+
+	l := NewLedger(testProvider(t), model.CurrencyUSD)
+	l.Add(model.CurrencyBRL, money.FromFloat(60), money.FromFloat(30), time.Date(2025, 3, 1, 0, 0, 0, 0, time.UTC))
+	l.Add(model.CurrencyBRL, money.FromFloat(50), money.FromFloat(25), time.Date(2025, 8, 1, 0, 0, 0, 0, time.UTC))
+	l.Add("EUR", money.FromFloat(5), money.FromFloat(5), time.Date(2025, 8, 1, 0, 0, 0, 0, time.UTC))
+
+	report := l.Totals()
+
+	if report.NaiveCost != money.FromFloat(20) || report.SmartCost != money.FromFloat(10) || report.Savings != money.FromFloat(10) {
+		t.Errorf("report = %+v, want naive 20, smart 10, savings 10", report)
+	}
+	if report.SavingsPercent != 50 {
+		t.Errorf("SavingsPercent = %.2f, want 50", report.SavingsPercent)
+	}
+	if len(report.Rates) != 2 || report.Rates[0].Date != "2025-01-01" || report.Rates[1].Date != "2025-07-01" {
+		t.Errorf("Rates = %+v, want the January and July BRL rates", report.Rates)
+	}
+	if len(report.MissingRates) != 1 || report.MissingRates[0] != "EUR" {
+		t.Errorf("MissingRates = %v, want [EUR]", report.MissingRates)
+	}
+	if NewLedger(nil, model.CurrencyUSD).Totals() != nil {
+		t.Error("NewLedger(nil provider).Totals() != nil")
+	}
+}
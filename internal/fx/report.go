@@ -0,0 +1,142 @@
+package fx
+
+import (
+	"math"
+	"sort"
+	"time"
+
+	"github.com/ivanjtm/YunoChallenge/internal/model"
+	"github.com/ivanjtm/YunoChallenge/internal/money"
+)
+
+func AddToCurrency(totals map[string]model.CurrencyTotals, cur model.Currency, naive, smart money.Amount) {
+	ct := totals[string(cur)]
+	ct.Currency = cur
+	ct.NaiveCost += naive
+	ct.SmartCost += smart
+	ct.Savings += naive - smart
+	ct.TransactionCount++
+	totals[string(cur)] = ct
+}
+
+func RoundTotals(totals map[string]model.CurrencyTotals) {
+	for k, ct := range totals {
+		cur := string(ct.Currency)
+		ct.NaiveCost = ct.NaiveCost.Round(cur)
+		ct.SmartCost = ct.SmartCost.Round(cur)
+		ct.Savings = ct.Savings.Round(cur)
+		totals[k] = ct
+	}
+}
+
+func Normalize(p Provider, to model.Currency, on time.Time, totals map[string]model.CurrencyTotals) *model.ReportingTotals {
+	if p == nil || to == "" {
+		return nil
+	}
+
+	keys := make([]string, 0, len(totals))
+	for k := range totals {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	report := &model.ReportingTotals{Currency: to, Rates: make([]model.FXRate, 0, len(keys))}
+	for _, k := range keys {
+		ct := totals[k]
+		rate, err := p.Rate(ct.Currency, to, on)
+		if err != nil {
+			report.MissingRates = append(report.MissingRates, ct.Currency)
+			continue
+		}
+		report.Rates = append(report.Rates, rate)
+		report.NaiveCost += ct.NaiveCost.MulRate(rate.Rate)
+		report.SmartCost += ct.SmartCost.MulRate(rate.Rate)
+		report.Savings += ct.Savings.MulRate(rate.Rate)
+	}
+
+	finish(report)
+	return report
+}
+
+type Ledger struct {
+	provider Provider
+	report   *model.ReportingTotals
+	seen     map[model.FXRate]bool
+	missing  map[model.Currency]bool
+}
+
+func NewLedger(p Provider, to model.Currency) *Ledger {
+	if p == nil || to == "" {
+		return nil
+	}
+	return &Ledger{
+		provider: p,
+		report:   &model.ReportingTotals{Currency: to, Rates: make([]model.FXRate, 0)},
+		seen:     make(map[model.FXRate]bool),
+		missing:  make(map[model.Currency]bool),
+	}
+}
+
+func (l *Ledger) Convert(amount money.Amount, cur model.Currency, on time.Time) (money.Amount, bool) {
+	if l == nil {
+		return 0, false
+	}
+	rate, err := l.provider.Rate(cur, l.report.Currency, on)
+	if err != nil {
+		l.missing[cur] = true
+		return 0, false
+	}
+	if rate.From != rate.To && !l.seen[rate] {
+		l.seen[rate] = true
+		l.report.Rates = append(l.report.Rates, rate)
+	}
+	return amount.MulRate(rate.Rate), true
+}
+
+func (l *Ledger) Add(cur model.Currency, naive, smart money.Amount, on time.Time) {
+	if l == nil {
+		return
+	}
+	n, ok := l.Convert(naive, cur, on)
+	if !ok {
+		return
+	}
+	s, _ := l.Convert(smart, cur, on)
+	l.report.NaiveCost += n
+	l.report.SmartCost += s
+	l.report.Savings += n - s
+}
+
+func (l *Ledger) Totals() *model.ReportingTotals {
+	if l == nil {
+		return nil
+	}
+	report := l.report
+	sort.Slice(report.Rates, func(i, j int) bool {
+		if report.Rates[i].From != report.Rates[j].From {
+			return report.Rates[i].From < report.Rates[j].From
+		}
+		return report.Rates[i].Date < report.Rates[j].Date
+	})
+	report.MissingRates = nil
+	for cur := range l.missing {
+		report.MissingRates = append(report.MissingRates, cur)
+	}
+	sort.Slice(report.MissingRates, func(i, j int) bool { return report.MissingRates[i] < report.MissingRates[j] })
+	finish(report)
+	return report
+}
+
+func finish(report *model.ReportingTotals) {
+	to := string(report.Currency)
+	report.NaiveCost = report.NaiveCost.Round(to)
+	report.SmartCost = report.SmartCost.Round(to)
+	report.Savings = report.Savings.Round(to)
+	if report.NaiveCost > 0 {
+		report.SavingsPercent = round2(report.Savings.Ratio(report.NaiveCost) * 100)
+	}
+}
+
+func round2(v float64) float64 {
+	return math.Round(v*100) / 100
+}
@@ -48,6 +48,22 @@ func (h *BatchHandler) Handle(w http.ResponseWriter, r *http.Request) {
 		}
 	}
 
-	result := h.Router.AnalyzeBatch(req.Transactions, time.Now())
+	if msg := validateStrategy(h.Router, req.Strategy, req.DayValue); msg != "" {
+		WriteError(w, http.StatusBadRequest, "validation_error", msg)
+		return
+	}
+
+	opts := router.RouteOptions{Strategy: req.Strategy, DayValue: req.DayValue, Locale: requestLocale(w, r)}
+	var result model.BatchRefundResult
+	switch req.Allocation {
+	case "", model.AllocationIndependent:
+		result = h.Router.AnalyzeBatchWithOptions(req.Transactions, opts, time.Now())
+	case model.AllocationOptimized:
+		result = h.Router.OptimizeBatchWithOptions(req.Transactions, opts, time.Now())
+	default:
+		WriteError(w, http.StatusBadRequest, "validation_error",
+			fmt.Sprintf("unknown allocation %q (want independent or optimized)", req.Allocation))
+		return
+	}
 	WriteJSON(w, http.StatusOK, result)
 }
@@ -7,14 +7,16 @@ import (
 )
 
 type HealthHandler struct {
-	Config *internalconfig.AppConfig
+	Configs *internalconfig.Watcher
 }
 
 func (h *HealthHandler) Handle(w http.ResponseWriter, r *http.Request) {
+	cfg := h.Configs.Current()
 	WriteJSON(w, http.StatusOK, map[string]any{
 		"status":                 "ok",
-		"processors_loaded":      len(h.Config.Processors),
-		"rules_loaded":           len(h.Config.Rules),
-		"transactions_available": len(h.Config.Transactions),
+		"processors_loaded":      len(cfg.Processors),
+		"rules_loaded":           len(cfg.Rules),
+		"transactions_available": len(cfg.Transactions),
+		"config":                 h.Configs.Status(),
 	})
 }
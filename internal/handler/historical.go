@@ -5,13 +5,15 @@ import (
 	"net/http"
 	"time"
 
+	internalconfig "github.com/ivanjtm/YunoChallenge/internal/config"
 	"github.com/ivanjtm/YunoChallenge/internal/historical"
 	"github.com/ivanjtm/YunoChallenge/internal/model"
 	"github.com/ivanjtm/YunoChallenge/internal/router"
 )
 
 type HistoricalHandler struct {
-	Router *router.Router
+	Router  *router.Router
+	Configs *internalconfig.Watcher
 }
 
 func (h *HistoricalHandler) Handle(w http.ResponseWriter, r *http.Request) {
@@ -26,6 +28,14 @@ func (h *HistoricalHandler) Handle(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	result := historical.Analyze(req.Transactions, h.Router, time.Now())
+	switch req.FeeBasis {
+	case "", model.FeeBasisTransactionTime, model.FeeBasisCurrent:
+	default:
+		WriteError(w, http.StatusBadRequest, "validation_error",
+			"fee_basis must be \"transaction_time\" or \"current\"")
+		return
+	}
+
+	result := historical.Analyze(req.Transactions, h.Router, h.Configs.Current().Markets.RuleNotes, req.FeeBasis, time.Now())
 	WriteJSON(w, http.StatusOK, result)
 }
@@ -0,0 +1,144 @@
+package handler
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/ivanjtm/YunoChallenge/internal/i18n"
+)
+
+const (
+	IdempotencyHeader    = "Idempotency-Key"
+	maxIdempotencyKeyLen = 255
+)
+
+type idempotencyEntry struct {
+	fingerprint string
+	inFlight    bool
+	status      int
+	header      http.Header
+	body        []byte
+	expiresAt   time.Time
+}
+
+type IdempotencyStore struct {
+	mu        sync.Mutex
+	ttl       time.Duration
+	entries   map[string]*idempotencyEntry
+	lastSweep time.Time
+	now       func() time.Time
+}
+
+func NewIdempotencyStore(ttl time.Duration) *IdempotencyStore {
+	return &IdempotencyStore{
+		ttl:     ttl,
+		entries: make(map[string]*idempotencyEntry),
+		now:     time.Now,
+	}
+}
+
+func (s *IdempotencyStore) sweepLocked(now time.Time) {
+	if now.Sub(s.lastSweep) < time.Minute {
+		return
+	}
+	for k, e := range s.entries {
+		if !e.inFlight && now.After(e.expiresAt) {
+			delete(s.entries, k)
+		}
+	}
+	s.lastSweep = now
+}
+
+type captureWriter struct {
+	http.ResponseWriter
+	status int
+	body   bytes.Buffer
+}
+
+func (cw *captureWriter) WriteHeader(code int) {
+	cw.status = code
+	cw.ResponseWriter.WriteHeader(code)
+}
+
+func (cw *captureWriter) Write(b []byte) (int, error) {
+	cw.body.Write(b)
+	return cw.ResponseWriter.Write(b)
+}
+
+func IdempotencyMiddleware(store *IdempotencyStore) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			key := r.Header.Get(IdempotencyHeader)
+			if key == "" {
+				next.ServeHTTP(w, r)
+				return
+			}
+			if len(key) > maxIdempotencyKeyLen {
+				WriteError(w, http.StatusBadRequest, "validation_error", "Idempotency-Key must be at most 255 characters")
+				return
+			}
+
+			body, err := io.ReadAll(r.Body)
+			if err != nil {
+				WriteError(w, http.StatusBadRequest, "invalid_body", "Failed to read request body: "+err.Error())
+				return
+			}
+			r.Body = io.NopCloser(bytes.NewReader(body))
+
+			locale := i18n.Match(r.Header.Get("Accept-Language"))
+			sum := sha256.Sum256(append([]byte(r.Method+" "+r.URL.Path+" "+string(locale)+"\n"), body...))
+			fingerprint := hex.EncodeToString(sum[:])
+			scopedKey := r.Method + " " + r.URL.Path + " " + key
+
+			store.mu.Lock()
+			now := store.now()
+			store.sweepLocked(now)
+			if e, ok := store.entries[scopedKey]; ok && (e.inFlight || now.Before(e.expiresAt)) {
+				store.mu.Unlock()
+				switch {
+				case e.fingerprint != fingerprint:
+					WriteError(w, http.StatusUnprocessableEntity, "idempotency_key_reused",
+						"Idempotency-Key was already used with a different request body or language")
+				case e.inFlight:
+					WriteError(w, http.StatusConflict, "request_in_progress",
+						"A request with this Idempotency-Key is still being processed")
+				default:
+					for k, v := range e.header {
+						w.Header()[k] = append([]string(nil), v...)
+					}
+					w.Header().Set("Idempotent-Replayed", "true")
+					w.WriteHeader(e.status)
+					w.Write(e.body)
+				}
+				return
+			}
+			entry := &idempotencyEntry{fingerprint: fingerprint, inFlight: true}
+			store.entries[scopedKey] = entry
+			store.mu.Unlock()
+
+			cw := &captureWriter{ResponseWriter: w, status: http.StatusOK}
+			completed := false
+			defer func() {
+				store.mu.Lock()
+				defer store.mu.Unlock()
+				if !completed {
+					delete(store.entries, scopedKey)
+					return
+				}
+				entry.inFlight = false
+				entry.status = cw.status
+				entry.header = cw.Header().Clone()
+				entry.body = cw.body.Bytes()
+				entry.expiresAt = store.now().Add(store.ttl)
+			}()
+
+			next.ServeHTTP(cw, r)
+			completed = true
+		})
+	}
+}
@@ -0,0 +1,207 @@
+package handler
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+type idempotencyFixture struct {
+	store   *IdempotencyStore
+	handler http.Handler
+	calls   atomic.Int32
+	status  atomic.Int32
+	clock   time.Time
+	mu      sync.Mutex
+}
+
+func newIdempotencyFixture(t *testing.T, ttl time.Duration, block <-chan struct{}, entered chan<- struct{}) *idempotencyFixture {
+	t.Helper()
+	f := &idempotencyFixture{
+		store: NewIdempotencyStore(ttl),
+		clock: time.Date(2025, 6, 15, 12, 0, 0, 0, time.UTC),
+	}
+	f.status.Store(http.StatusOK)
+	f.store.now = func() time.Time {
+		f.mu.Lock()
+		defer f.mu.Unlock()
+		return f.clock
+	}
+	f.handler = IdempotencyMiddleware(f.store)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := f.calls.Add(1)
+		if entered != nil {
+			entered <- struct{}{}
+		}
+		if block != nil {
+			<-block
+		}
+		requestLocale(w, r)
+		WriteJSON(w, int(f.status.Load()), map[string]int32{"call": n})
+	}))
+	return f
+}
+
+func (f *idempotencyFixture) advance(d time.Duration) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.clock = f.clock.Add(d)
+}
+
+func (f *idempotencyFixture) do(key, body, lang string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/refunds", strings.NewReader(body))
+	if key != "" {
+		req.Header.Set(IdempotencyHeader, key)
+	}
+	if lang != "" {
+		req.Header.Set("Accept-Language", lang)
+	}
+	rec := httptest.NewRecorder()
+	f.handler.ServeHTTP(rec, req)
+	return rec
+}
+
+func TestIdempotency_Retries(t *testing.T) {
+	t.Parallel()
+
+	type retry struct {
+		key, body, lang string
+		wait            time.Duration
+	}
+	first := retry{key: "k1", body: `{"amount":100}`, lang: "pt-BR"}
+
+	tests := []struct {
+		name      string
+		firstCode int
+		retry     retry
+		wantCode  int
+		wantError string
+		replayed  bool
+		calls     int32
+	}{
+		{"same request replays", http.StatusOK, first, http.StatusOK, "", true, 1},
+		{"equivalent language replays", http.StatusOK, retry{key: "k1", body: `{"amount":100}`, lang: "pt-br;q=0.9"}, http.StatusOK, "", true, 1},
+		{"client errors replay", http.StatusUnprocessableEntity, first, http.StatusUnprocessableEntity, "", true, 1},
+		{"different body", http.StatusOK, retry{key: "k1", body: `{"amount":200}`, lang: "pt-BR"}, http.StatusUnprocessableEntity, "idempotency_key_reused", false, 1},
+		{"different language", http.StatusOK, retry{key: "k1", body: `{"amount":100}`, lang: "es-MX"}, http.StatusUnprocessableEntity, "idempotency_key_reused", false, 1},
+		{"different key runs again", http.StatusOK, retry{key: "k2", body: `{"amount":100}`, lang: "pt-BR"}, http.StatusOK, "", false, 2},
+		{"no key runs again", http.StatusOK, retry{body: `{"amount":100}`, lang: "pt-BR"}, http.StatusOK, "", false, 2},
+		{"just before expiry replays", http.StatusOK, retry{key: "k1", body: `{"amount":100}`, lang: "pt-BR", wait: time.Hour - time.Second}, http.StatusOK, "", true, 1},
+		{"expired key runs again", http.StatusOK, retry{key: "k1", body: `{"amount":200}`, lang: "es-MX", wait: time.Hour}, http.StatusOK, "", false, 2},
+		{"5xx replays", http.StatusInternalServerError, first, http.StatusInternalServerError, "", true, 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			f := newIdempotencyFixture(t, time.Hour, nil, nil)
+			f.status.Store(int32(tt.firstCode))
+			orig := f.do(first.key, first.body, first.lang)
+			if orig.Code != tt.firstCode {
+				t.Fatalf("first status = %d, want %d", orig.Code, tt.firstCode)
+			}
+
+			f.status.Store(http.StatusOK)
+			f.advance(tt.retry.wait)
+			rec := f.do(tt.retry.key, tt.retry.body, tt.retry.lang)
+			if rec.Code != tt.wantCode {
+				t.Errorf("retry status = %d, want %d: %s", rec.Code, tt.wantCode, rec.Body)
+			}
+			if tt.wantError != "" && !strings.Contains(rec.Body.String(), `"error":"`+tt.wantError+`"`) {
+				t.Errorf("retry body = %s, want error %s", rec.Body, tt.wantError)
+			}
+			if got := rec.Header().Get("Idempotent-Replayed") == "true"; got != tt.replayed {
+				t.Errorf("Idempotent-Replayed = %v, want %v", got, tt.replayed)
+			}
+			if tt.replayed {
+				if rec.Body.String() != orig.Body.String() {
+					t.Errorf("replayed body = %s, want %s", rec.Body, orig.Body)
+				}
+				if got := rec.Header().Get("Content-Language"); got != orig.Header().Get("Content-Language") {
+					t.Errorf("replayed Content-Language = %q, want %q", got, orig.Header().Get("Content-Language"))
+				}
+			}
+			if got := f.calls.Load(); got != tt.calls {
+				t.Errorf("handler calls = %d, want %d", got, tt.calls)
+			}
+		})
+	}
+}
+
+func TestIdempotency_InFlight(t *testing.T) {
+	t.Parallel()
+
+	block := make(chan struct{})
+	entered := make(chan struct{}, 1)
+	f := newIdempotencyFixture(t, time.Hour, block, entered)
+
+	done := make(chan *httptest.ResponseRecorder)
+	go func() { done <- f.do("k1", `{"amount":100}`, "") }()
+	<-entered
+
+	if rec := f.do("k1", `{"amount":100}`, ""); rec.Code != http.StatusConflict || !strings.Contains(rec.Body.String(), "request_in_progress") {
+		t.Errorf("retry while running = %d %s, want 409 request_in_progress", rec.Code, rec.Body)
+	}
+	if rec := f.do("k1", `{"amount":200}`, ""); rec.Code != http.StatusUnprocessableEntity {
+		t.Errorf("different body while running = %d, want 422", rec.Code)
+	}
+
+	close(block)
+	if rec := <-done; rec.Code != http.StatusOK {
+		t.Fatalf("first request status = %d, want 200", rec.Code)
+	}
+	if rec := f.do("k1", `{"amount":100}`, ""); rec.Header().Get("Idempotent-Replayed") != "true" {
+		t.Errorf("retry after completion = %d %s, want a replay", rec.Code, rec.Body)
+	}
+	if got := f.calls.Load(); got != 1 {
+		t.Errorf("handler calls = %d, want 1", got)
+	}
+}
+
+func TestIdempotency_KeyTooLong(t *testing.T) {
+	t.Parallel()
+
+	f := newIdempotencyFixture(t, time.Hour, nil, nil)
+	if rec := f.do(strings.Repeat("k", maxIdempotencyKeyLen+1), `{}`, ""); rec.Code != http.StatusBadRequest {
+		t.Errorf("status = %d, want 400", rec.Code)
+	}
+	if got := f.calls.Load(); got != 0 {
+		t.Errorf("handler calls = %d, want 0", got)
+	}
+}
+
+func TestIdempotency_PanicReleasesKey(t *testing.T) {
+	t.Parallel()
+
+	var calls atomic.Int32
+	store := NewIdempotencyStore(time.Hour)
+	handler := RecoveryMiddleware(IdempotencyMiddleware(store)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if calls.Add(1) == 1 {
+			panic("boom")
+		}
+		WriteJSON(w, http.StatusCreated, map[string]string{"id": "ref-1"})
+	})))
+	do := func() *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/api/v1/refunds", strings.NewReader(`{"amount":100}`))
+		req.Header.Set(IdempotencyHeader, "k1")
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		return rec
+	}
+
+	if rec := do(); rec.Code != http.StatusInternalServerError {
+		t.Fatalf("first status = %d, want 500 from the recovered panic", rec.Code)
+	}
+	if rec := do(); rec.Code != http.StatusCreated || rec.Header().Get("Idempotent-Replayed") != "" {
+		t.Errorf("retry after a panic = %d (replayed %q), want the handler to run again", rec.Code, rec.Header().Get("Idempotent-Replayed"))
+	}
+	if rec := do(); rec.Code != http.StatusCreated || rec.Header().Get("Idempotent-Replayed") != "true" {
+		t.Errorf("third request = %d, want the stored 201 replayed", rec.Code)
+	}
+	if got := calls.Load(); got != 2 {
+		t.Errorf("handler calls = %d, want 2", got)
+	}
+}
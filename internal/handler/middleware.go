@@ -7,6 +7,8 @@ import (
 	"runtime/debug"
 	"strings"
 	"time"
+
+	"github.com/ivanjtm/YunoChallenge/internal/i18n"
 )
 
 func Chain(h http.Handler, middlewares ...func(http.Handler) http.Handler) http.Handler {
@@ -89,3 +91,10 @@ func WriteError(w http.ResponseWriter, status int, errCode string, message strin
 		Message: message,
 	})
 }
+
+func requestLocale(w http.ResponseWriter, r *http.Request) i18n.Locale {
+	locale := i18n.Match(r.Header.Get("Accept-Language"))
+	w.Header().Set("Content-Language", string(locale))
+	w.Header().Add("Vary", "Accept-Language")
+	return locale
+}
@@ -2,15 +2,19 @@ package handler
 
 import (
 	"encoding/json"
+	"fmt"
 	"net/http"
 	"time"
 
 	"github.com/ivanjtm/YunoChallenge/internal/model"
+	"github.com/ivanjtm/YunoChallenge/internal/money"
+	"github.com/ivanjtm/YunoChallenge/internal/refund"
 	"github.com/ivanjtm/YunoChallenge/internal/router"
 )
 
 type RefundHandler struct {
-	Router *router.Router
+	Router  *router.Router
+	Refunds *refund.Service
 }
 
 func (h *RefundHandler) Handle(w http.ResponseWriter, r *http.Request) {
@@ -20,32 +24,83 @@ func (h *RefundHandler) Handle(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	tx := req.Transaction
-	if tx.ID == "" {
-		WriteError(w, http.StatusBadRequest, "validation_error", "transaction.id is required")
+	if msg := validateRefundTransaction(req.Transaction); msg != "" {
+		WriteError(w, http.StatusBadRequest, "validation_error", msg)
 		return
 	}
-	if tx.Country == "" {
-		WriteError(w, http.StatusBadRequest, "validation_error", "transaction.country is required")
+
+	if status, code, msg := checkRefundAmount(h.Refunds, req.Transaction, req.RefundAmount); msg != "" {
+		WriteError(w, status, code, msg)
 		return
 	}
-	if tx.Currency == "" {
-		WriteError(w, http.StatusBadRequest, "validation_error", "transaction.currency is required")
+
+	if msg := validateStrategy(h.Router, req.Strategy, req.DayValue); msg != "" {
+		WriteError(w, http.StatusBadRequest, "validation_error", msg)
 		return
 	}
-	if tx.PaymentMethod == "" {
-		WriteError(w, http.StatusBadRequest, "validation_error", "transaction.payment_method is required")
-		return
+
+	opts := router.RouteOptions{RefundAmount: req.RefundAmount, Strategy: req.Strategy, DayValue: req.DayValue, Locale: requestLocale(w, r)}
+	result := h.Router.SelectRouteWithOptions(req.Transaction, opts, time.Now())
+	WriteJSON(w, http.StatusOK, result)
+}
+
+func validateStrategy(rt *router.Router, strategy model.RoutingStrategy, dayValue float64) string {
+	if !rt.HasStrategy(strategy) {
+		return fmt.Sprintf("unknown strategy %q", strategy)
 	}
-	if tx.Amount <= 0 {
-		WriteError(w, http.StatusBadRequest, "validation_error", "transaction.amount must be positive")
-		return
+	if dayValue < 0 {
+		return "day_value must not be negative"
 	}
-	if tx.Timestamp.IsZero() {
-		WriteError(w, http.StatusBadRequest, "validation_error", "transaction.timestamp is required")
-		return
+	return ""
+}
+
+func checkRefundAmount(refunds *refund.Service, tx model.Transaction, amount money.Amount) (int, string, string) {
+	if amount < 0 {
+		return http.StatusBadRequest, "validation_error", "refund_amount must be positive"
+	}
+	cur := string(tx.Currency)
+	if amount.Round(cur) != amount {
+		return http.StatusBadRequest, "validation_error",
+			fmt.Sprintf("refund_amount %s has more decimals than %s allows (%d)", amount, tx.Currency, money.MinorUnits(cur))
+	}
+	if amount > tx.Amount {
+		return http.StatusUnprocessableEntity, "validation_error",
+			fmt.Sprintf("refund_amount %s exceeds transaction amount %s", amount.Format(cur), tx.Amount.Format(cur))
+	}
+	if refunds == nil {
+		return 0, "", ""
 	}
 
-	result := h.Router.SelectRoute(tx, time.Now())
-	WriteJSON(w, http.StatusOK, result)
+	if amount == 0 {
+		amount = tx.Amount
+	}
+	remaining, err := refunds.RemainingAmount(tx)
+	if err != nil {
+		return http.StatusInternalServerError, "internal_error", err.Error()
+	}
+	if amount > remaining {
+		return http.StatusUnprocessableEntity, "exceeds_refundable_amount",
+			fmt.Sprintf("refund_amount %s exceeds remaining refundable amount %s %s", amount.Format(cur), remaining.Format(cur), tx.Currency)
+	}
+	return 0, "", ""
+}
+
+func validateRefundTransaction(tx model.Transaction) string {
+	switch {
+	case tx.ID == "":
+		return "transaction.id is required"
+	case tx.Country == "":
+		return "transaction.country is required"
+	case tx.Currency == "":
+		return "transaction.currency is required"
+	case tx.PaymentMethod == "":
+		return "transaction.payment_method is required"
+	case tx.Amount <= 0:
+		return "transaction.amount must be positive"
+	case tx.Amount.Round(string(tx.Currency)) != tx.Amount:
+		return fmt.Sprintf("transaction.amount has more decimals than %s allows (%d)", tx.Currency, money.MinorUnits(string(tx.Currency)))
+	case tx.Timestamp.IsZero():
+		return "transaction.timestamp is required"
+	}
+	return ""
 }
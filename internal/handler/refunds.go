@@ -0,0 +1,156 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/ivanjtm/YunoChallenge/internal/model"
+	"github.com/ivanjtm/YunoChallenge/internal/processor"
+	"github.com/ivanjtm/YunoChallenge/internal/refund"
+	"github.com/ivanjtm/YunoChallenge/internal/router"
+)
+
+type RefundsHandler struct {
+	Router  *router.Router
+	Refunds *refund.Service
+}
+
+func (h *RefundsHandler) Create(w http.ResponseWriter, r *http.Request) {
+	var req model.ExecuteRefundRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		WriteError(w, http.StatusBadRequest, "invalid_json", "Failed to parse request body: "+err.Error())
+		return
+	}
+
+	if msg := validateRefundTransaction(req.Transaction); msg != "" {
+		WriteError(w, http.StatusBadRequest, "validation_error", msg)
+		return
+	}
+
+	if status, code, msg := checkRefundAmount(h.Refunds, req.Transaction, req.RefundAmount); msg != "" {
+		WriteError(w, status, code, msg)
+		return
+	}
+
+	if msg := validateStrategy(h.Router, req.Strategy, req.DayValue); msg != "" {
+		WriteError(w, http.StatusBadRequest, "validation_error", msg)
+		return
+	}
+
+	now := time.Now()
+	opts := router.RouteOptions{RefundAmount: req.RefundAmount, Strategy: req.Strategy, DayValue: req.DayValue, Locale: requestLocale(w, r)}
+	var route model.RefundRouteResult
+	if req.Candidate == nil {
+		route = h.Router.ReserveRouteWithOptions(req.Transaction, opts, now)
+	} else {
+		var err error
+		route, err = h.Router.ReserveCandidateWithOptions(req.Transaction, *req.Candidate, opts, now)
+		switch {
+		case errors.Is(err, router.ErrCandidateNotEligible):
+			WriteError(w, http.StatusUnprocessableEntity, "candidate_not_eligible", err.Error())
+			return
+		case errors.Is(err, router.ErrCandidateUnavailable):
+			WriteError(w, http.StatusConflict, "candidate_unavailable", err.Error())
+			return
+		}
+	}
+
+	rec, err := h.Refunds.ExecuteRoute(r.Context(), req.Transaction, route, now)
+	if err != nil && rec.ID == "" {
+		writeRefundError(w, err)
+		return
+	}
+	WriteJSON(w, http.StatusCreated, rec)
+}
+
+func (h *RefundsHandler) Get(w http.ResponseWriter, r *http.Request) {
+	rec, err := h.Refunds.Get(r.PathValue("id"))
+	if err != nil {
+		writeRefundError(w, err)
+		return
+	}
+	WriteJSON(w, http.StatusOK, rec)
+}
+
+func (h *RefundsHandler) List(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	records, err := h.Refunds.List(refund.Filter{
+		TransactionID: q.Get("transaction_id"),
+		Status:        model.RefundStatus(q.Get("status")),
+	})
+	if err != nil {
+		writeRefundError(w, err)
+		return
+	}
+	WriteJSON(w, http.StatusOK, map[string]any{
+		"count":   len(records),
+		"refunds": records,
+	})
+}
+
+func (h *RefundsHandler) Summary(w http.ResponseWriter, r *http.Request) {
+	summary, err := h.Refunds.Summary(r.PathValue("id"))
+	if err != nil {
+		writeRefundError(w, err)
+		return
+	}
+	WriteJSON(w, http.StatusOK, summary)
+}
+
+func (h *RefundsHandler) Cancel(w http.ResponseWriter, r *http.Request) {
+	rec, err := h.Refunds.Cancel(r.Context(), r.PathValue("id"), time.Now())
+	if err != nil {
+		writeRefundError(w, err)
+		return
+	}
+	WriteJSON(w, http.StatusOK, rec)
+}
+
+func (h *RefundsHandler) Refresh(w http.ResponseWriter, r *http.Request) {
+	rec, err := h.Refunds.Refresh(r.Context(), r.PathValue("id"), time.Now())
+	if err != nil {
+		writeRefundError(w, err)
+		return
+	}
+	WriteJSON(w, http.StatusOK, rec)
+}
+
+func (h *RefundsHandler) UpdateStatus(w http.ResponseWriter, r *http.Request) {
+	var req model.RefundStatusUpdate
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		WriteError(w, http.StatusBadRequest, "invalid_json", "Failed to parse request body: "+err.Error())
+		return
+	}
+	if req.Status == "" {
+		WriteError(w, http.StatusBadRequest, "validation_error", "status is required")
+		return
+	}
+
+	rec, err := h.Refunds.Transition(r.PathValue("id"), req.Status, req.Note, time.Now())
+	if err != nil {
+		writeRefundError(w, err)
+		return
+	}
+	WriteJSON(w, http.StatusOK, rec)
+}
+
+func writeRefundError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, refund.ErrNotFound):
+		WriteError(w, http.StatusNotFound, "not_found", err.Error())
+	case errors.Is(err, refund.ErrExceedsRefundable):
+		WriteError(w, http.StatusUnprocessableEntity, "exceeds_refundable_amount", err.Error())
+	case errors.Is(err, refund.ErrInvalidTransition):
+		WriteError(w, http.StatusConflict, "invalid_transition", err.Error())
+	case errors.Is(err, processor.ErrNotCancellable):
+		WriteError(w, http.StatusConflict, "not_cancellable", err.Error())
+	case errors.Is(err, processor.ErrTimeout):
+		WriteError(w, http.StatusGatewayTimeout, "processor_timeout", err.Error())
+	case errors.As(err, new(*processor.Error)):
+		WriteError(w, http.StatusBadGateway, "processor_error", err.Error())
+	default:
+		WriteError(w, http.StatusInternalServerError, "internal_error", err.Error())
+	}
+}
@@ -1,65 +1,98 @@
 package historical
 
 import (
-	"math"
 	"sort"
 	"time"
 
+	"github.com/ivanjtm/YunoChallenge/internal/fx"
 	"github.com/ivanjtm/YunoChallenge/internal/model"
+	"github.com/ivanjtm/YunoChallenge/internal/money"
 	"github.com/ivanjtm/YunoChallenge/internal/router"
 )
 
-func Analyze(txns []model.Transaction, r *router.Router, now time.Time) model.HistoricalAnalysis {
+func Analyze(txns []model.Transaction, r *router.Router, notes []model.ComplexRuleNote, basis model.FeeBasis, now time.Time) model.HistoricalAnalysis {
+	if basis == "" {
+		basis = model.FeeBasisTransactionTime
+	}
+
 	result := model.HistoricalAnalysis{
+		FeeBasis:          basis,
 		TotalTransactions: len(txns),
-		MonthlySavings:    make(map[string]float64),
+		MonthlySavings:    make(map[string]map[string]money.Amount),
+		ByCurrency:        make(map[string]model.CurrencyTotals),
 	}
+	ledger := fx.NewLedger(r.FX, r.ReportingCurrency)
+	reportingMonthly := make(map[string]money.Amount)
 
 	type corridorKey struct {
 		Country       model.Country
 		PaymentMethod model.PaymentMethod
 	}
 	corridorCosts := make(map[corridorKey]struct {
-		totalNaive float64
-		totalSmart float64
+		currency   model.Currency
+		totalNaive money.Amount
+		reporting  money.Amount
 		count      int
 	})
 	processorCosts := make(map[string]struct {
-		totalNaive float64
-		totalSmart float64
+		totalNaive map[string]money.Amount
+		counts     map[string]int
+		reporting  money.Amount
 		count      int
 	})
 
 	for _, tx := range txns {
-		route := r.SelectRoute(tx, now)
+		opts := router.RouteOptions{}
+		if basis == model.FeeBasisTransactionTime {
+			opts.PricingTime = tx.Timestamp
+		}
+		route := r.SelectRouteWithOptions(tx, opts, now)
 
 		naiveCost := route.NaiveCost
 		smartCost := route.Selected.EstimatedCost
 		savings := naiveCost - smartCost
+		cur := string(tx.Currency)
 
-		result.TotalActualCost += naiveCost
-		result.TotalSmartCost += smartCost
-		result.TotalSavings += savings
+		fx.AddToCurrency(result.ByCurrency, tx.Currency, naiveCost, smartCost)
+		ledger.Add(tx.Currency, naiveCost, smartCost, tx.Timestamp)
+		reportingNaive, _ := ledger.Convert(naiveCost, tx.Currency, tx.Timestamp)
 
 		monthKey := tx.Timestamp.Format("2006-01")
-		result.MonthlySavings[monthKey] += savings
+		if result.MonthlySavings[monthKey] == nil {
+			result.MonthlySavings[monthKey] = make(map[string]money.Amount)
+		}
+		result.MonthlySavings[monthKey][cur] += savings
+		if converted, ok := ledger.Convert(savings, tx.Currency, tx.Timestamp); ok {
+			reportingMonthly[monthKey] += converted
+		}
 
 		ck := corridorKey{tx.Country, tx.PaymentMethod}
 		entry := corridorCosts[ck]
+		entry.currency = tx.Currency
 		entry.totalNaive += naiveCost
-		entry.totalSmart += smartCost
+		entry.reporting += reportingNaive
 		entry.count++
 		corridorCosts[ck] = entry
 
 		pe := processorCosts[tx.ProcessorID]
-		pe.totalNaive += naiveCost
+		if pe.totalNaive == nil {
+			pe.totalNaive = make(map[string]money.Amount)
+			pe.counts = make(map[string]int)
+		}
+		pe.totalNaive[cur] += naiveCost
+		pe.counts[cur]++
+		pe.reporting += reportingNaive
 		pe.count++
 		processorCosts[tx.ProcessorID] = pe
 	}
 
-	result.TotalActualCost = math.Round(result.TotalActualCost*100) / 100
-	result.TotalSmartCost = math.Round(result.TotalSmartCost*100) / 100
-	result.TotalSavings = math.Round(result.TotalSavings*100) / 100
+	fx.RoundTotals(result.ByCurrency)
+	for _, month := range result.MonthlySavings {
+		for cur, amount := range month {
+			month[cur] = amount.Round(cur)
+		}
+	}
+	result.Reporting = ledger.Totals()
 
 	if len(txns) > 0 {
 		minTime := txns[0].Timestamp
@@ -74,7 +107,21 @@ func Analyze(txns []model.Transaction, r *router.Router, now time.Time) model.Hi
 		}
 		spanDays := maxTime.Sub(minTime).Hours() / 24
 		if spanDays > 0 {
-			result.AnnualProjection = math.Round(result.TotalSavings / spanDays * 365 * 100) / 100
+			for k, ct := range result.ByCurrency {
+				ct.AnnualProjection = ct.Savings.MulRate(365 / spanDays).Round(string(ct.Currency))
+				result.ByCurrency[k] = ct
+			}
+			if result.Reporting != nil {
+				result.Reporting.AnnualProjection = result.Reporting.Savings.MulRate(365 / spanDays).Round(string(result.Reporting.Currency))
+			}
+		}
+	}
+
+	if result.Reporting != nil {
+		to := string(result.Reporting.Currency)
+		result.Reporting.MonthlySavings = make(map[string]money.Amount, len(reportingMonthly))
+		for month, amount := range reportingMonthly {
+			result.Reporting.MonthlySavings[month] = amount.Round(to)
 		}
 	}
 
@@ -82,66 +129,57 @@ func Analyze(txns []model.Transaction, r *router.Router, now time.Time) model.Hi
 		result.MostExpensiveCorridors = append(result.MostExpensiveCorridors, model.CostCorridor{
 			Country:       ck.Country,
 			PaymentMethod: ck.PaymentMethod,
-			AvgCost:       math.Round(data.totalNaive/float64(data.count)*100) / 100,
-			TotalCost:     math.Round(data.totalNaive*100) / 100,
+			Currency:      data.currency,
+			AvgCost:       data.totalNaive.Div(int64(data.count)).Round(string(data.currency)),
+			TotalCost:     data.totalNaive.Round(string(data.currency)),
+			ReportingCost: reportingRound(result.Reporting, data.reporting),
 			Count:         data.count,
 		})
 	}
 	sort.Slice(result.MostExpensiveCorridors, func(i, j int) bool {
-		return result.MostExpensiveCorridors[i].TotalCost > result.MostExpensiveCorridors[j].TotalCost
+		a, b := result.MostExpensiveCorridors[i], result.MostExpensiveCorridors[j]
+		if a.ReportingCost != b.ReportingCost {
+			return a.ReportingCost > b.ReportingCost
+		}
+		if a.Country != b.Country {
+			return a.Country < b.Country
+		}
+		return a.PaymentMethod < b.PaymentMethod
 	})
 	if len(result.MostExpensiveCorridors) > 5 {
 		result.MostExpensiveCorridors = result.MostExpensiveCorridors[:5]
 	}
 
 	for procID, data := range processorCosts {
-		result.HighestCostProcessors = append(result.HighestCostProcessors, model.ProcessorCostRank{
-			ProcessorID: procID,
-			TotalCost:   math.Round(data.totalNaive*100) / 100,
-			AvgCost:     math.Round(data.totalNaive/float64(data.count)*100) / 100,
-			Count:       data.count,
-		})
+		rank := model.ProcessorCostRank{
+			ProcessorID:   procID,
+			TotalCost:     make(map[string]money.Amount, len(data.totalNaive)),
+			AvgCost:       make(map[string]money.Amount, len(data.totalNaive)),
+			ReportingCost: reportingRound(result.Reporting, data.reporting),
+			Count:         data.count,
+		}
+		for cur, total := range data.totalNaive {
+			rank.TotalCost[cur] = total.Round(cur)
+			rank.AvgCost[cur] = total.Div(int64(data.counts[cur])).Round(cur)
+		}
+		result.HighestCostProcessors = append(result.HighestCostProcessors, rank)
 	}
 	sort.Slice(result.HighestCostProcessors, func(i, j int) bool {
-		return result.HighestCostProcessors[i].TotalCost > result.HighestCostProcessors[j].TotalCost
+		a, b := result.HighestCostProcessors[i], result.HighestCostProcessors[j]
+		if a.ReportingCost != b.ReportingCost {
+			return a.ReportingCost > b.ReportingCost
+		}
+		return a.ProcessorID < b.ProcessorID
 	})
 
-	result.ComplexRefundRules = []model.ComplexRuleNote{
-		{
-			Rule:        "OXXO_NO_SELF_REFUND",
-			Description: "OXXO cash payments cannot be refunded as OXXO",
-			Impact:      "Forces SPEI bank transfer, typically higher cost than same-method refunds",
-		},
-		{
-			Rule:        "BOLETO_NO_SELF_REFUND",
-			Description: "Boleto voucher payments cannot be refunded as Boleto",
-			Impact:      "Requires PIX or bank transfer; PIX is much cheaper when within 90-day window",
-		},
-		{
-			Rule:        "EFECTY_NO_SELF_REFUND",
-			Description: "Efecty cash payments cannot be refunded as Efecty",
-			Impact:      "Requires PSE or bank transfer; PSE is cheaper when within 60-day window",
-		},
-		{
-			Rule:        "PIX_90_DAY_WINDOW",
-			Description: "PIX-to-PIX refunds only available within 90 days of original transaction",
-			Impact:      "After 90 days, must use bank transfer at ~3x the cost of PIX refund",
-		},
-		{
-			Rule:        "PSE_60_DAY_WINDOW",
-			Description: "PSE-to-PSE refunds only available within 60 days of original transaction",
-			Impact:      "After 60 days, must use bank transfer at ~2x the cost of PSE refund",
-		},
-		{
-			Rule:        "REVERSAL_24H_WINDOW",
-			Description: "Free reversals (voids) only available for unsettled transactions within 24 hours",
-			Impact:      "Catching transactions within this window saves 100% of refund fees",
-		},
-	}
-
-	for k, v := range result.MonthlySavings {
-		result.MonthlySavings[k] = math.Round(v*100) / 100
-	}
+	result.ComplexRefundRules = append([]model.ComplexRuleNote{}, notes...)
 
 	return result
 }
+
+func reportingRound(report *model.ReportingTotals, amount money.Amount) money.Amount {
+	if report == nil {
+		return 0
+	}
+	return amount.Round(string(report.Currency))
+}
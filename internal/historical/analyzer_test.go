@@ -0,0 +1,221 @@
+package historical
+
+import (
+	"testing"
+	"time"
+
+	"github.com/ivanjtm/YunoChallenge/internal/fx"
+	"github.com/ivanjtm/YunoChallenge/internal/model"
+	"github.com/ivanjtm/YunoChallenge/internal/money"
+	"github.com/ivanjtm/YunoChallenge/internal/router"
+)
+
+var repricedAt = time.Date(2025, 3, 1, 0, 0, 0, 0, time.UTC)
+
+func testRouter(t *testing.T) *router.Router {
+	t.Helper()
+	fee := func(method model.RefundMethod, pm model.PaymentMethod, cur model.Currency, base float64, from, to *time.Time) model.RefundMethodFee {
+		return model.RefundMethodFee{Method: method, PaymentMethods: []model.PaymentMethod{pm}, Currency: cur, BaseFee: money.FromFloat(base), EffectiveFrom: from, EffectiveTo: to}
+	}
+	processors := []model.Processor{
+		{
+			ID: "orig", Name: "Orig",
+			SupportedCountries:  []model.Country{model.CountryBR, model.CountryMX},
+			SupportedCurrencies: []model.Currency{model.CurrencyBRL, model.CurrencyMXN},
+			RefundFees: []model.RefundMethodFee{
+				fee(model.RefundSameMethod, model.MethodPIX, model.CurrencyBRL, 10, nil, &repricedAt),
+				fee(model.RefundSameMethod, model.MethodPIX, model.CurrencyBRL, 6, &repricedAt, nil),
+				fee(model.RefundSameMethod, model.MethodCreditCard, model.CurrencyMXN, 32, nil, nil),
+			},
+			ProcessingDays: map[model.RefundMethod]int{model.RefundSameMethod: 1},
+		},
+		{
+			ID: "cheap", Name: "Cheap",
+			SupportedCountries:  []model.Country{model.CountryBR, model.CountryMX},
+			SupportedCurrencies: []model.Currency{model.CurrencyBRL, model.CurrencyMXN},
+			RefundFees: []model.RefundMethodFee{
+				fee(model.RefundBankTransfer, model.MethodPIX, model.CurrencyBRL, 4, nil, &repricedAt),
+				fee(model.RefundBankTransfer, model.MethodPIX, model.CurrencyBRL, 1, &repricedAt, nil),
+				fee(model.RefundBankTransfer, model.MethodCreditCard, model.CurrencyMXN, 20, nil, nil),
+			},
+			ProcessingDays: map[model.RefundMethod]int{model.RefundBankTransfer: 1},
+		},
+	}
+	allowed := []model.AllowedRefund{
+		{Method: model.RefundSameMethod, MaxAgeDays: 365},
+		{Method: model.RefundBankTransfer, MaxAgeDays: 365},
+	}
+	r := router.NewRouter(processors, []model.CompatibilityRule{
+		{OriginalMethod: model.MethodPIX, Country: model.CountryBR, AllowedRefunds: allowed},
+		{OriginalMethod: model.MethodCreditCard, Country: model.CountryMX, AllowedRefunds: allowed},
+	})
+
+	provider, err := fx.NewTableProvider([]fx.RateTable{
+		{Date: "2025-01-01", Base: model.CurrencyUSD, Rates: map[model.Currency]float64{model.CurrencyBRL: 5, model.CurrencyMXN: 20}},
+		{Date: "2025-02-01", Base: model.CurrencyUSD, Rates: map[model.Currency]float64{model.CurrencyBRL: 4, model.CurrencyMXN: 16}},
+	})
+	if err != nil {
+		t.Fatalf("NewTableProvider() error = %v", err)
+	}
+	r.FX = provider
+	return r
+}
+
+func testTransactions() []model.Transaction {
+	tx := func(id string, country model.Country, cur model.Currency, pm model.PaymentMethod, amount float64, at time.Time) model.Transaction {
+		return model.Transaction{ID: id, Country: country, Currency: cur, PaymentMethod: pm, ProcessorID: "orig", Amount: money.FromFloat(amount), Timestamp: at, Settled: true}
+	}
+	return []model.Transaction{
+		tx("jan_brl", model.CountryBR, model.CurrencyBRL, model.MethodPIX, 100, time.Date(2025, 1, 15, 12, 0, 0, 0, time.UTC)),
+		tx("feb_brl", model.CountryBR, model.CurrencyBRL, model.MethodPIX, 100, time.Date(2025, 2, 15, 12, 0, 0, 0, time.UTC)),
+		tx("feb_mxn", model.CountryMX, model.CurrencyMXN, model.MethodCreditCard, 500, time.Date(2025, 2, 20, 12, 0, 0, 0, time.UTC)),
+	}
+}
+
+func TestAnalyze_FeeBasis(t *testing.T) {
+	t.Parallel()
+
+	now := time.Date(2025, 6, 15, 12, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name      string
+		basis     model.FeeBasis
+		wantBasis model.FeeBasis
+		brl       model.CurrencyTotals
+		reporting model.ReportingTotals
+	}{
+		{
+			name: "default prices at transaction time", wantBasis: model.FeeBasisTransactionTime,
+			brl:       model.CurrencyTotals{Currency: model.CurrencyBRL, NaiveCost: money.FromFloat(20), SmartCost: money.FromFloat(8), Savings: money.FromFloat(12), TransactionCount: 2},
+			reporting: model.ReportingTotals{NaiveCost: money.FromFloat(6.5), SmartCost: money.FromFloat(3.05), Savings: money.FromFloat(3.45)},
+		},
+		{
+			name: "transaction time uses the fees then in effect", basis: model.FeeBasisTransactionTime, wantBasis: model.FeeBasisTransactionTime,
+			brl:       model.CurrencyTotals{Currency: model.CurrencyBRL, NaiveCost: money.FromFloat(20), SmartCost: money.FromFloat(8), Savings: money.FromFloat(12), TransactionCount: 2},
+			reporting: model.ReportingTotals{NaiveCost: money.FromFloat(6.5), SmartCost: money.FromFloat(3.05), Savings: money.FromFloat(3.45)},
+		},
+		{
+			name: "current uses today's fees", basis: model.FeeBasisCurrent, wantBasis: model.FeeBasisCurrent,
+			brl:       model.CurrencyTotals{Currency: model.CurrencyBRL, NaiveCost: money.FromFloat(12), SmartCost: money.FromFloat(2), Savings: money.FromFloat(10), TransactionCount: 2},
+			reporting: model.ReportingTotals{NaiveCost: money.FromFloat(4.7), SmartCost: money.FromFloat(1.7), Savings: money.FromFloat(3)},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			got := Analyze(testTransactions(), testRouter(t), nil, tt.basis, now)
+			if got.FeeBasis != tt.wantBasis {
+				t.Errorf("FeeBasis = %q, want %q", got.FeeBasis, tt.wantBasis)
+			}
+			brl := got.ByCurrency["BRL"]
+			brl.AnnualProjection = 0
+			if brl != tt.brl {
+				t.Errorf("ByCurrency[BRL] = %+v, want %+v", brl, tt.brl)
+			}
+			rep := got.Reporting
+			if rep == nil {
+				t.Fatal("Reporting = nil")
+			}
+			if rep.NaiveCost != tt.reporting.NaiveCost || rep.SmartCost != tt.reporting.SmartCost || rep.Savings != tt.reporting.Savings {
+				t.Errorf("Reporting = naive %s, smart %s, savings %s, want %s, %s, %s",
+					rep.NaiveCost, rep.SmartCost, rep.Savings, tt.reporting.NaiveCost, tt.reporting.SmartCost, tt.reporting.Savings)
+			}
+		})
+	}
+}
+
+func TestAnalyze_ConvertsEachTransactionAtItsDate(t *testing.T) {
+	t.Parallel()
+
+	now := time.Date(2025, 6, 15, 12, 0, 0, 0, time.UTC)
+	got := Analyze(testTransactions(), testRouter(t), nil, model.FeeBasisTransactionTime, now)
+
+	wantRates := []model.FXRate{
+		{From: model.CurrencyBRL, To: model.CurrencyUSD, Rate: 0.2, Date: "2025-01-01"},
+		{From: model.CurrencyBRL, To: model.CurrencyUSD, Rate: 0.25, Date: "2025-02-01"},
+		{From: model.CurrencyMXN, To: model.CurrencyUSD, Rate: 0.0625, Date: "2025-02-01"},
+	}
+	if len(got.Reporting.Rates) != len(wantRates) {
+		t.Fatalf("Reporting.Rates = %+v, want %+v", got.Reporting.Rates, wantRates)
+	}
+	for i, want := range wantRates {
+		if got.Reporting.Rates[i] != want {
+			t.Errorf("Reporting.Rates[%d] = %+v, want %+v", i, got.Reporting.Rates[i], want)
+		}
+	}
+
+	wantMonthly := map[string]money.Amount{"2025-01": money.FromFloat(1.2), "2025-02": money.FromFloat(2.25)}
+	if len(got.Reporting.MonthlySavings) != len(wantMonthly) {
+		t.Errorf("Reporting.MonthlySavings = %v, want %v", got.Reporting.MonthlySavings, wantMonthly)
+	}
+	for month, want := range wantMonthly {
+		if got.Reporting.MonthlySavings[month] != want {
+			t.Errorf("Reporting.MonthlySavings[%s] = %s, want %s", month, got.Reporting.MonthlySavings[month], want)
+		}
+	}
+	if len(got.Reporting.MissingRates) != 0 {
+		t.Errorf("Reporting.MissingRates = %v, want none", got.Reporting.MissingRates)
+	}
+}
+
+func TestAnalyze_KeepsCurrenciesApart(t *testing.T) {
+	t.Parallel()
+
+	now := time.Date(2025, 6, 15, 12, 0, 0, 0, time.UTC)
+	got := Analyze(testTransactions(), testRouter(t), nil, model.FeeBasisTransactionTime, now)
+
+	mxn := got.ByCurrency["MXN"]
+	if mxn.NaiveCost != money.FromFloat(32) || mxn.SmartCost != money.FromFloat(20) || mxn.TransactionCount != 1 {
+		t.Errorf("ByCurrency[MXN] = %+v, want naive 32, smart 20 from the one MXN refund", mxn)
+	}
+	if len(got.ByCurrency) != 2 {
+		t.Errorf("ByCurrency has %d currencies, want BRL and MXN", len(got.ByCurrency))
+	}
+
+	wantMonthly := map[string]map[string]money.Amount{
+		"2025-01": {"BRL": money.FromFloat(6)},
+		"2025-02": {"BRL": money.FromFloat(6), "MXN": money.FromFloat(12)},
+	}
+	for month, want := range wantMonthly {
+		gotMonth := got.MonthlySavings[month]
+		if len(gotMonth) != len(want) {
+			t.Errorf("MonthlySavings[%s] = %v, want %v", month, gotMonth, want)
+			continue
+		}
+		for cur, amount := range want {
+			if gotMonth[cur] != amount {
+				t.Errorf("MonthlySavings[%s][%s] = %s, want %s", month, cur, gotMonth[cur], amount)
+			}
+		}
+	}
+
+	if len(got.HighestCostProcessors) != 1 {
+		t.Fatalf("HighestCostProcessors = %+v, want only orig", got.HighestCostProcessors)
+	}
+	orig := got.HighestCostProcessors[0]
+	if orig.Count != 3 || orig.ReportingCost != money.FromFloat(6.5) {
+		t.Errorf("orig = %d refunds costing %s, want 3 costing 6.50 USD", orig.Count, orig.ReportingCost)
+	}
+	for cur, want := range map[string][2]money.Amount{"BRL": {money.FromFloat(20), money.FromFloat(10)}, "MXN": {money.FromFloat(32), money.FromFloat(32)}} {
+		if orig.TotalCost[cur] != want[0] || orig.AvgCost[cur] != want[1] {
+			t.Errorf("orig %s total %s avg %s, want %s and %s", cur, orig.TotalCost[cur], orig.AvgCost[cur], want[0], want[1])
+		}
+	}
+
+	corridors := got.MostExpensiveCorridors
+	if len(corridors) != 2 {
+		t.Fatalf("MostExpensiveCorridors = %+v, want BR/PIX and MX/CREDIT_CARD", corridors)
+	}
+	if corridors[0].Country != model.CountryBR || corridors[1].Country != model.CountryMX {
+		t.Errorf("corridors ranked %s, %s, want BR first by reporting cost despite the larger MXN amount", corridors[0].Country, corridors[1].Country)
+	}
+	want := []model.CostCorridor{
+		{Country: model.CountryBR, PaymentMethod: model.MethodPIX, Currency: model.CurrencyBRL, AvgCost: money.FromFloat(10), TotalCost: money.FromFloat(20), ReportingCost: money.FromFloat(4.5), Count: 2},
+		{Country: model.CountryMX, PaymentMethod: model.MethodCreditCard, Currency: model.CurrencyMXN, AvgCost: money.FromFloat(32), TotalCost: money.FromFloat(32), ReportingCost: money.FromFloat(2), Count: 1},
+	}
+	for i := range want {
+		if corridors[i] != want[i] {
+			t.Errorf("MostExpensiveCorridors[%d] = %+v, want %+v", i, corridors[i], want[i])
+		}
+	}
+}
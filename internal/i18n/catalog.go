@@ -0,0 +1,129 @@
+package i18n
+
+type Key string
+
+const (
+	KeyReversalWindow          Key = "WITHIN_REVERSAL_WINDOW"
+	KeyWithinWindow            Key = "WITHIN_WINDOW"
+	KeyNoTimeLimit             Key = "NO_TIME_LIMIT"
+	KeyNoRules                 Key = "NO_RULES"
+	KeyNoEligibleMethods       Key = "NO_ELIGIBLE_METHODS"
+	KeyProcessorsUnavailable   Key = "PROCESSORS_UNAVAILABLE"
+	KeyHigherRankedUnavailable Key = "HIGHER_RANKED_UNAVAILABLE"
+	KeyAlreadySettled          Key = "ALREADY_SETTLED"
+	KeyReversalWindowExpired   Key = "REVERSAL_WINDOW_EXPIRED"
+	KeyReversalCutoffPassed    Key = "REVERSAL_CUTOFF_PASSED"
+	KeyWindowExpired           Key = "WINDOW_EXPIRED"
+	KeyReversalWindowClosing   Key = "REVERSAL_WINDOW_CLOSING"
+	KeyWindowClosing           Key = "WINDOW_CLOSING"
+	KeyNoCandidates            Key = "NO_CANDIDATES"
+	KeyAccountCredit           Key = "ACCOUNT_CREDIT"
+	KeyRouteReversal           Key = "ROUTE_REVERSAL"
+	KeyRoute                   Key = "ROUTE"
+	KeyMethodSame              Key = "METHOD_SAME"
+	KeyMethodBankTransfer      Key = "METHOD_BANK_TRANSFER"
+	KeyCostBasePercent         Key = "COST_BASE_PERCENT"
+	KeyCostPercent             Key = "COST_PERCENT"
+	KeyCostFlat                Key = "COST_FLAT"
+	KeyTimeInstant             Key = "TIME_INSTANT"
+	KeyTimeOneDay              Key = "TIME_ONE_DAY"
+	KeyTimeDays                Key = "TIME_DAYS"
+	KeyAmountTier              Key = "AMOUNT_TIER"
+	KeyVolumeTier              Key = "VOLUME_TIER"
+	KeyLandedCost              Key = "LANDED_COST"
+	KeyTaxLine                 Key = "TAX_LINE"
+	KeyFXMarkup                Key = "FX_MARKUP"
+)
+
+var catalog = map[string]map[Key]string{
+	"en": {
+		KeyReversalWindow:          "Transaction is %s hours old and unsettled; free reversal available",
+		KeyWithinWindow:            "Within %s window (%d of %d days used, %d remaining)",
+		KeyNoTimeLimit:             "No time limit for this refund method",
+		KeyNoRules:                 "No compatibility rules found; only account credit available",
+		KeyNoEligibleMethods:       "No eligible refund methods; falling back to account credit",
+		KeyProcessorsUnavailable:   "All eligible processors unavailable; defaulting to account credit",
+		KeyHigherRankedUnavailable: "%d higher-ranked option(s) skipped due to processor availability",
+		KeyAlreadySettled:          "Transaction already settled; reversal not available",
+		KeyReversalWindowExpired:   "Transaction is %s hours old; reversal requires < %d hours",
+		KeyReversalCutoffPassed:    "Transaction is %s hours old; the reversal cutoff at %s %s has passed",
+		KeyWindowExpired:           "Transaction is %d days old; %s window is %d days",
+		KeyReversalWindowClosing:   "Free reversal window closes in %s hours",
+		KeyWindowClosing:           "%s refund window expires in %d days. After expiry, more expensive alternatives required.",
+		KeyNoCandidates:            "No eligible refund methods found; defaulting to account credit",
+		KeyAccountCredit:           "%s; funds credited to customer marketplace balance",
+		KeyRouteReversal:           "Free reversal via %s; %s",
+		KeyRoute:                   "%s via %s: %s, %s processing; %s",
+		KeyMethodSame:              "%s-to-%s",
+		KeyMethodBankTransfer:      "bank transfer",
+		KeyCostBasePercent:         "%s base + %s%% = %s",
+		KeyCostPercent:             "%s%% = %s",
+		KeyCostFlat:                "%s",
+		KeyTimeInstant:             "instant",
+		KeyTimeOneDay:              "1 day",
+		KeyTimeDays:                "%d days",
+		KeyAmountTier:              "amount tier above %s at %s%% applied",
+		KeyVolumeTier:              "volume tier %d+ refunds/month applied (%s%% discount)",
+		KeyLandedCost:              "landed cost %s incl. %s",
+		KeyTaxLine:                 "%s %s",
+		KeyFXMarkup:                "FX markup %s",
+	},
+	"pt": {
+		KeyReversalWindow:          "A transação tem %s horas e não foi liquidada; reversão gratuita disponível",
+		KeyWithinWindow:            "Dentro do prazo de %s (%d de %d dias usados, %d restantes)",
+		KeyNoTimeLimit:             "Sem prazo limite para este método de reembolso",
+		KeyNoRules:                 "Nenhuma regra de compatibilidade encontrada; apenas crédito em conta disponível",
+		KeyNoEligibleMethods:       "Nenhum método de reembolso elegível; usando crédito em conta",
+		KeyProcessorsUnavailable:   "Todos os processadores elegíveis estão indisponíveis; usando crédito em conta",
+		KeyHigherRankedUnavailable: "%d opção(ões) mais bem classificada(s) ignorada(s) por indisponibilidade do processador",
+		KeyAlreadySettled:          "Transação já liquidada; reversão não disponível",
+		KeyReversalWindowExpired:   "A transação tem %s horas; a reversão exige menos de %d horas",
+		KeyReversalCutoffPassed:    "A transação tem %s horas; o horário limite de reversão (%s %s) já passou",
+		KeyWindowExpired:           "A transação tem %d dias; o prazo de %s é de %d dias",
+		KeyReversalWindowClosing:   "A janela de reversão gratuita fecha em %s horas",
+		KeyWindowClosing:           "O prazo de reembolso %s expira em %d dias. Depois disso, serão necessárias alternativas mais caras.",
+		KeyNoCandidates:            "Nenhum método de reembolso elegível encontrado; usando crédito em conta",
+		KeyAccountCredit:           "%s; valor creditado no saldo do cliente no marketplace",
+		KeyRouteReversal:           "Reversão gratuita via %s; %s",
+		KeyRoute:                   "%s via %s: %s, processamento %s; %s",
+		KeyMethodSame:              "%s para %s",
+		KeyMethodBankTransfer:      "transferência bancária",
+		KeyCostBasePercent:         "%s de base + %s%% = %s",
+		KeyTimeInstant:             "instantâneo",
+		KeyTimeOneDay:              "em 1 dia",
+		KeyTimeDays:                "em %d dias",
+		KeyAmountTier:              "faixa de valor acima de %s a %s%% aplicada",
+		KeyVolumeTier:              "faixa de volume de %d+ reembolsos/mês aplicada (%s%% de desconto)",
+		KeyLandedCost:              "custo total %s incl. %s",
+		KeyFXMarkup:                "spread cambial %s",
+	},
+	"es": {
+		KeyReversalWindow:          "La transacción tiene %s horas y no está liquidada; reversión gratuita disponible",
+		KeyWithinWindow:            "Dentro del plazo de %s (%d de %d días usados, %d restantes)",
+		KeyNoTimeLimit:             "Sin límite de tiempo para este método de reembolso",
+		KeyNoRules:                 "No se encontraron reglas de compatibilidad; solo hay crédito en cuenta disponible",
+		KeyNoEligibleMethods:       "Ningún método de reembolso elegible; se usa crédito en cuenta",
+		KeyProcessorsUnavailable:   "Ningún procesador elegible está disponible; se usa crédito en cuenta",
+		KeyHigherRankedUnavailable: "%d opción(es) mejor clasificada(s) omitida(s) por disponibilidad del procesador",
+		KeyAlreadySettled:          "Transacción ya liquidada; reversión no disponible",
+		KeyReversalWindowExpired:   "La transacción tiene %s horas; la reversión requiere menos de %d horas",
+		KeyReversalCutoffPassed:    "La transacción tiene %s horas; ya pasó el corte de reversión de las %s %s",
+		KeyWindowExpired:           "La transacción tiene %d días; el plazo de %s es de %d días",
+		KeyReversalWindowClosing:   "La ventana de reversión gratuita cierra en %s horas",
+		KeyWindowClosing:           "El plazo de reembolso %s vence en %d días. Después, se requerirán alternativas más caras.",
+		KeyNoCandidates:            "No se encontraron métodos de reembolso elegibles; se usa crédito en cuenta",
+		KeyAccountCredit:           "%s; fondos acreditados al saldo del cliente en el marketplace",
+		KeyRouteReversal:           "Reversión gratuita vía %s; %s",
+		KeyRoute:                   "%s vía %s: %s, procesamiento %s; %s",
+		KeyMethodSame:              "%s a %s",
+		KeyMethodBankTransfer:      "transferencia bancaria",
+		KeyCostBasePercent:         "%s de base + %s%% = %s",
+		KeyTimeInstant:             "instantáneo",
+		KeyTimeOneDay:              "en 1 día",
+		KeyTimeDays:                "en %d días",
+		KeyAmountTier:              "tramo de monto superior a %s al %s%% aplicado",
+		KeyVolumeTier:              "tramo de volumen de %d+ reembolsos/mes aplicado (%s%% de descuento)",
+		KeyLandedCost:              "costo total %s incl. %s",
+		KeyFXMarkup:                "margen cambiario %s",
+	},
+}
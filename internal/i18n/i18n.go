@@ -0,0 +1,197 @@
+package i18n
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/ivanjtm/YunoChallenge/internal/money"
+)
+
+type Locale string
+
+const (
+	English      Locale = "en"
+	PortugueseBR Locale = "pt-BR"
+	SpanishMX    Locale = "es-MX"
+	SpanishCO    Locale = "es-CO"
+)
+
+var Supported = []Locale{English, PortugueseBR, SpanishMX, SpanishCO}
+
+type numberFormat struct {
+	language    string
+	decimal     string
+	group       string
+	currency    string
+	symbol      string
+	symbolSpace bool
+}
+
+var formats = map[Locale]numberFormat{
+	English:      {language: "en", decimal: "."},
+	PortugueseBR: {language: "pt", decimal: ",", group: ".", currency: "BRL", symbol: "R$", symbolSpace: true},
+	SpanishMX:    {language: "es", decimal: ".", group: ",", currency: "MXN", symbol: "$"},
+	SpanishCO:    {language: "es", decimal: ",", group: ".", currency: "COP", symbol: "$", symbolSpace: true},
+}
+
+type Message struct {
+	Key  Key
+	Args []any
+}
+
+func Msg(key Key, args ...any) Message {
+	return Message{Key: key, Args: args}
+}
+
+func (m Message) String() string {
+	return For(English).Render(m)
+}
+
+type Amount struct {
+	Value    money.Amount
+	Currency string
+}
+
+type Money struct {
+	Value    money.Amount
+	Currency string
+}
+
+type Decimal struct {
+	Value  float64
+	Places int
+}
+
+type Printer struct {
+	locale Locale
+	format numberFormat
+}
+
+func For(l Locale) Printer {
+	f, ok := formats[l]
+	if !ok {
+		l, f = English, formats[English]
+	}
+	return Printer{locale: l, format: f}
+}
+
+func (p Printer) Locale() Locale {
+	return p.locale
+}
+
+func (p Printer) Sprintf(key Key, args ...any) string {
+	tmpl, ok := catalog[p.format.language][key]
+	if !ok {
+		tmpl, ok = catalog["en"][key]
+	}
+	if !ok {
+		return string(key)
+	}
+	formatted := make([]any, len(args))
+	for i, a := range args {
+		formatted[i] = p.arg(a)
+	}
+	return fmt.Sprintf(tmpl, formatted...)
+}
+
+func (p Printer) Render(m Message) string {
+	return p.Sprintf(m.Key, m.Args...)
+}
+
+func (p Printer) arg(a any) any {
+	switch v := a.(type) {
+	case Message:
+		return p.Render(v)
+	case Amount:
+		return p.number(v.Value.Format(v.Currency))
+	case Money:
+		return p.money(v.Value, v.Currency)
+	case Decimal:
+		return p.number(strconv.FormatFloat(v.Value, 'f', v.Places, 64))
+	}
+	return a
+}
+
+func (p Printer) money(a money.Amount, currency string) string {
+	n := p.number(a.Format(currency))
+	if currency != p.format.currency {
+		return n + " " + currency
+	}
+	sign := ""
+	if strings.HasPrefix(n, "-") {
+		sign, n = "-", n[1:]
+	}
+	if p.format.symbolSpace {
+		return sign + p.format.symbol + " " + n
+	}
+	return sign + p.format.symbol + n
+}
+
+func (p Printer) number(s string) string {
+	sign := ""
+	if strings.HasPrefix(s, "-") {
+		sign, s = "-", s[1:]
+	}
+	whole, frac, hasFrac := strings.Cut(s, ".")
+	if p.format.group != "" && len(whole) > 3 {
+		var b strings.Builder
+		lead := len(whole) % 3
+		if lead > 0 {
+			b.WriteString(whole[:lead])
+		}
+		for i := lead; i < len(whole); i += 3 {
+			if b.Len() > 0 {
+				b.WriteString(p.format.group)
+			}
+			b.WriteString(whole[i : i+3])
+		}
+		whole = b.String()
+	}
+	if hasFrac {
+		return sign + whole + p.format.decimal + frac
+	}
+	return sign + whole
+}
+
+func Match(acceptLanguage string) Locale {
+	type weighted struct {
+		tag string
+		q   float64
+	}
+	var tags []weighted
+	for _, part := range strings.Split(acceptLanguage, ",") {
+		tag, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+		if tag == "" || tag == "*" {
+			continue
+		}
+		q := 1.0
+		if v, ok := strings.CutPrefix(strings.TrimSpace(params), "q="); ok {
+			parsed, err := strconv.ParseFloat(v, 64)
+			if err != nil {
+				continue
+			}
+			q = parsed
+		}
+		if q > 0 {
+			tags = append(tags, weighted{tag: tag, q: q})
+		}
+	}
+	sort.SliceStable(tags, func(i, j int) bool { return tags[i].q > tags[j].q })
+
+	for _, t := range tags {
+		for _, l := range Supported {
+			if strings.EqualFold(t.tag, string(l)) {
+				return l
+			}
+		}
+		lang, _, _ := strings.Cut(t.tag, "-")
+		for _, l := range Supported {
+			if strings.EqualFold(lang, formats[l].language) {
+				return l
+			}
+		}
+	}
+	return English
+}
@@ -0,0 +1,110 @@
+package i18n
+
+import (
+	"testing"
+
+	"github.com/ivanjtm/YunoChallenge/internal/money"
+)
+
+func TestMatch(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		header string
+		want   Locale
+	}{
+		{"", English},
+		{"pt-BR", PortugueseBR},
+		{"pt-br,en;q=0.5", PortugueseBR},
+		{"pt-PT", PortugueseBR},
+		{"es-CO", SpanishCO},
+		{"es", SpanishMX},
+		{"es-AR,es;q=0.9", SpanishMX},
+		{"fr-FR, es-CO;q=0.4", SpanishCO},
+		{"en;q=0.2, es-CO;q=0.8", SpanishCO},
+		{"es-CO;q=0, de", English},
+		{"*", English},
+		{"pt-BR;q=abc, es-MX;q=0.3", SpanishMX},
+	}
+	for _, tt := range tests {
+		if got := Match(tt.header); got != tt.want {
+			t.Errorf("Match(%q) = %s, want %s", tt.header, got, tt.want)
+		}
+	}
+}
+
+func TestPrinter_Formatting(t *testing.T) {
+	t.Parallel()
+
+	amount := money.MustParse("1234567.5")
+	tests := []struct {
+		locale     Locale
+		currency   string
+		wantAmount string
+		wantMoney  string
+		wantNumber string
+	}{
+		{English, "BRL", "1234567.50", "1234567.50 BRL", "-1234.5"},
+		{PortugueseBR, "BRL", "1.234.567,50", "R$ 1.234.567,50", "-1.234,5"},
+		{PortugueseBR, "USD", "1.234.567,50", "1.234.567,50 USD", "-1.234,5"},
+		{SpanishMX, "MXN", "1,234,567.50", "$1,234,567.50", "-1,234.5"},
+		{SpanishCO, "COP", "1.234.568", "$ 1.234.568", "-1.234,5"},
+	}
+	for _, tt := range tests {
+		p := For(tt.locale)
+		if got := p.arg(Amount{Value: amount, Currency: tt.currency}); got != tt.wantAmount {
+			t.Errorf("%s Amount = %q, want %q", tt.locale, got, tt.wantAmount)
+		}
+		if got := p.arg(Money{Value: amount, Currency: tt.currency}); got != tt.wantMoney {
+			t.Errorf("%s Money = %q, want %q", tt.locale, got, tt.wantMoney)
+		}
+		if got := p.arg(Decimal{Value: -1234.5, Places: 1}); got != tt.wantNumber {
+			t.Errorf("%s Decimal = %q, want %q", tt.locale, got, tt.wantNumber)
+		}
+	}
+}
+
+func TestPrinter_Messages(t *testing.T) {
+	t.Parallel()
+
+	msg := Msg(KeyWithinWindow, "SAME_METHOD", 2, 90, 88)
+	if got := msg.String(); got != "Within SAME_METHOD window (2 of 90 days used, 88 remaining)" {
+		t.Errorf("String() = %q", got)
+	}
+	if got := For(PortugueseBR).Render(msg); got != "Dentro do prazo de SAME_METHOD (2 de 90 dias usados, 88 restantes)" {
+		t.Errorf("pt-BR Render() = %q", got)
+	}
+
+	nested := For(SpanishMX).Sprintf(KeyAccountCredit, Msg(KeyNoRules))
+	if nested != "No se encontraron reglas de compatibilidad; solo hay crédito en cuenta disponible; fondos acreditados al saldo del cliente en el marketplace" {
+		t.Errorf("nested Sprintf() = %q", nested)
+	}
+
+	if got := For(SpanishCO).Sprintf(KeyTaxLine, "IOF", Amount{Value: money.MustParse("3.8"), Currency: "BRL"}); got != "IOF 3,80" {
+		t.Errorf("fallback to English template = %q, want IOF 3,80", got)
+	}
+	if got := For("de-DE").Locale(); got != English {
+		t.Errorf("For(unsupported).Locale() = %s, want en", got)
+	}
+}
+
+func TestCatalogComplete(t *testing.T) {
+	t.Parallel()
+
+	for lang, messages := range catalog {
+		for key := range messages {
+			if _, ok := catalog["en"][key]; !ok {
+				t.Errorf("%s message %s has no English source", lang, key)
+			}
+		}
+	}
+	for key := range catalog["en"] {
+		for _, lang := range []string{"pt", "es"} {
+			if _, ok := catalog[lang][key]; !ok && !languageNeutral[key] {
+				t.Errorf("%s is missing a %s translation", key, lang)
+			}
+		}
+	}
+}
+
+var languageNeutral = map[Key]bool{KeyCostPercent: true, KeyCostFlat: true, KeyTaxLine: true}
@@ -1,6 +1,10 @@
 package model
 
-import "time"
+import (
+	"time"
+
+	"github.com/ivanjtm/YunoChallenge/internal/money"
+)
 
 type Country string
 
@@ -16,6 +20,7 @@ const (
 	CurrencyBRL Currency = "BRL"
 	CurrencyMXN Currency = "MXN"
 	CurrencyCOP Currency = "COP"
+	CurrencyUSD Currency = "USD"
 )
 
 type PaymentMethod string
@@ -39,16 +44,64 @@ const (
 	RefundAccountCredit RefundMethod = "ACCOUNT_CREDIT"
 )
 
+type RoutingStrategy string
+
+const (
+	StrategyCheapest      RoutingStrategy = "cheapest"
+	StrategyFastest       RoutingStrategy = "fastest"
+	StrategyBalanced      RoutingStrategy = "balanced"
+	StrategyCustomerFirst RoutingStrategy = "customer_first"
+)
+
+type BatchAllocation string
+
+const (
+	AllocationIndependent BatchAllocation = "independent"
+	AllocationOptimized   BatchAllocation = "optimized"
+)
+
+type ReasonCode string
+
+const (
+	ReasonRuleAllowed             ReasonCode = "RULE_ALLOWED"
+	ReasonSettled                 ReasonCode = "SETTLED"
+	ReasonUnsettled               ReasonCode = "UNSETTLED"
+	ReasonReversalWindow          ReasonCode = "WITHIN_REVERSAL_WINDOW"
+	ReasonWithinWindow            ReasonCode = "WITHIN_WINDOW"
+	ReasonNoTimeLimit             ReasonCode = "NO_TIME_LIMIT"
+	ReasonConditionMet            ReasonCode = "CONDITION_MET"
+	ReasonProcessorRule           ReasonCode = "PROCESSOR_RULE"
+	ReasonNoRules                 ReasonCode = "NO_RULES"
+	ReasonNoEligibleMethods       ReasonCode = "NO_ELIGIBLE_METHODS"
+	ReasonProcessorsUnavailable   ReasonCode = "PROCESSORS_UNAVAILABLE"
+	ReasonHigherRankedUnavailable ReasonCode = "HIGHER_RANKED_UNAVAILABLE"
+)
+
+type FeeClamp string
+
+const (
+	ClampMin FeeClamp = "min"
+	ClampMax FeeClamp = "max"
+)
+
+type WindowUnit string
+
+const (
+	WindowHours WindowUnit = "hours"
+	WindowDays  WindowUnit = "days"
+)
+
 type Transaction struct {
-	ID            string        `json:"id"`
-	Country       Country       `json:"country"`
-	Currency      Currency      `json:"currency"`
-	PaymentMethod PaymentMethod `json:"payment_method"`
-	ProcessorID   string        `json:"processor_id"`
-	Amount        float64       `json:"amount"`
-	Timestamp     time.Time     `json:"timestamp"`
-	Settled       bool          `json:"settled"`
-	CustomerID    string        `json:"customer_id"`
+	ID            string            `json:"id"`
+	Country       Country           `json:"country"`
+	Currency      Currency          `json:"currency"`
+	PaymentMethod PaymentMethod     `json:"payment_method"`
+	ProcessorID   string            `json:"processor_id"`
+	Amount        money.Amount      `json:"amount"`
+	Timestamp     time.Time         `json:"timestamp"`
+	Settled       bool              `json:"settled"`
+	CustomerID    string            `json:"customer_id"`
+	Customer      map[string]string `json:"customer,omitempty"`
 }
 
 type Processor struct {
@@ -58,79 +111,224 @@ type Processor struct {
 	SupportedCurrencies []Currency           `json:"supported_currencies"`
 	RefundFees          []RefundMethodFee    `json:"refund_fees"`
 	DailyQuota          int                  `json:"daily_quota"`
+	QuotaLimits         []QuotaLimit         `json:"quota_limits,omitempty"`
 	ProcessingDays      map[RefundMethod]int `json:"processing_days"`
+	Endpoint            string               `json:"endpoint,omitempty"`
+	EffectiveFrom       *time.Time           `json:"effective_from,omitempty"`
+	EffectiveTo         *time.Time           `json:"effective_to,omitempty"`
+	FXMarkup            *FXMarkup            `json:"fx_markup,omitempty"`
+	ReversalWindow      *ReversalPolicy      `json:"reversal_window,omitempty"`
+}
+
+type FXMarkup struct {
+	Rate               float64  `json:"rate"`
+	SettlementCurrency Currency `json:"settlement_currency"`
+}
+
+type ReversalPolicy struct {
+	Hours        int    `json:"hours,omitempty"`
+	Cutoff       string `json:"cutoff,omitempty"`
+	TimeZone     string `json:"time_zone,omitempty"`
+	WarningHours int    `json:"warning_hours,omitempty"`
+}
+
+type TaxBase string
+
+const (
+	TaxBaseAmount TaxBase = "amount"
+	TaxBaseFee    TaxBase = "fee"
+)
+
+type TaxRule struct {
+	Name           string          `json:"name"`
+	Rate           float64         `json:"rate"`
+	Base           TaxBase         `json:"base,omitempty"`
+	RefundMethods  []RefundMethod  `json:"refund_methods,omitempty"`
+	PaymentMethods []PaymentMethod `json:"payment_methods,omitempty"`
+	Processors     []string        `json:"processors,omitempty"`
+}
+
+type CostBreakdown struct {
+	BaseFee          money.Amount `json:"base_fee"`
+	PercentComponent money.Amount `json:"percent_component"`
+	VolumeDiscount   money.Amount `json:"volume_discount,omitempty"`
+	Clamp            FeeClamp     `json:"clamp,omitempty"`
+	AmountTier       *AmountTier  `json:"amount_tier,omitempty"`
+	VolumeTier       *VolumeTier  `json:"volume_tier,omitempty"`
+	Fee              money.Amount `json:"fee"`
+	Tax              money.Amount `json:"tax"`
+	FXMarkup         money.Amount `json:"fx_markup"`
+	Total            money.Amount `json:"total"`
+	Taxes            []TaxLine    `json:"taxes,omitempty"`
+}
+
+type Eligibility struct {
+	ReasonCodes []ReasonCode `json:"reason_codes"`
+	Window      *RuleWindow  `json:"window,omitempty"`
+}
+
+type RuleWindow struct {
+	Unit      WindowUnit `json:"unit"`
+	Limit     float64    `json:"limit"`
+	Used      float64    `json:"used"`
+	Remaining float64    `json:"remaining"`
+	ExpiresAt time.Time  `json:"expires_at"`
+}
+
+type TaxLine struct {
+	Name   string       `json:"name"`
+	Amount money.Amount `json:"amount"`
 }
 
 type RefundMethodFee struct {
 	Method         RefundMethod    `json:"method"`
 	PaymentMethods []PaymentMethod `json:"payment_methods"`
 	Currency       Currency        `json:"currency"`
-	BaseFee        float64         `json:"base_fee"`
+	BaseFee        money.Amount    `json:"base_fee"`
 	PercentFee     float64         `json:"percent_fee"`
-	MinFee         float64         `json:"min_fee"`
-	MaxFee         float64         `json:"max_fee"`
+	MinFee         money.Amount    `json:"min_fee"`
+	MaxFee         money.Amount    `json:"max_fee"`
+	AmountTiers    []AmountTier    `json:"amount_tiers,omitempty"`
+	VolumeTiers    []VolumeTier    `json:"volume_tiers,omitempty"`
+	EffectiveFrom  *time.Time      `json:"effective_from,omitempty"`
+	EffectiveTo    *time.Time      `json:"effective_to,omitempty"`
+}
+
+type AmountTier struct {
+	Above      money.Amount `json:"above"`
+	PercentFee float64      `json:"percent_fee"`
+}
+
+type VolumeTier struct {
+	MinMonthlyRefunds int     `json:"min_monthly_refunds"`
+	Discount          float64 `json:"discount"`
 }
 
 type CompatibilityRule struct {
 	OriginalMethod PaymentMethod   `json:"original_method"`
 	Country        Country         `json:"country"`
+	ProcessorID    string          `json:"processor_id,omitempty"`
 	AllowedRefunds []AllowedRefund `json:"allowed_refunds"`
 }
 
 type AllowedRefund struct {
-	Method         RefundMethod `json:"method"`
-	MaxAgeDays     int          `json:"max_age_days"`
-	RequireSettled *bool        `json:"require_settled"`
+	Method         RefundMethod   `json:"method"`
+	MaxAgeDays     int            `json:"max_age_days"`
+	RequireSettled *bool          `json:"require_settled"`
+	When           *RuleCondition `json:"when,omitempty"`
+}
+
+type RuleCondition struct {
+	Amount     *AmountRange        `json:"amount,omitempty"`
+	Customer   map[string][]string `json:"customer,omitempty"`
+	Processors []string            `json:"processors,omitempty"`
+	Settled    *bool               `json:"settled,omitempty"`
+	DaysOfWeek []string            `json:"days_of_week,omitempty"`
+	All        []RuleCondition     `json:"all,omitempty"`
+	Any        []RuleCondition     `json:"any,omitempty"`
+	Not        *RuleCondition      `json:"not,omitempty"`
+}
+
+type AmountRange struct {
+	Min money.Amount `json:"min"`
+	Max money.Amount `json:"max"`
 }
 
 type RefundCandidate struct {
-	ProcessorID    string       `json:"processor_id"`
-	ProcessorName  string       `json:"processor_name"`
-	RefundMethod   RefundMethod `json:"refund_method"`
-	EstimatedCost  float64      `json:"estimated_cost"`
-	ProcessingDays int          `json:"processing_days"`
-	Reasoning      string       `json:"reasoning"`
+	ProcessorID        string        `json:"processor_id"`
+	ProcessorName      string        `json:"processor_name"`
+	RefundMethod       RefundMethod  `json:"refund_method"`
+	EstimatedCost      money.Amount  `json:"estimated_cost"`
+	CostBreakdown      CostBreakdown `json:"cost_breakdown"`
+	Eligibility        Eligibility   `json:"eligibility"`
+	ProcessingDays     int           `json:"processing_days"`
+	ExpectedCompletion time.Time     `json:"expected_completion,omitzero"`
+	Reasoning          string        `json:"reasoning"`
+	UnavailableReason  string        `json:"unavailable_reason,omitempty"`
 }
 
 type RefundRouteResult struct {
 	TransactionID string            `json:"transaction_id"`
 	Selected      RefundCandidate   `json:"selected"`
 	Alternatives  []RefundCandidate `json:"alternatives"`
-	NaiveCost     float64           `json:"naive_cost"`
-	Savings       float64           `json:"savings"`
+	Unavailable   []RefundCandidate `json:"unavailable,omitempty"`
+	Strategy      RoutingStrategy   `json:"strategy"`
+	RefundAmount  money.Amount      `json:"refund_amount"`
+	NaiveCost     money.Amount      `json:"naive_cost"`
+	Savings       money.Amount      `json:"savings"`
+	Reservation   string            `json:"-"`
 }
 
 type BatchRefundRequest struct {
-	Transactions []Transaction `json:"transactions"`
+	Transactions []Transaction   `json:"transactions"`
+	Strategy     RoutingStrategy `json:"strategy,omitempty"`
+	DayValue     float64         `json:"day_value,omitempty"`
+	Allocation   BatchAllocation `json:"allocation,omitempty"`
 }
 
 type BatchRefundResult struct {
+	Strategy          RoutingStrategy             `json:"strategy"`
+	Allocation        BatchAllocation             `json:"allocation"`
 	TotalTransactions int                         `json:"total_transactions"`
-	TotalNaiveCost    float64                     `json:"total_naive_cost"`
-	TotalSmartCost    float64                     `json:"total_smart_cost"`
-	TotalSavings      float64                     `json:"total_savings"`
-	SavingsPercent    float64                     `json:"savings_percent"`
 	Results           []RefundRouteResult         `json:"results"`
 	ByProcessor       map[string]ProcessorSummary `json:"by_processor"`
 	ByPaymentMethod   map[string]MethodSummary    `json:"by_payment_method"`
 	TimeSensitive     []TimeSensitiveFlag         `json:"time_sensitive"`
 	LimitedOptions    []LimitedOptionFlag         `json:"limited_options"`
+	Displaced         []DisplacedTransaction      `json:"displaced"`
+	ByCurrency        map[string]CurrencyTotals   `json:"by_currency"`
+	Reporting         *ReportingTotals            `json:"reporting,omitempty"`
+}
+
+type DisplacedTransaction struct {
+	TransactionID      string       `json:"transaction_id"`
+	Currency           Currency     `json:"currency"`
+	PreferredProcessor string       `json:"preferred_processor"`
+	PreferredMethod    RefundMethod `json:"preferred_method"`
+	AssignedProcessor  string       `json:"assigned_processor"`
+	AssignedMethod     RefundMethod `json:"assigned_method"`
+	ExtraCost          money.Amount `json:"extra_cost"`
+	Reason             string       `json:"reason"`
+}
+
+type CurrencyTotals struct {
+	Currency         Currency     `json:"currency"`
+	NaiveCost        money.Amount `json:"naive_cost"`
+	SmartCost        money.Amount `json:"smart_cost"`
+	Savings          money.Amount `json:"savings"`
+	AnnualProjection money.Amount `json:"annual_projection,omitempty"`
+	TransactionCount int          `json:"transaction_count"`
+}
+
+type FXRate struct {
+	From Currency `json:"from"`
+	To   Currency `json:"to"`
+	Rate float64  `json:"rate"`
+	Date string   `json:"date"`
+}
+
+type ReportingTotals struct {
+	Currency         Currency                `json:"currency"`
+	NaiveCost        money.Amount            `json:"naive_cost"`
+	SmartCost        money.Amount            `json:"smart_cost"`
+	Savings          money.Amount            `json:"savings"`
+	SavingsPercent   float64                 `json:"savings_percent"`
+	AnnualProjection money.Amount            `json:"annual_projection,omitempty"`
+	MonthlySavings   map[string]money.Amount `json:"monthly_savings,omitempty"`
+	Rates            []FXRate                `json:"rates"`
+	MissingRates     []Currency              `json:"missing_rates,omitempty"`
 }
 
 type ProcessorSummary struct {
-	ProcessorID      string  `json:"processor_id"`
-	NaiveCost        float64 `json:"naive_cost"`
-	SmartCost        float64 `json:"smart_cost"`
-	Savings          float64 `json:"savings"`
-	TransactionCount int     `json:"transaction_count"`
+	ProcessorID      string                    `json:"processor_id"`
+	TransactionCount int                       `json:"transaction_count"`
+	ByCurrency       map[string]CurrencyTotals `json:"by_currency"`
 }
 
 type MethodSummary struct {
-	Method           string  `json:"method"`
-	NaiveCost        float64 `json:"naive_cost"`
-	SmartCost        float64 `json:"smart_cost"`
-	Savings          float64 `json:"savings"`
-	TransactionCount int     `json:"transaction_count"`
+	Method           string                    `json:"method"`
+	TransactionCount int                       `json:"transaction_count"`
+	ByCurrency       map[string]CurrencyTotals `json:"by_currency"`
 }
 
 type TimeSensitiveFlag struct {
@@ -148,13 +346,58 @@ type LimitedOptionFlag struct {
 	Message          string `json:"message"`
 }
 
+type QuotaPeriod string
+
+const (
+	QuotaPeriodHour    QuotaPeriod = "hour"
+	QuotaPeriodDay     QuotaPeriod = "day"
+	QuotaPeriodMonth   QuotaPeriod = "month"
+	QuotaPeriodRolling QuotaPeriod = "rolling"
+)
+
+type QuotaLimit struct {
+	Period   QuotaPeriod  `json:"period"`
+	Limit    int          `json:"limit,omitempty"`
+	Amount   money.Amount `json:"amount,omitempty"`
+	Currency Currency     `json:"currency,omitempty"`
+	Method   RefundMethod `json:"method,omitempty"`
+	TimeZone string       `json:"time_zone,omitempty"`
+	Window   string       `json:"window,omitempty"`
+}
+
+type QuotaLimitStatus struct {
+	QuotaLimit
+	Used            int           `json:"used"`
+	Remaining       *int          `json:"remaining,omitempty"`
+	UsedAmount      *money.Amount `json:"used_amount,omitempty"`
+	RemainingAmount *money.Amount `json:"remaining_amount,omitempty"`
+	ResetsAt        time.Time     `json:"resets_at,omitzero"`
+}
+
+type QuotaDemand struct {
+	ProcessorID string       `json:"processor_id"`
+	Method      RefundMethod `json:"method,omitempty"`
+	Currency    Currency     `json:"currency,omitempty"`
+	Amount      money.Amount `json:"amount,omitempty"`
+}
+
+type QuotaReservation struct {
+	Token string `json:"token"`
+	QuotaDemand
+	ReservedAt time.Time `json:"reserved_at"`
+	ExpiresAt  time.Time `json:"expires_at"`
+}
+
 type QuotaStatus struct {
-	ProcessorID       string `json:"processor_id"`
-	DailyQuota        int    `json:"daily_quota"`
-	UsedToday         int    `json:"used_today"`
-	Remaining         int    `json:"remaining"`
-	IsAvailable       bool   `json:"is_available"`
-	UnavailableReason string `json:"unavailable_reason,omitempty"`
+	ProcessorID       string             `json:"processor_id"`
+	DailyQuota        int                `json:"daily_quota"`
+	UsedToday         int                `json:"used_today"`
+	Remaining         int                `json:"remaining"`
+	IsAvailable       bool               `json:"is_available"`
+	UnavailableReason string             `json:"unavailable_reason,omitempty"`
+	NextReset         time.Time          `json:"next_reset,omitzero"`
+	Reserved          int                `json:"reserved,omitempty"`
+	Limits            []QuotaLimitStatus `json:"limits,omitempty"`
 }
 
 type SimulationRequest struct {
@@ -168,30 +411,90 @@ type ProcessorOverride struct {
 }
 
 type HistoricalAnalysis struct {
-	TotalTransactions      int                 `json:"total_transactions"`
-	TotalActualCost        float64             `json:"total_actual_cost"`
-	TotalSmartCost         float64             `json:"total_smart_cost"`
-	TotalSavings           float64             `json:"total_savings"`
-	AnnualProjection       float64             `json:"annual_projection"`
-	MostExpensiveCorridors []CostCorridor      `json:"most_expensive_corridors"`
-	HighestCostProcessors  []ProcessorCostRank `json:"highest_cost_processors"`
-	ComplexRefundRules     []ComplexRuleNote    `json:"complex_refund_rules"`
-	MonthlySavings         map[string]float64  `json:"monthly_savings"`
+	FeeBasis               FeeBasis                           `json:"fee_basis"`
+	TotalTransactions      int                                `json:"total_transactions"`
+	MostExpensiveCorridors []CostCorridor                     `json:"most_expensive_corridors"`
+	HighestCostProcessors  []ProcessorCostRank                `json:"highest_cost_processors"`
+	ComplexRefundRules     []ComplexRuleNote                  `json:"complex_refund_rules"`
+	MonthlySavings         map[string]map[string]money.Amount `json:"monthly_savings"`
+	ByCurrency             map[string]CurrencyTotals          `json:"by_currency"`
+	Reporting              *ReportingTotals                   `json:"reporting,omitempty"`
 }
 
 type CostCorridor struct {
 	Country       Country       `json:"country"`
 	PaymentMethod PaymentMethod `json:"payment_method"`
-	AvgCost       float64       `json:"avg_cost"`
-	TotalCost     float64       `json:"total_cost"`
+	Currency      Currency      `json:"currency"`
+	AvgCost       money.Amount  `json:"avg_cost"`
+	TotalCost     money.Amount  `json:"total_cost"`
+	ReportingCost money.Amount  `json:"reporting_cost"`
 	Count         int           `json:"count"`
 }
 
 type ProcessorCostRank struct {
+	ProcessorID   string                  `json:"processor_id"`
+	TotalCost     map[string]money.Amount `json:"total_cost"`
+	AvgCost       map[string]money.Amount `json:"avg_cost"`
+	ReportingCost money.Amount            `json:"reporting_cost"`
+	Count         int                     `json:"count"`
+}
+
+type MarketConfig struct {
+	Currencies     []CurrencyConfig      `json:"currencies"`
+	Countries      []CountryConfig       `json:"countries"`
+	PaymentMethods []PaymentMethodConfig `json:"payment_methods"`
+	RuleNotes      []ComplexRuleNote     `json:"rule_notes"`
+}
+
+type CurrencyConfig struct {
+	Code       Currency          `json:"code"`
+	MinorUnits int               `json:"minor_units"`
+	DayValue   float64           `json:"day_value,omitempty"`
+	TestData   *CurrencyTestData `json:"test_data,omitempty"`
+}
+
+type CurrencyTestData struct {
+	TypicalAmount money.Amount `json:"typical_amount"`
+	MinAmount     money.Amount `json:"min_amount"`
+	MaxAmount     money.Amount `json:"max_amount"`
+}
+
+type CountryConfig struct {
+	Code           Country          `json:"code"`
+	Name           string           `json:"name"`
+	Currency       Currency         `json:"currency"`
+	Taxes          []TaxRule        `json:"taxes,omitempty"`
+	ReversalWindow *ReversalPolicy  `json:"reversal_window,omitempty"`
+	Calendar       *CalendarConfig  `json:"calendar,omitempty"`
+	TestData       *CountryTestData `json:"test_data,omitempty"`
+}
+
+type CalendarConfig struct {
+	TimeZone string   `json:"time_zone,omitempty"`
+	Holidays []string `json:"holidays,omitempty"`
+}
+
+type CountryTestData struct {
+	Weight         float64                 `json:"weight"`
+	PaymentMethods []WeightedPaymentMethod `json:"payment_methods"`
+	Processors     []WeightedProcessor     `json:"processors"`
+}
+
+type WeightedPaymentMethod struct {
+	Method PaymentMethod `json:"method"`
+	Weight float64       `json:"weight"`
+}
+
+type WeightedProcessor struct {
 	ProcessorID string  `json:"processor_id"`
-	TotalCost   float64 `json:"total_cost"`
-	AvgCost     float64 `json:"avg_cost"`
-	Count       int     `json:"count"`
+	Weight      float64 `json:"weight"`
+}
+
+type PaymentMethodConfig struct {
+	Code      PaymentMethod `json:"code"`
+	Name      string        `json:"name"`
+	Type      string        `json:"type"`
+	Countries []Country     `json:"countries"`
 }
 
 type ComplexRuleNote struct {
@@ -200,10 +503,96 @@ type ComplexRuleNote struct {
 	Impact      string `json:"impact"`
 }
 
+type RefundStatus string
+
+const (
+	RefundStatusPending    RefundStatus = "PENDING"
+	RefundStatusSubmitted  RefundStatus = "SUBMITTED"
+	RefundStatusProcessing RefundStatus = "PROCESSING"
+	RefundStatusSucceeded  RefundStatus = "SUCCEEDED"
+	RefundStatusFailed     RefundStatus = "FAILED"
+	RefundStatusCancelled  RefundStatus = "CANCELLED"
+)
+
+type RefundRecord struct {
+	ID                 string               `json:"id"`
+	TransactionID      string               `json:"transaction_id"`
+	CustomerID         string               `json:"customer_id,omitempty"`
+	OriginalProcessor  string               `json:"original_processor"`
+	PaymentMethod      PaymentMethod        `json:"payment_method"`
+	ProcessorID        string               `json:"processor_id"`
+	ProcessorName      string               `json:"processor_name"`
+	RefundMethod       RefundMethod         `json:"refund_method"`
+	Amount             money.Amount         `json:"amount"`
+	OriginalAmount     money.Amount         `json:"original_amount"`
+	Currency           Currency             `json:"currency"`
+	EstimatedCost      money.Amount         `json:"estimated_cost"`
+	ProcessingDays     int                  `json:"processing_days"`
+	Status             RefundStatus         `json:"status"`
+	CreatedAt          time.Time            `json:"created_at"`
+	UpdatedAt          time.Time            `json:"updated_at"`
+	ExpectedCompletion time.Time            `json:"expected_completion"`
+	ProcessorReference string               `json:"processor_reference,omitempty"`
+	FailureReason      string               `json:"failure_reason,omitempty"`
+	Attempts           []RefundAttempt      `json:"attempts,omitempty"`
+	History            []RefundStatusChange `json:"history"`
+}
+
+type RefundAttempt struct {
+	ProcessorID     string       `json:"processor_id"`
+	ProcessorName   string       `json:"processor_name"`
+	RefundMethod    RefundMethod `json:"refund_method"`
+	EstimatedCost   money.Amount `json:"estimated_cost"`
+	IncrementalCost money.Amount `json:"incremental_cost"`
+	Outcome         string       `json:"outcome"`
+	Error           string       `json:"error,omitempty"`
+	At              time.Time    `json:"at"`
+}
+
+type RefundStatusChange struct {
+	Status RefundStatus `json:"status"`
+	At     time.Time    `json:"at"`
+	Note   string       `json:"note,omitempty"`
+}
+
+type ExecuteRefundRequest struct {
+	Transaction  Transaction      `json:"transaction"`
+	RefundAmount money.Amount     `json:"refund_amount,omitempty"`
+	Strategy     RoutingStrategy  `json:"strategy,omitempty"`
+	DayValue     float64          `json:"day_value,omitempty"`
+	Candidate    *RefundCandidate `json:"candidate,omitempty"`
+}
+
+type TransactionRefundSummary struct {
+	TransactionID   string         `json:"transaction_id"`
+	Currency        Currency       `json:"currency"`
+	OriginalAmount  money.Amount   `json:"original_amount"`
+	RefundedAmount  money.Amount   `json:"refunded_amount"`
+	InFlightAmount  money.Amount   `json:"in_flight_amount"`
+	RemainingAmount money.Amount   `json:"remaining_amount"`
+	Refunds         []RefundRecord `json:"refunds"`
+}
+
+type RefundStatusUpdate struct {
+	Status RefundStatus `json:"status"`
+	Note   string       `json:"note"`
+}
+
 type SingleRefundRequest struct {
-	Transaction Transaction `json:"transaction"`
+	Transaction  Transaction     `json:"transaction"`
+	RefundAmount money.Amount    `json:"refund_amount,omitempty"`
+	Strategy     RoutingStrategy `json:"strategy,omitempty"`
+	DayValue     float64         `json:"day_value,omitempty"`
 }
 
+type FeeBasis string
+
+const (
+	FeeBasisTransactionTime FeeBasis = "transaction_time"
+	FeeBasisCurrent         FeeBasis = "current"
+)
+
 type HistoricalRequest struct {
 	Transactions []Transaction `json:"transactions"`
+	FeeBasis     FeeBasis      `json:"fee_basis,omitempty"`
 }
@@ -0,0 +1,338 @@
+package money
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+type Amount int64
+
+const (
+	Scale       = 10000
+	scaleDigits = 4
+)
+
+type RoundingMode string
+
+const (
+	HalfEven RoundingMode = "half_even"
+	HalfUp   RoundingMode = "half_up"
+	Down     RoundingMode = "down"
+)
+
+const DefaultMinorUnits = 2
+
+var ErrInvalidAmount = errors.New("invalid amount")
+
+var (
+	mu         sync.RWMutex
+	rounding   = HalfEven
+	minorUnits = map[string]int{
+		"BRL": 2,
+		"MXN": 2,
+		"COP": 0,
+		"USD": 2,
+	}
+)
+
+func ParseRoundingMode(s string) (RoundingMode, error) {
+	switch m := RoundingMode(strings.ToLower(s)); m {
+	case HalfEven, HalfUp, Down:
+		return m, nil
+	case "":
+		return HalfEven, nil
+	default:
+		return "", fmt.Errorf("unknown rounding mode %q (want half_even, half_up or down)", s)
+	}
+}
+
+func SetRoundingMode(m RoundingMode) {
+	mu.Lock()
+	defer mu.Unlock()
+	rounding = m
+}
+
+func CurrentRoundingMode() RoundingMode {
+	mu.RLock()
+	defer mu.RUnlock()
+	return rounding
+}
+
+func SetMinorUnits(units map[string]int) {
+	mu.Lock()
+	defer mu.Unlock()
+	for code, n := range units {
+		minorUnits[code] = n
+	}
+}
+
+func MinorUnits(currency string) int {
+	mu.RLock()
+	defer mu.RUnlock()
+	if n, ok := minorUnits[currency]; ok {
+		return n
+	}
+	return DefaultMinorUnits
+}
+
+func FromFloat(f float64) Amount {
+	return Amount(roundFloat(f*Scale, HalfEven))
+}
+
+func FromMinor(minor int64, currency string) Amount {
+	return Amount(minor * pow10(scaleDigits-MinorUnits(currency)))
+}
+
+func Parse(s string) (Amount, error) {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return 0, ErrInvalidAmount
+	}
+	neg := false
+	switch s[0] {
+	case '-':
+		neg = true
+		s = s[1:]
+	case '+':
+		s = s[1:]
+	}
+	if strings.ContainsAny(s, "eE") {
+		f, err := strconv.ParseFloat(s, 64)
+		if err != nil {
+			return 0, fmt.Errorf("%w: %q", ErrInvalidAmount, s)
+		}
+		if neg {
+			f = -f
+		}
+		return FromFloat(f), nil
+	}
+
+	whole, frac, _ := strings.Cut(s, ".")
+	if whole == "" && frac == "" || !digits(whole) || !digits(frac) {
+		return 0, fmt.Errorf("%w: %q", ErrInvalidAmount, s)
+	}
+	if whole == "" {
+		whole = "0"
+	}
+
+	units, err := strconv.ParseInt(whole, 10, 64)
+	if err != nil || units > math.MaxInt64/Scale {
+		return 0, fmt.Errorf("%w: %q", ErrInvalidAmount, s)
+	}
+
+	var rest int64
+	var carry bool
+	if len(frac) > scaleDigits {
+		extra := frac[scaleDigits:]
+		frac = frac[:scaleDigits]
+		carry = extra[0] > '5' || extra[0] == '5' && (strings.TrimRight(extra[1:], "0") != "" || lastDigitOdd(whole, frac))
+	}
+	frac += strings.Repeat("0", scaleDigits-len(frac))
+	fracUnits, _ := strconv.ParseInt(frac, 10, 64)
+	rest = units*Scale + fracUnits
+	if carry {
+		rest++
+	}
+	if neg {
+		rest = -rest
+	}
+	return Amount(rest), nil
+}
+
+func MustParse(s string) Amount {
+	a, err := Parse(s)
+	if err != nil {
+		panic(err)
+	}
+	return a
+}
+
+func (a Amount) Float64() float64 {
+	return float64(a) / Scale
+}
+
+func (a Amount) IsZero() bool     { return a == 0 }
+func (a Amount) IsNegative() bool { return a < 0 }
+
+func (a Amount) Abs() Amount {
+	if a < 0 {
+		return -a
+	}
+	return a
+}
+
+func (a Amount) Mul(n int64) Amount {
+	return a * Amount(n)
+}
+
+func (a Amount) MulRate(rate float64) Amount {
+	return Amount(roundFloat(float64(a)*rate, HalfEven))
+}
+
+func (a Amount) Div(n int64) Amount {
+	if n == 0 {
+		return 0
+	}
+	return Amount(divRound(int64(a), n, CurrentRoundingMode()))
+}
+
+func (a Amount) Ratio(b Amount) float64 {
+	if b == 0 {
+		return 0
+	}
+	return float64(a) / float64(b)
+}
+
+func (a Amount) RoundTo(places int, mode RoundingMode) Amount {
+	if places >= scaleDigits {
+		return a
+	}
+	if places < 0 {
+		places = 0
+	}
+	unit := pow10(scaleDigits - places)
+	return Amount(divRound(int64(a), unit, mode) * unit)
+}
+
+func (a Amount) Round(currency string) Amount {
+	return a.RoundTo(MinorUnits(currency), CurrentRoundingMode())
+}
+
+func (a Amount) String() string {
+	neg := a < 0
+	v := int64(a)
+	if neg {
+		v = -v
+	}
+	s := strconv.FormatInt(v/Scale, 10)
+	if frac := v % Scale; frac != 0 {
+		fs := strings.TrimRight(fmt.Sprintf("%0*d", scaleDigits, frac), "0")
+		s += "." + fs
+	}
+	if neg {
+		s = "-" + s
+	}
+	return s
+}
+
+func (a Amount) Format(currency string) string {
+	places := MinorUnits(currency)
+	v := int64(a.RoundTo(places, CurrentRoundingMode()))
+	neg := v < 0
+	if neg {
+		v = -v
+	}
+	s := strconv.FormatInt(v/Scale, 10)
+	if places > 0 {
+		frac := (v % Scale) / pow10(scaleDigits-places)
+		s += fmt.Sprintf(".%0*d", places, frac)
+	}
+	if neg {
+		s = "-" + s
+	}
+	return s
+}
+
+func (a Amount) MarshalJSON() ([]byte, error) {
+	return []byte(a.String()), nil
+}
+
+func (a *Amount) UnmarshalJSON(data []byte) error {
+	s := string(data)
+	if s == "null" {
+		return nil
+	}
+	s = strings.Trim(s, `"`)
+	v, err := Parse(s)
+	if err != nil {
+		return err
+	}
+	*a = v
+	return nil
+}
+
+func Sum(amounts ...Amount) Amount {
+	var total Amount
+	for _, a := range amounts {
+		total += a
+	}
+	return total
+}
+
+func Min(a, b Amount) Amount {
+	if a < b {
+		return a
+	}
+	return b
+}
+
+func Max(a, b Amount) Amount {
+	if a > b {
+		return a
+	}
+	return b
+}
+
+func divRound(n, d int64, mode RoundingMode) int64 {
+	if d < 0 {
+		n, d = -n, -d
+	}
+	q, r := n/d, n%d
+	if r == 0 || mode == Down {
+		return q
+	}
+	sign := int64(1)
+	if r < 0 {
+		sign, r = -1, -r
+	}
+	twice := 2 * r
+	switch {
+	case twice > d:
+		q += sign
+	case twice == d:
+		if mode == HalfUp || q%2 != 0 {
+			q += sign
+		}
+	}
+	return q
+}
+
+func roundFloat(f float64, mode RoundingMode) int64 {
+	switch mode {
+	case Down:
+		return int64(math.Trunc(f))
+	case HalfUp:
+		return int64(math.Round(f))
+	default:
+		return int64(math.RoundToEven(f))
+	}
+}
+
+func pow10(n int) int64 {
+	p := int64(1)
+	for range n {
+		p *= 10
+	}
+	return p
+}
+
+func digits(s string) bool {
+	for _, c := range s {
+		if c < '0' || c > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+func lastDigitOdd(whole, frac string) bool {
+	s := whole + frac
+	if s == "" {
+		return false
+	}
+	return (s[len(s)-1]-'0')%2 == 1
+}
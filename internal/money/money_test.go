@@ -0,0 +1,166 @@
+package money
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		in   string
+		want Amount
+	}{
+		{"0", 0},
+		{"320", 3200000},
+		{"320.5", 3205000},
+		{"0.1", 1000},
+		{"-3.456", -34560},
+		{".75", 7500},
+		{"1.00005", 10000},
+		{"1.00015", 10002},
+		{"1.000051", 10001},
+		{"1e3", 10000000},
+	}
+	for _, tt := range tests {
+		got, err := Parse(tt.in)
+		if err != nil {
+			t.Errorf("Parse(%q) error = %v", tt.in, err)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("Parse(%q) = %d, want %d", tt.in, got, tt.want)
+		}
+	}
+
+	for _, bad := range []string{"", "abc", "1.2.3", "--1", "."} {
+		if _, err := Parse(bad); !errors.Is(err, ErrInvalidAmount) {
+			t.Errorf("Parse(%q) error = %v, want ErrInvalidAmount", bad, err)
+		}
+	}
+}
+
+func TestAdditionIsExact(t *testing.T) {
+	t.Parallel()
+
+	var total Amount
+	for range 10 {
+		total += MustParse("0.1")
+	}
+	if total != MustParse("1") {
+		t.Errorf("ten times 0.1 = %s, want 1", total)
+	}
+}
+
+func TestRoundTo(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name   string
+		in     string
+		places int
+		mode   RoundingMode
+		want   string
+	}{
+		{"half even rounds down to even", "2.125", 2, HalfEven, "2.12"},
+		{"half even rounds up to even", "2.135", 2, HalfEven, "2.14"},
+		{"half even negative", "-2.125", 2, HalfEven, "-2.12"},
+		{"half up", "2.125", 2, HalfUp, "2.13"},
+		{"half up negative rounds away from zero", "-2.125", 2, HalfUp, "-2.13"},
+		{"down truncates", "2.129", 2, Down, "2.12"},
+		{"down negative truncates toward zero", "-2.129", 2, Down, "-2.12"},
+		{"above half", "-3.456", 2, HalfEven, "-3.46"},
+		{"zero decimals", "1500.5", 0, HalfEven, "1500"},
+		{"zero decimals odd", "1501.5", 0, HalfEven, "1502"},
+		{"no-op at full precision", "1.2345", 4, HalfEven, "1.2345"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			got := MustParse(tt.in).RoundTo(tt.places, tt.mode)
+			if got != MustParse(tt.want) {
+				t.Errorf("RoundTo(%s, %d, %s) = %s, want %s", tt.in, tt.places, tt.mode, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRoundToCurrencyMinorUnits(t *testing.T) {
+	t.Parallel()
+
+	amount := MustParse("1234.565")
+	if got := amount.Round("BRL"); got != MustParse("1234.56") {
+		t.Errorf("Round(BRL) = %s, want 1234.56", got)
+	}
+	if got := amount.Round("COP"); got != MustParse("1235") {
+		t.Errorf("Round(COP) = %s, want 1235", got)
+	}
+	if got := amount.Format("COP"); got != "1235" {
+		t.Errorf("Format(COP) = %q, want 1235", got)
+	}
+	if got := MustParse("5").Format("MXN"); got != "5.00" {
+		t.Errorf("Format(MXN) = %q, want 5.00", got)
+	}
+	if got := MinorUnits("XXX"); got != DefaultMinorUnits {
+		t.Errorf("MinorUnits(unknown) = %d, want %d", got, DefaultMinorUnits)
+	}
+}
+
+func TestMulRate(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		amount string
+		rate   float64
+		want   string
+	}{
+		{"14000", 0.03, "420"},
+		{"320", 0.005, "1.6"},
+		{"1000", 0.035, "35"},
+		{"0.01", 0.5, "0.005"},
+	}
+	for _, tt := range tests {
+		if got := MustParse(tt.amount).MulRate(tt.rate); got != MustParse(tt.want) {
+			t.Errorf("%s.MulRate(%v) = %s, want %s", tt.amount, tt.rate, got, tt.want)
+		}
+	}
+}
+
+func TestJSON(t *testing.T) {
+	t.Parallel()
+
+	var v struct {
+		Amount Amount `json:"amount"`
+		Fee    Amount `json:"fee"`
+	}
+	if err := json.Unmarshal([]byte(`{"amount": 320.10, "fee": "1.5"}`), &v); err != nil {
+		t.Fatalf("Unmarshal() error = %v", err)
+	}
+	if v.Amount != MustParse("320.1") || v.Fee != MustParse("1.5") {
+		t.Errorf("Unmarshal() = %+v", v)
+	}
+
+	v.Amount = -v.Amount
+	data, err := json.Marshal(v)
+	if err != nil {
+		t.Fatalf("Marshal() error = %v", err)
+	}
+	if string(data) != `{"amount":-320.1,"fee":1.5}` {
+		t.Errorf("Marshal() = %s", data)
+	}
+}
+
+func TestParseRoundingMode(t *testing.T) {
+	t.Parallel()
+
+	for in, want := range map[string]RoundingMode{"": HalfEven, "HALF_UP": HalfUp, "down": Down} {
+		if got, err := ParseRoundingMode(in); err != nil || got != want {
+			t.Errorf("ParseRoundingMode(%q) = %q, %v, want %q", in, got, err, want)
+		}
+	}
+	if _, err := ParseRoundingMode("ceiling"); err == nil {
+		t.Error("ParseRoundingMode(ceiling) error = nil, want error")
+	}
+}
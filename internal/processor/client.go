@@ -0,0 +1,100 @@
+package processor
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+
+	"github.com/ivanjtm/YunoChallenge/internal/model"
+	"github.com/ivanjtm/YunoChallenge/internal/money"
+)
+
+var (
+	ErrDeclined           = errors.New("refund declined by processor")
+	ErrUnavailable        = errors.New("processor unavailable")
+	ErrTimeout            = errors.New("processor request timed out")
+	ErrNotFound           = errors.New("refund not found at processor")
+	ErrNotCancellable     = errors.New("refund cannot be cancelled at processor")
+	ErrUnknownProcessor   = errors.New("no client registered for processor")
+	ErrUnexpectedResponse = errors.New("unexpected processor response")
+)
+
+type Error struct {
+	ProcessorID string
+	Code        string
+	Message     string
+	Err         error
+}
+
+func (e *Error) Error() string {
+	if e.Message == "" {
+		return fmt.Sprintf("%s: %v", e.ProcessorID, e.Err)
+	}
+	return fmt.Sprintf("%s: %v: %s", e.ProcessorID, e.Err, e.Message)
+}
+
+func (e *Error) Unwrap() error { return e.Err }
+
+type SubmitRequest struct {
+	RefundID      string              `json:"refund_id"`
+	TransactionID string              `json:"transaction_id"`
+	PaymentMethod model.PaymentMethod `json:"payment_method"`
+	RefundMethod  model.RefundMethod  `json:"refund_method"`
+	Amount        money.Amount        `json:"amount"`
+	Currency      model.Currency      `json:"currency"`
+}
+
+type SubmitResponse struct {
+	Reference string             `json:"reference"`
+	Status    model.RefundStatus `json:"status"`
+}
+
+type StatusResponse struct {
+	Reference string             `json:"reference"`
+	Status    model.RefundStatus `json:"status"`
+	Message   string             `json:"message,omitempty"`
+}
+
+type Client interface {
+	SubmitRefund(ctx context.Context, req SubmitRequest) (SubmitResponse, error)
+	QueryStatus(ctx context.Context, reference string) (StatusResponse, error)
+	CancelReversal(ctx context.Context, reference string) error
+}
+
+type Registry struct {
+	mu      sync.RWMutex
+	clients map[string]Client
+}
+
+func NewRegistry() *Registry {
+	return &Registry{clients: make(map[string]Client)}
+}
+
+func (r *Registry) Register(processorID string, c Client) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.clients[processorID] = c
+}
+
+func (r *Registry) Client(processorID string) (Client, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	c, ok := r.clients[processorID]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrUnknownProcessor, processorID)
+	}
+	return c, nil
+}
+
+func (r *Registry) ProcessorIDs() []string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	ids := make([]string, 0, len(r.clients))
+	for id := range r.clients {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	return ids
+}
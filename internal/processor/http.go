@@ -0,0 +1,112 @@
+package processor
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+type HTTPClient struct {
+	ProcessorID string
+	BaseURL     string
+	HTTP        *http.Client
+}
+
+func NewHTTPClient(processorID, baseURL string, timeout time.Duration) *HTTPClient {
+	return &HTTPClient{
+		ProcessorID: processorID,
+		BaseURL:     strings.TrimRight(baseURL, "/"),
+		HTTP:        &http.Client{Timeout: timeout},
+	}
+}
+
+type errorBody struct {
+	Error   string `json:"error"`
+	Message string `json:"message"`
+}
+
+func (c *HTTPClient) SubmitRefund(ctx context.Context, req SubmitRequest) (SubmitResponse, error) {
+	var resp SubmitResponse
+	err := c.do(ctx, http.MethodPost, "/refunds", req, &resp)
+	return resp, err
+}
+
+func (c *HTTPClient) QueryStatus(ctx context.Context, reference string) (StatusResponse, error) {
+	var resp StatusResponse
+	err := c.do(ctx, http.MethodGet, "/refunds/"+url.PathEscape(reference), nil, &resp)
+	return resp, err
+}
+
+func (c *HTTPClient) CancelReversal(ctx context.Context, reference string) error {
+	return c.do(ctx, http.MethodPost, "/refunds/"+url.PathEscape(reference)+"/cancel", nil, nil)
+}
+
+func (c *HTTPClient) do(ctx context.Context, method, path string, in, out any) error {
+	var body io.Reader
+	if in != nil {
+		data, err := json.Marshal(in)
+		if err != nil {
+			return fmt.Errorf("marshal request: %w", err)
+		}
+		body = bytes.NewReader(data)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, c.BaseURL+path, body)
+	if err != nil {
+		return fmt.Errorf("build request: %w", err)
+	}
+	if in != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	resp, err := c.HTTP.Do(req)
+	if err != nil {
+		if errors.Is(err, context.DeadlineExceeded) || isTimeout(err) {
+			return &Error{ProcessorID: c.ProcessorID, Code: "timeout", Err: ErrTimeout, Message: err.Error()}
+		}
+		return &Error{ProcessorID: c.ProcessorID, Code: "unavailable", Err: ErrUnavailable, Message: err.Error()}
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		var eb errorBody
+		json.NewDecoder(resp.Body).Decode(&eb)
+		return &Error{ProcessorID: c.ProcessorID, Code: eb.Error, Message: eb.Message, Err: statusError(resp.StatusCode)}
+	}
+
+	if out == nil {
+		return nil
+	}
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return &Error{ProcessorID: c.ProcessorID, Code: "invalid_response", Err: ErrUnexpectedResponse, Message: err.Error()}
+	}
+	return nil
+}
+
+func statusError(code int) error {
+	switch {
+	case code == http.StatusNotFound:
+		return ErrNotFound
+	case code == http.StatusConflict:
+		return ErrNotCancellable
+	case code == http.StatusPaymentRequired || code == http.StatusUnprocessableEntity:
+		return ErrDeclined
+	case code == http.StatusGatewayTimeout || code == http.StatusRequestTimeout:
+		return ErrTimeout
+	case code >= 500 || code == http.StatusTooManyRequests:
+		return ErrUnavailable
+	}
+	return ErrUnexpectedResponse
+}
+
+func isTimeout(err error) bool {
+	var te interface{ Timeout() bool }
+	return errors.As(err, &te) && te.Timeout()
+}
@@ -0,0 +1,236 @@
+package processor
+
+import (
+	"encoding/json"
+	"fmt"
+	"math/rand"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"time"
+
+	"github.com/ivanjtm/YunoChallenge/internal/model"
+)
+
+type MockProfile struct {
+	Latency         time.Duration
+	ErrorRate       float64
+	DeclineRate     float64
+	FailureRate     float64
+	CompletionDelay time.Duration
+}
+
+func DefaultMockProfiles() map[string]MockProfile {
+	return map[string]MockProfile{
+		"paybr":       {Latency: 40 * time.Millisecond, ErrorRate: 0.01, DeclineRate: 0.02, CompletionDelay: 5 * time.Second},
+		"mexpay":      {Latency: 60 * time.Millisecond, ErrorRate: 0.02, DeclineRate: 0.02, CompletionDelay: 5 * time.Second},
+		"colpay":      {Latency: 80 * time.Millisecond, ErrorRate: 0.03, DeclineRate: 0.03, CompletionDelay: 8 * time.Second},
+		"globalpay":   {Latency: 120 * time.Millisecond, ErrorRate: 0.01, DeclineRate: 0.01, CompletionDelay: 10 * time.Second},
+		"quickrefund": {Latency: 20 * time.Millisecond, ErrorRate: 0.05, DeclineRate: 0.04},
+		"valueproc":   {Latency: 200 * time.Millisecond, ErrorRate: 0.04, DeclineRate: 0.05, FailureRate: 0.02, CompletionDelay: 15 * time.Second},
+	}
+}
+
+type mockRefund struct {
+	reference   string
+	processorID string
+	method      model.RefundMethod
+	submittedAt time.Time
+	status      model.RefundStatus
+	willFail    bool
+}
+
+type Mock struct {
+	mu       sync.Mutex
+	profiles map[string]MockProfile
+	refunds  map[string]*mockRefund
+	rng      *rand.Rand
+	seq      int
+	now      func() time.Time
+	mux      *http.ServeMux
+}
+
+func NewMock(profiles map[string]MockProfile, seed int64) *Mock {
+	m := &Mock{
+		profiles: profiles,
+		refunds:  make(map[string]*mockRefund),
+		rng:      rand.New(rand.NewSource(seed)),
+		now:      time.Now,
+		mux:      http.NewServeMux(),
+	}
+	m.mux.HandleFunc("POST /{processor}/refunds", m.submit)
+	m.mux.HandleFunc("GET /{processor}/refunds/{ref}", m.query)
+	m.mux.HandleFunc("POST /{processor}/refunds/{ref}/cancel", m.cancel)
+	return m
+}
+
+func (m *Mock) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	m.mux.ServeHTTP(w, r)
+}
+
+func (m *Mock) SetProfile(processorID string, p MockProfile) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.profiles[processorID] = p
+}
+
+func StartMockServer(profiles map[string]MockProfile, seed int64, timeout time.Duration) (*httptest.Server, *Registry) {
+	srv := httptest.NewServer(NewMock(profiles, seed))
+	reg := NewRegistry()
+	for id := range profiles {
+		reg.Register(id, NewHTTPClient(id, srv.URL+"/"+id, timeout))
+	}
+	return srv, reg
+}
+
+func (m *Mock) submit(w http.ResponseWriter, r *http.Request) {
+	procID := r.PathValue("processor")
+	profile, ok := m.profile(procID)
+	if !ok {
+		writeMockError(w, http.StatusNotFound, "unknown_processor", procID)
+		return
+	}
+	if !m.delay(r, profile) {
+		return
+	}
+
+	var req SubmitRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeMockError(w, http.StatusBadRequest, "invalid_request", err.Error())
+		return
+	}
+
+	m.mu.Lock()
+	roll := m.rng.Float64()
+	failRoll := m.rng.Float64()
+	m.mu.Unlock()
+
+	switch {
+	case roll < profile.ErrorRate:
+		writeMockError(w, http.StatusServiceUnavailable, "service_unavailable", "simulated processor outage")
+		return
+	case roll < profile.ErrorRate+profile.DeclineRate:
+		writeMockError(w, http.StatusUnprocessableEntity, "declined", fmt.Sprintf("%s refund declined by issuer (simulated)", req.RefundMethod))
+		return
+	}
+
+	m.mu.Lock()
+	m.seq++
+	ref := fmt.Sprintf("%s_ref_%06d", procID, m.seq)
+	m.refunds[ref] = &mockRefund{
+		reference:   ref,
+		processorID: procID,
+		method:      req.RefundMethod,
+		submittedAt: m.now(),
+		status:      model.RefundStatusSubmitted,
+		willFail:    failRoll < profile.FailureRate,
+	}
+	m.mu.Unlock()
+
+	writeMockJSON(w, http.StatusAccepted, SubmitResponse{Reference: ref, Status: model.RefundStatusSubmitted})
+}
+
+func (m *Mock) query(w http.ResponseWriter, r *http.Request) {
+	profile, ok := m.profile(r.PathValue("processor"))
+	if !ok {
+		writeMockError(w, http.StatusNotFound, "unknown_processor", r.PathValue("processor"))
+		return
+	}
+	if !m.delay(r, profile) {
+		return
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	ref, ok := m.refunds[r.PathValue("ref")]
+	if !ok || ref.processorID != r.PathValue("processor") {
+		writeMockError(w, http.StatusNotFound, "not_found", r.PathValue("ref"))
+		return
+	}
+
+	m.advance(ref, profile)
+	resp := StatusResponse{Reference: ref.reference, Status: ref.status}
+	if ref.status == model.RefundStatusFailed {
+		resp.Message = "beneficiary account rejected the refund (simulated)"
+	}
+	writeMockJSON(w, http.StatusOK, resp)
+}
+
+func (m *Mock) cancel(w http.ResponseWriter, r *http.Request) {
+	profile, ok := m.profile(r.PathValue("processor"))
+	if !ok {
+		writeMockError(w, http.StatusNotFound, "unknown_processor", r.PathValue("processor"))
+		return
+	}
+	if !m.delay(r, profile) {
+		return
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	ref, ok := m.refunds[r.PathValue("ref")]
+	if !ok || ref.processorID != r.PathValue("processor") {
+		writeMockError(w, http.StatusNotFound, "not_found", r.PathValue("ref"))
+		return
+	}
+
+	m.advance(ref, profile)
+	if ref.method != model.RefundReversal {
+		writeMockError(w, http.StatusConflict, "not_cancellable", "only reversals can be cancelled")
+		return
+	}
+	if ref.status != model.RefundStatusSubmitted && ref.status != model.RefundStatusProcessing {
+		writeMockError(w, http.StatusConflict, "not_cancellable", fmt.Sprintf("reversal already %s", ref.status))
+		return
+	}
+	ref.status = model.RefundStatusCancelled
+	writeMockJSON(w, http.StatusOK, StatusResponse{Reference: ref.reference, Status: ref.status})
+}
+
+func (m *Mock) advance(ref *mockRefund, profile MockProfile) {
+	if ref.status != model.RefundStatusSubmitted && ref.status != model.RefundStatusProcessing {
+		return
+	}
+	elapsed := m.now().Sub(ref.submittedAt)
+	switch {
+	case elapsed >= profile.CompletionDelay:
+		if ref.willFail {
+			ref.status = model.RefundStatusFailed
+		} else {
+			ref.status = model.RefundStatusSucceeded
+		}
+	case elapsed >= profile.CompletionDelay/2:
+		ref.status = model.RefundStatusProcessing
+	}
+}
+
+func (m *Mock) profile(processorID string) (MockProfile, bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	p, ok := m.profiles[processorID]
+	return p, ok
+}
+
+func (m *Mock) delay(r *http.Request, p MockProfile) bool {
+	if p.Latency <= 0 {
+		return true
+	}
+	t := time.NewTimer(p.Latency)
+	defer t.Stop()
+	select {
+	case <-t.C:
+		return true
+	case <-r.Context().Done():
+		return false
+	}
+}
+
+func writeMockJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
+
+func writeMockError(w http.ResponseWriter, status int, code, msg string) {
+	writeMockJSON(w, status, errorBody{Error: code, Message: msg})
+}
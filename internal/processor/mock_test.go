@@ -0,0 +1,148 @@
+package processor
+
+import (
+	"context"
+	"errors"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/ivanjtm/YunoChallenge/internal/model"
+	"github.com/ivanjtm/YunoChallenge/internal/money"
+)
+
+func newTestMock(t *testing.T, profiles map[string]MockProfile) (*Mock, *Registry) {
+	t.Helper()
+	m := NewMock(profiles, 1)
+	srv := httptest.NewServer(m)
+	t.Cleanup(srv.Close)
+
+	reg := NewRegistry()
+	for id := range profiles {
+		reg.Register(id, NewHTTPClient(id, srv.URL+"/"+id, time.Second))
+	}
+	return m, reg
+}
+
+func submitReq(method model.RefundMethod) SubmitRequest {
+	return SubmitRequest{
+		RefundID:      "rfd_test",
+		TransactionID: "tx-1",
+		PaymentMethod: model.MethodPIX,
+		RefundMethod:  method,
+		Amount:        money.FromFloat(100),
+		Currency:      model.CurrencyBRL,
+	}
+}
+
+func TestMock_SubmitAndQueryLifecycle(t *testing.T) {
+	t.Parallel()
+
+	m, reg := newTestMock(t, map[string]MockProfile{"paybr": {CompletionDelay: time.Hour}})
+	clock := time.Date(2025, 6, 15, 12, 0, 0, 0, time.UTC)
+	m.now = func() time.Time { return clock }
+
+	c, err := reg.Client("paybr")
+	if err != nil {
+		t.Fatalf("Client() error = %v", err)
+	}
+	ctx := context.Background()
+
+	sub, err := c.SubmitRefund(ctx, submitReq(model.RefundSameMethod))
+	if err != nil {
+		t.Fatalf("SubmitRefund() error = %v", err)
+	}
+	if sub.Reference == "" || sub.Status != model.RefundStatusSubmitted {
+		t.Fatalf("SubmitRefund() = %+v, want reference and SUBMITTED", sub)
+	}
+
+	steps := []struct {
+		advance time.Duration
+		want    model.RefundStatus
+	}{
+		{0, model.RefundStatusSubmitted},
+		{31 * time.Minute, model.RefundStatusProcessing},
+		{30 * time.Minute, model.RefundStatusSucceeded},
+	}
+	for _, step := range steps {
+		clock = clock.Add(step.advance)
+		st, err := c.QueryStatus(ctx, sub.Reference)
+		if err != nil {
+			t.Fatalf("QueryStatus() error = %v", err)
+		}
+		if st.Status != step.want {
+			t.Errorf("after %v: Status = %s, want %s", step.advance, st.Status, step.want)
+		}
+	}
+}
+
+func TestMock_ErrorsMapToSentinels(t *testing.T) {
+	t.Parallel()
+
+	_, reg := newTestMock(t, map[string]MockProfile{
+		"down":     {ErrorRate: 1},
+		"declines": {DeclineRate: 1},
+	})
+	ctx := context.Background()
+
+	tests := []struct {
+		processorID string
+		want        error
+	}{
+		{"down", ErrUnavailable},
+		{"declines", ErrDeclined},
+	}
+	for _, tt := range tests {
+		c, _ := reg.Client(tt.processorID)
+		_, err := c.SubmitRefund(ctx, submitReq(model.RefundSameMethod))
+		if !errors.Is(err, tt.want) {
+			t.Errorf("%s: SubmitRefund() error = %v, want %v", tt.processorID, err, tt.want)
+		}
+		var perr *Error
+		if !errors.As(err, &perr) || perr.ProcessorID != tt.processorID {
+			t.Errorf("%s: error %v is not a *Error for the processor", tt.processorID, err)
+		}
+	}
+
+	if _, err := reg.Client("unknown"); !errors.Is(err, ErrUnknownProcessor) {
+		t.Errorf("Client(unknown) error = %v, want ErrUnknownProcessor", err)
+	}
+}
+
+func TestMock_LatencyTimesOut(t *testing.T) {
+	t.Parallel()
+
+	m := NewMock(map[string]MockProfile{"slow": {Latency: 200 * time.Millisecond}}, 1)
+	srv := httptest.NewServer(m)
+	t.Cleanup(srv.Close)
+
+	c := NewHTTPClient("slow", srv.URL+"/slow", 20*time.Millisecond)
+	_, err := c.SubmitRefund(context.Background(), submitReq(model.RefundSameMethod))
+	if !errors.Is(err, ErrTimeout) {
+		t.Errorf("SubmitRefund() error = %v, want ErrTimeout", err)
+	}
+}
+
+func TestMock_CancelReversalOnly(t *testing.T) {
+	t.Parallel()
+
+	_, reg := newTestMock(t, map[string]MockProfile{"paybr": {CompletionDelay: time.Hour}})
+	c, _ := reg.Client("paybr")
+	ctx := context.Background()
+
+	rev, _ := c.SubmitRefund(ctx, submitReq(model.RefundReversal))
+	if err := c.CancelReversal(ctx, rev.Reference); err != nil {
+		t.Errorf("CancelReversal(reversal) error = %v", err)
+	}
+	if st, _ := c.QueryStatus(ctx, rev.Reference); st.Status != model.RefundStatusCancelled {
+		t.Errorf("Status after cancel = %s, want CANCELLED", st.Status)
+	}
+
+	same, _ := c.SubmitRefund(ctx, submitReq(model.RefundSameMethod))
+	if err := c.CancelReversal(ctx, same.Reference); !errors.Is(err, ErrNotCancellable) {
+		t.Errorf("CancelReversal(same-method) error = %v, want ErrNotCancellable", err)
+	}
+	if err := c.CancelReversal(ctx, "missing"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("CancelReversal(missing) error = %v, want ErrNotFound", err)
+	}
+}
@@ -0,0 +1,30 @@
+//go:build unix
+
+package quota
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestFileStore_SingleProcess(t *testing.T) {
+	t.Parallel()
+
+	path := writeLog(t)
+	s, err := NewFileStore(path, time.Time{})
+	if err != nil {
+		t.Fatalf("NewFileStore() error = %v", err)
+	}
+
+	if _, err := NewFileStore(path, time.Time{}); !errors.Is(err, ErrLogInUse) {
+		t.Errorf("second NewFileStore() error = %v, want ErrLogInUse", err)
+	}
+
+	s.Close()
+	again, err := NewFileStore(path, time.Time{})
+	if err != nil {
+		t.Fatalf("NewFileStore() after Close error = %v", err)
+	}
+	again.Close()
+}
@@ -0,0 +1,200 @@
+package quota
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/ivanjtm/YunoChallenge/internal/model"
+	"github.com/ivanjtm/YunoChallenge/internal/money"
+)
+
+type limit struct {
+	model.QuotaLimit
+	loc    *time.Location
+	window time.Duration
+}
+
+func Limits(p model.Processor) []model.QuotaLimit {
+	var limits []model.QuotaLimit
+	if p.DailyQuota > 0 {
+		limits = append(limits, model.QuotaLimit{Period: model.QuotaPeriodDay, Limit: p.DailyQuota})
+	}
+	return append(limits, p.QuotaLimits...)
+}
+
+func ValidateLimits(p model.Processor) error {
+	if p.DailyQuota < 0 {
+		return fmt.Errorf("daily_quota %d is negative", p.DailyQuota)
+	}
+
+	seen := make(map[model.QuotaLimit]bool)
+	for i, l := range p.QuotaLimits {
+		if _, err := compileLimit(l); err != nil {
+			return fmt.Errorf("quota_limits[%d]: %w", i, err)
+		}
+		if l.Period == model.QuotaPeriodDay && l.Amount == 0 && l.Method == "" && p.DailyQuota > 0 {
+			return fmt.Errorf("quota_limits[%d]: daily_quota is already set; use one or the other", i)
+		}
+		key := l
+		key.Limit = 0
+		key.Amount = 0
+		if seen[key] {
+			return fmt.Errorf("quota_limits[%d]: duplicate %s limit", i, l.Period)
+		}
+		seen[key] = true
+	}
+	return nil
+}
+
+func Horizon(processors []model.Processor, now time.Time) time.Time {
+	h := monthStart(now)
+	for _, p := range processors {
+		if ph := horizon(compile(p), now); ph.Before(h) {
+			h = ph
+		}
+	}
+	return h
+}
+
+func compile(p model.Processor) []limit {
+	var limits []limit
+	for _, l := range Limits(p) {
+		if c, err := compileLimit(l); err == nil {
+			limits = append(limits, c)
+		}
+	}
+	return limits
+}
+
+func compileLimit(l model.QuotaLimit) (limit, error) {
+	switch {
+	case l.Limit < 0 || l.Amount < 0:
+		return limit{}, errors.New("limit and amount can't be negative")
+	case l.Limit > 0 && l.Amount > 0:
+		return limit{}, errors.New("set either limit or amount, not both")
+	case l.Limit == 0 && l.Amount == 0:
+		return limit{}, errors.New("limit or amount must be positive")
+	case l.Amount > 0 && l.Currency == "":
+		return limit{}, errors.New("amount limits need a currency")
+	case l.Amount == 0 && l.Currency != "":
+		return limit{}, errors.New("currency is only valid with amount")
+	}
+	c := limit{QuotaLimit: l, loc: time.UTC}
+	if l.TimeZone != "" {
+		loc, err := time.LoadLocation(l.TimeZone)
+		if err != nil {
+			return limit{}, fmt.Errorf("unknown time_zone %q", l.TimeZone)
+		}
+		c.loc = loc
+	}
+
+	switch l.Period {
+	case model.QuotaPeriodHour, model.QuotaPeriodDay, model.QuotaPeriodMonth:
+		if l.Window != "" {
+			return limit{}, fmt.Errorf("window is only valid for rolling limits, not %s", l.Period)
+		}
+	case model.QuotaPeriodRolling:
+		if l.TimeZone != "" {
+			return limit{}, errors.New("rolling limits don't take a time_zone")
+		}
+		d, err := time.ParseDuration(l.Window)
+		if err != nil || d < time.Minute {
+			return limit{}, fmt.Errorf("rolling window %q must be a duration of at least 1m", l.Window)
+		}
+		c.window = d
+	default:
+		return limit{}, fmt.Errorf("unknown period %q (want hour, day, month or rolling)", l.Period)
+	}
+	return c, nil
+}
+
+func (l limit) start(now time.Time) time.Time {
+	local := now.In(l.loc)
+	switch l.Period {
+	case model.QuotaPeriodHour:
+		return time.Date(local.Year(), local.Month(), local.Day(), local.Hour(), 0, 0, 0, l.loc)
+	case model.QuotaPeriodDay:
+		return time.Date(local.Year(), local.Month(), local.Day(), 0, 0, 0, 0, l.loc)
+	case model.QuotaPeriodMonth:
+		return time.Date(local.Year(), local.Month(), 1, 0, 0, 0, 0, l.loc)
+	default:
+		return now.Add(-l.window).Truncate(time.Minute)
+	}
+}
+
+func (l limit) end(now time.Time) time.Time {
+	start := l.start(now)
+	switch l.Period {
+	case model.QuotaPeriodHour:
+		return time.Date(start.Year(), start.Month(), start.Day(), start.Hour()+1, 0, 0, 0, l.loc)
+	case model.QuotaPeriodDay:
+		return start.AddDate(0, 0, 1)
+	case model.QuotaPeriodMonth:
+		return start.AddDate(0, 1, 0)
+	default:
+		return time.Time{}
+	}
+}
+
+func (l limit) label() string {
+	var name string
+	switch l.Period {
+	case model.QuotaPeriodHour:
+		name = "Hourly"
+	case model.QuotaPeriodDay:
+		name = "Daily"
+	case model.QuotaPeriodMonth:
+		name = "Monthly"
+	default:
+		name = "Rolling " + l.Window
+	}
+	if l.Method != "" {
+		name += " " + string(l.Method)
+	}
+	if l.Amount > 0 {
+		return name + " volume cap"
+	}
+	return name + " quota"
+}
+
+func (l limit) blocks(d model.QuotaDemand, used int, amount money.Amount) (string, bool) {
+	cur := string(l.Currency)
+	switch {
+	case l.Amount == 0:
+		return fmt.Sprintf("%s exhausted: %d/%d used", l.label(), used, l.Limit), used >= l.Limit
+	case d.Amount > l.Amount:
+		return fmt.Sprintf("%s is %s %s; refund of %s %s exceeds it", l.label(), l.Amount.Format(cur), cur, d.Amount.Format(cur), cur), true
+	case d.Amount == 0:
+		return fmt.Sprintf("%s reached: %s of %s %s used", l.label(), amount.Format(cur), l.Amount.Format(cur), cur), amount >= l.Amount
+	default:
+		return fmt.Sprintf("%s reached: %s of %s %s used, refund of %s %s doesn't fit", l.label(), amount.Format(cur), l.Amount.Format(cur), cur, d.Amount.Format(cur), cur), amount+d.Amount > l.Amount
+	}
+}
+
+func (l limit) applies(d model.QuotaDemand) bool {
+	return (l.Method == "" || l.Method == d.Method) && (l.Currency == "" || l.Currency == d.Currency)
+}
+
+func (l limit) primary() bool {
+	return l.Amount == 0 && l.Method == ""
+}
+
+func (l limit) storeWindow(since time.Time) Window {
+	return Window{Since: since, Method: l.Method, Currency: l.Currency, Limit: l.Limit, Amount: l.Amount}
+}
+
+func horizon(limits []limit, now time.Time) time.Time {
+	h := monthStart(now)
+	for _, l := range limits {
+		if s := l.start(now); s.Before(h) {
+			h = s
+		}
+	}
+	return h
+}
+
+func monthStart(now time.Time) time.Time {
+	now = now.UTC()
+	return time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.UTC)
+}
@@ -0,0 +1,196 @@
+package quota
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ivanjtm/YunoChallenge/internal/model"
+	"github.com/ivanjtm/YunoChallenge/internal/money"
+)
+
+func TestLimit_StartEnd(t *testing.T) {
+	t.Parallel()
+
+	utc := func(y int, m time.Month, d, h, min int) time.Time { return time.Date(y, m, d, h, min, 0, 0, time.UTC) }
+
+	tests := []struct {
+		name      string
+		limit     model.QuotaLimit
+		now       time.Time
+		wantStart time.Time
+		wantEnd   time.Time
+	}{
+		{"hour", model.QuotaLimit{Period: model.QuotaPeriodHour, Limit: 1}, utc(2025, 6, 15, 12, 34), utc(2025, 6, 15, 12, 0), utc(2025, 6, 15, 13, 0)},
+		{"hour across new year", model.QuotaLimit{Period: model.QuotaPeriodHour, Limit: 1}, time.Date(2025, 12, 31, 23, 59, 59, 0, time.UTC), utc(2025, 12, 31, 23, 0), utc(2026, 1, 1, 0, 0)},
+		{"hour in half-hour zone", model.QuotaLimit{Period: model.QuotaPeriodHour, Limit: 1, TimeZone: "Asia/Kolkata"}, utc(2025, 6, 15, 12, 10), utc(2025, 6, 15, 11, 30), utc(2025, 6, 15, 12, 30)},
+		{"day UTC", model.QuotaLimit{Period: model.QuotaPeriodDay, Limit: 1}, utc(2025, 6, 15, 12, 0), utc(2025, 6, 15, 0, 0), utc(2025, 6, 16, 0, 0)},
+		{"day before local midnight", model.QuotaLimit{Period: model.QuotaPeriodDay, Limit: 1, TimeZone: "America/Mexico_City"}, utc(2025, 6, 15, 3, 0), utc(2025, 6, 14, 6, 0), utc(2025, 6, 15, 6, 0)},
+		{"day after local midnight", model.QuotaLimit{Period: model.QuotaPeriodDay, Limit: 1, TimeZone: "America/Sao_Paulo"}, utc(2025, 6, 15, 3, 0), utc(2025, 6, 15, 3, 0), utc(2025, 6, 16, 3, 0)},
+		{"day on DST start", model.QuotaLimit{Period: model.QuotaPeriodDay, Limit: 1, TimeZone: "America/New_York"}, utc(2025, 3, 9, 12, 0), utc(2025, 3, 9, 5, 0), utc(2025, 3, 10, 4, 0)},
+		{"day on DST end", model.QuotaLimit{Period: model.QuotaPeriodDay, Limit: 1, TimeZone: "America/New_York"}, utc(2025, 11, 2, 12, 0), utc(2025, 11, 2, 4, 0), utc(2025, 11, 3, 5, 0)},
+		{"month", model.QuotaLimit{Period: model.QuotaPeriodMonth, Limit: 1}, utc(2024, 2, 29, 23, 0), utc(2024, 2, 1, 0, 0), utc(2024, 3, 1, 0, 0)},
+		{"month across new year", model.QuotaLimit{Period: model.QuotaPeriodMonth, Limit: 1}, utc(2025, 12, 31, 23, 59), utc(2025, 12, 1, 0, 0), utc(2026, 1, 1, 0, 0)},
+		{"month still previous locally", model.QuotaLimit{Period: model.QuotaPeriodMonth, Limit: 1, TimeZone: "America/Bogota"}, utc(2025, 7, 1, 3, 0), utc(2025, 6, 1, 5, 0), utc(2025, 7, 1, 5, 0)},
+		{"rolling", model.QuotaLimit{Period: model.QuotaPeriodRolling, Limit: 1, Window: "24h"}, time.Date(2025, 6, 15, 12, 0, 30, 0, time.UTC), utc(2025, 6, 14, 12, 0), time.Time{}},
+		{"rolling minutes", model.QuotaLimit{Period: model.QuotaPeriodRolling, Limit: 1, Window: "90m"}, utc(2025, 6, 15, 0, 30), utc(2025, 6, 14, 23, 0), time.Time{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			l, err := compileLimit(tt.limit)
+			if err != nil {
+				t.Fatalf("compileLimit() error = %v", err)
+			}
+			if got := l.start(tt.now); !got.Equal(tt.wantStart) {
+				t.Errorf("start() = %s, want %s", got.UTC(), tt.wantStart)
+			}
+			if got := l.end(tt.now); !got.Equal(tt.wantEnd) {
+				t.Errorf("end() = %s, want %s", got.UTC(), tt.wantEnd)
+			}
+		})
+	}
+}
+
+func TestCompileLimit_Periods(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		limit   model.QuotaLimit
+		wantErr string
+	}{
+		{"hour", model.QuotaLimit{Period: model.QuotaPeriodHour, Limit: 60}, ""},
+		{"day in zone", model.QuotaLimit{Period: model.QuotaPeriodDay, Limit: 800, TimeZone: "America/Mexico_City"}, ""},
+		{"rolling", model.QuotaLimit{Period: model.QuotaPeriodRolling, Limit: 600, Window: "24h"}, ""},
+		{"unknown period", model.QuotaLimit{Period: "week", Limit: 1}, "unknown period"},
+		{"unknown zone", model.QuotaLimit{Period: model.QuotaPeriodDay, Limit: 1, TimeZone: "Mars/Olympus"}, "unknown time_zone"},
+		{"window on calendar period", model.QuotaLimit{Period: model.QuotaPeriodDay, Limit: 1, Window: "24h"}, "window is only valid for rolling"},
+		{"rolling without window", model.QuotaLimit{Period: model.QuotaPeriodRolling, Limit: 1}, "must be a duration"},
+		{"rolling window too short", model.QuotaLimit{Period: model.QuotaPeriodRolling, Limit: 1, Window: "30s"}, "at least 1m"},
+		{"rolling with zone", model.QuotaLimit{Period: model.QuotaPeriodRolling, Limit: 1, Window: "1h", TimeZone: "UTC"}, "don't take a time_zone"},
+		{"zero limit", model.QuotaLimit{Period: model.QuotaPeriodDay}, "must be positive"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			_, err := compileLimit(tt.limit)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Errorf("compileLimit() error = %v, want nil", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("compileLimit() error = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestHorizon(t *testing.T) {
+	t.Parallel()
+
+	now := time.Date(2025, 6, 1, 3, 0, 0, 0, time.UTC)
+	procs := []model.Processor{
+		{ID: "a", DailyQuota: 10},
+		{ID: "b", QuotaLimits: []model.QuotaLimit{{Period: model.QuotaPeriodRolling, Limit: 5, Window: "72h"}}},
+	}
+	if got, want := Horizon(procs, now), time.Date(2025, 5, 29, 3, 0, 0, 0, time.UTC); !got.Equal(want) {
+		t.Errorf("Horizon() = %s, want the rolling window start %s", got, want)
+	}
+	if got, want := Horizon(procs[:1], now), time.Date(2025, 6, 1, 0, 0, 0, 0, time.UTC); !got.Equal(want) {
+		t.Errorf("Horizon(daily only) = %s, want the month start %s", got, want)
+	}
+}
+
+func TestLimit_AppliesAndBlocks(t *testing.T) {
+	t.Parallel()
+
+	brl := func(v float64) money.Amount { return money.FromFloat(v) }
+	count := model.QuotaLimit{Period: model.QuotaPeriodDay, Limit: 2}
+	method := model.QuotaLimit{Period: model.QuotaPeriodDay, Limit: 2, Method: model.RefundBankTransfer}
+	volume := model.QuotaLimit{Period: model.QuotaPeriodDay, Amount: brl(1000), Currency: model.CurrencyBRL}
+	transfer := model.QuotaDemand{ProcessorID: "p", Method: model.RefundBankTransfer, Currency: model.CurrencyBRL, Amount: brl(300)}
+	pix := transfer
+	pix.Method = model.RefundSameMethod
+
+	tests := []struct {
+		name        string
+		limit       model.QuotaLimit
+		demand      model.QuotaDemand
+		used        int
+		amount      money.Amount
+		wantApplies bool
+		wantBlocked bool
+		wantReason  string
+	}{
+		{"count limit has room", count, transfer, 1, 0, true, false, "Daily quota exhausted: 1/2 used"},
+		{"count limit full", count, transfer, 2, 0, true, true, "Daily quota exhausted: 2/2 used"},
+		{"method limit skips other methods", method, pix, 2, 0, false, true, ""},
+		{"method limit full", method, transfer, 2, 0, true, true, "Daily BANK_TRANSFER quota exhausted: 2/2 used"},
+		{"volume cap skips other currencies", volume, model.QuotaDemand{Currency: model.CurrencyMXN, Amount: brl(5000)}, 0, 0, false, true, ""},
+		{"volume cap fits exactly", volume, transfer, 3, brl(700), true, false, ""},
+		{"volume cap overshoot", volume, transfer, 3, brl(700.01), true, true, "Daily volume cap reached: 700.01 of 1000.00 BRL used, refund of 300.00 BRL doesn't fit"},
+		{"refund larger than the cap", volume, model.QuotaDemand{Currency: model.CurrencyBRL, Amount: brl(1500)}, 0, 0, true, true, "Daily volume cap is 1000.00 BRL; refund of 1500.00 BRL exceeds it"},
+		{"status check without amount", volume, model.QuotaDemand{Currency: model.CurrencyBRL}, 5, brl(1000), true, true, "Daily volume cap reached: 1000.00 of 1000.00 BRL used"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			l, err := compileLimit(tt.limit)
+			if err != nil {
+				t.Fatalf("compileLimit() error = %v", err)
+			}
+			if got := l.applies(tt.demand); got != tt.wantApplies {
+				t.Errorf("applies() = %v, want %v", got, tt.wantApplies)
+			}
+			if !tt.wantApplies {
+				return
+			}
+			reason, blocked := l.blocks(tt.demand, tt.used, tt.amount)
+			if blocked != tt.wantBlocked {
+				t.Errorf("blocks() = %v (%s), want %v", blocked, reason, tt.wantBlocked)
+			}
+			if tt.wantReason != "" && reason != tt.wantReason {
+				t.Errorf("reason = %q, want %q", reason, tt.wantReason)
+			}
+		})
+	}
+}
+
+func TestCompileLimit_VolumeAndMethod(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		limit   model.QuotaLimit
+		wantErr string
+	}{
+		{"volume cap", model.QuotaLimit{Period: model.QuotaPeriodDay, Amount: money.FromFloat(1000), Currency: model.CurrencyBRL}, ""},
+		{"method limit", model.QuotaLimit{Period: model.QuotaPeriodDay, Limit: 5, Method: model.RefundBankTransfer}, ""},
+		{"limit and amount", model.QuotaLimit{Period: model.QuotaPeriodDay, Limit: 5, Amount: money.FromFloat(1000), Currency: model.CurrencyBRL}, "not both"},
+		{"amount without currency", model.QuotaLimit{Period: model.QuotaPeriodDay, Amount: money.FromFloat(1000)}, "need a currency"},
+		{"currency without amount", model.QuotaLimit{Period: model.QuotaPeriodDay, Limit: 5, Currency: model.CurrencyBRL}, "only valid with amount"},
+		{"negative amount", model.QuotaLimit{Period: model.QuotaPeriodDay, Amount: money.FromFloat(-1), Currency: model.CurrencyBRL}, "can't be negative"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			_, err := compileLimit(tt.limit)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Errorf("compileLimit() error = %v, want nil", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("compileLimit() error = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
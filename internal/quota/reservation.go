@@ -0,0 +1,162 @@
+package quota
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/ivanjtm/YunoChallenge/internal/model"
+)
+
+const (
+	DefaultReservationTTL = 5 * time.Minute
+	expiredRetention      = 24 * time.Hour
+)
+
+var ErrReservationNotFound = errors.New("quota reservation not found or expired")
+
+type reservation struct {
+	model.QuotaReservation
+	override bool
+}
+
+func (t *Tracker) Reserve(d model.QuotaDemand, now time.Time) (model.QuotaReservation, bool, string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.expireLocked(now)
+
+	if a := t.availableLocked(d, now); !a.Available {
+		return model.QuotaReservation{}, false, a.Reason
+	}
+	override, ok := t.overrides[d.ProcessorID]
+	overridden := ok && override.QuotaUsed != nil
+
+	ok, err := t.consumeLocked(d, now, true)
+	if err != nil {
+		return model.QuotaReservation{}, false, fmt.Sprintf("Quota store unavailable: %v", err)
+	}
+	if !ok {
+		if err := t.loadLocked(now); err != nil {
+			return model.QuotaReservation{}, false, fmt.Sprintf("Quota store unavailable: %v", err)
+		}
+		if a := t.availableLocked(d, now); !a.Available {
+			return model.QuotaReservation{}, false, a.Reason
+		}
+		return model.QuotaReservation{}, false, "Quota taken by another instance"
+	}
+
+	ttl := t.ReservationTTL
+	if ttl <= 0 {
+		ttl = DefaultReservationTTL
+	}
+	r := model.QuotaReservation{
+		Token:       newToken(),
+		QuotaDemand: d,
+		ReservedAt:  now.UTC(),
+		ExpiresAt:   now.Add(ttl).UTC(),
+	}
+	t.reservations[r.Token] = reservation{QuotaReservation: r, override: overridden}
+	return r, true, ""
+}
+
+func (t *Tracker) Commit(token string, now time.Time) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.expireLocked(now)
+
+	if _, ok := t.reservations[token]; ok {
+		delete(t.reservations, token)
+		return nil
+	}
+	r, ok := t.expired[token]
+	if !ok {
+		return fmt.Errorf("%w: %s", ErrReservationNotFound, token)
+	}
+	if _, err := t.consumeLocked(r.QuotaDemand, r.ReservedAt, false); err != nil {
+		return fmt.Errorf("commit expired quota reservation: %w", err)
+	}
+	delete(t.expired, token)
+	return nil
+}
+
+func (t *Tracker) Release(token string, now time.Time) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.expireLocked(now)
+
+	r, ok := t.reservations[token]
+	if !ok {
+		if _, ok := t.expired[token]; ok {
+			delete(t.expired, token)
+			return nil
+		}
+		return fmt.Errorf("%w: %s", ErrReservationNotFound, token)
+	}
+	return t.releaseLocked(r, now)
+}
+
+func (t *Tracker) releaseLocked(r reservation, now time.Time) error {
+	u := Usage{
+		ProcessorID: r.ProcessorID,
+		Method:      r.Method,
+		Currency:    r.Currency,
+		Minute:      r.ReservedAt,
+		Count:       -1,
+		Amount:      -r.Amount,
+	}
+	if t.store != nil {
+		if _, err := t.store.Increment(u, nil); err != nil {
+			return fmt.Errorf("release quota reservation: %w", err)
+		}
+	}
+	if r.override {
+		if override, ok := t.overrides[r.ProcessorID]; ok && override.QuotaUsed != nil {
+			used := max(*override.QuotaUsed-1, 0)
+			override.QuotaUsed = &used
+			t.overrides[r.ProcessorID] = override
+		}
+	}
+	t.recordLocked(u, now)
+	delete(t.reservations, r.Token)
+	return nil
+}
+
+func (t *Tracker) expireLocked(now time.Time) {
+	for _, r := range t.reservations {
+		if now.Before(r.ExpiresAt) {
+			continue
+		}
+		if err := t.releaseLocked(r, now); err != nil {
+			if t.OnSyncError != nil {
+				t.OnSyncError(err)
+			}
+			continue
+		}
+		t.expired[r.Token] = r
+	}
+	for token, r := range t.expired {
+		if now.Sub(r.ExpiresAt) >= expiredRetention {
+			delete(t.expired, token)
+		}
+	}
+}
+
+func (t *Tracker) reservedLocked(processorID string) int {
+	n := 0
+	for _, r := range t.reservations {
+		if r.ProcessorID == processorID {
+			n++
+		}
+	}
+	return n
+}
+
+func newToken() string {
+	var b [12]byte
+	if _, err := rand.Read(b[:]); err != nil {
+		panic(fmt.Sprintf("quota: generating reservation token: %v", err))
+	}
+	return "qres_" + hex.EncodeToString(b[:])
+}
@@ -0,0 +1,220 @@
+package quota
+
+import (
+	"errors"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/ivanjtm/YunoChallenge/internal/model"
+	"github.com/ivanjtm/YunoChallenge/internal/money"
+)
+
+func TestTracker_ReservationSettlement(t *testing.T) {
+	t.Parallel()
+
+	now := time.Date(2025, 6, 15, 12, 0, 0, 0, time.UTC)
+	demand := model.QuotaDemand{ProcessorID: "p", Method: model.RefundBankTransfer, Currency: model.CurrencyBRL, Amount: money.FromFloat(100)}
+
+	tests := []struct {
+		name   string
+		settle func(*Tracker, string, time.Time) error
+		used   int
+	}{
+		{"commit keeps the slot", (*Tracker).Commit, 1},
+		{"release frees the slot", (*Tracker).Release, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			tr := NewTracker([]model.Processor{{ID: "p", DailyQuota: 1}})
+			r, ok, reason := tr.Reserve(demand, now)
+			if !ok {
+				t.Fatalf("Reserve() = false: %s", reason)
+			}
+			if r.Token == "" || r.QuotaDemand != demand || !r.ExpiresAt.Equal(now.Add(DefaultReservationTTL)) {
+				t.Errorf("Reserve() = %+v", r)
+			}
+			st := tr.Status(now)[0]
+			if st.UsedToday != 1 || st.Reserved != 1 {
+				t.Errorf("while held: used %d, reserved %d, want 1 and 1", st.UsedToday, st.Reserved)
+			}
+			if _, ok, _ := tr.Reserve(demand, now); ok {
+				t.Error("second Reserve() against a held slot = true, want false")
+			}
+
+			if err := tt.settle(tr, r.Token, now); err != nil {
+				t.Fatalf("settle error = %v", err)
+			}
+			st = tr.Status(now)[0]
+			if st.UsedToday != tt.used || st.Reserved != 0 {
+				t.Errorf("after settle: used %d, reserved %d, want %d and 0", st.UsedToday, st.Reserved, tt.used)
+			}
+
+			if err := tr.Commit(r.Token, now); !errors.Is(err, ErrReservationNotFound) {
+				t.Errorf("Commit() after settle error = %v, want ErrReservationNotFound", err)
+			}
+			if err := tr.Release(r.Token, now); !errors.Is(err, ErrReservationNotFound) {
+				t.Errorf("Release() after settle error = %v, want ErrReservationNotFound", err)
+			}
+			if got := tr.Status(now)[0].UsedToday; got != tt.used {
+				t.Errorf("UsedToday after a repeated settle = %d, want %d", got, tt.used)
+			}
+		})
+	}
+}
+
+func TestTracker_ReservationExpires(t *testing.T) {
+	t.Parallel()
+
+	now := time.Date(2025, 6, 15, 12, 0, 0, 0, time.UTC)
+	procs := []model.Processor{{ID: "p", DailyQuota: 1}}
+
+	tests := []struct {
+		name   string
+		settle func(*Tracker, string, time.Time) error
+		used   int
+	}{
+		{"late commit counts the refund", (*Tracker).Commit, 1},
+		{"late release leaves the slot free", (*Tracker).Release, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			path := filepath.Join(t.TempDir(), "quota.log")
+			store, err := NewFileStore(path, monthStart(now))
+			if err != nil {
+				t.Fatalf("NewFileStore() error = %v", err)
+			}
+			tr, err := NewPersistentTracker(procs, store, now)
+			if err != nil {
+				t.Fatalf("NewPersistentTracker() error = %v", err)
+			}
+			tr.ReservationTTL = time.Minute
+
+			r, ok, reason := tr.Reserve(model.QuotaDemand{ProcessorID: "p"}, now)
+			if !ok {
+				t.Fatalf("Reserve() = false: %s", reason)
+			}
+			if a := tr.Availability("p", now.Add(59*time.Second)); a.Available {
+				t.Error("Availability() before the TTL = available, want the slot still held")
+			}
+			if a := tr.Availability("p", now.Add(time.Minute)); !a.Available {
+				t.Errorf("Availability() at the TTL = %s, want the slot freed", a.Reason)
+			}
+
+			late := now.Add(2 * time.Minute)
+			if err := tt.settle(tr, r.Token, late); err != nil {
+				t.Fatalf("settle after expiry error = %v", err)
+			}
+			if st := tr.Status(late)[0]; st.UsedToday != tt.used || st.Reserved != 0 {
+				t.Errorf("after settle: used %d, reserved %d, want %d and 0", st.UsedToday, st.Reserved, tt.used)
+			}
+			if err := tr.Commit(r.Token, late); !errors.Is(err, ErrReservationNotFound) {
+				t.Errorf("second Commit() error = %v, want ErrReservationNotFound", err)
+			}
+
+			store.Close()
+			reopened, err := NewFileStore(path, monthStart(now))
+			if err != nil {
+				t.Fatalf("reopen error = %v", err)
+			}
+			defer reopened.Close()
+			restarted, err := NewPersistentTracker(procs, reopened, now)
+			if err != nil {
+				t.Fatalf("NewPersistentTracker() error = %v", err)
+			}
+			if got := restarted.Status(late)[0].UsedToday; got != tt.used {
+				t.Errorf("UsedToday after restart = %d, want %d from the log", got, tt.used)
+			}
+		})
+	}
+}
+
+func TestTracker_ExpiredReservationsAreForgotten(t *testing.T) {
+	t.Parallel()
+
+	now := time.Date(2025, 6, 15, 12, 0, 0, 0, time.UTC)
+	tr := NewTracker([]model.Processor{{ID: "p", DailyQuota: 10}})
+	tr.ReservationTTL = time.Minute
+
+	r, ok, reason := tr.Reserve(model.QuotaDemand{ProcessorID: "p"}, now)
+	if !ok {
+		t.Fatalf("Reserve() = false: %s", reason)
+	}
+	late := now.Add(time.Minute + expiredRetention)
+	if err := tr.Commit(r.Token, late); !errors.Is(err, ErrReservationNotFound) {
+		t.Errorf("Commit() a day after expiry error = %v, want ErrReservationNotFound", err)
+	}
+	if got := tr.Status(late)[0].UsedToday; got != 0 {
+		t.Errorf("UsedToday = %d, want 0", got)
+	}
+}
+
+func TestTracker_ReleaseUnderOverride(t *testing.T) {
+	t.Parallel()
+
+	now := time.Date(2025, 6, 15, 12, 0, 0, 0, time.UTC)
+	tr := NewTracker([]model.Processor{{ID: "p", DailyQuota: 10}})
+	tr.SetOverrides(map[string]model.ProcessorOverride{"p": {QuotaUsed: intPtr(9)}})
+
+	r, ok, reason := tr.Reserve(model.QuotaDemand{ProcessorID: "p"}, now)
+	if !ok {
+		t.Fatalf("Reserve() = false: %s", reason)
+	}
+	if a := tr.Availability("p", now); a.Available {
+		t.Error("Availability() with the overridden quota reserved = available, want exhausted")
+	}
+
+	if err := tr.Release(r.Token, now); err != nil {
+		t.Fatalf("Release() error = %v", err)
+	}
+	if got := tr.Status(now)[0].UsedToday; got != 9 {
+		t.Errorf("UsedToday after release = %d, want the override back at 9", got)
+	}
+	if _, ok, reason := tr.Reserve(model.QuotaDemand{ProcessorID: "p"}, now); !ok {
+		t.Errorf("Reserve() after release = false: %s", reason)
+	}
+
+	tr.ResetOverrides()
+	if got := tr.Status(now)[0].UsedToday; got != 1 {
+		t.Errorf("UsedToday without the override = %d, want only the held reservation", got)
+	}
+}
+
+func TestTracker_ConcurrentReserve(t *testing.T) {
+	t.Parallel()
+
+	now := time.Date(2025, 6, 15, 12, 0, 0, 0, time.UTC)
+	tr := NewTracker([]model.Processor{{ID: "p", DailyQuota: 1}})
+
+	const workers = 50
+	var (
+		wg     sync.WaitGroup
+		mu     sync.Mutex
+		tokens []string
+	)
+	for range workers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if r, ok, _ := tr.Reserve(model.QuotaDemand{ProcessorID: "p"}, now); ok {
+				mu.Lock()
+				tokens = append(tokens, r.Token)
+				mu.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+
+	if len(tokens) != 1 {
+		t.Fatalf("%d reservations succeeded against a limit of 1, want exactly 1", len(tokens))
+	}
+	st := tr.Status(now)[0]
+	if st.UsedToday != 1 || st.Reserved != 1 {
+		t.Errorf("used %d, reserved %d, want 1 and 1", st.UsedToday, st.Reserved)
+	}
+}
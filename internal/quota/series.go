@@ -0,0 +1,79 @@
+package quota
+
+import (
+	"sort"
+	"time"
+
+	"github.com/ivanjtm/YunoChallenge/internal/model"
+	"github.com/ivanjtm/YunoChallenge/internal/money"
+)
+
+type meter struct {
+	processorID string
+	method      model.RefundMethod
+	currency    model.Currency
+}
+
+type bucket struct {
+	minute time.Time
+	count  int
+	amount money.Amount
+}
+
+type series []bucket
+
+func (s series) add(at time.Time, n int, amount money.Amount) series {
+	minute := at.UTC().Truncate(time.Minute)
+	i := sort.Search(len(s), func(i int) bool { return !s[i].minute.Before(minute) })
+	if i < len(s) && s[i].minute.Equal(minute) {
+		s[i].count += n
+		s[i].amount += amount
+		return s
+	}
+	s = append(s, bucket{})
+	copy(s[i+1:], s[i:])
+	s[i] = bucket{minute: minute, count: n, amount: amount}
+	return s
+}
+
+func (s series) sumSince(since time.Time) (int, money.Amount) {
+	var count int
+	var amount money.Amount
+	for i := len(s) - 1; i >= 0 && !s[i].minute.Before(since); i-- {
+		count += s[i].count
+		amount += s[i].amount
+	}
+	return count, amount
+}
+
+func (s series) sumBetween(since, until time.Time) (int, money.Amount) {
+	var count int
+	var amount money.Amount
+	for _, b := range s {
+		if !b.minute.Before(since) && !b.minute.After(until) {
+			count += b.count
+			amount += b.amount
+		}
+	}
+	return count, amount
+}
+
+func (s series) prune(before time.Time) series {
+	i := sort.Search(len(s), func(i int) bool { return !s[i].minute.Before(before) })
+	if i == 0 {
+		return s
+	}
+	return append(series(nil), s[i:]...)
+}
+
+func (s series) freedAt(since time.Time, window time.Duration, count int, amount money.Amount) time.Time {
+	i := sort.Search(len(s), func(i int) bool { return !s[i].minute.Before(since) })
+	for ; i < len(s); i++ {
+		count -= s[i].count
+		amount -= s[i].amount
+		if count <= 0 && amount <= 0 {
+			return s[i].minute.Add(window + time.Minute)
+		}
+	}
+	return time.Time{}
+}
@@ -0,0 +1,78 @@
+package quota
+
+import (
+	"testing"
+	"time"
+
+	"github.com/ivanjtm/YunoChallenge/internal/money"
+)
+
+func TestSeries_AddSumPrune(t *testing.T) {
+	t.Parallel()
+
+	at := func(h, m, s int) time.Time { return time.Date(2025, 6, 15, h, m, s, 0, time.UTC) }
+	var s series
+	s = s.add(at(10, 5, 10), 1, money.FromFloat(10))
+	s = s.add(at(10, 0, 0), 2, money.FromFloat(20))
+	s = s.add(at(10, 5, 50), 1, money.FromFloat(5))
+	s = s.add(at(10, 30, 0), 3, 0)
+
+	if len(s) != 3 || !s[0].minute.Equal(at(10, 0, 0)) || s[1].count != 2 || s[1].amount != money.FromFloat(15) {
+		t.Fatalf("series = %+v, want three sorted minute buckets with 10:05 merged", s)
+	}
+
+	tests := []struct {
+		since      time.Time
+		wantCount  int
+		wantAmount money.Amount
+	}{
+		{at(9, 0, 0), 7, money.FromFloat(35)},
+		{at(10, 0, 0), 7, money.FromFloat(35)},
+		{at(10, 1, 0), 5, money.FromFloat(15)},
+		{at(10, 31, 0), 0, 0},
+	}
+	for _, tt := range tests {
+		count, amount := s.sumSince(tt.since)
+		if count != tt.wantCount || amount != tt.wantAmount {
+			t.Errorf("sumSince(%s) = %d, %s, want %d, %s", tt.since.Format("15:04"), count, amount, tt.wantCount, tt.wantAmount)
+		}
+	}
+
+	if pruned := s.prune(at(10, 5, 0)); len(pruned) != 2 || len(s) != 3 {
+		t.Errorf("prune() = %+v (original %d buckets), want the last two and the original untouched", pruned, len(s))
+	}
+}
+
+func TestSeries_FreedAt(t *testing.T) {
+	t.Parallel()
+
+	at := func(h, m int) time.Time { return time.Date(2025, 6, 15, h, m, 0, 0, time.UTC) }
+	var s series
+	s = s.add(at(10, 0), 2, money.FromFloat(200))
+	s = s.add(at(10, 5), 1, money.FromFloat(50))
+	s = s.add(at(10, 30), 3, money.FromFloat(300))
+
+	tests := []struct {
+		name   string
+		since  time.Time
+		count  int
+		amount money.Amount
+		want   time.Time
+	}{
+		{"one slot from the oldest bucket", at(10, 0), 1, 0, at(11, 1)},
+		{"needs the second bucket too", at(10, 0), 3, 0, at(11, 6)},
+		{"older buckets already outside", at(10, 1), 1, 0, at(11, 6)},
+		{"amount freed by the oldest bucket", at(10, 0), 0, money.FromFloat(150), at(11, 1)},
+		{"amount needs two buckets", at(10, 0), 0, money.FromFloat(250), at(11, 6)},
+		{"never frees enough", at(10, 0), 7, 0, time.Time{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			if got := s.freedAt(tt.since, time.Hour, tt.count, tt.amount); !got.Equal(tt.want) {
+				t.Errorf("freedAt() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
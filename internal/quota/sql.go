@@ -0,0 +1,106 @@
+package quota
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/ivanjtm/YunoChallenge/internal/money"
+)
+
+const SQLSchema = `CREATE TABLE IF NOT EXISTS quota_buckets (
+	processor_id TEXT    NOT NULL,
+	method       TEXT    NOT NULL DEFAULT '',
+	currency     TEXT    NOT NULL DEFAULT '',
+	minute       TEXT    NOT NULL,
+	used         INTEGER NOT NULL,
+	amount       BIGINT  NOT NULL DEFAULT 0,
+	PRIMARY KEY (processor_id, method, currency, minute)
+);
+CREATE TABLE IF NOT EXISTS quota_locks (
+	processor_id TEXT    PRIMARY KEY,
+	version      INTEGER NOT NULL
+)`
+
+type SQLStore struct {
+	db *sql.DB
+}
+
+func NewSQLStore(db *sql.DB) (*SQLStore, error) {
+	if _, err := db.Exec(SQLSchema); err != nil {
+		return nil, fmt.Errorf("create quota tables: %w", err)
+	}
+	return &SQLStore{db: db}, nil
+}
+
+func (s *SQLStore) Load(since time.Time) ([]Usage, error) {
+	rows, err := s.db.Query(
+		`SELECT processor_id, method, currency, minute, used, amount FROM quota_buckets WHERE minute >= $1`,
+		minuteKey(since))
+	if err != nil {
+		return nil, fmt.Errorf("query quota usage: %w", err)
+	}
+	defer rows.Close()
+
+	var usages []Usage
+	for rows.Next() {
+		var u Usage
+		var minute string
+		if err := rows.Scan(&u.ProcessorID, &u.Method, &u.Currency, &minute, &u.Count, &u.Amount); err != nil {
+			return nil, fmt.Errorf("scan quota usage: %w", err)
+		}
+		if u.Minute, err = time.Parse(minuteLayout, minute); err != nil {
+			return nil, fmt.Errorf("parse quota minute %q: %w", minute, err)
+		}
+		usages = append(usages, u)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("read quota usage: %w", err)
+	}
+	return usages, nil
+}
+
+func (s *SQLStore) Increment(u Usage, windows []Window) (bool, error) {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return false, fmt.Errorf("begin quota transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec(
+		`INSERT INTO quota_locks (processor_id, version) VALUES ($1, 0) ON CONFLICT (processor_id) DO NOTHING`,
+		u.ProcessorID); err != nil {
+		return false, fmt.Errorf("create quota lock: %w", err)
+	}
+	if _, err := tx.Exec(
+		`UPDATE quota_locks SET version = version + 1 WHERE processor_id = $1`,
+		u.ProcessorID); err != nil {
+		return false, fmt.Errorf("acquire quota lock: %w", err)
+	}
+
+	for _, w := range windows {
+		var used int
+		var amount money.Amount
+		if err := tx.QueryRow(`
+			SELECT COALESCE(SUM(used), 0), COALESCE(SUM(amount), 0) FROM quota_buckets
+			WHERE processor_id = $1 AND minute >= $2 AND ($3 = '' OR method = $3) AND ($4 = '' OR currency = $4)`,
+			u.ProcessorID, minuteKey(w.Since), w.Method, w.Currency).Scan(&used, &amount); err != nil {
+			return false, fmt.Errorf("read quota usage: %w", err)
+		}
+		if !w.admits(used, amount, u) {
+			return false, nil
+		}
+	}
+
+	if _, err := tx.Exec(`
+		INSERT INTO quota_buckets (processor_id, method, currency, minute, used, amount) VALUES ($1, $2, $3, $4, $5, $6)
+		ON CONFLICT (processor_id, method, currency, minute)
+		DO UPDATE SET used = quota_buckets.used + $5, amount = quota_buckets.amount + $6`,
+		u.ProcessorID, u.Method, u.Currency, minuteKey(u.Minute), u.Count, int64(u.Amount)); err != nil {
+		return false, fmt.Errorf("increment quota usage: %w", err)
+	}
+	if err := tx.Commit(); err != nil {
+		return false, fmt.Errorf("commit quota usage: %w", err)
+	}
+	return true, nil
+}
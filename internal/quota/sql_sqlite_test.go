@@ -0,0 +1,117 @@
+//go:build sqlite
+
+package quota
+
+import (
+	"database/sql"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	_ "modernc.org/sqlite"
+
+	"github.com/ivanjtm/YunoChallenge/internal/model"
+	"github.com/ivanjtm/YunoChallenge/internal/money"
+)
+
+func openSQLite(t *testing.T, path string) *SQLStore {
+	t.Helper()
+	db, err := sql.Open("sqlite", "file:"+path+"?_txlock=immediate&_pragma=busy_timeout(10000)&_pragma=journal_mode(WAL)")
+	if err != nil {
+		t.Fatalf("sql.Open() error = %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+	s, err := NewSQLStore(db)
+	if err != nil {
+		t.Fatalf("NewSQLStore() error = %v", err)
+	}
+	return s
+}
+
+func TestSQLStore_SQLite(t *testing.T) {
+	t.Parallel()
+
+	now := time.Date(2025, 6, 15, 12, 0, 0, 0, time.UTC)
+	s := openSQLite(t, filepath.Join(t.TempDir(), "quota.db"))
+	brl := func(v float64) Usage {
+		return Usage{ProcessorID: "paybr", Method: model.RefundBankTransfer, Currency: model.CurrencyBRL, Minute: now, Count: 1, Amount: money.FromFloat(v)}
+	}
+	volume := []Window{{Since: now.Add(-time.Hour), Currency: model.CurrencyBRL, Amount: money.FromFloat(1000)}}
+
+	steps := []struct {
+		name    string
+		usage   Usage
+		windows []Window
+		want    bool
+	}{
+		{"first refund", brl(600), volume, true},
+		{"overshoots the cap", brl(500), volume, false},
+		{"fits exactly", brl(400), volume, true},
+		{"release", Usage{ProcessorID: "paybr", Method: model.RefundBankTransfer, Currency: model.CurrencyBRL, Minute: now, Count: -1, Amount: -money.FromFloat(400)}, nil, true},
+		{"other processors are separate", Usage{ProcessorID: "mexpay", Currency: model.CurrencyMXN, Minute: now, Count: 1, Amount: money.FromFloat(5000)}, volume, true},
+	}
+	for _, st := range steps {
+		ok, err := s.Increment(st.usage, st.windows)
+		if err != nil {
+			t.Fatalf("%s: Increment() error = %v", st.name, err)
+		}
+		if ok != st.want {
+			t.Errorf("%s: Increment() = %v, want %v", st.name, ok, st.want)
+		}
+	}
+
+	usages, err := s.Load(now.Add(-time.Hour))
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	totals := usageTotals(usages)
+	if got := totals[meter{processorID: "paybr", method: model.RefundBankTransfer, currency: model.CurrencyBRL}]; got.count != 1 || got.amount != money.FromFloat(600) {
+		t.Errorf("paybr = %+v, want 1 refund of 600.00", got)
+	}
+	if got := totals[meter{processorID: "mexpay", currency: model.CurrencyMXN}]; got.count != 1 || got.amount != money.FromFloat(5000) {
+		t.Errorf("mexpay = %+v, want 1 refund of 5000.00", got)
+	}
+	if old, err := s.Load(now.Add(time.Minute)); err != nil || len(old) != 0 {
+		t.Errorf("Load() after the last minute = %v, %v, want nothing", old, err)
+	}
+}
+
+func TestSQLStore_SQLiteReplicasShareLimit(t *testing.T) {
+	t.Parallel()
+
+	now := time.Date(2025, 6, 15, 12, 0, 0, 0, time.UTC)
+	path := filepath.Join(t.TempDir(), "quota.db")
+	replicas := []*SQLStore{openSQLite(t, path), openSQLite(t, path), openSQLite(t, path)}
+	windows := []Window{{Since: now.Add(-24 * time.Hour), Limit: 10}}
+
+	var granted atomic.Int32
+	var wg sync.WaitGroup
+	for i := range 60 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			ok, err := replicas[i%len(replicas)].Increment(Usage{ProcessorID: "paybr", Minute: now, Count: 1}, windows)
+			if err != nil {
+				t.Errorf("Increment() error = %v", err)
+				return
+			}
+			if ok {
+				granted.Add(1)
+			}
+		}()
+	}
+	wg.Wait()
+
+	if got := granted.Load(); got != 10 {
+		t.Errorf("%d increments granted across replicas, want exactly the limit of 10", got)
+	}
+	usages, err := replicas[0].Load(now.Add(-time.Hour))
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if got := usageTotals(usages)[meter{processorID: "paybr"}].count; got != 10 {
+		t.Errorf("stored count = %d, want 10", got)
+	}
+}
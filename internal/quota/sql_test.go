@@ -0,0 +1,196 @@
+package quota
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"fmt"
+	"io"
+	"maps"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/ivanjtm/YunoChallenge/internal/model"
+	"github.com/ivanjtm/YunoChallenge/internal/money"
+)
+
+type fakeBucketKey struct {
+	processorID, method, currency, minute string
+}
+
+type fakeBucket struct {
+	used, amount int64
+}
+
+type fakeDB struct {
+	mu       sync.Mutex
+	buckets  map[fakeBucketKey]fakeBucket
+	snapshot map[fakeBucketKey]fakeBucket
+	locks    int
+	commits  int
+}
+
+func (db *fakeDB) Connect(context.Context) (driver.Conn, error) { return &fakeConn{db: db}, nil }
+func (db *fakeDB) Driver() driver.Driver                        { return nil }
+
+type fakeConn struct{ db *fakeDB }
+
+func (c *fakeConn) Prepare(query string) (driver.Stmt, error) {
+	return &fakeStmt{db: c.db, query: query}, nil
+}
+func (c *fakeConn) Close() error { return nil }
+
+func (c *fakeConn) Begin() (driver.Tx, error) {
+	c.db.mu.Lock()
+	defer c.db.mu.Unlock()
+	c.db.snapshot = maps.Clone(c.db.buckets)
+	return &fakeTx{db: c.db}, nil
+}
+
+type fakeTx struct{ db *fakeDB }
+
+func (tx *fakeTx) Commit() error {
+	tx.db.mu.Lock()
+	defer tx.db.mu.Unlock()
+	tx.db.commits++
+	return nil
+}
+
+func (tx *fakeTx) Rollback() error {
+	tx.db.mu.Lock()
+	defer tx.db.mu.Unlock()
+	tx.db.buckets = tx.db.snapshot
+	return nil
+}
+
+type fakeStmt struct {
+	db    *fakeDB
+	query string
+}
+
+func (s *fakeStmt) Close() error  { return nil }
+func (s *fakeStmt) NumInput() int { return -1 }
+
+func (s *fakeStmt) Exec(args []driver.Value) (driver.Result, error) {
+	s.db.mu.Lock()
+	defer s.db.mu.Unlock()
+	switch {
+	case strings.Contains(s.query, "CREATE TABLE"), strings.Contains(s.query, "INSERT INTO quota_locks"):
+	case strings.Contains(s.query, "UPDATE quota_locks"):
+		s.db.locks++
+	case strings.Contains(s.query, "INSERT INTO quota_buckets"):
+		k := fakeBucketKey{args[0].(string), args[1].(string), args[2].(string), args[3].(string)}
+		b := s.db.buckets[k]
+		b.used += args[4].(int64)
+		b.amount += args[5].(int64)
+		s.db.buckets[k] = b
+	default:
+		return nil, fmt.Errorf("unexpected exec %q", s.query)
+	}
+	return driver.RowsAffected(1), nil
+}
+
+func (s *fakeStmt) Query(args []driver.Value) (driver.Rows, error) {
+	s.db.mu.Lock()
+	defer s.db.mu.Unlock()
+	switch {
+	case strings.Contains(s.query, "SUM(used)"):
+		var used, amount int64
+		for k, b := range s.db.buckets {
+			if k.processorID == args[0] && k.minute >= args[1].(string) &&
+				(args[2] == "" || k.method == args[2]) && (args[3] == "" || k.currency == args[3]) {
+				used += b.used
+				amount += b.amount
+			}
+		}
+		return &fakeRows{cols: []string{"used", "amount"}, rows: [][]driver.Value{{used, amount}}}, nil
+	case strings.Contains(s.query, "SELECT processor_id"):
+		rows := &fakeRows{cols: []string{"processor_id", "method", "currency", "minute", "used", "amount"}}
+		for k, b := range s.db.buckets {
+			if k.minute >= args[0].(string) {
+				rows.rows = append(rows.rows, []driver.Value{k.processorID, k.method, k.currency, k.minute, b.used, b.amount})
+			}
+		}
+		return rows, nil
+	}
+	return nil, fmt.Errorf("unexpected query %q", s.query)
+}
+
+type fakeRows struct {
+	cols []string
+	rows [][]driver.Value
+}
+
+func (r *fakeRows) Columns() []string { return r.cols }
+func (r *fakeRows) Close() error      { return nil }
+
+func (r *fakeRows) Next(dest []driver.Value) error {
+	if len(r.rows) == 0 {
+		return io.EOF
+	}
+	copy(dest, r.rows[0])
+	r.rows = r.rows[1:]
+	return nil
+}
+
+func TestSQLStore(t *testing.T) {
+	t.Parallel()
+
+	fake := &fakeDB{buckets: make(map[fakeBucketKey]fakeBucket)}
+	db := sql.OpenDB(fake)
+	defer db.Close()
+	s, err := NewSQLStore(db)
+	if err != nil {
+		t.Fatalf("NewSQLStore() error = %v", err)
+	}
+
+	now := time.Date(2025, 6, 15, 12, 0, 30, 0, time.UTC)
+	day := now.Truncate(24 * time.Hour)
+	u := Usage{ProcessorID: "paybr", Method: model.RefundBankTransfer, Currency: model.CurrencyBRL, Minute: now, Count: 1, Amount: money.FromFloat(300)}
+	windows := []Window{
+		{Since: day, Limit: 3},
+		{Since: day, Method: model.RefundBankTransfer, Currency: model.CurrencyBRL, Amount: money.FromFloat(700)},
+	}
+
+	for i, want := range []bool{true, true, false} {
+		ok, err := s.Increment(u, windows)
+		if err != nil {
+			t.Fatalf("Increment() #%d error = %v", i+1, err)
+		}
+		if ok != want {
+			t.Errorf("Increment() #%d = %v, want %v", i+1, ok, want)
+		}
+	}
+	if fake.locks != 3 || fake.commits != 2 {
+		t.Errorf("locks = %d, commits = %d, want every increment locked and two committed", fake.locks, fake.commits)
+	}
+
+	other := u
+	other.Method = model.RefundSameMethod
+	if ok, err := s.Increment(other, windows); err != nil || !ok {
+		t.Errorf("Increment(other method) = %v, %v, want it outside the bank-transfer cap", ok, err)
+	}
+	if ok, _ := s.Increment(other, windows); ok {
+		t.Error("Increment() over the count limit = true, want false")
+	}
+
+	usages, err := s.Load(day)
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	totals := usageTotals(usages)
+	transfer := totals[meter{processorID: "paybr", method: model.RefundBankTransfer, currency: model.CurrencyBRL}]
+	if transfer.count != 2 || transfer.amount != money.FromFloat(600) {
+		t.Errorf("bank transfer usage = %+v, want 2 refunds totalling 600.00", transfer)
+	}
+	for _, got := range usages {
+		if !got.Minute.Equal(now.Truncate(time.Minute)) {
+			t.Errorf("Minute = %s, want %s", got.Minute, now.Truncate(time.Minute))
+		}
+	}
+	if later, _ := s.Load(now.Add(time.Minute)); len(later) != 0 {
+		t.Errorf("Load(after usage) = %+v, want none", later)
+	}
+}
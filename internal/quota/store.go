@@ -0,0 +1,285 @@
+package quota
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/ivanjtm/YunoChallenge/internal/model"
+	"github.com/ivanjtm/YunoChallenge/internal/money"
+)
+
+const minuteLayout = "2006-01-02T15:04Z"
+
+var ErrLogInUse = errors.New("quota log is in use by another process")
+
+type Usage struct {
+	ProcessorID string
+	Method      model.RefundMethod
+	Currency    model.Currency
+	Minute      time.Time
+	Count       int
+	Amount      money.Amount
+}
+
+type Window struct {
+	Since    time.Time
+	Method   model.RefundMethod
+	Currency model.Currency
+	Limit    int
+	Amount   money.Amount
+}
+
+func (w Window) covers(m meter) bool {
+	return (w.Method == "" || w.Method == m.method) && (w.Currency == "" || w.Currency == m.currency)
+}
+
+func (w Window) admits(count int, amount money.Amount, u Usage) bool {
+	if !w.covers(meter{processorID: u.ProcessorID, method: u.Method, currency: u.Currency}) {
+		return true
+	}
+	if w.Limit > 0 && count+u.Count > w.Limit {
+		return false
+	}
+	return w.Amount == 0 || amount+u.Amount <= w.Amount
+}
+
+type Store interface {
+	Load(since time.Time) ([]Usage, error)
+	Increment(u Usage, windows []Window) (ok bool, err error)
+}
+
+func minuteKey(t time.Time) string {
+	return t.UTC().Truncate(time.Minute).Format(minuteLayout)
+}
+
+type walEntry struct {
+	Minute      string             `json:"minute"`
+	ProcessorID string             `json:"processor_id"`
+	Method      model.RefundMethod `json:"method,omitempty"`
+	Currency    model.Currency     `json:"currency,omitempty"`
+	N           int                `json:"n"`
+	Amount      money.Amount       `json:"amount,omitempty"`
+}
+
+func (e walEntry) meter() meter {
+	return meter{processorID: e.ProcessorID, method: e.Method, currency: e.Currency}
+}
+
+func (e walEntry) time() (time.Time, error) {
+	return time.Parse(minuteLayout, e.Minute)
+}
+
+type FileStore struct {
+	mu    sync.Mutex
+	path  string
+	file  *os.File
+	lock  *os.File
+	usage map[meter]series
+}
+
+func NewFileStore(path string, since time.Time) (*FileStore, error) {
+	lock, err := os.OpenFile(path+".lock", os.O_RDWR|os.O_CREATE, 0o644)
+	if err != nil {
+		return nil, fmt.Errorf("open quota lock %s.lock: %w", path, err)
+	}
+	if err := lockFile(lock); err != nil {
+		lock.Close()
+		return nil, fmt.Errorf("lock quota log %s: %w", path, err)
+	}
+
+	s := &FileStore{path: path, lock: lock, usage: make(map[meter]series)}
+	if err := s.open(since); err != nil {
+		lock.Close()
+		return nil, err
+	}
+	return s, nil
+}
+
+func (s *FileStore) open(since time.Time) error {
+	if err := s.replay(); err != nil {
+		return err
+	}
+	if err := s.compact(since); err != nil {
+		return err
+	}
+
+	f, err := os.OpenFile(s.path, os.O_APPEND|os.O_WRONLY|os.O_CREATE, 0o644)
+	if err != nil {
+		return fmt.Errorf("open quota log %s: %w", s.path, err)
+	}
+	s.file = f
+	return nil
+}
+
+func (s *FileStore) replay() error {
+	data, err := os.ReadFile(s.path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("read quota log %s: %w", s.path, err)
+	}
+
+	lines := bytes.Split(data, []byte("\n"))
+	for i, line := range lines {
+		if len(bytes.TrimSpace(line)) == 0 {
+			continue
+		}
+		var e walEntry
+		if err := json.Unmarshal(line, &e); err != nil {
+			if i == len(lines)-1 {
+				break
+			}
+			return fmt.Errorf("decode quota log %s line %d: %w", s.path, i+1, err)
+		}
+		at, err := e.time()
+		if err != nil || e.ProcessorID == "" {
+			return fmt.Errorf("decode quota log %s line %d: invalid entry", s.path, i+1)
+		}
+		m := e.meter()
+		s.usage[m] = s.usage[m].add(at, e.N, e.Amount)
+	}
+	return nil
+}
+
+func (s *FileStore) compact(since time.Time) error {
+	var entries []walEntry
+	for m, buckets := range s.usage {
+		buckets = buckets.prune(since)
+		if len(buckets) == 0 {
+			delete(s.usage, m)
+			continue
+		}
+		s.usage[m] = buckets
+		for _, b := range buckets {
+			entries = append(entries, walEntry{
+				Minute:      minuteKey(b.minute),
+				ProcessorID: m.processorID,
+				Method:      m.method,
+				Currency:    m.currency,
+				N:           b.count,
+				Amount:      b.amount,
+			})
+		}
+	}
+	sort.Slice(entries, func(i, j int) bool {
+		a, b := entries[i], entries[j]
+		if a.Minute != b.Minute {
+			return a.Minute < b.Minute
+		}
+		if a.ProcessorID != b.ProcessorID {
+			return a.ProcessorID < b.ProcessorID
+		}
+		if a.Method != b.Method {
+			return a.Method < b.Method
+		}
+		return a.Currency < b.Currency
+	})
+
+	var buf bytes.Buffer
+	for _, e := range entries {
+		line, err := json.Marshal(e)
+		if err != nil {
+			return fmt.Errorf("marshal quota entry: %w", err)
+		}
+		buf.Write(line)
+		buf.WriteByte('\n')
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(s.path), ".quota-*.log")
+	if err != nil {
+		return fmt.Errorf("create temp file: %w", err)
+	}
+	if _, err := tmp.Write(buf.Bytes()); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return fmt.Errorf("write quota log: %w", err)
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return fmt.Errorf("sync quota log: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmp.Name())
+		return fmt.Errorf("close quota log: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), s.path); err != nil {
+		os.Remove(tmp.Name())
+		return fmt.Errorf("replace %s: %w", s.path, err)
+	}
+	return nil
+}
+
+func (s *FileStore) Load(since time.Time) ([]Usage, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var usages []Usage
+	for m, buckets := range s.usage {
+		for _, b := range buckets.prune(since) {
+			usages = append(usages, Usage{
+				ProcessorID: m.processorID,
+				Method:      m.method,
+				Currency:    m.currency,
+				Minute:      b.minute,
+				Count:       b.count,
+				Amount:      b.amount,
+			})
+		}
+	}
+	return usages, nil
+}
+
+func (s *FileStore) Increment(u Usage, windows []Window) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, w := range windows {
+		var count int
+		var amount money.Amount
+		for m, buckets := range s.usage {
+			if m.processorID == u.ProcessorID && w.covers(m) {
+				c, a := buckets.sumSince(w.Since)
+				count += c
+				amount += a
+			}
+		}
+		if !w.admits(count, amount, u) {
+			return false, nil
+		}
+	}
+
+	e := walEntry{Minute: minuteKey(u.Minute), ProcessorID: u.ProcessorID, Method: u.Method, Currency: u.Currency, N: u.Count, Amount: u.Amount}
+	line, err := json.Marshal(e)
+	if err != nil {
+		return false, fmt.Errorf("marshal quota entry: %w", err)
+	}
+	if _, err := s.file.Write(append(line, '\n')); err != nil {
+		return false, fmt.Errorf("append quota log: %w", err)
+	}
+	if err := s.file.Sync(); err != nil {
+		return false, fmt.Errorf("sync quota log: %w", err)
+	}
+
+	m := e.meter()
+	s.usage[m] = s.usage[m].add(u.Minute, u.Count, u.Amount)
+	return true, nil
+}
+
+func (s *FileStore) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	err := s.file.Close()
+	if lerr := s.lock.Close(); err == nil {
+		err = lerr
+	}
+	return err
+}
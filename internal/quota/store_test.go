@@ -0,0 +1,183 @@
+package quota
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ivanjtm/YunoChallenge/internal/model"
+	"github.com/ivanjtm/YunoChallenge/internal/money"
+)
+
+func writeLog(t *testing.T, lines ...string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "quota.log")
+	if err := os.WriteFile(path, []byte(strings.Join(lines, "\n")), 0o644); err != nil {
+		t.Fatalf("WriteFile() error = %v", err)
+	}
+	return path
+}
+
+func usageTotals(usages []Usage) map[meter]bucket {
+	totals := make(map[meter]bucket)
+	for _, u := range usages {
+		m := meter{processorID: u.ProcessorID, method: u.Method, currency: u.Currency}
+		b := totals[m]
+		b.count += u.Count
+		b.amount += u.Amount
+		totals[m] = b
+	}
+	return totals
+}
+
+func TestFileStore_Replay(t *testing.T) {
+	t.Parallel()
+
+	since := time.Date(2025, 6, 1, 0, 0, 0, 0, time.UTC)
+	path := writeLog(t,
+		`{"minute":"2025-06-15T10:00Z","processor_id":"paybr","method":"SAME_METHOD","currency":"BRL","n":1,"amount":320}`,
+		`{"minute":"2025-06-15T10:00Z","processor_id":"paybr","method":"SAME_METHOD","currency":"BRL","n":2,"amount":100}`,
+		`{"minute":"2025-06-15T10:01Z","processor_id":"paybr","method":"SAME_METHOD","currency":"BRL","n":-1,"amount":-320}`,
+		`{"minute":"2025-06-15T10:02Z","processor_id":"pay`,
+	)
+
+	s, err := NewFileStore(path, since)
+	if err != nil {
+		t.Fatalf("NewFileStore() error = %v", err)
+	}
+	defer s.Close()
+
+	usages, err := s.Load(since)
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	totals := usageTotals(usages)
+
+	paybr := totals[meter{processorID: "paybr", method: model.RefundSameMethod, currency: model.CurrencyBRL}]
+	if paybr.count != 2 || paybr.amount != money.FromFloat(100) {
+		t.Errorf("paybr = %+v, want 2 refunds of 100.00 after the release", paybr)
+	}
+}
+
+func TestFileStore_ReplayRejectsCorruptLine(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name string
+		line string
+	}{
+		{"not json", `not json`},
+		{"no minute", `{"day":"2025-06-14","processor_id":"paybr","n":1}`},
+		{"no processor", `{"minute":"2025-06-15T10:00Z","n":1}`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			path := writeLog(t,
+				`{"minute":"2025-06-15T10:00Z","processor_id":"paybr","n":1}`,
+				tt.line,
+				`{"minute":"2025-06-15T10:01Z","processor_id":"paybr","n":1}`,
+			)
+			if _, err := NewFileStore(path, time.Time{}); err == nil || !strings.Contains(err.Error(), "line 2") {
+				t.Errorf("NewFileStore() error = %v, want a decode error for line 2", err)
+			}
+		})
+	}
+}
+
+func TestFileStore_Compaction(t *testing.T) {
+	t.Parallel()
+
+	since := time.Date(2025, 6, 1, 0, 0, 0, 0, time.UTC)
+	path := writeLog(t,
+		`{"minute":"2025-06-15T10:00Z","processor_id":"paybr","n":1}`,
+		`{"minute":"2025-05-31T23:59Z","processor_id":"paybr","n":5}`,
+		`{"minute":"2025-06-15T10:00Z","processor_id":"paybr","n":1}`,
+		`{"minute":"2025-06-02T08:00Z","processor_id":"colpay","n":3}`,
+	)
+
+	s, err := NewFileStore(path, since)
+	if err != nil {
+		t.Fatalf("NewFileStore() error = %v", err)
+	}
+	s.Close()
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("ReadFile() error = %v", err)
+	}
+	want := `{"minute":"2025-06-02T08:00Z","processor_id":"colpay","n":3}` + "\n" +
+		`{"minute":"2025-06-15T10:00Z","processor_id":"paybr","n":2}` + "\n"
+	if string(data) != want {
+		t.Errorf("compacted log =\n%s\nwant\n%s", data, want)
+	}
+}
+
+func TestFileStore_Increment(t *testing.T) {
+	t.Parallel()
+
+	now := time.Date(2025, 6, 15, 12, 0, 0, 0, time.UTC)
+	day := now.Truncate(24 * time.Hour)
+	pix := Usage{ProcessorID: "paybr", Method: model.RefundSameMethod, Currency: model.CurrencyBRL, Minute: now, Count: 1, Amount: money.FromFloat(300)}
+	transfer := pix
+	transfer.Method = model.RefundBankTransfer
+
+	tests := []struct {
+		name    string
+		u       Usage
+		windows []Window
+		want    bool
+	}{
+		{"unconditional", pix, nil, true},
+		{"count below limit", pix, []Window{{Since: day, Limit: 3}}, true},
+		{"count at limit", pix, []Window{{Since: day, Limit: 2}}, false},
+		{"window starts after usage", pix, []Window{{Since: now.Add(time.Minute), Limit: 1}}, true},
+		{"amount fits", pix, []Window{{Since: day, Currency: model.CurrencyBRL, Amount: money.FromFloat(900)}}, true},
+		{"amount overshoots", pix, []Window{{Since: day, Currency: model.CurrencyBRL, Amount: money.FromFloat(899)}}, false},
+		{"other currency not counted", pix, []Window{{Since: day, Currency: model.CurrencyMXN, Amount: money.FromFloat(100)}}, true},
+		{"method limit counts its method only", transfer, []Window{{Since: day, Method: model.RefundBankTransfer, Limit: 1}}, true},
+		{"any failing window rejects", pix, []Window{{Since: day, Limit: 10}, {Since: day, Limit: 2}}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			path := writeLog(t)
+			s, err := NewFileStore(path, day)
+			if err != nil {
+				t.Fatalf("NewFileStore() error = %v", err)
+			}
+			for range 2 {
+				if _, err := s.Increment(pix, nil); err != nil {
+					t.Fatalf("Increment() error = %v", err)
+				}
+			}
+
+			ok, err := s.Increment(tt.u, tt.windows)
+			if err != nil {
+				t.Fatalf("Increment() error = %v", err)
+			}
+			if ok != tt.want {
+				t.Errorf("Increment() = %v, want %v", ok, tt.want)
+			}
+			s.Close()
+
+			reopened, err := NewFileStore(path, day)
+			if err != nil {
+				t.Fatalf("reopen error = %v", err)
+			}
+			defer reopened.Close()
+			usages, _ := reopened.Load(day)
+			var count int
+			for _, u := range usages {
+				count += u.Count
+			}
+			if want := map[bool]int{true: 3, false: 2}[tt.want]; count != want {
+				t.Errorf("persisted count = %d, want %d", count, want)
+			}
+		})
+	}
+}
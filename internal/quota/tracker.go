@@ -1,80 +1,305 @@
 package quota
 
 import (
+	"context"
 	"fmt"
 	"sync"
 	"time"
 
 	"github.com/ivanjtm/YunoChallenge/internal/model"
+	"github.com/ivanjtm/YunoChallenge/internal/money"
 )
 
+type Availability struct {
+	Available bool
+	Reason    string
+	NextReset time.Time
+}
+
 type Tracker struct {
-	mu         sync.Mutex
-	processors map[string]model.Processor
-	usage      map[string]int
-	overrides  map[string]model.ProcessorOverride
-	resetDate  time.Time
+	mu           sync.Mutex
+	processors   map[string]model.Processor
+	limits       map[string][]limit
+	usage        map[meter]series
+	overrides    map[string]model.ProcessorOverride
+	reservations map[string]reservation
+	expired      map[string]reservation
+	store        Store
+
+	ReservationTTL time.Duration
+	OnSyncError    func(error)
 }
 
 func NewTracker(processors []model.Processor) *Tracker {
-	procMap := make(map[string]model.Processor)
-	for _, p := range processors {
-		procMap[p.ID] = p
+	t := &Tracker{
+		usage:        make(map[meter]series),
+		overrides:    make(map[string]model.ProcessorOverride),
+		reservations: make(map[string]reservation),
+		expired:      make(map[string]reservation),
+	}
+	t.setProcessorsLocked(processors)
+	return t
+}
+
+func NewPersistentTracker(processors []model.Processor, store Store, now time.Time) (*Tracker, error) {
+	t := NewTracker(processors)
+	t.store = store
+	if err := t.loadLocked(now); err != nil {
+		return nil, err
 	}
-	return &Tracker{
-		processors: procMap,
-		usage:      make(map[string]int),
-		overrides:  make(map[string]model.ProcessorOverride),
-		resetDate:  time.Now().UTC().Truncate(24 * time.Hour),
+	return t, nil
+}
+
+func (t *Tracker) Sync(now time.Time) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.expireLocked(now)
+	return t.loadLocked(now)
+}
+
+func (t *Tracker) Poll(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case now := <-ticker.C:
+			if err := t.Sync(now); err != nil && t.OnSyncError != nil {
+				t.OnSyncError(err)
+			}
+		}
+	}
+}
+
+func (t *Tracker) loadLocked(now time.Time) error {
+	if t.store == nil {
+		return nil
+	}
+	usages, err := t.store.Load(t.horizonLocked(now))
+	if err != nil {
+		return fmt.Errorf("load quota usage: %w", err)
+	}
+	usage := make(map[meter]series)
+	for _, u := range usages {
+		m := meter{processorID: u.ProcessorID, method: u.Method, currency: u.Currency}
+		usage[m] = usage[m].add(u.Minute, u.Count, u.Amount)
+	}
+	t.usage = usage
+	return nil
+}
+
+func (t *Tracker) horizonLocked(now time.Time) time.Time {
+	h := monthStart(now)
+	for _, limits := range t.limits {
+		if lh := horizon(limits, now); lh.Before(h) {
+			h = lh
+		}
 	}
+	return h
 }
 
-func (t *Tracker) resetIfNewDay(now time.Time) {
-	today := now.UTC().Truncate(24 * time.Hour)
-	if today.After(t.resetDate) {
-		t.usage = make(map[string]int)
-		t.resetDate = today
+func (t *Tracker) SetProcessors(processors []model.Processor) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.setProcessorsLocked(processors)
+}
+
+func (t *Tracker) setProcessorsLocked(processors []model.Processor) {
+	t.processors = make(map[string]model.Processor)
+	t.limits = make(map[string][]limit)
+	for _, p := range processors {
+		t.processors[p.ID] = p
+		t.limits[p.ID] = compile(p)
 	}
 }
 
 func (t *Tracker) IsAvailable(processorID string, now time.Time) (bool, string) {
+	a := t.Availability(processorID, now)
+	return a.Available, a.Reason
+}
+
+func (t *Tracker) Availability(processorID string, now time.Time) Availability {
+	return t.AvailabilityFor(model.QuotaDemand{ProcessorID: processorID}, now)
+}
+
+func (t *Tracker) AvailabilityFor(d model.QuotaDemand, now time.Time) Availability {
 	t.mu.Lock()
 	defer t.mu.Unlock()
-	t.resetIfNewDay(now)
+	t.expireLocked(now)
+	return t.availableLocked(d, now)
+}
 
-	if override, ok := t.overrides[processorID]; ok {
+func (t *Tracker) availableLocked(d model.QuotaDemand, now time.Time) Availability {
+	if override, ok := t.overrides[d.ProcessorID]; ok {
 		if override.Available != nil && !*override.Available {
-			return false, "Processor marked as unavailable (simulated)"
+			return Availability{Reason: "Processor marked as unavailable (simulated)"}
 		}
 		if override.AtCapacity != nil && *override.AtCapacity {
-			return false, "Processor marked as at capacity (simulated)"
+			return Availability{Reason: "Processor marked as at capacity (simulated)"}
 		}
 	}
 
-	proc, ok := t.processors[processorID]
-	if !ok {
-		return false, fmt.Sprintf("Unknown processor: %s", processorID)
+	if _, ok := t.processors[d.ProcessorID]; !ok {
+		return Availability{Reason: fmt.Sprintf("Unknown processor: %s", d.ProcessorID)}
 	}
 
-	if proc.DailyQuota > 0 {
-		used := t.usage[processorID]
-		if override, ok := t.overrides[processorID]; ok && override.QuotaUsed != nil {
-			used = *override.QuotaUsed
+	a := Availability{Available: true}
+	for i, l := range t.limits[d.ProcessorID] {
+		if !l.applies(d) {
+			continue
+		}
+		used, amount := t.usedLocked(d.ProcessorID, i, l, now)
+		reason, blocked := l.blocks(d, used, amount)
+		if !blocked {
+			continue
 		}
-		if used >= proc.DailyQuota {
-			return false, fmt.Sprintf("Daily quota exhausted: %d/%d used", used, proc.DailyQuota)
+		var reset time.Time
+		if d.Amount <= l.Amount || l.Amount == 0 {
+			reset = t.resetLocked(d, l, used, amount, now)
+		}
+		if a.Available {
+			a = Availability{Reason: reason}
+			if !reset.IsZero() {
+				a.Reason += fmt.Sprintf("; resets at %s", reset.UTC().Format(time.RFC3339))
+			}
+		}
+		if reset.After(a.NextReset) {
+			a.NextReset = reset
 		}
 	}
+	return a
+}
 
-	return true, ""
+func (t *Tracker) usedLocked(processorID string, i int, l limit, now time.Time) (int, money.Amount) {
+	if override, ok := t.overrides[processorID]; ok && override.QuotaUsed != nil && i == t.primaryLocked(processorID) {
+		return *override.QuotaUsed, 0
+	}
+	return t.sumLocked(processorID, l.storeWindow(l.start(now)))
+}
+
+func (t *Tracker) primaryLocked(processorID string) int {
+	for i, l := range t.limits[processorID] {
+		if l.primary() {
+			return i
+		}
+	}
+	return -1
+}
+
+func (t *Tracker) sumLocked(processorID string, w Window) (int, money.Amount) {
+	var count int
+	var amount money.Amount
+	for m, s := range t.usage {
+		if m.processorID == processorID && w.covers(m) {
+			c, a := s.sumSince(w.Since)
+			count += c
+			amount += a
+		}
+	}
+	return count, amount
+}
+
+func (t *Tracker) resetLocked(d model.QuotaDemand, l limit, used int, amount money.Amount, now time.Time) time.Time {
+	if l.Period != model.QuotaPeriodRolling {
+		return l.end(now)
+	}
+
+	w := l.storeWindow(l.start(now))
+	var merged series
+	for m, s := range t.usage {
+		if m.processorID == d.ProcessorID && w.covers(m) {
+			for _, b := range s.prune(w.Since) {
+				merged = merged.add(b.minute, b.count, b.amount)
+			}
+		}
+	}
+	if l.Amount > 0 {
+		return merged.freedAt(w.Since, l.window, 0, max(amount+d.Amount-l.Amount, 1))
+	}
+	return merged.freedAt(w.Since, l.window, max(used-l.Limit+1, 1), 0)
 }
 
 func (t *Tracker) Consume(processorID string, now time.Time) error {
 	t.mu.Lock()
 	defer t.mu.Unlock()
-	t.resetIfNewDay(now)
-	t.usage[processorID]++
-	return nil
+	_, err := t.consumeLocked(model.QuotaDemand{ProcessorID: processorID}, now, false)
+	return err
+}
+
+func (t *Tracker) TryConsume(processorID string, now time.Time) (bool, string) {
+	return t.TryConsumeFor(model.QuotaDemand{ProcessorID: processorID}, now)
+}
+
+func (t *Tracker) TryConsumeFor(d model.QuotaDemand, now time.Time) (bool, string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.expireLocked(now)
+
+	if a := t.availableLocked(d, now); !a.Available {
+		return false, a.Reason
+	}
+	ok, err := t.consumeLocked(d, now, true)
+	if err != nil {
+		return false, fmt.Sprintf("Quota store unavailable: %v", err)
+	}
+	if !ok {
+		if err := t.loadLocked(now); err != nil {
+			return false, fmt.Sprintf("Quota store unavailable: %v", err)
+		}
+		if a := t.availableLocked(d, now); !a.Available {
+			return false, a.Reason
+		}
+		return false, "Quota taken by another instance"
+	}
+	return true, ""
+}
+
+func (t *Tracker) MonthlyVolume(processorID string, at time.Time) int {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	var used int
+	for m, s := range t.usage {
+		if m.processorID == processorID {
+			c, _ := s.sumBetween(monthStart(at), at)
+			used += c
+		}
+	}
+	return used
+}
+
+func (t *Tracker) consumeLocked(d model.QuotaDemand, now time.Time, enforce bool) (bool, error) {
+	u := Usage{ProcessorID: d.ProcessorID, Method: d.Method, Currency: d.Currency, Minute: now, Count: 1, Amount: d.Amount}
+	override, overridden := t.overrides[d.ProcessorID]
+	overridden = overridden && override.QuotaUsed != nil
+	if t.store != nil {
+		var windows []Window
+		if enforce && !overridden {
+			for _, l := range t.limits[d.ProcessorID] {
+				if l.applies(d) {
+					windows = append(windows, l.storeWindow(l.start(now)))
+				}
+			}
+		}
+		ok, err := t.store.Increment(u, windows)
+		if err != nil || !ok {
+			return false, err
+		}
+	}
+	if overridden {
+		used := *override.QuotaUsed + 1
+		override.QuotaUsed = &used
+		t.overrides[d.ProcessorID] = override
+	}
+	t.recordLocked(u, now)
+	return true, nil
+}
+
+func (t *Tracker) recordLocked(u Usage, now time.Time) {
+	m := meter{processorID: u.ProcessorID, method: u.Method, currency: u.Currency}
+	s := t.usage[m].add(u.Minute, u.Count, u.Amount)
+	t.usage[m] = s.prune(horizon(t.limits[u.ProcessorID], now))
 }
 
 func (t *Tracker) SetOverrides(overrides map[string]model.ProcessorOverride) {
@@ -94,45 +319,50 @@ func (t *Tracker) ResetOverrides() {
 func (t *Tracker) Status(now time.Time) []model.QuotaStatus {
 	t.mu.Lock()
 	defer t.mu.Unlock()
-	t.resetIfNewDay(now)
+	t.expireLocked(now)
 
 	var statuses []model.QuotaStatus
-	for id, proc := range t.processors {
-		used := t.usage[id]
-		if override, ok := t.overrides[id]; ok && override.QuotaUsed != nil {
-			used = *override.QuotaUsed
-		}
-
-		remaining := proc.DailyQuota - used
-		if remaining < 0 {
-			remaining = 0
+	for id := range t.processors {
+		d := model.QuotaDemand{ProcessorID: id}
+		a := t.availableLocked(d, now)
+		usedToday, _ := t.sumLocked(id, Window{Since: now.UTC().Truncate(24 * time.Hour)})
+		status := model.QuotaStatus{
+			ProcessorID:       id,
+			IsAvailable:       a.Available,
+			UnavailableReason: a.Reason,
+			NextReset:         a.NextReset,
+			UsedToday:         usedToday,
+			Reserved:          t.reservedLocked(id),
 		}
 
-		available, reason := true, ""
-
-		if override, ok := t.overrides[id]; ok {
-			if override.Available != nil && !*override.Available {
-				available = false
-				reason = "Processor marked as unavailable (simulated)"
-			} else if override.AtCapacity != nil && *override.AtCapacity {
-				available = false
-				reason = "Processor marked as at capacity (simulated)"
+		daily := false
+		for i, l := range t.limits[id] {
+			used, amount := t.usedLocked(id, i, l, now)
+			ls := model.QuotaLimitStatus{
+				QuotaLimit: l.QuotaLimit,
+				Used:       used,
+				ResetsAt:   t.resetLocked(d, l, used, amount, now),
 			}
-		}
+			if l.Amount > 0 {
+				remaining := max(l.Amount-amount, 0)
+				ls.UsedAmount, ls.RemainingAmount = &amount, &remaining
+			} else {
+				remaining := max(l.Limit-used, 0)
+				ls.Remaining = &remaining
+			}
+			status.Limits = append(status.Limits, ls)
 
-		if available && proc.DailyQuota > 0 && used >= proc.DailyQuota {
-			available = false
-			reason = fmt.Sprintf("Daily quota exhausted: %d/%d", used, proc.DailyQuota)
+			if l.Period == model.QuotaPeriodDay && l.primary() && !daily {
+				daily = true
+				status.DailyQuota = l.Limit
+				status.UsedToday = used
+				status.Remaining = *ls.Remaining
+			}
+			if status.IsAvailable && (status.NextReset.IsZero() || (!ls.ResetsAt.IsZero() && ls.ResetsAt.Before(status.NextReset))) {
+				status.NextReset = ls.ResetsAt
+			}
 		}
-
-		statuses = append(statuses, model.QuotaStatus{
-			ProcessorID:       id,
-			DailyQuota:        proc.DailyQuota,
-			UsedToday:         used,
-			Remaining:         remaining,
-			IsAvailable:       available,
-			UnavailableReason: reason,
-		})
+		statuses = append(statuses, status)
 	}
 	return statuses
 }
@@ -0,0 +1,308 @@
+package quota
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/ivanjtm/YunoChallenge/internal/model"
+	"github.com/ivanjtm/YunoChallenge/internal/money"
+)
+
+func intPtr(n int) *int { return &n }
+
+func TestTracker_OverrideUsageIsPersisted(t *testing.T) {
+	t.Parallel()
+
+	now := time.Date(2025, 6, 15, 12, 0, 0, 0, time.UTC)
+	procs := []model.Processor{{ID: "paybr", DailyQuota: 10}}
+	path := filepath.Join(t.TempDir(), "quota.log")
+	store, err := NewFileStore(path, monthStart(now))
+	if err != nil {
+		t.Fatalf("NewFileStore() error = %v", err)
+	}
+	tr, err := NewPersistentTracker(procs, store, now)
+	if err != nil {
+		t.Fatalf("NewPersistentTracker() error = %v", err)
+	}
+
+	tr.SetOverrides(map[string]model.ProcessorOverride{"paybr": {QuotaUsed: intPtr(9)}})
+	if ok, reason := tr.TryConsume("paybr", now); !ok {
+		t.Fatalf("TryConsume() under override = false: %s", reason)
+	}
+	if ok, _ := tr.TryConsume("paybr", now); ok {
+		t.Error("TryConsume() past the overridden count = true, want false")
+	}
+	if err := tr.Sync(now); err != nil {
+		t.Fatalf("Sync() error = %v", err)
+	}
+	if got := tr.MonthlyVolume("paybr", now); got != 1 {
+		t.Errorf("MonthlyVolume() after Sync = %d, want the overridden route to survive", got)
+	}
+
+	tr.ResetOverrides()
+	store.Close()
+	reopened, err := NewFileStore(path, monthStart(now))
+	if err != nil {
+		t.Fatalf("reopen error = %v", err)
+	}
+	defer reopened.Close()
+	restarted, err := NewPersistentTracker(procs, reopened, now)
+	if err != nil {
+		t.Fatalf("NewPersistentTracker() error = %v", err)
+	}
+	if got := restarted.Status(now)[0].UsedToday; got != 1 {
+		t.Errorf("UsedToday after restart = %d, want 1", got)
+	}
+}
+
+func TestTracker_AvailabilityResets(t *testing.T) {
+	t.Parallel()
+
+	at := func(h, m int) time.Time { return time.Date(2025, 6, 15, h, m, 0, 0, time.UTC) }
+
+	tests := []struct {
+		name      string
+		limits    []model.QuotaLimit
+		consumed  []time.Time
+		now       time.Time
+		available bool
+		reset     time.Time
+		reason    string
+	}{
+		{
+			name:     "day limit resets at local midnight",
+			limits:   []model.QuotaLimit{{Period: model.QuotaPeriodDay, Limit: 2, TimeZone: "America/Mexico_City"}},
+			consumed: []time.Time{at(10, 0), at(11, 0)},
+			now:      at(12, 0),
+			reset:    time.Date(2025, 6, 16, 6, 0, 0, 0, time.UTC),
+			reason:   "Daily quota exhausted: 2/2 used; resets at 2025-06-16T06:00:00Z",
+		},
+		{
+			name:      "previous local day doesn't count",
+			limits:    []model.QuotaLimit{{Period: model.QuotaPeriodDay, Limit: 2, TimeZone: "America/Mexico_City"}},
+			consumed:  []time.Time{at(5, 0), at(5, 30)},
+			now:       at(6, 0),
+			available: true,
+		},
+		{
+			name:     "hour limit",
+			limits:   []model.QuotaLimit{{Period: model.QuotaPeriodHour, Limit: 1}},
+			consumed: []time.Time{at(12, 59)},
+			now:      at(12, 59),
+			reset:    at(13, 0),
+			reason:   "Hourly quota exhausted: 1/1 used; resets at 2025-06-15T13:00:00Z",
+		},
+		{
+			name:     "rolling limit frees the oldest route",
+			limits:   []model.QuotaLimit{{Period: model.QuotaPeriodRolling, Limit: 2, Window: "1h"}},
+			consumed: []time.Time{at(10, 0), at(10, 20)},
+			now:      at(10, 30),
+			reset:    at(11, 1),
+			reason:   "Rolling 1h quota exhausted: 2/2 used; resets at 2025-06-15T11:01:00Z",
+		},
+		{
+			name:      "rolling limit after the oldest route ages out",
+			limits:    []model.QuotaLimit{{Period: model.QuotaPeriodRolling, Limit: 2, Window: "1h"}},
+			consumed:  []time.Time{at(10, 0), at(10, 20)},
+			now:       at(11, 1),
+			available: true,
+		},
+		{
+			name: "reset waits for every full limit",
+			limits: []model.QuotaLimit{
+				{Period: model.QuotaPeriodHour, Limit: 1},
+				{Period: model.QuotaPeriodMonth, Limit: 1},
+			},
+			consumed: []time.Time{at(12, 10)},
+			now:      at(12, 20),
+			reset:    time.Date(2025, 7, 1, 0, 0, 0, 0, time.UTC),
+			reason:   "Hourly quota exhausted: 1/1 used; resets at 2025-06-15T13:00:00Z",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			tr := NewTracker([]model.Processor{{ID: "p", QuotaLimits: tt.limits}})
+			for _, at := range tt.consumed {
+				if err := tr.Consume("p", at); err != nil {
+					t.Fatalf("Consume() error = %v", err)
+				}
+			}
+
+			a := tr.Availability("p", tt.now)
+			if a.Available != tt.available {
+				t.Fatalf("Available = %v (%s), want %v", a.Available, a.Reason, tt.available)
+			}
+			if !a.NextReset.Equal(tt.reset) {
+				t.Errorf("NextReset = %s, want %s", a.NextReset, tt.reset)
+			}
+			if a.Reason != tt.reason {
+				t.Errorf("Reason = %q, want %q", a.Reason, tt.reason)
+			}
+		})
+	}
+}
+
+func TestTracker_VolumeAndMethodLimits(t *testing.T) {
+	t.Parallel()
+
+	at := func(h, m int) time.Time { return time.Date(2025, 6, 15, h, m, 0, 0, time.UTC) }
+	brl := func(v float64) model.QuotaDemand {
+		return model.QuotaDemand{ProcessorID: "p", Method: model.RefundBankTransfer, Currency: model.CurrencyBRL, Amount: money.FromFloat(v)}
+	}
+	method := func(m model.RefundMethod) model.QuotaDemand {
+		return model.QuotaDemand{ProcessorID: "p", Method: m, Currency: model.CurrencyBRL, Amount: money.FromFloat(10)}
+	}
+	volume := []model.QuotaLimit{{Period: model.QuotaPeriodDay, Amount: money.FromFloat(1000), Currency: model.CurrencyBRL}}
+	rolling := []model.QuotaLimit{{Period: model.QuotaPeriodRolling, Window: "1h", Amount: money.FromFloat(1000), Currency: model.CurrencyBRL}}
+	transfers := []model.QuotaLimit{{Period: model.QuotaPeriodDay, Limit: 1, Method: model.RefundBankTransfer}}
+
+	tests := []struct {
+		name      string
+		limits    []model.QuotaLimit
+		consumed  []model.QuotaDemand
+		demand    model.QuotaDemand
+		available bool
+		reset     time.Time
+		reason    string
+	}{
+		{
+			name:      "fits under the volume cap",
+			limits:    volume,
+			consumed:  []model.QuotaDemand{brl(700)},
+			demand:    brl(300),
+			available: true,
+		},
+		{
+			name:     "overshoots the remaining volume",
+			limits:   volume,
+			consumed: []model.QuotaDemand{brl(700)},
+			demand:   brl(400),
+			reset:    time.Date(2025, 6, 16, 0, 0, 0, 0, time.UTC),
+			reason:   "Daily volume cap reached: 700.00 of 1000.00 BRL used, refund of 400.00 BRL doesn't fit; resets at 2025-06-16T00:00:00Z",
+		},
+		{
+			name:   "larger than the cap never resets",
+			limits: volume,
+			demand: brl(1500),
+			reason: "Daily volume cap is 1000.00 BRL; refund of 1500.00 BRL exceeds it",
+		},
+		{
+			name:      "other currencies skip the cap",
+			limits:    volume,
+			consumed:  []model.QuotaDemand{brl(1000)},
+			demand:    model.QuotaDemand{ProcessorID: "p", Currency: model.CurrencyMXN, Amount: money.FromFloat(5000)},
+			available: true,
+		},
+		{
+			name:     "rolling volume waits for enough amount to age out",
+			limits:   rolling,
+			consumed: []model.QuotaDemand{brl(600), brl(300)},
+			demand:   brl(400),
+			reset:    at(11, 1),
+			reason:   "Rolling 1h volume cap reached: 900.00 of 1000.00 BRL used, refund of 400.00 BRL doesn't fit; resets at 2025-06-15T11:01:00Z",
+		},
+		{
+			name:     "method cap blocks its method",
+			limits:   transfers,
+			consumed: []model.QuotaDemand{method(model.RefundBankTransfer)},
+			demand:   method(model.RefundBankTransfer),
+			reset:    time.Date(2025, 6, 16, 0, 0, 0, 0, time.UTC),
+			reason:   "Daily BANK_TRANSFER quota exhausted: 1/1 used; resets at 2025-06-16T00:00:00Z",
+		},
+		{
+			name:      "method cap leaves other methods alone",
+			limits:    transfers,
+			consumed:  []model.QuotaDemand{method(model.RefundBankTransfer)},
+			demand:    method(model.RefundSameMethod),
+			available: true,
+		},
+		{
+			name:      "other methods don't count toward the cap",
+			limits:    transfers,
+			consumed:  []model.QuotaDemand{method(model.RefundSameMethod)},
+			demand:    method(model.RefundBankTransfer),
+			available: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			tr := NewTracker([]model.Processor{{ID: "p", QuotaLimits: tt.limits}})
+			for i, d := range tt.consumed {
+				if ok, reason := tr.TryConsumeFor(d, at(10, 20*i)); !ok {
+					t.Fatalf("TryConsumeFor() setup = false: %s", reason)
+				}
+			}
+
+			now := at(10, 30)
+			a := tr.AvailabilityFor(tt.demand, now)
+			if a.Available != tt.available {
+				t.Fatalf("Available = %v (%s), want %v", a.Available, a.Reason, tt.available)
+			}
+			if !a.NextReset.Equal(tt.reset) {
+				t.Errorf("NextReset = %s, want %s", a.NextReset, tt.reset)
+			}
+			if a.Reason != tt.reason {
+				t.Errorf("Reason = %q, want %q", a.Reason, tt.reason)
+			}
+
+			ok, reason := tr.TryConsumeFor(tt.demand, now)
+			if ok != tt.available {
+				t.Fatalf("TryConsumeFor() = %v (%s), want %v", ok, reason, tt.available)
+			}
+			if !ok && reason != tt.reason {
+				t.Errorf("TryConsumeFor() reason = %q, want %q", reason, tt.reason)
+			}
+			want := len(tt.consumed)
+			if ok {
+				want++
+			}
+			if got := tr.Status(now)[0].UsedToday; got != want {
+				t.Errorf("UsedToday = %d, want %d", got, want)
+			}
+		})
+	}
+}
+
+func TestTracker_MonthlyVolumeAt(t *testing.T) {
+	t.Parallel()
+
+	tr := NewTracker([]model.Processor{{ID: "paybr"}, {ID: "mexpay"}})
+	for _, at := range []time.Time{
+		time.Date(2025, 6, 2, 9, 0, 0, 0, time.UTC),
+		time.Date(2025, 6, 10, 9, 0, 0, 0, time.UTC),
+		time.Date(2025, 6, 15, 12, 0, 0, 0, time.UTC),
+	} {
+		if err := tr.Consume("paybr", at); err != nil {
+			t.Fatalf("Consume() error = %v", err)
+		}
+	}
+	if err := tr.Consume("mexpay", time.Date(2025, 6, 3, 9, 0, 0, 0, time.UTC)); err != nil {
+		t.Fatalf("Consume() error = %v", err)
+	}
+
+	tests := []struct {
+		name string
+		at   time.Time
+		want int
+	}{
+		{"now counts the whole month", time.Date(2025, 6, 15, 12, 0, 30, 0, time.UTC), 3},
+		{"mid-month counts refunds up to then", time.Date(2025, 6, 10, 12, 0, 0, 0, time.UTC), 2},
+		{"the same minute counts", time.Date(2025, 6, 2, 9, 0, 0, 0, time.UTC), 1},
+		{"start of the month", time.Date(2025, 6, 1, 0, 0, 0, 0, time.UTC), 0},
+		{"an earlier month", time.Date(2025, 5, 20, 12, 0, 0, 0, time.UTC), 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			if got := tr.MonthlyVolume("paybr", tt.at); got != tt.want {
+				t.Errorf("MonthlyVolume(%s) = %d, want %d", tt.at.Format(time.DateTime), got, tt.want)
+			}
+		})
+	}
+}
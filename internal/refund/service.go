@@ -0,0 +1,432 @@
+package refund
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/ivanjtm/YunoChallenge/internal/model"
+	"github.com/ivanjtm/YunoChallenge/internal/money"
+	"github.com/ivanjtm/YunoChallenge/internal/processor"
+)
+
+const internalProcessorID = "internal"
+
+var (
+	ErrInvalidTransition = errors.New("invalid refund status transition")
+	ErrExceedsRefundable = errors.New("refund amount exceeds remaining refundable amount")
+)
+
+var transitions = map[model.RefundStatus][]model.RefundStatus{
+	model.RefundStatusPending:    {model.RefundStatusSubmitted, model.RefundStatusFailed, model.RefundStatusCancelled},
+	model.RefundStatusSubmitted:  {model.RefundStatusProcessing, model.RefundStatusSucceeded, model.RefundStatusFailed, model.RefundStatusCancelled},
+	model.RefundStatusProcessing: {model.RefundStatusSucceeded, model.RefundStatusFailed},
+}
+
+func CanTransition(from, to model.RefundStatus) bool {
+	for _, next := range transitions[from] {
+		if next == to {
+			return true
+		}
+	}
+	return false
+}
+
+func IsTerminal(status model.RefundStatus) bool {
+	return len(transitions[status]) == 0
+}
+
+const (
+	OutcomeAccepted = "ACCEPTED"
+	OutcomeFailed   = "FAILED"
+	OutcomeSkipped  = "SKIPPED"
+)
+
+type QuotaConsumer interface {
+	Reserve(d model.QuotaDemand, now time.Time) (model.QuotaReservation, bool, string)
+	Commit(token string, now time.Time) error
+	Release(token string, now time.Time) error
+}
+
+type Service struct {
+	mu      sync.Mutex
+	store   Store
+	clients *processor.Registry
+	quota   QuotaConsumer
+	now     func() time.Time
+}
+
+func NewService(store Store, clients *processor.Registry, quota QuotaConsumer) *Service {
+	return &Service{store: store, clients: clients, quota: quota, now: time.Now}
+}
+
+func (s *Service) Execute(ctx context.Context, tx model.Transaction, c model.RefundCandidate, now time.Time) (model.RefundRecord, error) {
+	return s.ExecuteRoute(ctx, tx, model.RefundRouteResult{TransactionID: tx.ID, Selected: c, RefundAmount: tx.Amount}, now)
+}
+
+func (s *Service) ExecuteRoute(ctx context.Context, tx model.Transaction, route model.RefundRouteResult, now time.Time) (model.RefundRecord, error) {
+	rec, err := s.Create(tx, route.RefundAmount, route.Selected, now)
+	if err != nil {
+		s.settleQuota(route.Reservation, false)
+		return model.RefundRecord{}, err
+	}
+
+	candidates := append([]model.RefundCandidate{route.Selected}, route.Alternatives...)
+	var lastErr error
+	for i, c := range candidates {
+		token := ""
+		if i == 0 {
+			token = route.Reservation
+		}
+		attempt := model.RefundAttempt{
+			ProcessorID:     c.ProcessorID,
+			ProcessorName:   c.ProcessorName,
+			RefundMethod:    c.RefundMethod,
+			EstimatedCost:   c.EstimatedCost,
+			IncrementalCost: c.EstimatedCost - route.Selected.EstimatedCost,
+			At:              now.UTC(),
+		}
+
+		if i > 0 && c.ProcessorID != internalProcessorID && s.quota != nil {
+			demand := model.QuotaDemand{ProcessorID: c.ProcessorID, Method: c.RefundMethod, Currency: rec.Currency, Amount: rec.Amount}
+			res, ok, reason := s.quota.Reserve(demand, now)
+			if !ok {
+				attempt.Outcome = OutcomeSkipped
+				attempt.Error = reason
+				if rec, err = s.recordAttempt(rec.ID, attempt, now); err != nil {
+					return rec, err
+				}
+				continue
+			}
+			token = res.Token
+		}
+
+		ref, err := s.dispatch(ctx, rec, c)
+		if err != nil {
+			lastErr = err
+			attempt.Outcome = OutcomeFailed
+			attempt.Error = err.Error()
+			if qerr := s.settleQuota(token, false); qerr != nil {
+				attempt.Error += "; " + qerr.Error()
+			}
+			if rec, err = s.recordAttempt(rec.ID, attempt, now); err != nil {
+				return rec, err
+			}
+			continue
+		}
+
+		attempt.Outcome = OutcomeAccepted
+		if qerr := s.settleQuota(token, true); qerr != nil {
+			attempt.Error = qerr.Error()
+		}
+		return s.accept(rec.ID, attempt, c, ref, now)
+	}
+
+	if lastErr == nil {
+		lastErr = fmt.Errorf("%w: no eligible processor had capacity", processor.ErrUnavailable)
+	}
+	return s.fail(rec.ID, lastErr, now)
+}
+
+func (s *Service) settleQuota(token string, accepted bool) error {
+	if token == "" || s.quota == nil {
+		return nil
+	}
+	if accepted {
+		return s.quota.Commit(token, s.now())
+	}
+	return s.quota.Release(token, s.now())
+}
+
+func (s *Service) Create(tx model.Transaction, amount money.Amount, c model.RefundCandidate, now time.Time) (model.RefundRecord, error) {
+	if amount <= 0 {
+		amount = tx.Amount
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	existing, err := s.store.List(Filter{TransactionID: tx.ID})
+	if err != nil {
+		return model.RefundRecord{}, fmt.Errorf("list refunds: %w", err)
+	}
+	refunded, inFlight := committedAmounts(existing)
+	if remaining := tx.Amount - refunded - inFlight; amount > remaining {
+		cur := string(tx.Currency)
+		return model.RefundRecord{}, fmt.Errorf("%w: requested %s %s, remaining %s of %s",
+			ErrExceedsRefundable, amount.Format(cur), tx.Currency, money.Max(remaining, 0).Format(cur), tx.Amount.Format(cur))
+	}
+
+	now = now.UTC()
+	rec := model.RefundRecord{
+		ID:                 newID(),
+		TransactionID:      tx.ID,
+		CustomerID:         tx.CustomerID,
+		OriginalProcessor:  tx.ProcessorID,
+		PaymentMethod:      tx.PaymentMethod,
+		ProcessorID:        c.ProcessorID,
+		ProcessorName:      c.ProcessorName,
+		RefundMethod:       c.RefundMethod,
+		Amount:             amount,
+		OriginalAmount:     tx.Amount,
+		Currency:           tx.Currency,
+		EstimatedCost:      c.EstimatedCost,
+		ProcessingDays:     c.ProcessingDays,
+		Status:             model.RefundStatusPending,
+		CreatedAt:          now,
+		UpdatedAt:          now,
+		ExpectedCompletion: expectedCompletion(c, now),
+		History: []model.RefundStatusChange{{
+			Status: model.RefundStatusPending,
+			At:     now,
+			Note:   fmt.Sprintf("Refund created via %s (%s)", c.ProcessorName, c.RefundMethod),
+		}},
+	}
+
+	if err := s.store.Save(rec); err != nil {
+		return model.RefundRecord{}, fmt.Errorf("save refund: %w", err)
+	}
+	return rec, nil
+}
+
+func (s *Service) RemainingAmount(tx model.Transaction) (money.Amount, error) {
+	existing, err := s.store.List(Filter{TransactionID: tx.ID})
+	if err != nil {
+		return 0, fmt.Errorf("list refunds: %w", err)
+	}
+	refunded, inFlight := committedAmounts(existing)
+	return money.Max(tx.Amount-refunded-inFlight, 0), nil
+}
+
+func (s *Service) Summary(transactionID string) (model.TransactionRefundSummary, error) {
+	records, err := s.store.List(Filter{TransactionID: transactionID})
+	if err != nil {
+		return model.TransactionRefundSummary{}, fmt.Errorf("list refunds: %w", err)
+	}
+
+	summary := model.TransactionRefundSummary{
+		TransactionID: transactionID,
+		Refunds:       records,
+	}
+	if len(records) == 0 {
+		summary.Refunds = []model.RefundRecord{}
+		return summary, nil
+	}
+
+	refunded, inFlight := committedAmounts(records)
+	summary.Currency = records[0].Currency
+	summary.OriginalAmount = records[0].OriginalAmount
+	summary.RefundedAmount = refunded
+	summary.InFlightAmount = inFlight
+	summary.RemainingAmount = money.Max(summary.OriginalAmount-refunded-inFlight, 0)
+	return summary, nil
+}
+
+func committedAmounts(records []model.RefundRecord) (refunded, inFlight money.Amount) {
+	for _, rec := range records {
+		switch rec.Status {
+		case model.RefundStatusSucceeded:
+			refunded += rec.Amount
+		case model.RefundStatusPending, model.RefundStatusSubmitted, model.RefundStatusProcessing:
+			inFlight += rec.Amount
+		}
+	}
+	return refunded, inFlight
+}
+
+func (s *Service) Get(id string) (model.RefundRecord, error) {
+	return s.store.Get(id)
+}
+
+func (s *Service) List(filter Filter) ([]model.RefundRecord, error) {
+	return s.store.List(filter)
+}
+
+func (s *Service) dispatch(ctx context.Context, rec model.RefundRecord, c model.RefundCandidate) (string, error) {
+	if c.ProcessorID == internalProcessorID || s.clients == nil {
+		return "", nil
+	}
+
+	client, err := s.clients.Client(c.ProcessorID)
+	if err != nil {
+		return "", err
+	}
+	resp, err := client.SubmitRefund(ctx, processor.SubmitRequest{
+		RefundID:      rec.ID,
+		TransactionID: rec.TransactionID,
+		PaymentMethod: rec.PaymentMethod,
+		RefundMethod:  c.RefundMethod,
+		Amount:        rec.Amount,
+		Currency:      rec.Currency,
+	})
+	if err != nil {
+		return "", err
+	}
+	return resp.Reference, nil
+}
+
+func (s *Service) recordAttempt(id string, attempt model.RefundAttempt, now time.Time) (model.RefundRecord, error) {
+	return s.update(id, now, func(rec *model.RefundRecord) error {
+		rec.Attempts = append(rec.Attempts, attempt)
+		return nil
+	})
+}
+
+func (s *Service) accept(id string, attempt model.RefundAttempt, c model.RefundCandidate, ref string, now time.Time) (model.RefundRecord, error) {
+	return s.update(id, now, func(rec *model.RefundRecord) error {
+		rec.Attempts = append(rec.Attempts, attempt)
+		if rec.ProcessorID != c.ProcessorID || rec.RefundMethod != c.RefundMethod {
+			rec.ProcessorID = c.ProcessorID
+			rec.ProcessorName = c.ProcessorName
+			rec.RefundMethod = c.RefundMethod
+			rec.EstimatedCost = c.EstimatedCost
+			rec.ProcessingDays = c.ProcessingDays
+			rec.ExpectedCompletion = expectedCompletion(c, now.UTC())
+		}
+		rec.ProcessorReference = ref
+
+		switch {
+		case c.ProcessorID == internalProcessorID:
+			if err := applyStatus(rec, model.RefundStatusSubmitted, "Account credit issued internally", now); err != nil {
+				return err
+			}
+			return applyStatus(rec, model.RefundStatusSucceeded, "Funds credited to customer marketplace balance", now)
+		default:
+			note := "Accepted by " + c.ProcessorName + " as " + ref
+			if ref == "" {
+				note = "Accepted by " + c.ProcessorName + ", which returned no reference to track it by"
+			}
+			if len(rec.Attempts) > 1 {
+				note += fmt.Sprintf(" after %d failed attempt(s)", len(rec.Attempts)-1)
+			}
+			return applyStatus(rec, model.RefundStatusSubmitted, note, now)
+		}
+	})
+}
+
+func (s *Service) Refresh(ctx context.Context, id string, now time.Time) (model.RefundRecord, error) {
+	rec, err := s.store.Get(id)
+	if err != nil {
+		return model.RefundRecord{}, err
+	}
+	if IsTerminal(rec.Status) || rec.ProcessorReference == "" || s.clients == nil {
+		return rec, nil
+	}
+
+	client, err := s.clients.Client(rec.ProcessorID)
+	if err != nil {
+		return rec, err
+	}
+	resp, err := client.QueryStatus(ctx, rec.ProcessorReference)
+	if err != nil {
+		return rec, err
+	}
+	if resp.Status == rec.Status {
+		return rec, nil
+	}
+
+	return s.update(id, now, func(rec *model.RefundRecord) error {
+		if resp.Status == model.RefundStatusSucceeded && rec.Status == model.RefundStatusSubmitted {
+			if err := applyStatus(rec, model.RefundStatusProcessing, "Reported by processor", now); err != nil {
+				return err
+			}
+		}
+		if resp.Status == model.RefundStatusFailed {
+			rec.FailureReason = resp.Message
+		}
+		note := "Reported by processor"
+		if resp.Message != "" {
+			note += ": " + resp.Message
+		}
+		return applyStatus(rec, resp.Status, note, now)
+	})
+}
+
+func (s *Service) Transition(id string, status model.RefundStatus, note string, now time.Time) (model.RefundRecord, error) {
+	return s.update(id, now, func(rec *model.RefundRecord) error {
+		return applyStatus(rec, status, note, now)
+	})
+}
+
+func (s *Service) Cancel(ctx context.Context, id string, now time.Time) (model.RefundRecord, error) {
+	rec, err := s.store.Get(id)
+	if err != nil {
+		return model.RefundRecord{}, err
+	}
+	if !CanTransition(rec.Status, model.RefundStatusCancelled) {
+		return model.RefundRecord{}, fmt.Errorf("%w: %s -> %s", ErrInvalidTransition, rec.Status, model.RefundStatusCancelled)
+	}
+
+	if rec.ProcessorReference != "" && s.clients != nil {
+		if rec.RefundMethod != model.RefundReversal {
+			return model.RefundRecord{}, fmt.Errorf("%w: %s refunds cannot be cancelled once submitted", processor.ErrNotCancellable, rec.RefundMethod)
+		}
+		client, err := s.clients.Client(rec.ProcessorID)
+		if err != nil {
+			return model.RefundRecord{}, err
+		}
+		if err := client.CancelReversal(ctx, rec.ProcessorReference); err != nil {
+			return model.RefundRecord{}, err
+		}
+	}
+
+	return s.Transition(id, model.RefundStatusCancelled, "Cancelled by operator", now)
+}
+
+func (s *Service) fail(id string, cause error, now time.Time) (model.RefundRecord, error) {
+	rec, err := s.update(id, now, func(rec *model.RefundRecord) error {
+		rec.FailureReason = cause.Error()
+		return applyStatus(rec, model.RefundStatusFailed, "Submission failed: "+cause.Error(), now)
+	})
+	if err != nil {
+		return rec, err
+	}
+	return rec, cause
+}
+
+func (s *Service) update(id string, now time.Time, fn func(rec *model.RefundRecord) error) (model.RefundRecord, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	rec, err := s.store.Get(id)
+	if err != nil {
+		return model.RefundRecord{}, err
+	}
+	if err := fn(&rec); err != nil {
+		return model.RefundRecord{}, err
+	}
+	rec.UpdatedAt = now.UTC()
+
+	if err := s.store.Save(rec); err != nil {
+		return model.RefundRecord{}, fmt.Errorf("save refund: %w", err)
+	}
+	return rec, nil
+}
+
+func applyStatus(rec *model.RefundRecord, status model.RefundStatus, note string, now time.Time) error {
+	if !CanTransition(rec.Status, status) {
+		return fmt.Errorf("%w: %s -> %s", ErrInvalidTransition, rec.Status, status)
+	}
+	rec.Status = status
+	rec.History = append(rec.History, model.RefundStatusChange{Status: status, At: now.UTC(), Note: note})
+	return nil
+}
+
+func newID() string {
+	var b [12]byte
+	if _, err := rand.Read(b[:]); err != nil {
+		panic(fmt.Sprintf("refund: generating id: %v", err))
+	}
+	return "rfd_" + hex.EncodeToString(b[:])
+}
+
+func expectedCompletion(c model.RefundCandidate, now time.Time) time.Time {
+	if !c.ExpectedCompletion.IsZero() {
+		return c.ExpectedCompletion.UTC()
+	}
+	return now.AddDate(0, 0, c.ProcessingDays)
+}
@@ -0,0 +1,582 @@
+package refund
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ivanjtm/YunoChallenge/internal/model"
+	"github.com/ivanjtm/YunoChallenge/internal/money"
+	"github.com/ivanjtm/YunoChallenge/internal/processor"
+	"github.com/ivanjtm/YunoChallenge/internal/quota"
+)
+
+func testTransaction(now time.Time) model.Transaction {
+	return model.Transaction{
+		ID:            "tx-refund-1",
+		Country:       model.CountryBR,
+		Currency:      model.CurrencyBRL,
+		PaymentMethod: model.MethodPIX,
+		ProcessorID:   "globalpay",
+		Amount:        money.FromFloat(320.0),
+		Timestamp:     now.Add(-48 * time.Hour),
+		Settled:       true,
+		CustomerID:    "cust_042",
+	}
+}
+
+func testCandidate() model.RefundCandidate {
+	return model.RefundCandidate{
+		ProcessorID:    "paybr",
+		ProcessorName:  "PayBR",
+		RefundMethod:   model.RefundSameMethod,
+		EstimatedCost:  money.FromFloat(2.1),
+		ProcessingDays: 1,
+	}
+}
+
+func TestService_Create(t *testing.T) {
+	t.Parallel()
+
+	now := time.Date(2025, 6, 15, 12, 0, 0, 0, time.UTC)
+	svc := NewService(NewMemoryStore(), nil, nil)
+
+	rec, err := svc.Create(testTransaction(now), 0, testCandidate(), now)
+	if err != nil {
+		t.Fatalf("Create() error = %v", err)
+	}
+
+	if rec.ID == "" {
+		t.Error("ID is empty")
+	}
+	if rec.Status != model.RefundStatusPending {
+		t.Errorf("Status = %s, want PENDING", rec.Status)
+	}
+	if rec.ProcessorID != "paybr" || rec.OriginalProcessor != "globalpay" {
+		t.Errorf("ProcessorID/OriginalProcessor = %s/%s, want paybr/globalpay", rec.ProcessorID, rec.OriginalProcessor)
+	}
+	if want := now.AddDate(0, 0, 1); !rec.ExpectedCompletion.Equal(want) {
+		t.Errorf("ExpectedCompletion = %v, want %v", rec.ExpectedCompletion, want)
+	}
+	if len(rec.History) != 1 || rec.History[0].Status != model.RefundStatusPending {
+		t.Errorf("History = %+v, want single PENDING entry", rec.History)
+	}
+
+	got, err := svc.Get(rec.ID)
+	if err != nil {
+		t.Fatalf("Get() error = %v", err)
+	}
+	if got.TransactionID != "tx-refund-1" {
+		t.Errorf("Get().TransactionID = %s, want tx-refund-1", got.TransactionID)
+	}
+}
+
+func TestService_Transition(t *testing.T) {
+	t.Parallel()
+
+	now := time.Date(2025, 6, 15, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name    string
+		path    []model.RefundStatus
+		wantErr bool
+	}{
+		{name: "happy path", path: []model.RefundStatus{model.RefundStatusSubmitted, model.RefundStatusProcessing, model.RefundStatusSucceeded}},
+		{name: "fail after submit", path: []model.RefundStatus{model.RefundStatusSubmitted, model.RefundStatusFailed}},
+		{name: "cancel pending", path: []model.RefundStatus{model.RefundStatusCancelled}},
+		{name: "cannot skip to processing", path: []model.RefundStatus{model.RefundStatusProcessing}, wantErr: true},
+		{name: "cannot cancel processing", path: []model.RefundStatus{model.RefundStatusSubmitted, model.RefundStatusProcessing, model.RefundStatusCancelled}, wantErr: true},
+		{name: "terminal is final", path: []model.RefundStatus{model.RefundStatusCancelled, model.RefundStatusSubmitted}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			svc := NewService(NewMemoryStore(), nil, nil)
+			rec, err := svc.Create(testTransaction(now), 0, testCandidate(), now)
+			if err != nil {
+				t.Fatalf("Create() error = %v", err)
+			}
+
+			var lastErr error
+			for i, status := range tt.path {
+				rec, lastErr = svc.Transition(rec.ID, status, "", now.Add(time.Duration(i+1)*time.Minute))
+				if lastErr != nil {
+					break
+				}
+			}
+
+			if tt.wantErr {
+				if !errors.Is(lastErr, ErrInvalidTransition) {
+					t.Errorf("error = %v, want ErrInvalidTransition", lastErr)
+				}
+				return
+			}
+			if lastErr != nil {
+				t.Fatalf("Transition() error = %v", lastErr)
+			}
+			if rec.Status != tt.path[len(tt.path)-1] {
+				t.Errorf("Status = %s, want %s", rec.Status, tt.path[len(tt.path)-1])
+			}
+			if len(rec.History) != len(tt.path)+1 {
+				t.Errorf("len(History) = %d, want %d", len(rec.History), len(tt.path)+1)
+			}
+		})
+	}
+}
+
+func TestService_GetUnknown(t *testing.T) {
+	t.Parallel()
+
+	svc := NewService(NewMemoryStore(), nil, nil)
+	if _, err := svc.Get("rfd_missing"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Get() error = %v, want ErrNotFound", err)
+	}
+}
+
+func TestService_ListFilters(t *testing.T) {
+	t.Parallel()
+
+	now := time.Date(2025, 6, 15, 12, 0, 0, 0, time.UTC)
+	svc := NewService(NewMemoryStore(), nil, nil)
+
+	tx1 := testTransaction(now)
+	tx2 := testTransaction(now)
+	tx2.ID = "tx-refund-2"
+
+	first, _ := svc.Create(tx1, money.FromFloat(100), testCandidate(), now)
+	svc.Create(tx1, money.FromFloat(100), testCandidate(), now.Add(time.Minute))
+	svc.Create(tx2, 0, testCandidate(), now.Add(2*time.Minute))
+	svc.Cancel(context.Background(), first.ID, now.Add(3*time.Minute))
+
+	byTx, _ := svc.List(Filter{TransactionID: "tx-refund-1"})
+	if len(byTx) != 2 {
+		t.Errorf("len(List(tx-refund-1)) = %d, want 2", len(byTx))
+	}
+	if len(byTx) == 2 && byTx[0].ID != first.ID {
+		t.Errorf("List not ordered by creation time: first = %s, want %s", byTx[0].ID, first.ID)
+	}
+
+	cancelled, _ := svc.List(Filter{Status: model.RefundStatusCancelled})
+	if len(cancelled) != 1 {
+		t.Errorf("len(List(CANCELLED)) = %d, want 1", len(cancelled))
+	}
+}
+
+func TestMemoryStore_ReturnsCopies(t *testing.T) {
+	t.Parallel()
+
+	now := time.Date(2025, 6, 15, 12, 0, 0, 0, time.UTC)
+	store := NewMemoryStore()
+	rec := model.RefundRecord{
+		ID:       "ref-copy",
+		Status:   model.RefundStatusSubmitted,
+		History:  []model.RefundStatusChange{{Status: model.RefundStatusSubmitted, At: now}},
+		Attempts: []model.RefundAttempt{{ProcessorID: "paybr", Outcome: OutcomeAccepted, At: now}},
+	}
+	if err := store.Save(rec); err != nil {
+		t.Fatalf("Save() error = %v", err)
+	}
+	rec.Attempts[0].Outcome = OutcomeFailed
+
+	got, err := store.Get("ref-copy")
+	if err != nil {
+		t.Fatalf("Get() error = %v", err)
+	}
+	got.Attempts[0].Outcome = OutcomeSkipped
+	got.History[0].Note = "edited"
+
+	list, err := store.List(Filter{})
+	if err != nil {
+		t.Fatalf("List() error = %v", err)
+	}
+	again, _ := store.Get("ref-copy")
+	for _, r := range []model.RefundRecord{again, list[0]} {
+		if r.Attempts[0].Outcome != OutcomeAccepted || r.History[0].Note != "" {
+			t.Errorf("stored record = %+v, want it unaffected by edits to saved or returned copies", r)
+		}
+	}
+}
+
+func TestFileStore_PersistsAcrossReopen(t *testing.T) {
+	t.Parallel()
+
+	now := time.Date(2025, 6, 15, 12, 0, 0, 0, time.UTC)
+	path := filepath.Join(t.TempDir(), "refunds.json")
+
+	store, err := NewFileStore(path)
+	if err != nil {
+		t.Fatalf("NewFileStore() error = %v", err)
+	}
+	svc := NewService(store, nil, nil)
+	rec, err := svc.Create(testTransaction(now), 0, testCandidate(), now)
+	if err != nil {
+		t.Fatalf("Create() error = %v", err)
+	}
+	if _, err := svc.Transition(rec.ID, model.RefundStatusSubmitted, "sent", now.Add(time.Minute)); err != nil {
+		t.Fatalf("Transition() error = %v", err)
+	}
+
+	reopened, err := NewFileStore(path)
+	if err != nil {
+		t.Fatalf("reopen NewFileStore() error = %v", err)
+	}
+	got, err := reopened.Get(rec.ID)
+	if err != nil {
+		t.Fatalf("Get() after reopen error = %v", err)
+	}
+	if got.Status != model.RefundStatusSubmitted {
+		t.Errorf("Status after reopen = %s, want SUBMITTED", got.Status)
+	}
+	if len(got.History) != 2 {
+		t.Errorf("len(History) after reopen = %d, want 2", len(got.History))
+	}
+}
+
+func TestService_ExecuteDispatchesToProcessor(t *testing.T) {
+	t.Parallel()
+
+	now := time.Date(2025, 6, 15, 12, 0, 0, 0, time.UTC)
+	srv, clients := processor.StartMockServer(map[string]processor.MockProfile{
+		"paybr":  {},
+		"mexpay": {DeclineRate: 1},
+	}, 1, time.Second)
+	t.Cleanup(srv.Close)
+	svc := NewService(NewMemoryStore(), clients, nil)
+	ctx := context.Background()
+
+	rec, err := svc.Execute(ctx, testTransaction(now), testCandidate(), now)
+	if err != nil {
+		t.Fatalf("Execute() error = %v", err)
+	}
+	if rec.ProcessorReference == "" {
+		t.Error("ProcessorReference is empty after successful submit")
+	}
+
+	rec, err = svc.Refresh(ctx, rec.ID, now)
+	if err != nil {
+		t.Fatalf("Refresh() error = %v", err)
+	}
+	if rec.Status != model.RefundStatusSucceeded {
+		t.Errorf("Status after Refresh = %s, want SUCCEEDED", rec.Status)
+	}
+
+	declined := testCandidate()
+	declined.ProcessorID = "mexpay"
+	otherTx := testTransaction(now)
+	otherTx.ID = "tx-refund-declined"
+	rec, err = svc.Execute(ctx, otherTx, declined, now)
+	if !errors.Is(err, processor.ErrDeclined) {
+		t.Errorf("Execute(declining processor) error = %v, want ErrDeclined", err)
+	}
+	if rec.Status != model.RefundStatusFailed || rec.FailureReason == "" {
+		t.Errorf("declined record = %s/%q, want FAILED with reason", rec.Status, rec.FailureReason)
+	}
+}
+
+func TestService_ExecuteAccountCreditSucceedsImmediately(t *testing.T) {
+	t.Parallel()
+
+	now := time.Date(2025, 6, 15, 12, 0, 0, 0, time.UTC)
+	svc := NewService(NewMemoryStore(), processor.NewRegistry(), nil)
+
+	credit := model.RefundCandidate{ProcessorID: "internal", ProcessorName: "Account Credit", RefundMethod: model.RefundAccountCredit}
+	rec, err := svc.Execute(context.Background(), testTransaction(now), credit, now)
+	if err != nil {
+		t.Fatalf("Execute() error = %v", err)
+	}
+	if rec.Status != model.RefundStatusSucceeded {
+		t.Errorf("Status = %s, want SUCCEEDED", rec.Status)
+	}
+}
+
+type noReferenceClient struct{}
+
+func (noReferenceClient) SubmitRefund(ctx context.Context, req processor.SubmitRequest) (processor.SubmitResponse, error) {
+	return processor.SubmitResponse{Status: model.RefundStatusSubmitted}, nil
+}
+
+func (noReferenceClient) QueryStatus(ctx context.Context, reference string) (processor.StatusResponse, error) {
+	return processor.StatusResponse{}, processor.ErrNotFound
+}
+
+func (noReferenceClient) CancelReversal(ctx context.Context, reference string) error {
+	return processor.ErrNotCancellable
+}
+
+func TestService_ExecuteWithoutProcessorReference(t *testing.T) {
+	t.Parallel()
+
+	now := time.Date(2025, 6, 15, 12, 0, 0, 0, time.UTC)
+	clients := processor.NewRegistry()
+	clients.Register("paybr", noReferenceClient{})
+	svc := NewService(NewMemoryStore(), clients, nil)
+
+	rec, err := svc.Execute(context.Background(), testTransaction(now), testCandidate(), now)
+	if err != nil {
+		t.Fatalf("Execute() error = %v", err)
+	}
+	if rec.Status != model.RefundStatusSubmitted {
+		t.Errorf("Status = %s, want SUBMITTED once the processor accepted it", rec.Status)
+	}
+	if rec.ProcessorReference != "" {
+		t.Errorf("ProcessorReference = %q, want empty", rec.ProcessorReference)
+	}
+	last := rec.History[len(rec.History)-1]
+	if !strings.Contains(last.Note, "no reference") {
+		t.Errorf("last history note = %q, want it to say the processor returned no reference", last.Note)
+	}
+	if rec, err = svc.Refresh(context.Background(), rec.ID, now); err != nil || rec.Status != model.RefundStatusSubmitted {
+		t.Errorf("Refresh() = %s, %v, want SUBMITTED with nothing to query", rec.Status, err)
+	}
+}
+
+type fakeQuota map[string]bool
+
+func (f fakeQuota) Reserve(d model.QuotaDemand, now time.Time) (model.QuotaReservation, bool, string) {
+	if f[d.ProcessorID] {
+		return model.QuotaReservation{}, false, "Daily quota exhausted (test)"
+	}
+	return model.QuotaReservation{Token: "res-" + d.ProcessorID, QuotaDemand: d}, true, ""
+}
+
+func (f fakeQuota) Commit(token string, now time.Time) error  { return nil }
+func (f fakeQuota) Release(token string, now time.Time) error { return nil }
+
+func TestService_ExecuteRouteFailsOverToAlternatives(t *testing.T) {
+	t.Parallel()
+
+	now := time.Date(2025, 6, 15, 12, 0, 0, 0, time.UTC)
+	srv, clients := processor.StartMockServer(map[string]processor.MockProfile{
+		"paybr":     {DeclineRate: 1},
+		"quickpay":  {},
+		"valueproc": {},
+	}, 1, time.Second)
+	t.Cleanup(srv.Close)
+	svc := NewService(NewMemoryStore(), clients, fakeQuota{"quickpay": true})
+
+	route := model.RefundRouteResult{
+		TransactionID: "tx-refund-1",
+		Selected:      testCandidate(),
+		Alternatives: []model.RefundCandidate{
+			{ProcessorID: "quickpay", ProcessorName: "QuickPay", RefundMethod: model.RefundSameMethod, EstimatedCost: money.FromFloat(2.5), ProcessingDays: 0},
+			{ProcessorID: "valueproc", ProcessorName: "ValueProc", RefundMethod: model.RefundSameMethod, EstimatedCost: money.FromFloat(3.06), ProcessingDays: 3},
+		},
+	}
+
+	rec, err := svc.ExecuteRoute(context.Background(), testTransaction(now), route, now)
+	if err != nil {
+		t.Fatalf("ExecuteRoute() error = %v", err)
+	}
+
+	if rec.Status != model.RefundStatusSubmitted {
+		t.Errorf("Status = %s, want SUBMITTED", rec.Status)
+	}
+	if rec.ProcessorID != "valueproc" {
+		t.Errorf("ProcessorID = %s, want valueproc", rec.ProcessorID)
+	}
+	if rec.EstimatedCost != money.FromFloat(3.06) {
+		t.Errorf("EstimatedCost = %s, want 3.06", rec.EstimatedCost)
+	}
+
+	wantOutcomes := []string{OutcomeFailed, OutcomeSkipped, OutcomeAccepted}
+	if len(rec.Attempts) != len(wantOutcomes) {
+		t.Fatalf("len(Attempts) = %d, want %d: %+v", len(rec.Attempts), len(wantOutcomes), rec.Attempts)
+	}
+	for i, want := range wantOutcomes {
+		if rec.Attempts[i].Outcome != want {
+			t.Errorf("Attempts[%d].Outcome = %s, want %s", i, rec.Attempts[i].Outcome, want)
+		}
+	}
+	if rec.Attempts[0].Error == "" || rec.Attempts[1].Error == "" {
+		t.Error("failed/skipped attempts should record an error")
+	}
+	if got := rec.Attempts[2].IncrementalCost; got != money.FromFloat(0.96) {
+		t.Errorf("Attempts[2].IncrementalCost = %s, want 0.96", got)
+	}
+}
+
+func TestService_ExecuteRouteSettlesReservations(t *testing.T) {
+	t.Parallel()
+
+	now := time.Date(2025, 6, 15, 12, 0, 0, 0, time.UTC)
+	srv, clients := processor.StartMockServer(map[string]processor.MockProfile{
+		"paybr":     {DeclineRate: 1},
+		"valueproc": {},
+	}, 1, time.Second)
+	t.Cleanup(srv.Close)
+	tracker := quota.NewTracker([]model.Processor{
+		{ID: "paybr", DailyQuota: 1},
+		{ID: "valueproc", DailyQuota: 1},
+	})
+	svc := NewService(NewMemoryStore(), clients, tracker)
+	svc.now = func() time.Time { return now }
+
+	tx := testTransaction(now)
+	res, ok, reason := tracker.Reserve(model.QuotaDemand{ProcessorID: "paybr", Method: model.RefundSameMethod, Currency: tx.Currency, Amount: tx.Amount}, now)
+	if !ok {
+		t.Fatalf("Reserve(paybr) failed: %s", reason)
+	}
+	route := model.RefundRouteResult{
+		TransactionID: tx.ID,
+		Selected:      testCandidate(),
+		Alternatives: []model.RefundCandidate{
+			{ProcessorID: "valueproc", ProcessorName: "ValueProc", RefundMethod: model.RefundSameMethod, EstimatedCost: money.FromFloat(3.06), ProcessingDays: 3},
+		},
+		Reservation: res.Token,
+	}
+
+	rec, err := svc.ExecuteRoute(context.Background(), tx, route, now)
+	if err != nil {
+		t.Fatalf("ExecuteRoute() error = %v", err)
+	}
+	if rec.ProcessorID != "valueproc" {
+		t.Fatalf("ProcessorID = %s, want valueproc", rec.ProcessorID)
+	}
+
+	want := map[string]int{"paybr": 0, "valueproc": 1}
+	for _, s := range tracker.Status(now) {
+		if s.UsedToday != want[s.ProcessorID] {
+			t.Errorf("%s UsedToday = %d, want %d", s.ProcessorID, s.UsedToday, want[s.ProcessorID])
+		}
+		if s.Reserved != 0 {
+			t.Errorf("%s Reserved = %d, want 0 after the refund settled", s.ProcessorID, s.Reserved)
+		}
+	}
+	if err := tracker.Release(res.Token, now); !errors.Is(err, quota.ErrReservationNotFound) {
+		t.Errorf("Release(released token) error = %v, want ErrReservationNotFound", err)
+	}
+}
+
+func TestService_ExecuteRouteSettlesExpiredReservations(t *testing.T) {
+	t.Parallel()
+
+	now := time.Date(2025, 6, 15, 12, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name    string
+		profile processor.MockProfile
+		status  model.RefundStatus
+		used    int
+	}{
+		{"slow accept still counts", processor.MockProfile{}, model.RefundStatusSubmitted, 1},
+		{"slow decline frees the slot", processor.MockProfile{DeclineRate: 1}, model.RefundStatusFailed, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			srv, clients := processor.StartMockServer(map[string]processor.MockProfile{"paybr": tt.profile}, 1, time.Second)
+			t.Cleanup(srv.Close)
+			tracker := quota.NewTracker([]model.Processor{{ID: "paybr", DailyQuota: 1}})
+			tracker.ReservationTTL = time.Minute
+			svc := NewService(NewMemoryStore(), clients, tracker)
+			settledAt := now.Add(2 * time.Minute)
+			svc.now = func() time.Time { return settledAt }
+
+			tx := testTransaction(now)
+			res, ok, reason := tracker.Reserve(model.QuotaDemand{ProcessorID: "paybr", Method: model.RefundSameMethod, Currency: tx.Currency, Amount: tx.Amount}, now)
+			if !ok {
+				t.Fatalf("Reserve(paybr) failed: %s", reason)
+			}
+			route := model.RefundRouteResult{TransactionID: tx.ID, Selected: testCandidate(), RefundAmount: tx.Amount, Reservation: res.Token}
+
+			rec, _ := svc.ExecuteRoute(context.Background(), tx, route, now)
+			if rec.Status != tt.status {
+				t.Fatalf("Status = %s, want %s", rec.Status, tt.status)
+			}
+			if len(rec.Attempts) != 1 {
+				t.Fatalf("len(Attempts) = %d, want 1", len(rec.Attempts))
+			}
+			if strings.Contains(rec.Attempts[0].Error, "reservation") {
+				t.Errorf("Attempts[0].Error = %q, want no quota error for a reservation that outlived its TTL", rec.Attempts[0].Error)
+			}
+			if st := tracker.Status(settledAt)[0]; st.UsedToday != tt.used || st.Reserved != 0 {
+				t.Errorf("paybr used %d, reserved %d, want %d and 0", st.UsedToday, st.Reserved, tt.used)
+			}
+		})
+	}
+}
+
+func TestService_ExecuteRouteAllAttemptsFail(t *testing.T) {
+	t.Parallel()
+
+	now := time.Date(2025, 6, 15, 12, 0, 0, 0, time.UTC)
+	srv, clients := processor.StartMockServer(map[string]processor.MockProfile{
+		"paybr":     {ErrorRate: 1},
+		"valueproc": {DeclineRate: 1},
+	}, 1, time.Second)
+	t.Cleanup(srv.Close)
+	svc := NewService(NewMemoryStore(), clients, nil)
+
+	route := model.RefundRouteResult{
+		Selected: testCandidate(),
+		Alternatives: []model.RefundCandidate{
+			{ProcessorID: "valueproc", ProcessorName: "ValueProc", RefundMethod: model.RefundBankTransfer, EstimatedCost: money.FromFloat(3.95), ProcessingDays: 5},
+		},
+	}
+
+	rec, err := svc.ExecuteRoute(context.Background(), testTransaction(now), route, now)
+	if !errors.Is(err, processor.ErrDeclined) {
+		t.Errorf("ExecuteRoute() error = %v, want last error ErrDeclined", err)
+	}
+	if rec.Status != model.RefundStatusFailed {
+		t.Errorf("Status = %s, want FAILED", rec.Status)
+	}
+	if len(rec.Attempts) != 2 {
+		t.Errorf("len(Attempts) = %d, want 2", len(rec.Attempts))
+	}
+}
+
+func TestService_PartialRefundsCumulativeLimit(t *testing.T) {
+	t.Parallel()
+
+	now := time.Date(2025, 6, 15, 12, 0, 0, 0, time.UTC)
+	svc := NewService(NewMemoryStore(), nil, nil)
+	tx := testTransaction(now)
+
+	first, err := svc.Create(tx, money.FromFloat(120), testCandidate(), now)
+	if err != nil {
+		t.Fatalf("Create(120) error = %v", err)
+	}
+	if first.Amount != money.FromFloat(120) || first.OriginalAmount != money.FromFloat(320) {
+		t.Errorf("Amount/OriginalAmount = %s/%s, want 120/320", first.Amount, first.OriginalAmount)
+	}
+
+	if _, err := svc.Create(tx, money.FromFloat(200.01), testCandidate(), now); !errors.Is(err, ErrExceedsRefundable) {
+		t.Errorf("Create(200.01) error = %v, want ErrExceedsRefundable", err)
+	}
+
+	second, err := svc.Create(tx, money.FromFloat(200), testCandidate(), now)
+	if err != nil {
+		t.Fatalf("Create(200) error = %v", err)
+	}
+
+	if remaining, _ := svc.RemainingAmount(tx); remaining != 0 {
+		t.Errorf("RemainingAmount = %s, want 0", remaining)
+	}
+
+	if _, err := svc.Cancel(context.Background(), second.ID, now); err != nil {
+		t.Fatalf("Cancel() error = %v", err)
+	}
+	if _, err := svc.Transition(first.ID, model.RefundStatusSubmitted, "", now); err != nil {
+		t.Fatalf("Transition(SUBMITTED) error = %v", err)
+	}
+	if _, err := svc.Transition(first.ID, model.RefundStatusSucceeded, "", now); err != nil {
+		t.Fatalf("Transition(SUCCEEDED) error = %v", err)
+	}
+
+	summary, err := svc.Summary(tx.ID)
+	if err != nil {
+		t.Fatalf("Summary() error = %v", err)
+	}
+	if summary.RefundedAmount != money.FromFloat(120) || summary.InFlightAmount != 0 || summary.RemainingAmount != money.FromFloat(200) {
+		t.Errorf("Summary refunded/in-flight/remaining = %s/%s/%s, want 120/0/200",
+			summary.RefundedAmount, summary.InFlightAmount, summary.RemainingAmount)
+	}
+	if len(summary.Refunds) != 2 {
+		t.Errorf("len(Summary.Refunds) = %d, want 2", len(summary.Refunds))
+	}
+}
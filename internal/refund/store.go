@@ -0,0 +1,164 @@
+package refund
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+
+	"github.com/ivanjtm/YunoChallenge/internal/model"
+)
+
+var ErrNotFound = errors.New("refund not found")
+
+type Filter struct {
+	TransactionID string
+	Status        model.RefundStatus
+}
+
+func (f Filter) matches(rec model.RefundRecord) bool {
+	if f.TransactionID != "" && rec.TransactionID != f.TransactionID {
+		return false
+	}
+	if f.Status != "" && rec.Status != f.Status {
+		return false
+	}
+	return true
+}
+
+type Store interface {
+	Save(rec model.RefundRecord) error
+	Get(id string) (model.RefundRecord, error)
+	List(filter Filter) ([]model.RefundRecord, error)
+}
+
+type MemoryStore struct {
+	mu      sync.RWMutex
+	records map[string]model.RefundRecord
+}
+
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{records: make(map[string]model.RefundRecord)}
+}
+
+func (s *MemoryStore) Save(rec model.RefundRecord) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.records[rec.ID] = cloneRecord(rec)
+	return nil
+}
+
+func (s *MemoryStore) Get(id string) (model.RefundRecord, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	rec, ok := s.records[id]
+	if !ok {
+		return model.RefundRecord{}, fmt.Errorf("%w: %s", ErrNotFound, id)
+	}
+	return cloneRecord(rec), nil
+}
+
+func (s *MemoryStore) List(filter Filter) ([]model.RefundRecord, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	out := make([]model.RefundRecord, 0, len(s.records))
+	for _, rec := range s.records {
+		if filter.matches(rec) {
+			out = append(out, cloneRecord(rec))
+		}
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if !out[i].CreatedAt.Equal(out[j].CreatedAt) {
+			return out[i].CreatedAt.Before(out[j].CreatedAt)
+		}
+		return out[i].ID < out[j].ID
+	})
+	return out, nil
+}
+
+type FileStore struct {
+	*MemoryStore
+	path string
+}
+
+func NewFileStore(path string) (*FileStore, error) {
+	fs := &FileStore{MemoryStore: NewMemoryStore(), path: path}
+
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return fs, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("read refund store %s: %w", path, err)
+	}
+
+	var records []model.RefundRecord
+	if len(data) > 0 {
+		if err := json.Unmarshal(data, &records); err != nil {
+			return nil, fmt.Errorf("decode refund store %s: %w", path, err)
+		}
+	}
+	for _, rec := range records {
+		fs.records[rec.ID] = rec
+	}
+	return fs, nil
+}
+
+func (s *FileStore) Save(rec model.RefundRecord) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	prev, existed := s.records[rec.ID]
+	s.records[rec.ID] = cloneRecord(rec)
+	if err := s.flushLocked(); err != nil {
+		if existed {
+			s.records[rec.ID] = prev
+		} else {
+			delete(s.records, rec.ID)
+		}
+		return err
+	}
+	return nil
+}
+
+func (s *FileStore) flushLocked() error {
+	records := make([]model.RefundRecord, 0, len(s.records))
+	for _, rec := range s.records {
+		records = append(records, rec)
+	}
+	sort.Slice(records, func(i, j int) bool { return records[i].ID < records[j].ID })
+
+	data, err := json.MarshalIndent(records, "", "  ")
+	if err != nil {
+		return fmt.Errorf("marshal refunds: %w", err)
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(s.path), ".refunds-*.json")
+	if err != nil {
+		return fmt.Errorf("create temp file: %w", err)
+	}
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return fmt.Errorf("write refunds: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmp.Name())
+		return fmt.Errorf("close refunds: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), s.path); err != nil {
+		os.Remove(tmp.Name())
+		return fmt.Errorf("replace %s: %w", s.path, err)
+	}
+	return nil
+}
+
+func cloneRecord(rec model.RefundRecord) model.RefundRecord {
+	rec.History = append([]model.RefundStatusChange(nil), rec.History...)
+	rec.Attempts = append([]model.RefundAttempt(nil), rec.Attempts...)
+	return rec
+}
@@ -3,29 +3,45 @@ package router
 import (
 	"fmt"
 	"runtime"
+	"slices"
+	"sort"
 	"sync"
 	"time"
 
+	"github.com/ivanjtm/YunoChallenge/internal/fx"
+	"github.com/ivanjtm/YunoChallenge/internal/i18n"
 	"github.com/ivanjtm/YunoChallenge/internal/model"
+	"github.com/ivanjtm/YunoChallenge/internal/money"
 	"github.com/ivanjtm/YunoChallenge/internal/rules"
 )
 
-type indexedRoute struct {
-	index int
-	tx    model.Transaction
-	route model.RefundRouteResult
+func (r *Router) AnalyzeBatch(txns []model.Transaction, now time.Time) model.BatchRefundResult {
+	return r.AnalyzeBatchWithOptions(txns, RouteOptions{}, now)
 }
 
-func (r *Router) AnalyzeBatch(txns []model.Transaction, now time.Time) model.BatchRefundResult {
-	n := len(txns)
-	result := model.BatchRefundResult{
-		TotalTransactions: n,
-		Results:           make([]model.RefundRouteResult, n),
-		ByProcessor:       make(map[string]model.ProcessorSummary),
-		ByPaymentMethod:   make(map[string]model.MethodSummary),
-		TimeSensitive:     make([]model.TimeSensitiveFlag, 0),
-		LimitedOptions:    make([]model.LimitedOptionFlag, 0),
+func (r *Router) AnalyzeBatchWithOptions(txns []model.Transaction, opts RouteOptions, now time.Time) model.BatchRefundResult {
+	opts.RefundAmount = 0
+	cfg := r.snapshot()
+	preferred := r.routeBatch(cfg, txns, opts, now, quotaCheck)
+	routes := r.routeBatch(cfg, txns, opts, now, quotaCommit)
+	return r.summarizeBatch(cfg, txns, preferred, routes, opts, model.AllocationIndependent, now)
+}
+
+func (r *Router) OptimizeBatchWithOptions(txns []model.Transaction, opts RouteOptions, now time.Time) model.BatchRefundResult {
+	opts.RefundAmount = 0
+	cfg := r.snapshot()
+	preferred := r.routeBatch(cfg, txns, opts, now, quotaCheck)
+
+	routes := make([]model.RefundRouteResult, len(txns))
+	for _, i := range r.allocationOrder(txns, preferred, now) {
+		routes[i] = r.routeWith(cfg, txns[i], opts, now, quotaCommit)
 	}
+	return r.summarizeBatch(cfg, txns, preferred, routes, opts, model.AllocationOptimized, now)
+}
+
+func (r *Router) routeBatch(cfg routingConfig, txns []model.Transaction, opts RouteOptions, now time.Time, mode quotaMode) []model.RefundRouteResult {
+	n := len(txns)
+	routes := make([]model.RefundRouteResult, n)
 
 	workers := runtime.NumCPU()
 	if workers > n {
@@ -35,62 +51,139 @@ func (r *Router) AnalyzeBatch(txns []model.Transaction, now time.Time) model.Bat
 		workers = 1
 	}
 
-	jobs := make(chan indexedRoute, n)
-	results := make(chan indexedRoute, n)
-
+	jobs := make(chan int, n)
 	var wg sync.WaitGroup
 	for w := 0; w < workers; w++ {
 		wg.Add(1)
 		go func() {
 			defer wg.Done()
-			for j := range jobs {
-				j.route = r.SelectRoute(j.tx, now)
-				results <- j
+			for i := range jobs {
+				routes[i] = r.routeWith(cfg, txns[i], opts, now, mode)
 			}
 		}()
 	}
 
-	for i, tx := range txns {
-		jobs <- indexedRoute{index: i, tx: tx}
+	for i := range txns {
+		jobs <- i
 	}
 	close(jobs)
+	wg.Wait()
+	return routes
+}
 
-	go func() {
-		wg.Wait()
-		close(results)
-	}()
+func (r *Router) allocationOrder(txns []model.Transaction, preferred []model.RefundRouteResult, now time.Time) []int {
+	type regret struct {
+		index    int
+		fallback bool
+		delta    float64
+	}
+	regrets := make([]regret, len(txns))
+	for i, route := range preferred {
+		regrets[i] = regret{index: i}
+		best := route.Selected
+		for _, alt := range route.Alternatives {
+			if alt.ProcessorID == best.ProcessorID || alt.RefundMethod == model.RefundAccountCredit {
+				continue
+			}
+			regrets[i].fallback = true
+			regrets[i].delta = r.reportingValue(alt.EstimatedCost-best.EstimatedCost, txns[i].Currency, now)
+			break
+		}
+	}
 
-	for ir := range results {
-		result.Results[ir.index] = ir.route
-		route := ir.route
-		tx := ir.tx
+	sort.SliceStable(regrets, func(i, j int) bool {
+		if regrets[i].fallback != regrets[j].fallback {
+			return !regrets[i].fallback
+		}
+		return regrets[i].delta > regrets[j].delta
+	})
 
-		result.TotalNaiveCost += route.NaiveCost
-		result.TotalSmartCost += route.Selected.EstimatedCost
-		result.TotalSavings += route.Savings
+	order := make([]int, len(regrets))
+	for i, rg := range regrets {
+		order[i] = rg.index
+	}
+	return order
+}
+
+func (r *Router) reportingValue(amount money.Amount, cur model.Currency, now time.Time) float64 {
+	if r.FX != nil {
+		if converted, _, err := fx.Convert(r.FX, amount, cur, r.ReportingCurrency, now); err == nil {
+			return converted.Float64()
+		}
+	}
+	return amount.Float64()
+}
+
+func displacement(tx model.Transaction, first, route model.RefundRouteResult) (model.DisplacedTransaction, bool) {
+	want := first.Selected
+	if want.ProcessorID == route.Selected.ProcessorID && want.RefundMethod == route.Selected.RefundMethod {
+		return model.DisplacedTransaction{}, false
+	}
+	i := slices.IndexFunc(route.Unavailable, func(c model.RefundCandidate) bool {
+		return c.ProcessorID == want.ProcessorID && c.RefundMethod == want.RefundMethod
+	})
+	if i < 0 {
+		return model.DisplacedTransaction{}, false
+	}
+	preferred := route.Unavailable[i]
+	return model.DisplacedTransaction{
+		TransactionID:      tx.ID,
+		Currency:           tx.Currency,
+		PreferredProcessor: preferred.ProcessorID,
+		PreferredMethod:    preferred.RefundMethod,
+		AssignedProcessor:  route.Selected.ProcessorID,
+		AssignedMethod:     route.Selected.RefundMethod,
+		ExtraCost:          route.Selected.EstimatedCost - want.EstimatedCost,
+		Reason:             preferred.UnavailableReason,
+	}, true
+}
+
+func (r *Router) summarizeBatch(cfg routingConfig, txns []model.Transaction, preferred, routes []model.RefundRouteResult, opts RouteOptions, allocation model.BatchAllocation, now time.Time) model.BatchRefundResult {
+	strategy, _ := cfg.scorer(opts)
+	printer := i18n.For(opts.Locale)
+
+	result := model.BatchRefundResult{
+		Strategy:          strategy,
+		Allocation:        allocation,
+		TotalTransactions: len(txns),
+		Results:           routes,
+		ByProcessor:       make(map[string]model.ProcessorSummary),
+		ByPaymentMethod:   make(map[string]model.MethodSummary),
+		TimeSensitive:     make([]model.TimeSensitiveFlag, 0),
+		LimitedOptions:    make([]model.LimitedOptionFlag, 0),
+		Displaced:         make([]model.DisplacedTransaction, 0),
+		ByCurrency:        make(map[string]model.CurrencyTotals),
+	}
+
+	for i, route := range routes {
+		tx := txns[i]
 
 		ps := result.ByProcessor[tx.ProcessorID]
 		ps.ProcessorID = tx.ProcessorID
-		ps.NaiveCost += route.NaiveCost
-		ps.SmartCost += route.Selected.EstimatedCost
-		ps.Savings += route.Savings
+		if ps.ByCurrency == nil {
+			ps.ByCurrency = make(map[string]model.CurrencyTotals)
+		}
+		fx.AddToCurrency(ps.ByCurrency, tx.Currency, route.NaiveCost, route.Selected.EstimatedCost)
 		ps.TransactionCount++
 		result.ByProcessor[tx.ProcessorID] = ps
 
+		fx.AddToCurrency(result.ByCurrency, tx.Currency, route.NaiveCost, route.Selected.EstimatedCost)
+
 		methodKey := string(tx.PaymentMethod)
 		ms := result.ByPaymentMethod[methodKey]
 		ms.Method = methodKey
-		ms.NaiveCost += route.NaiveCost
-		ms.SmartCost += route.Selected.EstimatedCost
-		ms.Savings += route.Savings
+		if ms.ByCurrency == nil {
+			ms.ByCurrency = make(map[string]model.CurrencyTotals)
+		}
+		fx.AddToCurrency(ms.ByCurrency, tx.Currency, route.NaiveCost, route.Selected.EstimatedCost)
 		ms.TransactionCount++
 		result.ByPaymentMethod[methodKey] = ms
 
-		tsFlags := rules.TimeSensitiveWindows(tx, r.RuleIndex, now, 15)
+		policy := rules.ResolveReversalPolicy(cfg.reversal[tx.Country], processorReversalWindow(cfg.processors, tx.ProcessorID))
+		tsFlags := rules.LocalizedTimeSensitiveWindows(tx, cfg.ruleIndex, policy, now.In(cfg.calendars[tx.Country].Location()), 15, printer)
 		result.TimeSensitive = append(result.TimeSensitive, tsFlags...)
 
-		switch tx.PaymentMethod {
-		case model.MethodOXXO, model.MethodBoleto, model.MethodEfecty:
+		if !cfg.ruleIndex.AllowsSelfRefund(tx.PaymentMethod, tx.Country) {
 			totalOptions := 1 + len(route.Alternatives)
 			result.LimitedOptions = append(result.LimitedOptions, model.LimitedOptionFlag{
 				TransactionID:    tx.ID,
@@ -99,20 +192,29 @@ func (r *Router) AnalyzeBatch(txns []model.Transaction, now time.Time) model.Bat
 				Message:          fmt.Sprintf("%s cannot be refunded via %s; requires alternative method. %d routing option(s) available.", tx.PaymentMethod, tx.PaymentMethod, totalOptions),
 			})
 		}
-	}
 
-	if result.TotalNaiveCost > 0 {
-		result.SavingsPercent = (result.TotalSavings / result.TotalNaiveCost) * 100
+		if d, ok := displacement(tx, preferred[i], route); ok {
+			result.Displaced = append(result.Displaced, d)
+		}
 	}
 
-	result.TotalNaiveCost = roundTo2(result.TotalNaiveCost)
-	result.TotalSmartCost = roundTo2(result.TotalSmartCost)
-	result.TotalSavings = roundTo2(result.TotalSavings)
-	result.SavingsPercent = roundTo2(result.SavingsPercent)
+	fx.RoundTotals(result.ByCurrency)
+	for _, ps := range result.ByProcessor {
+		fx.RoundTotals(ps.ByCurrency)
+	}
+	for _, ms := range result.ByPaymentMethod {
+		fx.RoundTotals(ms.ByCurrency)
+	}
+	result.Reporting = fx.Normalize(r.FX, r.ReportingCurrency, now, result.ByCurrency)
 
 	return result
 }
 
-func roundTo2(v float64) float64 {
-	return float64(int(v*100+0.5)) / 100
+func processorReversalWindow(processors []model.Processor, id string) *model.ReversalPolicy {
+	for _, p := range processors {
+		if p.ID == id {
+			return p.ReversalWindow
+		}
+	}
+	return nil
 }
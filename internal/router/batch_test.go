@@ -2,16 +2,38 @@ package router
 
 import (
 	"fmt"
+	"math"
+	"slices"
+	"strings"
 	"testing"
 	"time"
 
+	"github.com/ivanjtm/YunoChallenge/internal/fx"
 	"github.com/ivanjtm/YunoChallenge/internal/model"
+	"github.com/ivanjtm/YunoChallenge/internal/money"
+	"github.com/ivanjtm/YunoChallenge/internal/quota"
 )
 
 func newTestRouter() *Router {
 	return NewRouter(allProcessors(), allCompatRules())
 }
 
+func roundTo2(v float64) float64 {
+	return math.Round(v*100) / 100
+}
+
+func newTestFX(t *testing.T) *fx.TableProvider {
+	t.Helper()
+	provider, err := fx.NewTableProvider([]fx.RateTable{{
+		Date: "2025-01-01", Base: model.CurrencyUSD,
+		Rates: map[model.Currency]float64{model.CurrencyBRL: 5.0, model.CurrencyMXN: 20.0, model.CurrencyCOP: 4000.0},
+	}})
+	if err != nil {
+		t.Fatalf("NewTableProvider() error = %v", err)
+	}
+	return provider
+}
+
 func TestAnalyzeBatch_SingleTransaction(t *testing.T) {
 	t.Parallel()
 
@@ -25,7 +47,7 @@ func TestAnalyzeBatch_SingleTransaction(t *testing.T) {
 			Currency:      model.CurrencyBRL,
 			PaymentMethod: model.MethodPIX,
 			ProcessorID:   "paybr",
-			Amount:        200.0,
+			Amount:        money.FromFloat(200.0),
 			Timestamp:     now.Add(-48 * time.Hour),
 			Settled:       true,
 		},
@@ -48,14 +70,15 @@ func TestAnalyzeBatch_SingleTransaction(t *testing.T) {
 		t.Errorf("batch route processor = %s, single route processor = %s",
 			result.Results[0].Selected.ProcessorID, singleRoute.Selected.ProcessorID)
 	}
-	if !almostEqual(result.TotalNaiveCost, singleRoute.NaiveCost) {
-		t.Errorf("TotalNaiveCost = %.2f, want %.2f", result.TotalNaiveCost, singleRoute.NaiveCost)
+	brl := result.ByCurrency["BRL"]
+	if !almostEqual(brl.NaiveCost.Float64(), singleRoute.NaiveCost.Float64()) {
+		t.Errorf("BRL NaiveCost = %s, want %s", brl.NaiveCost, singleRoute.NaiveCost)
 	}
-	if !almostEqual(result.TotalSmartCost, singleRoute.Selected.EstimatedCost) {
-		t.Errorf("TotalSmartCost = %.2f, want %.2f", result.TotalSmartCost, singleRoute.Selected.EstimatedCost)
+	if !almostEqual(brl.SmartCost.Float64(), singleRoute.Selected.EstimatedCost.Float64()) {
+		t.Errorf("BRL SmartCost = %s, want %s", brl.SmartCost, singleRoute.Selected.EstimatedCost)
 	}
-	if !almostEqual(result.TotalSavings, singleRoute.Savings) {
-		t.Errorf("TotalSavings = %.2f, want %.2f", result.TotalSavings, singleRoute.Savings)
+	if !almostEqual(brl.Savings.Float64(), singleRoute.Savings.Float64()) {
+		t.Errorf("BRL Savings = %s, want %s", brl.Savings, singleRoute.Savings)
 	}
 }
 
@@ -68,17 +91,17 @@ func TestAnalyzeBatch_MultipleTransactions(t *testing.T) {
 	txns := []model.Transaction{
 		{
 			ID: "tx-1", Country: model.CountryBR, Currency: model.CurrencyBRL,
-			PaymentMethod: model.MethodPIX, ProcessorID: "paybr", Amount: 200.0,
+			PaymentMethod: model.MethodPIX, ProcessorID: "paybr", Amount: money.FromFloat(200.0),
 			Timestamp: now.Add(-48 * time.Hour), Settled: true,
 		},
 		{
 			ID: "tx-2", Country: model.CountryMX, Currency: model.CurrencyMXN,
-			PaymentMethod: model.MethodCreditCard, ProcessorID: "mexpay", Amount: 1000.0,
+			PaymentMethod: model.MethodCreditCard, ProcessorID: "mexpay", Amount: money.FromFloat(1000.0),
 			Timestamp: now.Add(-3 * time.Hour), Settled: false,
 		},
 		{
 			ID: "tx-3", Country: model.CountryCO, Currency: model.CurrencyCOP,
-			PaymentMethod: model.MethodPSE, ProcessorID: "colpay", Amount: 50000.0,
+			PaymentMethod: model.MethodPSE, ProcessorID: "colpay", Amount: money.FromFloat(50000.0),
 			Timestamp: now.Add(-10 * 24 * time.Hour), Settled: true,
 		},
 	}
@@ -120,17 +143,8 @@ func TestAnalyzeBatch_EmptyBatch(t *testing.T) {
 	if len(result.Results) != 0 {
 		t.Errorf("len(Results) = %d, want 0", len(result.Results))
 	}
-	if result.TotalNaiveCost != 0 {
-		t.Errorf("TotalNaiveCost = %.2f, want 0", result.TotalNaiveCost)
-	}
-	if result.TotalSmartCost != 0 {
-		t.Errorf("TotalSmartCost = %.2f, want 0", result.TotalSmartCost)
-	}
-	if result.TotalSavings != 0 {
-		t.Errorf("TotalSavings = %.2f, want 0", result.TotalSavings)
-	}
-	if result.SavingsPercent != 0 {
-		t.Errorf("SavingsPercent = %.2f, want 0", result.SavingsPercent)
+	if len(result.ByCurrency) != 0 {
+		t.Errorf("ByCurrency = %+v, want empty", result.ByCurrency)
 	}
 	if result.ByProcessor == nil {
 		t.Error("ByProcessor is nil, want non-nil map")
@@ -183,48 +197,36 @@ func TestAnalyzeBatch_SavingsAccumulation(t *testing.T) {
 	txns := []model.Transaction{
 		{
 			ID: "tx-s1", Country: model.CountryBR, Currency: model.CurrencyBRL,
-			PaymentMethod: model.MethodPIX, ProcessorID: "paybr", Amount: 200.0,
+			PaymentMethod: model.MethodPIX, ProcessorID: "paybr", Amount: money.FromFloat(200.0),
 			Timestamp: now.Add(-48 * time.Hour), Settled: true,
 		},
 		{
 			ID: "tx-s2", Country: model.CountryMX, Currency: model.CurrencyMXN,
-			PaymentMethod: model.MethodSPEI, ProcessorID: "mexpay", Amount: 500.0,
+			PaymentMethod: model.MethodSPEI, ProcessorID: "mexpay", Amount: money.FromFloat(500.0),
 			Timestamp: now.Add(-48 * time.Hour), Settled: true,
 		},
 		{
 			ID: "tx-s3", Country: model.CountryCO, Currency: model.CurrencyCOP,
-			PaymentMethod: model.MethodCreditCard, ProcessorID: "colpay", Amount: 100000.0,
+			PaymentMethod: model.MethodCreditCard, ProcessorID: "colpay", Amount: money.FromFloat(100000.0),
 			Timestamp: now.Add(-3 * time.Hour), Settled: false,
 		},
 	}
 
 	result := r.AnalyzeBatch(txns, now)
 
-	var expectedNaive, expectedSmart, expectedSavings float64
+	expected := make(map[string]model.CurrencyTotals)
 	for _, tx := range txns {
 		route := r.SelectRoute(tx, now)
-		expectedNaive += route.NaiveCost
-		expectedSmart += route.Selected.EstimatedCost
-		expectedSavings += route.Savings
+		fx.AddToCurrency(expected, tx.Currency, route.NaiveCost, route.Selected.EstimatedCost)
 	}
-	expectedNaive = roundTo2(expectedNaive)
-	expectedSmart = roundTo2(expectedSmart)
-	expectedSavings = roundTo2(expectedSavings)
+	fx.RoundTotals(expected)
 
-	if !almostEqual(result.TotalNaiveCost, expectedNaive) {
-		t.Errorf("TotalNaiveCost = %.2f, want %.2f", result.TotalNaiveCost, expectedNaive)
-	}
-	if !almostEqual(result.TotalSmartCost, expectedSmart) {
-		t.Errorf("TotalSmartCost = %.2f, want %.2f", result.TotalSmartCost, expectedSmart)
+	if len(result.ByCurrency) != 3 {
+		t.Fatalf("ByCurrency has %d currencies, want 3", len(result.ByCurrency))
 	}
-	if !almostEqual(result.TotalSavings, expectedSavings) {
-		t.Errorf("TotalSavings = %.2f, want %.2f", result.TotalSavings, expectedSavings)
-	}
-
-	if result.TotalNaiveCost > 0 {
-		expectedPct := roundTo2((result.TotalSavings / result.TotalNaiveCost) * 100)
-		if !almostEqual(result.SavingsPercent, expectedPct) {
-			t.Errorf("SavingsPercent = %.2f, want %.2f", result.SavingsPercent, expectedPct)
+	for cur, want := range expected {
+		if got := result.ByCurrency[cur]; got != want {
+			t.Errorf("ByCurrency[%s] = %+v, want %+v", cur, got, want)
 		}
 	}
 }
@@ -238,17 +240,17 @@ func TestAnalyzeBatch_SavingsByProcessor(t *testing.T) {
 	txns := []model.Transaction{
 		{
 			ID: "tx-bp1", Country: model.CountryBR, Currency: model.CurrencyBRL,
-			PaymentMethod: model.MethodPIX, ProcessorID: "paybr", Amount: 200.0,
+			PaymentMethod: model.MethodPIX, ProcessorID: "paybr", Amount: money.FromFloat(200.0),
 			Timestamp: now.Add(-48 * time.Hour), Settled: true,
 		},
 		{
 			ID: "tx-bp2", Country: model.CountryBR, Currency: model.CurrencyBRL,
-			PaymentMethod: model.MethodCreditCard, ProcessorID: "paybr", Amount: 500.0,
+			PaymentMethod: model.MethodCreditCard, ProcessorID: "paybr", Amount: money.FromFloat(500.0),
 			Timestamp: now.Add(-48 * time.Hour), Settled: true,
 		},
 		{
 			ID: "tx-bp3", Country: model.CountryMX, Currency: model.CurrencyMXN,
-			PaymentMethod: model.MethodCreditCard, ProcessorID: "mexpay", Amount: 1000.0,
+			PaymentMethod: model.MethodCreditCard, ProcessorID: "mexpay", Amount: money.FromFloat(1000.0),
 			Timestamp: now.Add(-48 * time.Hour), Settled: true,
 		},
 	}
@@ -278,12 +280,16 @@ func TestAnalyzeBatch_SavingsByProcessor(t *testing.T) {
 		t.Errorf("mexpay TransactionCount = %d, want 1", mexpay.TransactionCount)
 	}
 
-	var totalProcSavings float64
+	procSavings := make(map[string]money.Amount)
 	for _, ps := range result.ByProcessor {
-		totalProcSavings += ps.Savings
+		for cur, ct := range ps.ByCurrency {
+			procSavings[cur] += ct.Savings
+		}
 	}
-	if !almostEqual(totalProcSavings, result.TotalSavings) {
-		t.Errorf("sum of processor savings = %.2f, TotalSavings = %.2f", totalProcSavings, result.TotalSavings)
+	for cur, ct := range result.ByCurrency {
+		if procSavings[cur] != ct.Savings {
+			t.Errorf("sum of %s processor savings = %s, ByCurrency savings = %s", cur, procSavings[cur], ct.Savings)
+		}
 	}
 }
 
@@ -296,17 +302,17 @@ func TestAnalyzeBatch_SavingsByMethod(t *testing.T) {
 	txns := []model.Transaction{
 		{
 			ID: "tx-bm1", Country: model.CountryBR, Currency: model.CurrencyBRL,
-			PaymentMethod: model.MethodPIX, ProcessorID: "paybr", Amount: 300.0,
+			PaymentMethod: model.MethodPIX, ProcessorID: "paybr", Amount: money.FromFloat(300.0),
 			Timestamp: now.Add(-48 * time.Hour), Settled: true,
 		},
 		{
 			ID: "tx-bm2", Country: model.CountryBR, Currency: model.CurrencyBRL,
-			PaymentMethod: model.MethodPIX, ProcessorID: "paybr", Amount: 700.0,
+			PaymentMethod: model.MethodPIX, ProcessorID: "paybr", Amount: money.FromFloat(700.0),
 			Timestamp: now.Add(-48 * time.Hour), Settled: true,
 		},
 		{
 			ID: "tx-bm3", Country: model.CountryMX, Currency: model.CurrencyMXN,
-			PaymentMethod: model.MethodOXXO, ProcessorID: "mexpay", Amount: 500.0,
+			PaymentMethod: model.MethodOXXO, ProcessorID: "mexpay", Amount: money.FromFloat(500.0),
 			Timestamp: now.Add(-5 * 24 * time.Hour), Settled: true,
 		},
 	}
@@ -336,12 +342,16 @@ func TestAnalyzeBatch_SavingsByMethod(t *testing.T) {
 		t.Errorf("OXXO TransactionCount = %d, want 1", oxxo.TransactionCount)
 	}
 
-	var totalMethodSavings float64
+	methodSavings := make(map[string]money.Amount)
 	for _, ms := range result.ByPaymentMethod {
-		totalMethodSavings += ms.Savings
+		for cur, ct := range ms.ByCurrency {
+			methodSavings[cur] += ct.Savings
+		}
 	}
-	if !almostEqual(totalMethodSavings, result.TotalSavings) {
-		t.Errorf("sum of method savings = %.2f, TotalSavings = %.2f", totalMethodSavings, result.TotalSavings)
+	for cur, ct := range result.ByCurrency {
+		if methodSavings[cur] != ct.Savings {
+			t.Errorf("sum of %s method savings = %s, ByCurrency savings = %s", cur, methodSavings[cur], ct.Savings)
+		}
 	}
 }
 
@@ -354,7 +364,7 @@ func TestAnalyzeBatch_TimeSensitive_PIXNearExpiry(t *testing.T) {
 	txns := []model.Transaction{
 		{
 			ID: "tx-pix-expiring", Country: model.CountryBR, Currency: model.CurrencyBRL,
-			PaymentMethod: model.MethodPIX, ProcessorID: "paybr", Amount: 200.0,
+			PaymentMethod: model.MethodPIX, ProcessorID: "paybr", Amount: money.FromFloat(200.0),
 			Timestamp: now.Add(-85 * 24 * time.Hour), Settled: true,
 		},
 	}
@@ -388,7 +398,7 @@ func TestAnalyzeBatch_TimeSensitive_ReversalNearExpiry(t *testing.T) {
 	txns := []model.Transaction{
 		{
 			ID: "tx-reversal-expiring", Country: model.CountryBR, Currency: model.CurrencyBRL,
-			PaymentMethod: model.MethodPIX, ProcessorID: "paybr", Amount: 200.0,
+			PaymentMethod: model.MethodPIX, ProcessorID: "paybr", Amount: money.FromFloat(200.0),
 			Timestamp: now.Add(-20 * time.Hour), Settled: false,
 		},
 	}
@@ -419,7 +429,7 @@ func TestAnalyzeBatch_TimeSensitive_NoFlagWhenFarFromExpiry(t *testing.T) {
 	txns := []model.Transaction{
 		{
 			ID: "tx-pix-fresh", Country: model.CountryBR, Currency: model.CurrencyBRL,
-			PaymentMethod: model.MethodPIX, ProcessorID: "paybr", Amount: 200.0,
+			PaymentMethod: model.MethodPIX, ProcessorID: "paybr", Amount: money.FromFloat(200.0),
 			Timestamp: now.Add(-10 * 24 * time.Hour), Settled: true,
 		},
 	}
@@ -448,7 +458,7 @@ func TestAnalyzeBatch_LimitedOptions(t *testing.T) {
 			name: "OXXO flagged as limited",
 			tx: model.Transaction{
 				ID: "tx-oxxo-ltd", Country: model.CountryMX, Currency: model.CurrencyMXN,
-				PaymentMethod: model.MethodOXXO, ProcessorID: "mexpay", Amount: 500.0,
+				PaymentMethod: model.MethodOXXO, ProcessorID: "mexpay", Amount: money.FromFloat(500.0),
 				Timestamp: now.Add(-5 * 24 * time.Hour), Settled: true,
 			},
 			method: "OXXO",
@@ -457,7 +467,7 @@ func TestAnalyzeBatch_LimitedOptions(t *testing.T) {
 			name: "BOLETO flagged as limited",
 			tx: model.Transaction{
 				ID: "tx-boleto-ltd", Country: model.CountryBR, Currency: model.CurrencyBRL,
-				PaymentMethod: model.MethodBoleto, ProcessorID: "paybr", Amount: 300.0,
+				PaymentMethod: model.MethodBoleto, ProcessorID: "paybr", Amount: money.FromFloat(300.0),
 				Timestamp: now.Add(-5 * 24 * time.Hour), Settled: true,
 			},
 			method: "BOLETO",
@@ -466,7 +476,7 @@ func TestAnalyzeBatch_LimitedOptions(t *testing.T) {
 			name: "EFECTY flagged as limited",
 			tx: model.Transaction{
 				ID: "tx-efecty-ltd", Country: model.CountryCO, Currency: model.CurrencyCOP,
-				PaymentMethod: model.MethodEfecty, ProcessorID: "colpay", Amount: 50000.0,
+				PaymentMethod: model.MethodEfecty, ProcessorID: "colpay", Amount: money.FromFloat(50000.0),
 				Timestamp: now.Add(-5 * 24 * time.Hour), Settled: true,
 			},
 			method: "EFECTY",
@@ -514,17 +524,17 @@ func TestAnalyzeBatch_NoLimitedOptionsForRegularMethods(t *testing.T) {
 	txns := []model.Transaction{
 		{
 			ID: "tx-pix-nolimit", Country: model.CountryBR, Currency: model.CurrencyBRL,
-			PaymentMethod: model.MethodPIX, ProcessorID: "paybr", Amount: 200.0,
+			PaymentMethod: model.MethodPIX, ProcessorID: "paybr", Amount: money.FromFloat(200.0),
 			Timestamp: now.Add(-48 * time.Hour), Settled: true,
 		},
 		{
 			ID: "tx-cc-nolimit", Country: model.CountryMX, Currency: model.CurrencyMXN,
-			PaymentMethod: model.MethodCreditCard, ProcessorID: "mexpay", Amount: 1000.0,
+			PaymentMethod: model.MethodCreditCard, ProcessorID: "mexpay", Amount: money.FromFloat(1000.0),
 			Timestamp: now.Add(-3 * time.Hour), Settled: false,
 		},
 		{
 			ID: "tx-spei-nolimit", Country: model.CountryMX, Currency: model.CurrencyMXN,
-			PaymentMethod: model.MethodSPEI, ProcessorID: "mexpay", Amount: 800.0,
+			PaymentMethod: model.MethodSPEI, ProcessorID: "mexpay", Amount: money.FromFloat(800.0),
 			Timestamp: now.Add(-48 * time.Hour), Settled: true,
 		},
 	}
@@ -546,7 +556,7 @@ func TestAnalyzeBatch_ResultOrderMatchesInput(t *testing.T) {
 	for i := range txns {
 		txns[i] = model.Transaction{
 			ID: fmt.Sprintf("tx-order-%d", i), Country: model.CountryBR, Currency: model.CurrencyBRL,
-			PaymentMethod: model.MethodPIX, ProcessorID: "paybr", Amount: float64(100 + i*50),
+			PaymentMethod: model.MethodPIX, ProcessorID: "paybr", Amount: money.FromFloat(float64(100 + i*50)),
 			Timestamp: now.Add(-48 * time.Hour), Settled: true,
 		}
 	}
@@ -592,7 +602,7 @@ func TestAnalyzeBatch_Concurrency_ResultCountMatchesInput(t *testing.T) {
 		m := methods[i%len(methods)]
 		txns[i] = model.Transaction{
 			ID: fmt.Sprintf("tx-conc-%d", i), Country: m.c, Currency: m.u,
-			PaymentMethod: m.m, ProcessorID: m.p, Amount: float64(100 + i*10),
+			PaymentMethod: m.m, ProcessorID: m.p, Amount: money.FromFloat(float64(100 + i*10)),
 			Timestamp: now.Add(-time.Duration(i+1) * 24 * time.Hour), Settled: i%2 == 0,
 		}
 	}
@@ -630,7 +640,7 @@ func TestAnalyzeBatch_Concurrency_Deterministic(t *testing.T) {
 	for i := 0; i < n; i++ {
 		txns[i] = model.Transaction{
 			ID: fmt.Sprintf("tx-det-%d", i), Country: model.CountryBR, Currency: model.CurrencyBRL,
-			PaymentMethod: model.MethodPIX, ProcessorID: "paybr", Amount: float64(100 + i*25),
+			PaymentMethod: model.MethodPIX, ProcessorID: "paybr", Amount: money.FromFloat(float64(100 + i*25)),
 			Timestamp: now.Add(-48 * time.Hour), Settled: true,
 		}
 	}
@@ -638,17 +648,8 @@ func TestAnalyzeBatch_Concurrency_Deterministic(t *testing.T) {
 	result1 := r.AnalyzeBatch(txns, now)
 	result2 := r.AnalyzeBatch(txns, now)
 
-	if !almostEqual(result1.TotalNaiveCost, result2.TotalNaiveCost) {
-		t.Errorf("run1 TotalNaiveCost = %.2f, run2 = %.2f", result1.TotalNaiveCost, result2.TotalNaiveCost)
-	}
-	if !almostEqual(result1.TotalSmartCost, result2.TotalSmartCost) {
-		t.Errorf("run1 TotalSmartCost = %.2f, run2 = %.2f", result1.TotalSmartCost, result2.TotalSmartCost)
-	}
-	if !almostEqual(result1.TotalSavings, result2.TotalSavings) {
-		t.Errorf("run1 TotalSavings = %.2f, run2 = %.2f", result1.TotalSavings, result2.TotalSavings)
-	}
-	if !almostEqual(result1.SavingsPercent, result2.SavingsPercent) {
-		t.Errorf("run1 SavingsPercent = %.2f, run2 = %.2f", result1.SavingsPercent, result2.SavingsPercent)
+	if brl1, brl2 := result1.ByCurrency["BRL"], result2.ByCurrency["BRL"]; brl1 != brl2 {
+		t.Errorf("run1 BRL totals = %+v, run2 = %+v", brl1, brl2)
 	}
 
 	for i := 0; i < n; i++ {
@@ -660,8 +661,8 @@ func TestAnalyzeBatch_Concurrency_Deterministic(t *testing.T) {
 			t.Errorf("Results[%d] Selected.ProcessorID mismatch: %s vs %s",
 				i, result1.Results[i].Selected.ProcessorID, result2.Results[i].Selected.ProcessorID)
 		}
-		if !almostEqual(result1.Results[i].Selected.EstimatedCost, result2.Results[i].Selected.EstimatedCost) {
-			t.Errorf("Results[%d] Selected.EstimatedCost mismatch: %.2f vs %.2f",
+		if !almostEqual(result1.Results[i].Selected.EstimatedCost.Float64(), result2.Results[i].Selected.EstimatedCost.Float64()) {
+			t.Errorf("Results[%d] Selected.EstimatedCost mismatch: %s vs %s",
 				i, result1.Results[i].Selected.EstimatedCost, result2.Results[i].Selected.EstimatedCost)
 		}
 	}
@@ -699,7 +700,7 @@ func TestAnalyzeBatch_Concurrency_MixedPaymentMethods(t *testing.T) {
 		s := scenarios[i%len(scenarios)]
 		txns[i] = model.Transaction{
 			ID: fmt.Sprintf("tx-mix-%d", i), Country: s.country, Currency: s.currency,
-			PaymentMethod: s.method, ProcessorID: s.processor, Amount: float64(200 + i*5),
+			PaymentMethod: s.method, ProcessorID: s.processor, Amount: money.FromFloat(float64(200 + i*5)),
 			Timestamp: now.Add(-time.Duration(s.ageHours) * time.Hour), Settled: s.settled,
 		}
 	}
@@ -716,14 +717,10 @@ func TestAnalyzeBatch_Concurrency_MixedPaymentMethods(t *testing.T) {
 		}
 	}
 
-	if result.TotalNaiveCost < 0 {
-		t.Errorf("TotalNaiveCost = %.2f, want >= 0", result.TotalNaiveCost)
-	}
-	if result.TotalSmartCost < 0 {
-		t.Errorf("TotalSmartCost = %.2f, want >= 0", result.TotalSmartCost)
-	}
-	if result.TotalSavings < 0 {
-		t.Errorf("TotalSavings = %.2f, want >= 0", result.TotalSavings)
+	for cur, ct := range result.ByCurrency {
+		if ct.NaiveCost < 0 || ct.SmartCost < 0 || ct.Savings < 0 {
+			t.Errorf("ByCurrency[%s] = %+v, want non-negative totals", cur, ct)
+		}
 	}
 }
 
@@ -736,46 +733,38 @@ func TestAnalyzeBatch_SavingsEqualsSumOfIndividual(t *testing.T) {
 	txns := []model.Transaction{
 		{
 			ID: "tx-sum1", Country: model.CountryBR, Currency: model.CurrencyBRL,
-			PaymentMethod: model.MethodPIX, ProcessorID: "paybr", Amount: 150.0,
+			PaymentMethod: model.MethodPIX, ProcessorID: "paybr", Amount: money.FromFloat(150.0),
 			Timestamp: now.Add(-48 * time.Hour), Settled: true,
 		},
 		{
 			ID: "tx-sum2", Country: model.CountryMX, Currency: model.CurrencyMXN,
-			PaymentMethod: model.MethodCreditCard, ProcessorID: "mexpay", Amount: 2000.0,
+			PaymentMethod: model.MethodCreditCard, ProcessorID: "mexpay", Amount: money.FromFloat(2000.0),
 			Timestamp: now.Add(-72 * time.Hour), Settled: true,
 		},
 		{
 			ID: "tx-sum3", Country: model.CountryCO, Currency: model.CurrencyCOP,
-			PaymentMethod: model.MethodEfecty, ProcessorID: "colpay", Amount: 75000.0,
+			PaymentMethod: model.MethodEfecty, ProcessorID: "colpay", Amount: money.FromFloat(75000.0),
 			Timestamp: now.Add(-10 * 24 * time.Hour), Settled: true,
 		},
 		{
 			ID: "tx-sum4", Country: model.CountryBR, Currency: model.CurrencyBRL,
-			PaymentMethod: model.MethodCreditCard, ProcessorID: "paybr", Amount: 800.0,
+			PaymentMethod: model.MethodCreditCard, ProcessorID: "paybr", Amount: money.FromFloat(800.0),
 			Timestamp: now.Add(-2 * time.Hour), Settled: false,
 		},
 	}
 
 	result := r.AnalyzeBatch(txns, now)
 
-	var sumNaive, sumSmart, sumSavings float64
-	for _, rr := range result.Results {
-		sumNaive += rr.NaiveCost
-		sumSmart += rr.Selected.EstimatedCost
-		sumSavings += rr.Savings
+	sums := make(map[string]model.CurrencyTotals)
+	for i, rr := range result.Results {
+		fx.AddToCurrency(sums, txns[i].Currency, rr.NaiveCost, rr.Selected.EstimatedCost)
 	}
-	sumNaive = roundTo2(sumNaive)
-	sumSmart = roundTo2(sumSmart)
-	sumSavings = roundTo2(sumSavings)
+	fx.RoundTotals(sums)
 
-	if !almostEqual(result.TotalNaiveCost, sumNaive) {
-		t.Errorf("TotalNaiveCost = %.2f, sum of individual NaiveCost = %.2f", result.TotalNaiveCost, sumNaive)
-	}
-	if !almostEqual(result.TotalSmartCost, sumSmart) {
-		t.Errorf("TotalSmartCost = %.2f, sum of individual SmartCost = %.2f", result.TotalSmartCost, sumSmart)
-	}
-	if !almostEqual(result.TotalSavings, sumSavings) {
-		t.Errorf("TotalSavings = %.2f, sum of individual Savings = %.2f", result.TotalSavings, sumSavings)
+	for cur, want := range sums {
+		if got := result.ByCurrency[cur]; got != want {
+			t.Errorf("ByCurrency[%s] = %+v, sum of individual routes = %+v", cur, got, want)
+		}
 	}
 }
 
@@ -788,17 +777,17 @@ func TestAnalyzeBatch_ByProcessorCostConsistency(t *testing.T) {
 	txns := []model.Transaction{
 		{
 			ID: "tx-pc1", Country: model.CountryBR, Currency: model.CurrencyBRL,
-			PaymentMethod: model.MethodPIX, ProcessorID: "paybr", Amount: 200.0,
+			PaymentMethod: model.MethodPIX, ProcessorID: "paybr", Amount: money.FromFloat(200.0),
 			Timestamp: now.Add(-48 * time.Hour), Settled: true,
 		},
 		{
 			ID: "tx-pc2", Country: model.CountryMX, Currency: model.CurrencyMXN,
-			PaymentMethod: model.MethodCreditCard, ProcessorID: "mexpay", Amount: 1000.0,
+			PaymentMethod: model.MethodCreditCard, ProcessorID: "mexpay", Amount: money.FromFloat(1000.0),
 			Timestamp: now.Add(-72 * time.Hour), Settled: true,
 		},
 		{
 			ID: "tx-pc3", Country: model.CountryCO, Currency: model.CurrencyCOP,
-			PaymentMethod: model.MethodPSE, ProcessorID: "colpay", Amount: 50000.0,
+			PaymentMethod: model.MethodPSE, ProcessorID: "colpay", Amount: money.FromFloat(50000.0),
 			Timestamp: now.Add(-10 * 24 * time.Hour), Settled: true,
 		},
 	}
@@ -806,10 +795,12 @@ func TestAnalyzeBatch_ByProcessorCostConsistency(t *testing.T) {
 	result := r.AnalyzeBatch(txns, now)
 
 	for procID, ps := range result.ByProcessor {
-		expectedSavings := ps.NaiveCost - ps.SmartCost
-		if !almostEqual(ps.Savings, expectedSavings) {
-			t.Errorf("ByProcessor[%s] Savings = %.2f, want NaiveCost(%.2f) - SmartCost(%.2f) = %.2f",
-				procID, ps.Savings, ps.NaiveCost, ps.SmartCost, expectedSavings)
+		for cur, ct := range ps.ByCurrency {
+			expectedSavings := ct.NaiveCost - ct.SmartCost
+			if !almostEqual(ct.Savings.Float64(), expectedSavings.Float64()) {
+				t.Errorf("ByProcessor[%s][%s] Savings = %s, want NaiveCost(%s) - SmartCost(%s) = %s",
+					procID, cur, ct.Savings, ct.NaiveCost, ct.SmartCost, expectedSavings)
+			}
 		}
 	}
 }
@@ -823,12 +814,12 @@ func TestAnalyzeBatch_ByMethodCostConsistency(t *testing.T) {
 	txns := []model.Transaction{
 		{
 			ID: "tx-mc1", Country: model.CountryBR, Currency: model.CurrencyBRL,
-			PaymentMethod: model.MethodPIX, ProcessorID: "paybr", Amount: 200.0,
+			PaymentMethod: model.MethodPIX, ProcessorID: "paybr", Amount: money.FromFloat(200.0),
 			Timestamp: now.Add(-48 * time.Hour), Settled: true,
 		},
 		{
 			ID: "tx-mc2", Country: model.CountryMX, Currency: model.CurrencyMXN,
-			PaymentMethod: model.MethodCreditCard, ProcessorID: "mexpay", Amount: 1000.0,
+			PaymentMethod: model.MethodCreditCard, ProcessorID: "mexpay", Amount: money.FromFloat(1000.0),
 			Timestamp: now.Add(-72 * time.Hour), Settled: true,
 		},
 	}
@@ -836,10 +827,12 @@ func TestAnalyzeBatch_ByMethodCostConsistency(t *testing.T) {
 	result := r.AnalyzeBatch(txns, now)
 
 	for method, ms := range result.ByPaymentMethod {
-		expectedSavings := ms.NaiveCost - ms.SmartCost
-		if !almostEqual(ms.Savings, expectedSavings) {
-			t.Errorf("ByPaymentMethod[%s] Savings = %.2f, want NaiveCost(%.2f) - SmartCost(%.2f) = %.2f",
-				method, ms.Savings, ms.NaiveCost, ms.SmartCost, expectedSavings)
+		for cur, ct := range ms.ByCurrency {
+			expectedSavings := ct.NaiveCost - ct.SmartCost
+			if !almostEqual(ct.Savings.Float64(), expectedSavings.Float64()) {
+				t.Errorf("ByPaymentMethod[%s][%s] Savings = %s, want NaiveCost(%s) - SmartCost(%s) = %s",
+					method, cur, ct.Savings, ct.NaiveCost, ct.SmartCost, expectedSavings)
+			}
 		}
 	}
 }
@@ -849,22 +842,30 @@ func TestAnalyzeBatch_SavingsPercentCalculation(t *testing.T) {
 
 	now := time.Date(2025, 6, 15, 12, 0, 0, 0, time.UTC)
 	r := newTestRouter()
+	r.FX = newTestFX(t)
 
 	txns := []model.Transaction{
 		{
 			ID: "tx-pct1", Country: model.CountryBR, Currency: model.CurrencyBRL,
-			PaymentMethod: model.MethodCreditCard, ProcessorID: "paybr", Amount: 1000.0,
+			PaymentMethod: model.MethodCreditCard, ProcessorID: "paybr", Amount: money.FromFloat(1000.0),
 			Timestamp: now.Add(-2 * time.Hour), Settled: false,
 		},
+		{
+			ID: "tx-pct2", Country: model.CountryMX, Currency: model.CurrencyMXN,
+			PaymentMethod: model.MethodCreditCard, ProcessorID: "mexpay", Amount: money.FromFloat(1000.0),
+			Timestamp: now.Add(-48 * time.Hour), Settled: true,
+		},
 	}
 
 	result := r.AnalyzeBatch(txns, now)
 
-	if result.TotalNaiveCost > 0 {
-		expectedPct := roundTo2((result.TotalSavings / result.TotalNaiveCost) * 100)
-		if !almostEqual(result.SavingsPercent, expectedPct) {
-			t.Errorf("SavingsPercent = %.2f, want %.2f", result.SavingsPercent, expectedPct)
-		}
+	rep := result.Reporting
+	if rep == nil || rep.NaiveCost <= 0 {
+		t.Fatalf("Reporting = %+v, want converted naive cost", rep)
+	}
+	expectedPct := roundTo2(rep.Savings.Ratio(rep.NaiveCost) * 100)
+	if !almostEqual(rep.SavingsPercent, expectedPct) {
+		t.Errorf("Reporting.SavingsPercent = %.2f, want %.2f", rep.SavingsPercent, expectedPct)
 	}
 }
 
@@ -874,11 +875,12 @@ func TestAnalyzeBatch_SavingsPercentZeroWhenNoNaiveCost(t *testing.T) {
 	now := time.Date(2025, 6, 15, 12, 0, 0, 0, time.UTC)
 
 	r := NewRouter(nil, nil)
+	r.FX = newTestFX(t)
 
 	result := r.AnalyzeBatch(nil, now)
 
-	if result.SavingsPercent != 0 {
-		t.Errorf("SavingsPercent = %.2f, want 0 when no transactions", result.SavingsPercent)
+	if result.Reporting == nil || result.Reporting.SavingsPercent != 0 {
+		t.Errorf("Reporting = %+v, want SavingsPercent 0 when no transactions", result.Reporting)
 	}
 }
 
@@ -891,7 +893,7 @@ func TestAnalyzeBatch_LimitedOptionsMessage(t *testing.T) {
 	txns := []model.Transaction{
 		{
 			ID: "tx-oxxo-msg", Country: model.CountryMX, Currency: model.CurrencyMXN,
-			PaymentMethod: model.MethodOXXO, ProcessorID: "mexpay", Amount: 500.0,
+			PaymentMethod: model.MethodOXXO, ProcessorID: "mexpay", Amount: money.FromFloat(500.0),
 			Timestamp: now.Add(-5 * 24 * time.Hour), Settled: true,
 		},
 	}
@@ -926,12 +928,12 @@ func TestAnalyzeBatch_MultipleTimeSensitiveFlags(t *testing.T) {
 	txns := []model.Transaction{
 		{
 			ID: "tx-ts1", Country: model.CountryBR, Currency: model.CurrencyBRL,
-			PaymentMethod: model.MethodPIX, ProcessorID: "paybr", Amount: 200.0,
+			PaymentMethod: model.MethodPIX, ProcessorID: "paybr", Amount: money.FromFloat(200.0),
 			Timestamp: now.Add(-85 * 24 * time.Hour), Settled: true,
 		},
 		{
 			ID: "tx-ts2", Country: model.CountryBR, Currency: model.CurrencyBRL,
-			PaymentMethod: model.MethodPIX, ProcessorID: "paybr", Amount: 300.0,
+			PaymentMethod: model.MethodPIX, ProcessorID: "paybr", Amount: money.FromFloat(300.0),
 			Timestamp: now.Add(-20 * time.Hour), Settled: false,
 		},
 	}
@@ -966,22 +968,22 @@ func TestAnalyzeBatch_AllLimitedMethodsInOneBatch(t *testing.T) {
 	txns := []model.Transaction{
 		{
 			ID: "tx-lo-oxxo", Country: model.CountryMX, Currency: model.CurrencyMXN,
-			PaymentMethod: model.MethodOXXO, ProcessorID: "mexpay", Amount: 500.0,
+			PaymentMethod: model.MethodOXXO, ProcessorID: "mexpay", Amount: money.FromFloat(500.0),
 			Timestamp: now.Add(-5 * 24 * time.Hour), Settled: true,
 		},
 		{
 			ID: "tx-lo-boleto", Country: model.CountryBR, Currency: model.CurrencyBRL,
-			PaymentMethod: model.MethodBoleto, ProcessorID: "paybr", Amount: 300.0,
+			PaymentMethod: model.MethodBoleto, ProcessorID: "paybr", Amount: money.FromFloat(300.0),
 			Timestamp: now.Add(-5 * 24 * time.Hour), Settled: true,
 		},
 		{
 			ID: "tx-lo-efecty", Country: model.CountryCO, Currency: model.CurrencyCOP,
-			PaymentMethod: model.MethodEfecty, ProcessorID: "colpay", Amount: 50000.0,
+			PaymentMethod: model.MethodEfecty, ProcessorID: "colpay", Amount: money.FromFloat(50000.0),
 			Timestamp: now.Add(-5 * 24 * time.Hour), Settled: true,
 		},
 		{
 			ID: "tx-lo-pix", Country: model.CountryBR, Currency: model.CurrencyBRL,
-			PaymentMethod: model.MethodPIX, ProcessorID: "paybr", Amount: 200.0,
+			PaymentMethod: model.MethodPIX, ProcessorID: "paybr", Amount: money.FromFloat(200.0),
 			Timestamp: now.Add(-48 * time.Hour), Settled: true,
 		},
 	}
@@ -1016,17 +1018,17 @@ func TestAnalyzeBatch_TransactionCountInSummaries(t *testing.T) {
 	txns := []model.Transaction{
 		{
 			ID: "tx-cnt1", Country: model.CountryBR, Currency: model.CurrencyBRL,
-			PaymentMethod: model.MethodPIX, ProcessorID: "paybr", Amount: 100.0,
+			PaymentMethod: model.MethodPIX, ProcessorID: "paybr", Amount: money.FromFloat(100.0),
 			Timestamp: now.Add(-48 * time.Hour), Settled: true,
 		},
 		{
 			ID: "tx-cnt2", Country: model.CountryBR, Currency: model.CurrencyBRL,
-			PaymentMethod: model.MethodPIX, ProcessorID: "paybr", Amount: 200.0,
+			PaymentMethod: model.MethodPIX, ProcessorID: "paybr", Amount: money.FromFloat(200.0),
 			Timestamp: now.Add(-48 * time.Hour), Settled: true,
 		},
 		{
 			ID: "tx-cnt3", Country: model.CountryBR, Currency: model.CurrencyBRL,
-			PaymentMethod: model.MethodPIX, ProcessorID: "paybr", Amount: 300.0,
+			PaymentMethod: model.MethodPIX, ProcessorID: "paybr", Amount: money.FromFloat(300.0),
 			Timestamp: now.Add(-48 * time.Hour), Settled: true,
 		},
 	}
@@ -1058,12 +1060,12 @@ func TestAnalyzeBatch_RoundedValues(t *testing.T) {
 	txns := []model.Transaction{
 		{
 			ID: "tx-round1", Country: model.CountryBR, Currency: model.CurrencyBRL,
-			PaymentMethod: model.MethodPIX, ProcessorID: "paybr", Amount: 333.33,
+			PaymentMethod: model.MethodPIX, ProcessorID: "paybr", Amount: money.FromFloat(333.33),
 			Timestamp: now.Add(-48 * time.Hour), Settled: true,
 		},
 		{
 			ID: "tx-round2", Country: model.CountryMX, Currency: model.CurrencyMXN,
-			PaymentMethod: model.MethodSPEI, ProcessorID: "mexpay", Amount: 777.77,
+			PaymentMethod: model.MethodSPEI, ProcessorID: "mexpay", Amount: money.FromFloat(777.77),
 			Timestamp: now.Add(-48 * time.Hour), Settled: true,
 		},
 	}
@@ -1077,10 +1079,11 @@ func TestAnalyzeBatch_RoundedValues(t *testing.T) {
 		}
 	}
 
-	checkRounded("TotalNaiveCost", result.TotalNaiveCost)
-	checkRounded("TotalSmartCost", result.TotalSmartCost)
-	checkRounded("TotalSavings", result.TotalSavings)
-	checkRounded("SavingsPercent", result.SavingsPercent)
+	for cur, ct := range result.ByCurrency {
+		checkRounded(cur+" NaiveCost", ct.NaiveCost.Float64())
+		checkRounded(cur+" SmartCost", ct.SmartCost.Float64())
+		checkRounded(cur+" Savings", ct.Savings.Float64())
+	}
 }
 
 func TestAnalyzeBatch_BatchMatchesSingleRoutes(t *testing.T) {
@@ -1092,17 +1095,17 @@ func TestAnalyzeBatch_BatchMatchesSingleRoutes(t *testing.T) {
 	txns := []model.Transaction{
 		{
 			ID: "tx-match1", Country: model.CountryBR, Currency: model.CurrencyBRL,
-			PaymentMethod: model.MethodPIX, ProcessorID: "paybr", Amount: 500.0,
+			PaymentMethod: model.MethodPIX, ProcessorID: "paybr", Amount: money.FromFloat(500.0),
 			Timestamp: now.Add(-2 * time.Hour), Settled: false,
 		},
 		{
 			ID: "tx-match2", Country: model.CountryMX, Currency: model.CurrencyMXN,
-			PaymentMethod: model.MethodOXXO, ProcessorID: "mexpay", Amount: 1000.0,
+			PaymentMethod: model.MethodOXXO, ProcessorID: "mexpay", Amount: money.FromFloat(1000.0),
 			Timestamp: now.Add(-5 * 24 * time.Hour), Settled: true,
 		},
 		{
 			ID: "tx-match3", Country: model.CountryCO, Currency: model.CurrencyCOP,
-			PaymentMethod: model.MethodCreditCard, ProcessorID: "colpay", Amount: 200000.0,
+			PaymentMethod: model.MethodCreditCard, ProcessorID: "colpay", Amount: money.FromFloat(200000.0),
 			Timestamp: now.Add(-100 * 24 * time.Hour), Settled: true,
 		},
 	}
@@ -1121,16 +1124,16 @@ func TestAnalyzeBatch_BatchMatchesSingleRoutes(t *testing.T) {
 			t.Errorf("tx %s: batch RefundMethod = %s, single = %s",
 				tx.ID, batchRoute.Selected.RefundMethod, singleRoute.Selected.RefundMethod)
 		}
-		if !almostEqual(batchRoute.Selected.EstimatedCost, singleRoute.Selected.EstimatedCost) {
-			t.Errorf("tx %s: batch EstimatedCost = %.2f, single = %.2f",
+		if !almostEqual(batchRoute.Selected.EstimatedCost.Float64(), singleRoute.Selected.EstimatedCost.Float64()) {
+			t.Errorf("tx %s: batch EstimatedCost = %s, single = %s",
 				tx.ID, batchRoute.Selected.EstimatedCost, singleRoute.Selected.EstimatedCost)
 		}
-		if !almostEqual(batchRoute.NaiveCost, singleRoute.NaiveCost) {
-			t.Errorf("tx %s: batch NaiveCost = %.2f, single = %.2f",
+		if !almostEqual(batchRoute.NaiveCost.Float64(), singleRoute.NaiveCost.Float64()) {
+			t.Errorf("tx %s: batch NaiveCost = %s, single = %s",
 				tx.ID, batchRoute.NaiveCost, singleRoute.NaiveCost)
 		}
-		if !almostEqual(batchRoute.Savings, singleRoute.Savings) {
-			t.Errorf("tx %s: batch Savings = %.2f, single = %.2f",
+		if !almostEqual(batchRoute.Savings.Float64(), singleRoute.Savings.Float64()) {
+			t.Errorf("tx %s: batch Savings = %s, single = %s",
 				tx.ID, batchRoute.Savings, singleRoute.Savings)
 		}
 		if len(batchRoute.Alternatives) != len(singleRoute.Alternatives) {
@@ -1153,7 +1156,8 @@ func TestRoundTo2(t *testing.T) {
 		{name: "round down", in: 1.554, want: 1.55},
 		{name: "zero", in: 0.0, want: 0.0},
 		{name: "large", in: 123456.789, want: 123456.79},
-		{name: "negative", in: -3.456, want: -3.45},
+		{name: "negative", in: -3.456, want: -3.46},
+		{name: "negative half", in: -0.125, want: -0.13},
 		{name: "many decimals", in: 0.1234567, want: 0.12},
 	}
 
@@ -1167,3 +1171,288 @@ func TestRoundTo2(t *testing.T) {
 		})
 	}
 }
+
+func TestAnalyzeBatch_DoesNotOversubscribeQuota(t *testing.T) {
+	t.Parallel()
+
+	now := time.Date(2025, 6, 15, 12, 0, 0, 0, time.UTC)
+	procs := allProcessors()
+	for i := range procs {
+		if procs[i].ID == "paybr" {
+			procs[i].DailyQuota = 5
+		}
+	}
+	r := NewRouter(procs, allCompatRules())
+	r.Quota = quota.NewTracker(procs)
+
+	txns := make([]model.Transaction, 50)
+	for i := range txns {
+		txns[i] = model.Transaction{
+			ID: fmt.Sprintf("tx-quota-%d", i), Country: model.CountryBR, Currency: model.CurrencyBRL,
+			PaymentMethod: model.MethodPIX, ProcessorID: "globalpay", Amount: money.FromFloat(300.0),
+			Timestamp: now.Add(-48 * time.Hour), Settled: true,
+		}
+	}
+
+	result := r.AnalyzeBatch(txns, now)
+
+	paybrCount := 0
+	for _, route := range result.Results {
+		if route.Selected.ProcessorID == "paybr" {
+			paybrCount++
+		}
+	}
+	if paybrCount != 5 {
+		t.Errorf("paybr selected %d times, want exactly 5 (its daily quota)", paybrCount)
+	}
+	if ok, _ := r.Quota.IsAvailable("paybr", now); ok {
+		t.Error("paybr still available after batch consumed its quota")
+	}
+}
+
+func TestAnalyzeBatchWithOptions_Strategy(t *testing.T) {
+	t.Parallel()
+
+	now := time.Date(2025, 6, 15, 12, 0, 0, 0, time.UTC)
+	r := NewRouter(allProcessors(), allCompatRules())
+
+	txns := []model.Transaction{{
+		ID: "tx-batch-fast", Country: model.CountryMX, Currency: model.CurrencyMXN,
+		PaymentMethod: model.MethodCreditCard, ProcessorID: "mexpay", Amount: money.FromFloat(1000.0),
+		Timestamp: now.Add(-48 * time.Hour), Settled: true,
+	}}
+
+	cheapest := r.AnalyzeBatch(txns, now)
+	fastest := r.AnalyzeBatchWithOptions(txns, RouteOptions{Strategy: model.StrategyFastest}, now)
+
+	if cheapest.Strategy != model.StrategyCheapest || fastest.Strategy != model.StrategyFastest {
+		t.Errorf("Strategy = %s/%s, want cheapest/fastest", cheapest.Strategy, fastest.Strategy)
+	}
+	if fastest.Results[0].Selected.ProcessingDays > cheapest.Results[0].Selected.ProcessingDays {
+		t.Errorf("fastest selected %d days, cheapest %d days", fastest.Results[0].Selected.ProcessingDays, cheapest.Results[0].Selected.ProcessingDays)
+	}
+	if fast, cheap := fastest.ByCurrency["MXN"].SmartCost, cheapest.ByCurrency["MXN"].SmartCost; fast < cheap {
+		t.Errorf("fastest MXN SmartCost %s below cheapest %s", fast, cheap)
+	}
+}
+
+func TestAnalyzeBatch_PerCurrencyAndReportingTotals(t *testing.T) {
+	t.Parallel()
+
+	now := time.Date(2025, 6, 15, 12, 0, 0, 0, time.UTC)
+	r := NewRouter(allProcessors(), allCompatRules())
+	provider, err := fx.NewTableProvider([]fx.RateTable{{
+		Date: "2025-01-01", Base: model.CurrencyUSD,
+		Rates: map[model.Currency]float64{model.CurrencyBRL: 5.0, model.CurrencyMXN: 20.0},
+	}})
+	if err != nil {
+		t.Fatalf("NewTableProvider() error = %v", err)
+	}
+	r.FX = provider
+
+	txns := []model.Transaction{
+		{ID: "tx-brl", Country: model.CountryBR, Currency: model.CurrencyBRL, PaymentMethod: model.MethodPIX,
+			ProcessorID: "globalpay", Amount: money.FromFloat(320.0), Timestamp: now.Add(-48 * time.Hour), Settled: true},
+		{ID: "tx-mxn", Country: model.CountryMX, Currency: model.CurrencyMXN, PaymentMethod: model.MethodCreditCard,
+			ProcessorID: "mexpay", Amount: money.FromFloat(1000.0), Timestamp: now.Add(-48 * time.Hour), Settled: true},
+	}
+
+	result := r.AnalyzeBatch(txns, now)
+
+	brl, mxn := result.ByCurrency["BRL"], result.ByCurrency["MXN"]
+	if brl.TransactionCount != 1 || mxn.TransactionCount != 1 {
+		t.Fatalf("ByCurrency = %+v, want one transaction per currency", result.ByCurrency)
+	}
+	if !almostEqual(brl.Savings.Float64(), result.Results[0].Savings.Float64()) || !almostEqual(mxn.Savings.Float64(), result.Results[1].Savings.Float64()) {
+		t.Errorf("per-currency savings %v/%v do not match route savings", brl.Savings, mxn.Savings)
+	}
+
+	if result.Reporting == nil {
+		t.Fatal("Reporting is nil with an FX provider configured")
+	}
+	wantSavings := brl.Savings.Float64()/5.0 + mxn.Savings.Float64()/20.0
+	if math.Abs(result.Reporting.Savings.Float64()-wantSavings) > 0.011 {
+		t.Errorf("Reporting.Savings = %s, want %.2f", result.Reporting.Savings, wantSavings)
+	}
+	if result.Reporting.Currency != model.CurrencyUSD || len(result.Reporting.Rates) != 2 {
+		t.Errorf("Reporting = %+v, want USD with two rates", result.Reporting)
+	}
+	for _, rate := range result.Reporting.Rates {
+		if rate.Date != "2025-01-01" {
+			t.Errorf("rate %s date = %s, want 2025-01-01", rate.From, rate.Date)
+		}
+	}
+}
+
+func TestOptimizeBatch_AssignsScarceQuotaBySavings(t *testing.T) {
+	t.Parallel()
+
+	now := time.Date(2025, 6, 15, 12, 0, 0, 0, time.UTC)
+	procs := allProcessors()
+	for i := range procs {
+		if procs[i].ID == "paybr" {
+			procs[i].DailyQuota = 1
+		}
+	}
+	r := NewRouter(procs, allCompatRules())
+	r.Quota = quota.NewTracker(procs)
+
+	pix := func(id string, amount float64) model.Transaction {
+		return model.Transaction{
+			ID: id, Country: model.CountryBR, Currency: model.CurrencyBRL,
+			PaymentMethod: model.MethodPIX, ProcessorID: "globalpay", Amount: money.FromFloat(amount),
+			Timestamp: now.Add(-48 * time.Hour), Settled: true,
+		}
+	}
+	boleto := pix("tx-boleto", 320)
+	boleto.PaymentMethod = model.MethodBoleto
+	txns := []model.Transaction{pix("tx-small", 50), boleto, pix("tx-large", 5000)}
+
+	result := r.OptimizeBatchWithOptions(txns, RouteOptions{}, now)
+
+	if result.Allocation != model.AllocationOptimized {
+		t.Errorf("Allocation = %q, want optimized", result.Allocation)
+	}
+	wantSelected := []string{"valueproc", "valueproc", "paybr"}
+	for i, want := range wantSelected {
+		if got := result.Results[i].Selected.ProcessorID; got != want {
+			t.Errorf("Results[%d] (%s) selected %s, want %s", i, txns[i].ID, got, want)
+		}
+	}
+	if want, got := money.FromFloat(1.0+3.95+25.5), result.ByCurrency["BRL"].SmartCost; got != want {
+		t.Errorf("BRL SmartCost = %s, want %s", got, want)
+	}
+
+	if len(result.Displaced) != 1 {
+		t.Fatalf("len(Displaced) = %d, want 1: %+v", len(result.Displaced), result.Displaced)
+	}
+	d := result.Displaced[0]
+	if d.TransactionID != "tx-small" || d.PreferredProcessor != "paybr" || d.AssignedProcessor != "valueproc" {
+		t.Errorf("Displaced[0] = %+v, want tx-small moved from paybr to valueproc", d)
+	}
+	if d.ExtraCost != money.FromFloat(0.25) {
+		t.Errorf("Displaced[0].ExtraCost = %s, want 0.25", d.ExtraCost)
+	}
+	if !strings.HasPrefix(d.Reason, "Daily quota exhausted: 1/1 used") {
+		t.Errorf("Displaced[0].Reason = %q, want the quota reason", d.Reason)
+	}
+}
+
+func TestAnalyzeBatch_ReportsDisplaced(t *testing.T) {
+	t.Parallel()
+
+	now := time.Date(2025, 6, 15, 12, 0, 0, 0, time.UTC)
+	procs := allProcessors()
+	for i := range procs {
+		if procs[i].ID == "paybr" {
+			procs[i].DailyQuota = 2
+		}
+	}
+	r := NewRouter(procs, allCompatRules())
+	r.Quota = quota.NewTracker(procs)
+
+	txns := make([]model.Transaction, 5)
+	for i := range txns {
+		txns[i] = model.Transaction{
+			ID: fmt.Sprintf("tx-displaced-%d", i), Country: model.CountryBR, Currency: model.CurrencyBRL,
+			PaymentMethod: model.MethodPIX, ProcessorID: "globalpay", Amount: money.FromFloat(320.0),
+			Timestamp: now.Add(-48 * time.Hour), Settled: true,
+		}
+	}
+
+	result := r.AnalyzeBatch(txns, now)
+
+	if result.Allocation != model.AllocationIndependent {
+		t.Errorf("Allocation = %q, want independent", result.Allocation)
+	}
+	if len(result.Displaced) != 3 {
+		t.Fatalf("len(Displaced) = %d, want 3", len(result.Displaced))
+	}
+	for _, d := range result.Displaced {
+		if d.PreferredProcessor != "paybr" || d.ExtraCost != money.FromFloat(0.96) {
+			t.Errorf("Displaced %s = %+v, want paybr displaced at 0.96 extra", d.TransactionID, d)
+		}
+	}
+}
+
+func TestAnalyzeBatch_ExhaustedBeforeBatchIsNotDisplaced(t *testing.T) {
+	t.Parallel()
+
+	now := time.Date(2025, 6, 15, 12, 0, 0, 0, time.UTC)
+	procs := allProcessors()
+	for i := range procs {
+		if procs[i].ID == "paybr" {
+			procs[i].DailyQuota = 2
+		}
+	}
+	r := NewRouter(procs, allCompatRules())
+	r.Quota = quota.NewTracker(procs)
+	for range 2 {
+		if err := r.Quota.Consume("paybr", now); err != nil {
+			t.Fatalf("Consume() error = %v", err)
+		}
+	}
+
+	txns := make([]model.Transaction, 3)
+	for i := range txns {
+		txns[i] = model.Transaction{
+			ID: fmt.Sprintf("tx-exhausted-%d", i), Country: model.CountryBR, Currency: model.CurrencyBRL,
+			PaymentMethod: model.MethodPIX, ProcessorID: "globalpay", Amount: money.FromFloat(320.0),
+			Timestamp: now.Add(-48 * time.Hour), Settled: true,
+		}
+	}
+
+	for name, result := range map[string]model.BatchRefundResult{
+		"independent": r.AnalyzeBatch(txns, now),
+		"optimized":   r.OptimizeBatchWithOptions(txns, RouteOptions{}, now),
+	} {
+		for _, route := range result.Results {
+			if route.Selected.ProcessorID == "paybr" {
+				t.Errorf("%s: %s routed to exhausted paybr", name, route.TransactionID)
+			}
+		}
+		if len(result.Displaced) != 0 {
+			t.Errorf("%s: Displaced = %+v, want none when paybr was full before the batch", name, result.Displaced)
+		}
+	}
+}
+
+func TestOptimizeBatch_CheaperThanIndependentOrder(t *testing.T) {
+	t.Parallel()
+
+	now := time.Date(2025, 6, 15, 12, 0, 0, 0, time.UTC)
+	procs := allProcessors()
+	for i := range procs {
+		if procs[i].ID == "paybr" {
+			procs[i].DailyQuota = 1
+		}
+	}
+	pix := func(id string, amount float64) model.Transaction {
+		return model.Transaction{
+			ID: id, Country: model.CountryBR, Currency: model.CurrencyBRL,
+			PaymentMethod: model.MethodPIX, ProcessorID: "globalpay", Amount: money.FromFloat(amount),
+			Timestamp: now.Add(-48 * time.Hour), Settled: true,
+		}
+	}
+	txns := []model.Transaction{pix("tx-small", 50), pix("tx-large", 5000)}
+
+	independent := NewRouter(procs, allCompatRules())
+	independent.Quota = quota.NewTracker(procs)
+	var inOrder money.Amount
+	for _, tx := range txns {
+		inOrder += independent.CommitRoute(tx, now).Selected.EstimatedCost
+	}
+
+	r := NewRouter(procs, allCompatRules())
+	r.Quota = quota.NewTracker(procs)
+	preferred := r.routeBatch(r.snapshot(), txns, RouteOptions{}, now, quotaCheck)
+	if got := r.allocationOrder(txns, preferred, now); !slices.Equal(got, []int{1, 0}) {
+		t.Errorf("allocationOrder() = %v, want the large refund with more regret first", got)
+	}
+
+	result := r.OptimizeBatchWithOptions(txns, RouteOptions{}, now)
+	optimized := result.ByCurrency["BRL"].SmartCost
+	if inOrder != money.FromFloat(41.25) || optimized != money.FromFloat(26.5) {
+		t.Errorf("SmartCost in input order = %s, optimized = %s, want 41.25 and 26.50", inOrder, optimized)
+	}
+}
@@ -1,69 +1,404 @@
 package router
 
 import (
+	"errors"
 	"fmt"
+	"maps"
+	"slices"
 	"sort"
+	"strings"
+	"sync"
 	"time"
 
+	"github.com/ivanjtm/YunoChallenge/internal/calendar"
 	"github.com/ivanjtm/YunoChallenge/internal/cost"
+	"github.com/ivanjtm/YunoChallenge/internal/fx"
+	"github.com/ivanjtm/YunoChallenge/internal/i18n"
 	"github.com/ivanjtm/YunoChallenge/internal/model"
+	"github.com/ivanjtm/YunoChallenge/internal/money"
+	"github.com/ivanjtm/YunoChallenge/internal/quota"
 	"github.com/ivanjtm/YunoChallenge/internal/rules"
 )
 
+const accountCreditProcessorID = "internal"
+
+var (
+	ErrCandidateNotEligible = errors.New("candidate is not an eligible route for this transaction")
+	ErrCandidateUnavailable = errors.New("candidate processor has no capacity")
+)
+
 type Router struct {
-	Processors []model.Processor
-	RuleIndex  *rules.RuleIndex
+	mu sync.RWMutex
+
+	Processors        []model.Processor
+	RuleIndex         *rules.RuleIndex
+	Quota             *quota.Tracker
+	Scorers           map[model.RoutingStrategy]Scorer
+	DefaultStrategy   model.RoutingStrategy
+	FX                fx.Provider
+	ReportingCurrency model.Currency
+	Taxes             map[model.Country][]model.TaxRule
+	ReversalPolicies  map[model.Country]*model.ReversalPolicy
+	Calendars         map[model.Country]*calendar.Calendar
+}
+
+type Config struct {
+	Processors       []model.Processor
+	Rules            []model.CompatibilityRule
+	Scorers          map[model.RoutingStrategy]Scorer
+	Taxes            map[model.Country][]model.TaxRule
+	ReversalPolicies map[model.Country]*model.ReversalPolicy
+	Calendars        map[model.Country]*calendar.Calendar
+}
+
+type routingConfig struct {
+	processors      []model.Processor
+	ruleIndex       *rules.RuleIndex
+	scorers         map[model.RoutingStrategy]Scorer
+	defaultStrategy model.RoutingStrategy
+	taxes           map[model.Country][]model.TaxRule
+	reversal        map[model.Country]*model.ReversalPolicy
+	calendars       map[model.Country]*calendar.Calendar
 }
 
 func NewRouter(processors []model.Processor, compatRules []model.CompatibilityRule) *Router {
 	return &Router{
-		Processors: processors,
-		RuleIndex:  rules.NewRuleIndex(compatRules),
+		Processors:        processors,
+		RuleIndex:         rules.NewRuleIndex(compatRules),
+		Scorers:           defaultScorers(),
+		DefaultStrategy:   model.StrategyCheapest,
+		ReportingCurrency: model.CurrencyUSD,
+	}
+}
+
+func (r *Router) Reload(processors []model.Processor, compatRules []model.CompatibilityRule) {
+	idx := rules.NewRuleIndex(compatRules)
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.Processors = processors
+	r.RuleIndex = idx
+}
+
+func (r *Router) Apply(c Config) {
+	idx := rules.NewRuleIndex(c.Rules)
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	scorers := maps.Clone(r.Scorers)
+	maps.Copy(scorers, c.Scorers)
+	r.Processors = c.Processors
+	r.RuleIndex = idx
+	r.Scorers = scorers
+	r.Taxes = c.Taxes
+	r.ReversalPolicies = c.ReversalPolicies
+	r.Calendars = c.Calendars
+}
+
+func (r *Router) SetScorer(strategy model.RoutingStrategy, s Scorer) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	scorers := maps.Clone(r.Scorers)
+	scorers[strategy] = s
+	r.Scorers = scorers
+}
+
+func (r *Router) SetTaxes(taxes map[model.Country][]model.TaxRule) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.Taxes = taxes
+}
+
+func (r *Router) SetReversalPolicies(policies map[model.Country]*model.ReversalPolicy) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.ReversalPolicies = policies
+}
+
+func (r *Router) SetCalendars(calendars map[model.Country]*calendar.Calendar) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.Calendars = calendars
+}
+
+func (r *Router) snapshot() routingConfig {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return routingConfig{
+		processors:      r.Processors,
+		ruleIndex:       r.RuleIndex,
+		scorers:         r.Scorers,
+		defaultStrategy: r.DefaultStrategy,
+		taxes:           r.Taxes,
+		reversal:        r.ReversalPolicies,
+		calendars:       r.Calendars,
 	}
 }
 
+type RouteOptions struct {
+	RefundAmount money.Amount
+	Strategy     model.RoutingStrategy
+	DayValue     float64
+	PricingTime  time.Time
+	Locale       i18n.Locale
+}
+
+type quotaMode int
+
+const (
+	quotaCheck quotaMode = iota
+	quotaCommit
+	quotaReserve
+)
+
 func (r *Router) SelectRoute(tx model.Transaction, now time.Time) model.RefundRouteResult {
-	eligiblePaths := rules.FindEligiblePaths(tx, r.RuleIndex, now)
+	return r.route(tx, RouteOptions{}, now, quotaCheck)
+}
 
-	var candidates []model.RefundCandidate
+func (r *Router) CommitRoute(tx model.Transaction, now time.Time) model.RefundRouteResult {
+	return r.route(tx, RouteOptions{}, now, quotaCommit)
+}
+
+func (r *Router) SelectRouteWithOptions(tx model.Transaction, opts RouteOptions, now time.Time) model.RefundRouteResult {
+	return r.route(tx, opts, now, quotaCheck)
+}
+
+func (r *Router) CommitRouteWithOptions(tx model.Transaction, opts RouteOptions, now time.Time) model.RefundRouteResult {
+	return r.route(tx, opts, now, quotaCommit)
+}
+
+func (r *Router) ReserveRouteWithOptions(tx model.Transaction, opts RouteOptions, now time.Time) model.RefundRouteResult {
+	return r.route(tx, opts, now, quotaReserve)
+}
+
+func (r *Router) ReserveCandidateWithOptions(tx model.Transaction, want model.RefundCandidate, opts RouteOptions, now time.Time) (model.RefundRouteResult, error) {
+	route := r.route(tx, opts, now, quotaCheck)
+
+	for _, c := range route.Unavailable {
+		if c.ProcessorID == want.ProcessorID && c.RefundMethod == want.RefundMethod {
+			return route, fmt.Errorf("%w: %s/%s: %s", ErrCandidateUnavailable, c.ProcessorID, c.RefundMethod, c.UnavailableReason)
+		}
+	}
+
+	ranked := append([]model.RefundCandidate{route.Selected}, route.Alternatives...)
+	i := slices.IndexFunc(ranked, func(c model.RefundCandidate) bool {
+		return c.ProcessorID == want.ProcessorID && c.RefundMethod == want.RefundMethod
+	})
+	if i < 0 {
+		return route, fmt.Errorf("%w: %s/%s", ErrCandidateNotEligible, want.ProcessorID, want.RefundMethod)
+	}
+	selected := ranked[i]
+
+	if r.Quota != nil && selected.ProcessorID != accountCreditProcessorID {
+		demand := model.QuotaDemand{ProcessorID: selected.ProcessorID, Method: selected.RefundMethod, Currency: tx.Currency, Amount: route.RefundAmount}
+		res, ok, reason := r.Quota.Reserve(demand, now)
+		if !ok {
+			return route, fmt.Errorf("%w: %s/%s: %s", ErrCandidateUnavailable, selected.ProcessorID, selected.RefundMethod, reason)
+		}
+		route.Reservation = res.Token
+	}
+
+	route.Selected = selected
+	route.Alternatives = append(slices.Clone(ranked[:i]), ranked[i+1:]...)
+	route.Savings = route.NaiveCost - selected.EstimatedCost
+	return route, nil
+}
+
+func (r *Router) route(tx model.Transaction, opts RouteOptions, now time.Time, mode quotaMode) model.RefundRouteResult {
+	return r.routeWith(r.snapshot(), tx, opts, now, mode)
+}
+
+func (r *Router) routeWith(cfg routingConfig, tx model.Transaction, opts RouteOptions, now time.Time, mode quotaMode) model.RefundRouteResult {
+	if opts.RefundAmount > 0 {
+		tx.Amount = opts.RefundAmount
+	}
+
+	pricedAt := opts.PricingTime
+	if pricedAt.IsZero() {
+		pricedAt = now
+	}
+
+	strategy, scorer := cfg.scorer(opts)
+	printer := i18n.For(opts.Locale)
+	volume := func(processorID string) int {
+		if r.Quota == nil {
+			return 0
+		}
+		return r.Quota.MonthlyVolume(processorID, pricedAt)
+	}
+	cal := cfg.calendars[tx.Country]
+	ranked := rankCandidates(tx, cfg.processors, cfg.ruleIndex, cfg.taxes[tx.Country], cfg.reversal[tx.Country], scorer, volume, now.In(cal.Location()), pricedAt, printer)
+	candidates, unavailable, skipped, reservation := r.applyQuota(tx, ranked, now, mode)
+
+	if len(candidates) == 0 {
+		candidates = []model.RefundCandidate{{
+			ProcessorID:    accountCreditProcessorID,
+			ProcessorName:  "Account Credit",
+			RefundMethod:   model.RefundAccountCredit,
+			EstimatedCost:  0,
+			ProcessingDays: 0,
+			Reasoning:      printer.Sprintf(i18n.KeyProcessorsUnavailable),
+			Eligibility:    model.Eligibility{ReasonCodes: []model.ReasonCode{model.ReasonProcessorsUnavailable}},
+		}}
+	}
+
+	setExpectedCompletion(candidates, cal, now)
+	setExpectedCompletion(unavailable, cal, now)
 
+	naiveCost := cost.NaiveBreakdownAt(tx, cfg.processors, cfg.taxes[tx.Country], pricedAt, volume(tx.ProcessorID)).Total
+
+	selected := candidates[0]
+	if skipped > 0 {
+		selected.Reasoning += "; " + printer.Sprintf(i18n.KeyHigherRankedUnavailable, skipped)
+		selected.Eligibility.ReasonCodes = append(slices.Clip(selected.Eligibility.ReasonCodes), model.ReasonHigherRankedUnavailable)
+	}
+	var alternatives []model.RefundCandidate
+	if len(candidates) > 1 {
+		alternatives = candidates[1:]
+	}
+
+	return model.RefundRouteResult{
+		TransactionID: tx.ID,
+		Selected:      selected,
+		Alternatives:  alternatives,
+		Unavailable:   unavailable,
+		Strategy:      strategy,
+		RefundAmount:  tx.Amount,
+		NaiveCost:     naiveCost,
+		Savings:       naiveCost - selected.EstimatedCost,
+		Reservation:   reservation,
+	}
+}
+
+func setExpectedCompletion(candidates []model.RefundCandidate, cal *calendar.Calendar, now time.Time) {
+	for i := range candidates {
+		candidates[i].ExpectedCompletion = cal.AddBusinessDays(now, candidates[i].ProcessingDays)
+	}
+}
+
+func (r *Router) applyQuota(tx model.Transaction, candidates []model.RefundCandidate, now time.Time, mode quotaMode) (available, unavailable []model.RefundCandidate, skipped int, reservation string) {
+	if r.Quota == nil {
+		return candidates, nil, 0, ""
+	}
+
+	committed := mode == quotaCheck
+	for _, c := range candidates {
+		if c.ProcessorID == accountCreditProcessorID {
+			committed = true
+			available = append(available, c)
+			continue
+		}
+
+		demand := model.QuotaDemand{ProcessorID: c.ProcessorID, Method: c.RefundMethod, Currency: tx.Currency, Amount: tx.Amount}
+		var ok bool
+		var reason string
+		switch {
+		case !committed && mode == quotaReserve:
+			var res model.QuotaReservation
+			res, ok, reason = r.Quota.Reserve(demand, now)
+			reservation, committed = res.Token, ok
+		case !committed:
+			ok, reason = r.Quota.TryConsumeFor(demand, now)
+			committed = ok
+		default:
+			a := r.Quota.AvailabilityFor(demand, now)
+			ok, reason = a.Available, a.Reason
+		}
+
+		if !ok {
+			c.UnavailableReason = reason
+			if len(available) == 0 {
+				skipped++
+			}
+			unavailable = append(unavailable, c)
+			continue
+		}
+		available = append(available, c)
+	}
+	return available, unavailable, skipped, reservation
+}
+
+func rankCandidates(tx model.Transaction, processors []model.Processor, ruleIndex *rules.RuleIndex, taxes []model.TaxRule, reversal *model.ReversalPolicy, scorer Scorer, volume func(string) int, now, pricedAt time.Time, p i18n.Printer) []model.RefundCandidate {
+	eligiblePaths := rules.FindEligiblePathsFor(tx, ruleIndex, rules.ResolveReversalPolicy(reversal), now)
+
+	methods := make([]model.RefundMethod, 0, len(eligiblePaths))
 	for _, path := range eligiblePaths {
-		if path.Method == model.RefundAccountCredit {
+		methods = append(methods, path.Method)
+	}
+	processorPaths := make(map[string][]rules.EligiblePath)
+	for _, proc := range processors {
+		policy := rules.ResolveReversalPolicy(reversal, proc.ReversalWindow)
+		paths, ok := rules.FindProcessorPaths(tx, ruleIndex, proc.ID, policy, now)
+		if !ok {
+			if proc.ReversalWindow == nil {
+				continue
+			}
+			paths = rules.FindEligiblePathsFor(tx, ruleIndex, policy, now)
+		}
+		processorPaths[proc.ID] = paths
+		for _, path := range paths {
+			if path.Method != model.RefundAccountCredit && !slices.Contains(methods, path.Method) {
+				methods = append(methods, path.Method)
+			}
+		}
+	}
+
+	var candidates []model.RefundCandidate
+
+	for _, method := range methods {
+		if method == model.RefundAccountCredit {
+			path, _ := findPath(eligiblePaths, method)
 			candidates = append(candidates, model.RefundCandidate{
-				ProcessorID:    "internal",
+				ProcessorID:    accountCreditProcessorID,
 				ProcessorName:  "Account Credit",
 				RefundMethod:   model.RefundAccountCredit,
 				EstimatedCost:  0,
 				ProcessingDays: 0,
-				Reasoning:      path.Reason + "; funds credited to customer marketplace balance",
+				Reasoning:      p.Sprintf(i18n.KeyAccountCredit, path.Message),
+				Eligibility:    model.Eligibility{ReasonCodes: path.Codes, Window: path.Window},
 			})
 			continue
 		}
 
-		for _, proc := range r.Processors {
+		for _, proc := range processors {
+			if !cost.ProcessorActive(proc, pricedAt) {
+				continue
+			}
 			if !cost.SupportsCountryAndCurrency(proc, tx.Country, tx.Currency) {
 				continue
 			}
+			paths, scoped := processorPaths[proc.ID]
+			if !scoped {
+				paths = eligiblePaths
+			}
+			path, ok := findPath(paths, method)
+			if !ok {
+				continue
+			}
 
-			fee := cost.FindMatchingFee(proc, path.Method, tx.PaymentMethod, tx.Currency)
+			fee := cost.FindMatchingFeeAt(proc, path.Method, tx.PaymentMethod, tx.Currency, pricedAt)
 			if fee == nil {
 				continue
 			}
 
-			refundCost := cost.Calculate(tx.Amount, *fee)
+			pricing := cost.Price(tx.Amount, *fee, volume(proc.ID))
+			refundCost := pricing.Cost
+			breakdown := cost.Landed(tx, proc, path.Method, pricing, taxes)
 
 			days := 0
 			if d, ok := proc.ProcessingDays[path.Method]; ok {
 				days = d
 			}
 
-			reasoning := buildReasoning(tx, proc, path, *fee, refundCost, days)
+			reasoning := buildReasoning(p, tx, proc, path, *fee, refundCost, days) + tierNote(p, pricing, tx.Currency) + landedNote(p, breakdown, tx.Currency)
 
 			candidates = append(candidates, model.RefundCandidate{
 				ProcessorID:    proc.ID,
 				ProcessorName:  proc.Name,
 				RefundMethod:   path.Method,
-				EstimatedCost:  refundCost,
+				EstimatedCost:  breakdown.Total,
+				CostBreakdown:  breakdown,
+				Eligibility:    model.Eligibility{ReasonCodes: path.Codes, Window: path.Window},
 				ProcessingDays: days,
 				Reasoning:      reasoning,
 			})
@@ -76,6 +411,10 @@ func (r *Router) SelectRoute(tx model.Transaction, now time.Time) model.RefundRo
 		if iIsCredit != jIsCredit {
 			return !iIsCredit
 		}
+		si, sj := scorer.Score(tx, candidates[i]), scorer.Score(tx, candidates[j])
+		if si != sj {
+			return si < sj
+		}
 		if candidates[i].EstimatedCost != candidates[j].EstimatedCost {
 			return candidates[i].EstimatedCost < candidates[j].EstimatedCost
 		}
@@ -90,60 +429,85 @@ func (r *Router) SelectRoute(tx model.Transaction, now time.Time) model.RefundRo
 
 	if len(candidates) == 0 {
 		candidates = []model.RefundCandidate{{
-			ProcessorID:    "internal",
+			ProcessorID:    accountCreditProcessorID,
 			ProcessorName:  "Account Credit",
 			RefundMethod:   model.RefundAccountCredit,
 			EstimatedCost:  0,
 			ProcessingDays: 0,
-			Reasoning:      "No eligible refund methods found; defaulting to account credit",
+			Reasoning:      p.Sprintf(i18n.KeyNoCandidates),
+			Eligibility:    model.Eligibility{ReasonCodes: []model.ReasonCode{model.ReasonNoEligibleMethods}},
 		}}
 	}
 
-	naiveCost := cost.CalculateNaive(tx, r.Processors)
-
-	selected := candidates[0]
-	var alternatives []model.RefundCandidate
-	if len(candidates) > 1 {
-		alternatives = candidates[1:]
-	}
+	return candidates
+}
 
-	return model.RefundRouteResult{
-		TransactionID: tx.ID,
-		Selected:      selected,
-		Alternatives:  alternatives,
-		NaiveCost:     naiveCost,
-		Savings:       naiveCost - selected.EstimatedCost,
+func findPath(paths []rules.EligiblePath, method model.RefundMethod) (rules.EligiblePath, bool) {
+	for _, path := range paths {
+		if path.Method == method {
+			return path, true
+		}
 	}
+	return rules.EligiblePath{}, false
 }
 
-func buildReasoning(tx model.Transaction, proc model.Processor, path rules.EligiblePath, fee model.RefundMethodFee, refundCost float64, days int) string {
-	methodDesc := string(path.Method)
+func buildReasoning(p i18n.Printer, tx model.Transaction, proc model.Processor, path rules.EligiblePath, fee model.RefundMethodFee, refundCost money.Amount, days int) string {
+	var methodDesc any = string(path.Method)
 	switch path.Method {
 	case model.RefundReversal:
-		return fmt.Sprintf("Free reversal via %s; %s", proc.Name, path.Reason)
+		return p.Sprintf(i18n.KeyRouteReversal, proc.Name, path.Message)
 	case model.RefundSameMethod:
-		methodDesc = fmt.Sprintf("%s-to-%s", tx.PaymentMethod, tx.PaymentMethod)
+		methodDesc = i18n.Msg(i18n.KeyMethodSame, tx.PaymentMethod, tx.PaymentMethod)
 	case model.RefundBankTransfer:
-		methodDesc = "bank transfer"
+		methodDesc = i18n.Msg(i18n.KeyMethodBankTransfer)
 	}
 
-	costDesc := ""
+	cur := string(tx.Currency)
+	total := i18n.Money{Value: refundCost, Currency: cur}
+	percent := i18n.Decimal{Value: fee.PercentFee * 100, Places: 1}
+	var costDesc i18n.Message
 	if fee.BaseFee > 0 && fee.PercentFee > 0 {
-		costDesc = fmt.Sprintf("%.2f base + %.1f%% = %.2f %s", fee.BaseFee, fee.PercentFee*100, refundCost, tx.Currency)
+		costDesc = i18n.Msg(i18n.KeyCostBasePercent, i18n.Amount{Value: fee.BaseFee, Currency: cur}, percent, total)
 	} else if fee.PercentFee > 0 {
-		costDesc = fmt.Sprintf("%.1f%% = %.2f %s", fee.PercentFee*100, refundCost, tx.Currency)
+		costDesc = i18n.Msg(i18n.KeyCostPercent, percent, total)
 	} else {
-		costDesc = fmt.Sprintf("%.2f %s", refundCost, tx.Currency)
+		costDesc = i18n.Msg(i18n.KeyCostFlat, total)
 	}
 
-	timeDesc := ""
+	var timeDesc i18n.Message
 	if days == 0 {
-		timeDesc = "instant"
+		timeDesc = i18n.Msg(i18n.KeyTimeInstant)
 	} else if days == 1 {
-		timeDesc = "1 day"
+		timeDesc = i18n.Msg(i18n.KeyTimeOneDay)
 	} else {
-		timeDesc = fmt.Sprintf("%d days", days)
+		timeDesc = i18n.Msg(i18n.KeyTimeDays, days)
 	}
 
-	return fmt.Sprintf("%s via %s: %s, %s processing; %s", methodDesc, proc.Name, costDesc, timeDesc, path.Reason)
+	return p.Sprintf(i18n.KeyRoute, methodDesc, proc.Name, costDesc, timeDesc, path.Message)
+}
+
+func tierNote(p i18n.Printer, pricing cost.Pricing, currency model.Currency) string {
+	note := ""
+	if t := pricing.AmountTier; t != nil {
+		note += "; " + p.Sprintf(i18n.KeyAmountTier, i18n.Amount{Value: t.Above, Currency: string(currency)}, i18n.Decimal{Value: t.PercentFee * 100, Places: 1})
+	}
+	if t := pricing.VolumeTier; t != nil {
+		note += "; " + p.Sprintf(i18n.KeyVolumeTier, t.MinMonthlyRefunds, i18n.Decimal{Value: t.Discount * 100, Places: 0})
+	}
+	return note
+}
+
+func landedNote(p i18n.Printer, b model.CostBreakdown, currency model.Currency) string {
+	if b.Tax == 0 && b.FXMarkup == 0 {
+		return ""
+	}
+	cur := string(currency)
+	var parts []string
+	for _, t := range b.Taxes {
+		parts = append(parts, p.Sprintf(i18n.KeyTaxLine, t.Name, i18n.Amount{Value: t.Amount, Currency: cur}))
+	}
+	if b.FXMarkup > 0 {
+		parts = append(parts, p.Sprintf(i18n.KeyFXMarkup, i18n.Amount{Value: b.FXMarkup, Currency: cur}))
+	}
+	return "; " + p.Sprintf(i18n.KeyLandedCost, i18n.Money{Value: b.Total, Currency: cur}, strings.Join(parts, ", "))
 }
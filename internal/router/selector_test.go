@@ -1,12 +1,19 @@
 package router
 
 import (
+	"errors"
 	"math"
+	"slices"
 	"strings"
+	"sync"
 	"testing"
 	"time"
 
+	"github.com/ivanjtm/YunoChallenge/internal/calendar"
+	"github.com/ivanjtm/YunoChallenge/internal/i18n"
 	"github.com/ivanjtm/YunoChallenge/internal/model"
+	"github.com/ivanjtm/YunoChallenge/internal/money"
+	"github.com/ivanjtm/YunoChallenge/internal/quota"
 	"github.com/ivanjtm/YunoChallenge/internal/rules"
 )
 
@@ -32,28 +39,28 @@ func testProcessorPayBR() model.Processor {
 				Method:         model.RefundSameMethod,
 				PaymentMethods: []model.PaymentMethod{model.MethodPIX},
 				Currency:       model.CurrencyBRL,
-				BaseFee:        0.5,
+				BaseFee:        money.FromFloat(0.5),
 				PercentFee:     0.005,
-				MinFee:         0.75,
+				MinFee:         money.FromFloat(0.75),
 				MaxFee:         0,
 			},
 			{
 				Method:         model.RefundSameMethod,
 				PaymentMethods: []model.PaymentMethod{model.MethodCreditCard},
 				Currency:       model.CurrencyBRL,
-				BaseFee:        1.5,
+				BaseFee:        money.FromFloat(1.5),
 				PercentFee:     0.025,
-				MinFee:         2.0,
-				MaxFee:         150.0,
+				MinFee:         money.FromFloat(2.0),
+				MaxFee:         money.FromFloat(150.0),
 			},
 			{
 				Method:         model.RefundBankTransfer,
 				PaymentMethods: []model.PaymentMethod{model.MethodPIX, model.MethodBoleto, model.MethodCreditCard},
 				Currency:       model.CurrencyBRL,
-				BaseFee:        1.0,
+				BaseFee:        money.FromFloat(1.0),
 				PercentFee:     0.015,
-				MinFee:         1.5,
-				MaxFee:         100.0,
+				MinFee:         money.FromFloat(1.5),
+				MaxFee:         money.FromFloat(100.0),
 			},
 		},
 		DailyQuota: 1000,
@@ -85,28 +92,28 @@ func testProcessorMexPay() model.Processor {
 				Method:         model.RefundSameMethod,
 				PaymentMethods: []model.PaymentMethod{model.MethodSPEI},
 				Currency:       model.CurrencyMXN,
-				BaseFee:        5.0,
+				BaseFee:        money.FromFloat(5.0),
 				PercentFee:     0.008,
-				MinFee:         8.0,
+				MinFee:         money.FromFloat(8.0),
 				MaxFee:         0,
 			},
 			{
 				Method:         model.RefundSameMethod,
 				PaymentMethods: []model.PaymentMethod{model.MethodCreditCard},
 				Currency:       model.CurrencyMXN,
-				BaseFee:        15.0,
+				BaseFee:        money.FromFloat(15.0),
 				PercentFee:     0.02,
-				MinFee:         20.0,
-				MaxFee:         2500.0,
+				MinFee:         money.FromFloat(20.0),
+				MaxFee:         money.FromFloat(2500.0),
 			},
 			{
 				Method:         model.RefundBankTransfer,
 				PaymentMethods: []model.PaymentMethod{model.MethodSPEI, model.MethodOXXO, model.MethodCreditCard},
 				Currency:       model.CurrencyMXN,
-				BaseFee:        10.0,
+				BaseFee:        money.FromFloat(10.0),
 				PercentFee:     0.012,
-				MinFee:         15.0,
-				MaxFee:         1800.0,
+				MinFee:         money.FromFloat(15.0),
+				MaxFee:         money.FromFloat(1800.0),
 			},
 		},
 		DailyQuota: 800,
@@ -138,28 +145,28 @@ func testProcessorColPay() model.Processor {
 				Method:         model.RefundSameMethod,
 				PaymentMethods: []model.PaymentMethod{model.MethodPSE},
 				Currency:       model.CurrencyCOP,
-				BaseFee:        1500.0,
+				BaseFee:        money.FromFloat(1500.0),
 				PercentFee:     0.006,
-				MinFee:         2000.0,
+				MinFee:         money.FromFloat(2000.0),
 				MaxFee:         0,
 			},
 			{
 				Method:         model.RefundSameMethod,
 				PaymentMethods: []model.PaymentMethod{model.MethodCreditCard},
 				Currency:       model.CurrencyCOP,
-				BaseFee:        3500.0,
+				BaseFee:        money.FromFloat(3500.0),
 				PercentFee:     0.022,
-				MinFee:         5000.0,
-				MaxFee:         350000.0,
+				MinFee:         money.FromFloat(5000.0),
+				MaxFee:         money.FromFloat(350000.0),
 			},
 			{
 				Method:         model.RefundBankTransfer,
 				PaymentMethods: []model.PaymentMethod{model.MethodPSE, model.MethodEfecty, model.MethodCreditCard},
 				Currency:       model.CurrencyCOP,
-				BaseFee:        2500.0,
+				BaseFee:        money.FromFloat(2500.0),
 				PercentFee:     0.018,
-				MinFee:         4000.0,
-				MaxFee:         280000.0,
+				MinFee:         money.FromFloat(4000.0),
+				MaxFee:         money.FromFloat(280000.0),
 			},
 		},
 		DailyQuota: 600,
@@ -209,55 +216,55 @@ func testProcessorGlobalPay() model.Processor {
 				Method:         model.RefundSameMethod,
 				PaymentMethods: []model.PaymentMethod{model.MethodCreditCard},
 				Currency:       model.CurrencyBRL,
-				BaseFee:        2.0,
+				BaseFee:        money.FromFloat(2.0),
 				PercentFee:     0.02,
-				MinFee:         3.0,
-				MaxFee:         200.0,
+				MinFee:         money.FromFloat(3.0),
+				MaxFee:         money.FromFloat(200.0),
 			},
 			{
 				Method:         model.RefundSameMethod,
 				PaymentMethods: []model.PaymentMethod{model.MethodCreditCard},
 				Currency:       model.CurrencyMXN,
-				BaseFee:        20.0,
+				BaseFee:        money.FromFloat(20.0),
 				PercentFee:     0.02,
-				MinFee:         30.0,
-				MaxFee:         3500.0,
+				MinFee:         money.FromFloat(30.0),
+				MaxFee:         money.FromFloat(3500.0),
 			},
 			{
 				Method:         model.RefundSameMethod,
 				PaymentMethods: []model.PaymentMethod{model.MethodCreditCard},
 				Currency:       model.CurrencyCOP,
-				BaseFee:        5000.0,
+				BaseFee:        money.FromFloat(5000.0),
 				PercentFee:     0.02,
-				MinFee:         7500.0,
-				MaxFee:         500000.0,
+				MinFee:         money.FromFloat(7500.0),
+				MaxFee:         money.FromFloat(500000.0),
 			},
 			{
 				Method:         model.RefundBankTransfer,
 				PaymentMethods: []model.PaymentMethod{model.MethodPIX, model.MethodBoleto, model.MethodCreditCard},
 				Currency:       model.CurrencyBRL,
-				BaseFee:        2.0,
+				BaseFee:        money.FromFloat(2.0),
 				PercentFee:     0.02,
-				MinFee:         3.0,
-				MaxFee:         200.0,
+				MinFee:         money.FromFloat(3.0),
+				MaxFee:         money.FromFloat(200.0),
 			},
 			{
 				Method:         model.RefundBankTransfer,
 				PaymentMethods: []model.PaymentMethod{model.MethodSPEI, model.MethodOXXO, model.MethodCreditCard},
 				Currency:       model.CurrencyMXN,
-				BaseFee:        20.0,
+				BaseFee:        money.FromFloat(20.0),
 				PercentFee:     0.02,
-				MinFee:         30.0,
-				MaxFee:         3500.0,
+				MinFee:         money.FromFloat(30.0),
+				MaxFee:         money.FromFloat(3500.0),
 			},
 			{
 				Method:         model.RefundBankTransfer,
 				PaymentMethods: []model.PaymentMethod{model.MethodPSE, model.MethodEfecty, model.MethodCreditCard},
 				Currency:       model.CurrencyCOP,
-				BaseFee:        5000.0,
+				BaseFee:        money.FromFloat(5000.0),
 				PercentFee:     0.02,
-				MinFee:         7500.0,
-				MaxFee:         500000.0,
+				MinFee:         money.FromFloat(7500.0),
+				MaxFee:         money.FromFloat(500000.0),
 			},
 		},
 		DailyQuota: 2000,
@@ -298,36 +305,36 @@ func testProcessorQuickRefund() model.Processor {
 				Method:         model.RefundSameMethod,
 				PaymentMethods: []model.PaymentMethod{model.MethodPIX, model.MethodCreditCard},
 				Currency:       model.CurrencyBRL,
-				BaseFee:        3.0,
+				BaseFee:        money.FromFloat(3.0),
 				PercentFee:     0.03,
-				MinFee:         4.5,
+				MinFee:         money.FromFloat(4.5),
 				MaxFee:         0,
 			},
 			{
 				Method:         model.RefundSameMethod,
 				PaymentMethods: []model.PaymentMethod{model.MethodSPEI, model.MethodCreditCard},
 				Currency:       model.CurrencyMXN,
-				BaseFee:        30.0,
+				BaseFee:        money.FromFloat(30.0),
 				PercentFee:     0.03,
-				MinFee:         45.0,
+				MinFee:         money.FromFloat(45.0),
 				MaxFee:         0,
 			},
 			{
 				Method:         model.RefundBankTransfer,
 				PaymentMethods: []model.PaymentMethod{model.MethodPIX, model.MethodBoleto, model.MethodCreditCard},
 				Currency:       model.CurrencyBRL,
-				BaseFee:        2.5,
+				BaseFee:        money.FromFloat(2.5),
 				PercentFee:     0.025,
-				MinFee:         4.0,
+				MinFee:         money.FromFloat(4.0),
 				MaxFee:         0,
 			},
 			{
 				Method:         model.RefundBankTransfer,
 				PaymentMethods: []model.PaymentMethod{model.MethodSPEI, model.MethodOXXO, model.MethodCreditCard},
 				Currency:       model.CurrencyMXN,
-				BaseFee:        25.0,
+				BaseFee:        money.FromFloat(25.0),
 				PercentFee:     0.025,
-				MinFee:         40.0,
+				MinFee:         money.FromFloat(40.0),
 				MaxFee:         0,
 			},
 		},
@@ -378,55 +385,55 @@ func testProcessorValueProc() model.Processor {
 				Method:         model.RefundSameMethod,
 				PaymentMethods: []model.PaymentMethod{model.MethodPIX, model.MethodCreditCard},
 				Currency:       model.CurrencyBRL,
-				BaseFee:        0.5,
+				BaseFee:        money.FromFloat(0.5),
 				PercentFee:     0.008,
-				MinFee:         1.0,
-				MaxFee:         80.0,
+				MinFee:         money.FromFloat(1.0),
+				MaxFee:         money.FromFloat(80.0),
 			},
 			{
 				Method:         model.RefundSameMethod,
 				PaymentMethods: []model.PaymentMethod{model.MethodSPEI, model.MethodCreditCard},
 				Currency:       model.CurrencyMXN,
-				BaseFee:        5.0,
+				BaseFee:        money.FromFloat(5.0),
 				PercentFee:     0.008,
-				MinFee:         10.0,
-				MaxFee:         1400.0,
+				MinFee:         money.FromFloat(10.0),
+				MaxFee:         money.FromFloat(1400.0),
 			},
 			{
 				Method:         model.RefundSameMethod,
 				PaymentMethods: []model.PaymentMethod{model.MethodPSE, model.MethodCreditCard},
 				Currency:       model.CurrencyCOP,
-				BaseFee:        1500.0,
+				BaseFee:        money.FromFloat(1500.0),
 				PercentFee:     0.008,
-				MinFee:         2500.0,
-				MaxFee:         200000.0,
+				MinFee:         money.FromFloat(2500.0),
+				MaxFee:         money.FromFloat(200000.0),
 			},
 			{
 				Method:         model.RefundBankTransfer,
 				PaymentMethods: []model.PaymentMethod{model.MethodPIX, model.MethodBoleto, model.MethodCreditCard},
 				Currency:       model.CurrencyBRL,
-				BaseFee:        0.75,
+				BaseFee:        money.FromFloat(0.75),
 				PercentFee:     0.01,
-				MinFee:         1.5,
-				MaxFee:         100.0,
+				MinFee:         money.FromFloat(1.5),
+				MaxFee:         money.FromFloat(100.0),
 			},
 			{
 				Method:         model.RefundBankTransfer,
 				PaymentMethods: []model.PaymentMethod{model.MethodSPEI, model.MethodOXXO, model.MethodCreditCard},
 				Currency:       model.CurrencyMXN,
-				BaseFee:        8.0,
+				BaseFee:        money.FromFloat(8.0),
 				PercentFee:     0.01,
-				MinFee:         12.0,
-				MaxFee:         1800.0,
+				MinFee:         money.FromFloat(12.0),
+				MaxFee:         money.FromFloat(1800.0),
 			},
 			{
 				Method:         model.RefundBankTransfer,
 				PaymentMethods: []model.PaymentMethod{model.MethodPSE, model.MethodEfecty, model.MethodCreditCard},
 				Currency:       model.CurrencyCOP,
-				BaseFee:        2000.0,
+				BaseFee:        money.FromFloat(2000.0),
 				PercentFee:     0.01,
-				MinFee:         3500.0,
-				MaxFee:         250000.0,
+				MinFee:         money.FromFloat(3500.0),
+				MaxFee:         money.FromFloat(250000.0),
 			},
 		},
 		DailyQuota: 200,
@@ -561,7 +568,7 @@ func TestSelectRoute(t *testing.T) {
 				Currency:      model.CurrencyBRL,
 				PaymentMethod: model.MethodPIX,
 				ProcessorID:   "globalpay",
-				Amount:        200.0,
+				Amount:        money.FromFloat(200.0),
 				Timestamp:     now.Add(-48 * time.Hour),
 				Settled:       true,
 			},
@@ -580,7 +587,7 @@ func TestSelectRoute(t *testing.T) {
 				Currency:      model.CurrencyBRL,
 				PaymentMethod: model.MethodPIX,
 				ProcessorID:   "paybr",
-				Amount:        500.0,
+				Amount:        money.FromFloat(500.0),
 				Timestamp:     now.Add(-2 * time.Hour),
 				Settled:       false,
 			},
@@ -599,7 +606,7 @@ func TestSelectRoute(t *testing.T) {
 				Currency:      model.CurrencyMXN,
 				PaymentMethod: model.MethodOXXO,
 				ProcessorID:   "mexpay",
-				Amount:        1000.0,
+				Amount:        money.FromFloat(1000.0),
 				Timestamp:     now.Add(-5 * 24 * time.Hour),
 				Settled:       true,
 			},
@@ -618,7 +625,7 @@ func TestSelectRoute(t *testing.T) {
 				Currency:      "USD",
 				PaymentMethod: "CRYPTO",
 				ProcessorID:   "nonexistent",
-				Amount:        100.0,
+				Amount:        money.FromFloat(100.0),
 				Timestamp:     now.Add(-1 * time.Hour),
 				Settled:       false,
 			},
@@ -637,7 +644,7 @@ func TestSelectRoute(t *testing.T) {
 				Currency:      model.CurrencyCOP,
 				PaymentMethod: model.MethodEfecty,
 				ProcessorID:   "colpay",
-				Amount:        50000.0,
+				Amount:        money.FromFloat(50000.0),
 				Timestamp:     now.Add(-10 * 24 * time.Hour),
 				Settled:       true,
 			},
@@ -665,17 +672,17 @@ func TestSelectRoute(t *testing.T) {
 			if result.Selected.RefundMethod != tt.wantMethod {
 				t.Errorf("Selected.RefundMethod = %s, want %s", result.Selected.RefundMethod, tt.wantMethod)
 			}
-			if !almostEqual(result.Selected.EstimatedCost, tt.wantCost) {
-				t.Errorf("Selected.EstimatedCost = %.2f, want %.2f", result.Selected.EstimatedCost, tt.wantCost)
+			if !almostEqual(result.Selected.EstimatedCost.Float64(), tt.wantCost) {
+				t.Errorf("Selected.EstimatedCost = %s, want %.2f", result.Selected.EstimatedCost, tt.wantCost)
 			}
 			if len(result.Alternatives) < tt.wantMinAlts {
 				t.Errorf("len(Alternatives) = %d, want >= %d", len(result.Alternatives), tt.wantMinAlts)
 			}
 			if tt.wantSavingsGt0 && result.Savings <= 0 {
-				t.Errorf("Savings = %.2f, want > 0", result.Savings)
+				t.Errorf("Savings = %s, want > 0", result.Savings)
 			}
 			if tt.wantNaiveCostGt0 && result.NaiveCost <= 0 {
-				t.Errorf("NaiveCost = %.2f, want > 0", result.NaiveCost)
+				t.Errorf("NaiveCost = %s, want > 0", result.NaiveCost)
 			}
 			if result.Selected.Reasoning == "" {
 				t.Error("Selected.Reasoning is empty")
@@ -696,7 +703,7 @@ func TestSelectRoute_ReversalCostIsZero(t *testing.T) {
 		Currency:      model.CurrencyBRL,
 		PaymentMethod: model.MethodPIX,
 		ProcessorID:   "paybr",
-		Amount:        10000.0,
+		Amount:        money.FromFloat(10000.0),
 		Timestamp:     now.Add(-1 * time.Hour),
 		Settled:       false,
 	}
@@ -707,7 +714,7 @@ func TestSelectRoute_ReversalCostIsZero(t *testing.T) {
 		t.Fatalf("Selected.RefundMethod = %s, want REVERSAL", result.Selected.RefundMethod)
 	}
 	if result.Selected.EstimatedCost != 0 {
-		t.Errorf("Selected.EstimatedCost = %.2f, want 0", result.Selected.EstimatedCost)
+		t.Errorf("Selected.EstimatedCost = %s, want 0", result.Selected.EstimatedCost)
 	}
 	if result.Selected.ProcessingDays != 0 {
 		t.Errorf("Selected.ProcessingDays = %d, want 0", result.Selected.ProcessingDays)
@@ -726,7 +733,7 @@ func TestSelectRoute_AccountCreditRankedLast(t *testing.T) {
 		Currency:      model.CurrencyMXN,
 		PaymentMethod: model.MethodOXXO,
 		ProcessorID:   "mexpay",
-		Amount:        500.0,
+		Amount:        money.FromFloat(500.0),
 		Timestamp:     now.Add(-3 * 24 * time.Hour),
 		Settled:       true,
 	}
@@ -746,7 +753,7 @@ func TestSelectRoute_AccountCreditRankedLast(t *testing.T) {
 		t.Errorf("last alternative should be ACCOUNT_CREDIT, got %s", lastAlt.RefundMethod)
 	}
 	if lastAlt.EstimatedCost != 0 {
-		t.Errorf("ACCOUNT_CREDIT cost = %.2f, want 0", lastAlt.EstimatedCost)
+		t.Errorf("ACCOUNT_CREDIT cost = %s, want 0", lastAlt.EstimatedCost)
 	}
 }
 
@@ -762,7 +769,7 @@ func TestSelectRoute_SavingsCalculation(t *testing.T) {
 		Currency:      model.CurrencyBRL,
 		PaymentMethod: model.MethodPIX,
 		ProcessorID:   "paybr",
-		Amount:        500.0,
+		Amount:        money.FromFloat(500.0),
 		Timestamp:     now.Add(-1 * time.Hour),
 		Settled:       false,
 	}
@@ -770,8 +777,8 @@ func TestSelectRoute_SavingsCalculation(t *testing.T) {
 	result := r.SelectRoute(tx, now)
 
 	expectedSavings := result.NaiveCost - result.Selected.EstimatedCost
-	if !almostEqual(result.Savings, expectedSavings) {
-		t.Errorf("Savings = %.2f, want NaiveCost(%.2f) - SelectedCost(%.2f) = %.2f",
+	if !almostEqual(result.Savings.Float64(), expectedSavings.Float64()) {
+		t.Errorf("Savings = %s, want NaiveCost(%s) - SelectedCost(%s) = %s",
 			result.Savings, result.NaiveCost, result.Selected.EstimatedCost, expectedSavings)
 	}
 
@@ -780,7 +787,7 @@ func TestSelectRoute_SavingsCalculation(t *testing.T) {
 	}
 	if result.Selected.RefundMethod == model.RefundReversal && result.NaiveCost > 0 {
 		if result.Savings != result.NaiveCost {
-			t.Errorf("when selected is free reversal, Savings(%.2f) should equal NaiveCost(%.2f)",
+			t.Errorf("when selected is free reversal, Savings(%s) should equal NaiveCost(%s)",
 				result.Savings, result.NaiveCost)
 		}
 	}
@@ -798,7 +805,7 @@ func TestSelectRoute_AlternativesSortedByCost(t *testing.T) {
 		Currency:      model.CurrencyBRL,
 		PaymentMethod: model.MethodPIX,
 		ProcessorID:   "quickrefund",
-		Amount:        200.0,
+		Amount:        money.FromFloat(200.0),
 		Timestamp:     now.Add(-48 * time.Hour),
 		Settled:       true,
 	}
@@ -819,7 +826,7 @@ func TestSelectRoute_AlternativesSortedByCost(t *testing.T) {
 		}
 		if !prevIsCredit && !currIsCredit {
 			if prev.EstimatedCost > curr.EstimatedCost {
-				t.Errorf("candidate[%d] cost=%.2f > candidate[%d] cost=%.2f",
+				t.Errorf("candidate[%d] cost=%s > candidate[%d] cost=%s",
 					i-1, prev.EstimatedCost, i, curr.EstimatedCost)
 			}
 		}
@@ -841,7 +848,7 @@ func TestSelectRoute_OriginalProcessorTiebreaker(t *testing.T) {
 				Method:         model.RefundSameMethod,
 				PaymentMethods: []model.PaymentMethod{model.MethodPIX},
 				Currency:       model.CurrencyBRL,
-				BaseFee:        1.0,
+				BaseFee:        money.FromFloat(1.0),
 				PercentFee:     0.01,
 				MinFee:         0.0,
 				MaxFee:         0,
@@ -863,7 +870,7 @@ func TestSelectRoute_OriginalProcessorTiebreaker(t *testing.T) {
 				Method:         model.RefundSameMethod,
 				PaymentMethods: []model.PaymentMethod{model.MethodPIX},
 				Currency:       model.CurrencyBRL,
-				BaseFee:        1.0,
+				BaseFee:        money.FromFloat(1.0),
 				PercentFee:     0.01,
 				MinFee:         0.0,
 				MaxFee:         0,
@@ -891,7 +898,7 @@ func TestSelectRoute_OriginalProcessorTiebreaker(t *testing.T) {
 		Currency:      model.CurrencyBRL,
 		PaymentMethod: model.MethodPIX,
 		ProcessorID:   "proc_b",
-		Amount:        100.0,
+		Amount:        money.FromFloat(100.0),
 		Timestamp:     now.Add(-48 * time.Hour),
 		Settled:       true,
 	}
@@ -919,7 +926,7 @@ func TestSelectRoute_OriginalProcessorTiebreaker_NoEffectWhenCostDiffers(t *test
 				Method:         model.RefundSameMethod,
 				PaymentMethods: []model.PaymentMethod{model.MethodPIX},
 				Currency:       model.CurrencyBRL,
-				BaseFee:        0.5,
+				BaseFee:        money.FromFloat(0.5),
 				PercentFee:     0.005,
 				MinFee:         0.0,
 				MaxFee:         0,
@@ -941,7 +948,7 @@ func TestSelectRoute_OriginalProcessorTiebreaker_NoEffectWhenCostDiffers(t *test
 				Method:         model.RefundSameMethod,
 				PaymentMethods: []model.PaymentMethod{model.MethodPIX},
 				Currency:       model.CurrencyBRL,
-				BaseFee:        5.0,
+				BaseFee:        money.FromFloat(5.0),
 				PercentFee:     0.05,
 				MinFee:         0.0,
 				MaxFee:         0,
@@ -969,7 +976,7 @@ func TestSelectRoute_OriginalProcessorTiebreaker_NoEffectWhenCostDiffers(t *test
 		Currency:      model.CurrencyBRL,
 		PaymentMethod: model.MethodPIX,
 		ProcessorID:   "expensive",
-		Amount:        200.0,
+		Amount:        money.FromFloat(200.0),
 		Timestamp:     now.Add(-48 * time.Hour),
 		Settled:       true,
 	}
@@ -997,7 +1004,7 @@ func TestSelectRoute_ProcessingDaysTiebreaker(t *testing.T) {
 				Method:         model.RefundSameMethod,
 				PaymentMethods: []model.PaymentMethod{model.MethodPIX},
 				Currency:       model.CurrencyBRL,
-				BaseFee:        1.0,
+				BaseFee:        money.FromFloat(1.0),
 				PercentFee:     0.01,
 				MinFee:         0.0,
 				MaxFee:         0,
@@ -1019,7 +1026,7 @@ func TestSelectRoute_ProcessingDaysTiebreaker(t *testing.T) {
 				Method:         model.RefundSameMethod,
 				PaymentMethods: []model.PaymentMethod{model.MethodPIX},
 				Currency:       model.CurrencyBRL,
-				BaseFee:        1.0,
+				BaseFee:        money.FromFloat(1.0),
 				PercentFee:     0.01,
 				MinFee:         0.0,
 				MaxFee:         0,
@@ -1047,7 +1054,7 @@ func TestSelectRoute_ProcessingDaysTiebreaker(t *testing.T) {
 		Currency:      model.CurrencyBRL,
 		PaymentMethod: model.MethodPIX,
 		ProcessorID:   "slow",
-		Amount:        200.0,
+		Amount:        money.FromFloat(200.0),
 		Timestamp:     now.Add(-48 * time.Hour),
 		Settled:       true,
 	}
@@ -1084,7 +1091,7 @@ func TestSelectRoute_NoCandidatesFallsBackToAccountCredit(t *testing.T) {
 		Currency:      model.CurrencyBRL,
 		PaymentMethod: model.MethodPIX,
 		ProcessorID:   "paybr",
-		Amount:        200.0,
+		Amount:        money.FromFloat(200.0),
 		Timestamp:     now.Add(-10 * 24 * time.Hour),
 		Settled:       true,
 	}
@@ -1099,7 +1106,7 @@ func TestSelectRoute_NoCandidatesFallsBackToAccountCredit(t *testing.T) {
 		t.Errorf("Selected.RefundMethod = %s, want ACCOUNT_CREDIT", result.Selected.RefundMethod)
 	}
 	if result.Selected.EstimatedCost != 0 {
-		t.Errorf("Selected.EstimatedCost = %.2f, want 0", result.Selected.EstimatedCost)
+		t.Errorf("Selected.EstimatedCost = %s, want 0", result.Selected.EstimatedCost)
 	}
 	if len(result.Alternatives) != 0 {
 		t.Errorf("len(Alternatives) = %d, want 0", len(result.Alternatives))
@@ -1121,7 +1128,7 @@ func TestSelectRoute_MultipleAlternativesSorted(t *testing.T) {
 		Currency:      model.CurrencyBRL,
 		PaymentMethod: model.MethodPIX,
 		ProcessorID:   "quickrefund",
-		Amount:        1000.0,
+		Amount:        money.FromFloat(1000.0),
 		Timestamp:     now.Add(-48 * time.Hour),
 		Settled:       true,
 	}
@@ -1141,7 +1148,7 @@ func TestSelectRoute_MultipleAlternativesSorted(t *testing.T) {
 	}
 	for i := 1; i < len(nonCreditCandidates); i++ {
 		if nonCreditCandidates[i].EstimatedCost < nonCreditCandidates[i-1].EstimatedCost {
-			t.Errorf("non-credit candidate[%d] cost=%.2f < candidate[%d] cost=%.2f; not sorted ascending",
+			t.Errorf("non-credit candidate[%d] cost=%s < candidate[%d] cost=%s; not sorted ascending",
 				i, nonCreditCandidates[i].EstimatedCost, i-1, nonCreditCandidates[i-1].EstimatedCost)
 		}
 	}
@@ -1159,7 +1166,7 @@ func TestSelectRoute_PIX_BR_CostValues(t *testing.T) {
 		Currency:      model.CurrencyBRL,
 		PaymentMethod: model.MethodPIX,
 		ProcessorID:   "paybr",
-		Amount:        200.0,
+		Amount:        money.FromFloat(200.0),
 		Timestamp:     now.Add(-48 * time.Hour),
 		Settled:       true,
 	}
@@ -1175,13 +1182,13 @@ func TestSelectRoute_PIX_BR_CostValues(t *testing.T) {
 	if result.Selected.ProcessorID != "paybr" || result.Selected.RefundMethod != model.RefundSameMethod {
 		t.Fatalf("expected PayBR SAME_METHOD as selected, got %s %s", result.Selected.ProcessorID, result.Selected.RefundMethod)
 	}
-	if !almostEqual(result.Selected.EstimatedCost, paybr_same) {
-		t.Errorf("Selected cost = %.2f, want %.2f (PayBR PIX same-method)", result.Selected.EstimatedCost, paybr_same)
+	if !almostEqual(result.Selected.EstimatedCost.Float64(), paybr_same) {
+		t.Errorf("Selected cost = %s, want %.2f (PayBR PIX same-method)", result.Selected.EstimatedCost, paybr_same)
 	}
 
 	naiveCost := result.NaiveCost
-	if !almostEqual(naiveCost, paybr_same) {
-		t.Errorf("NaiveCost = %.2f, want %.2f (naive through paybr same-method for PIX)", naiveCost, paybr_same)
+	if !almostEqual(naiveCost.Float64(), paybr_same) {
+		t.Errorf("NaiveCost = %s, want %.2f (naive through paybr same-method for PIX)", naiveCost, paybr_same)
 	}
 }
 
@@ -1197,7 +1204,7 @@ func TestSelectRoute_OXXO_MX_BankTransferCandidates(t *testing.T) {
 		Currency:      model.CurrencyMXN,
 		PaymentMethod: model.MethodOXXO,
 		ProcessorID:   "mexpay",
-		Amount:        1000.0,
+		Amount:        money.FromFloat(1000.0),
 		Timestamp:     now.Add(-5 * 24 * time.Hour),
 		Settled:       true,
 	}
@@ -1243,7 +1250,7 @@ func TestSelectRoute_EmptyProcessorList(t *testing.T) {
 		Currency:      model.CurrencyBRL,
 		PaymentMethod: model.MethodPIX,
 		ProcessorID:   "paybr",
-		Amount:        200.0,
+		Amount:        money.FromFloat(200.0),
 		Timestamp:     now.Add(-48 * time.Hour),
 		Settled:       true,
 	}
@@ -1283,7 +1290,7 @@ func TestSelectRoute_TransactionIDPassedThrough(t *testing.T) {
 				Currency:      model.CurrencyBRL,
 				PaymentMethod: model.MethodPIX,
 				ProcessorID:   "paybr",
-				Amount:        100.0,
+				Amount:        money.FromFloat(100.0),
 				Timestamp:     now.Add(-48 * time.Hour),
 				Settled:       true,
 			}
@@ -1301,7 +1308,7 @@ func TestSelectRoute_AccountCreditAlwaysLastInOXXOCandidates(t *testing.T) {
 	now := time.Date(2025, 6, 15, 12, 0, 0, 0, time.UTC)
 	r := NewRouter(allProcessors(), allCompatRules())
 
-	amounts := []float64{100, 500, 1000, 5000, 50000}
+	amounts := []money.Amount{money.FromFloat(100), money.FromFloat(500), money.FromFloat(1000), money.FromFloat(5000), money.FromFloat(50000)}
 
 	for _, amt := range amounts {
 		tx := model.Transaction{
@@ -1327,13 +1334,13 @@ func TestSelectRoute_AccountCreditAlwaysLastInOXXOCandidates(t *testing.T) {
 		}
 
 		if creditIdx == -1 {
-			t.Errorf("amount=%.0f: ACCOUNT_CREDIT not found in candidates", amt)
+			t.Errorf("amount=%s: ACCOUNT_CREDIT not found in candidates", amt)
 			continue
 		}
 
 		for i := creditIdx + 1; i < len(all); i++ {
 			if all[i].RefundMethod != model.RefundAccountCredit {
-				t.Errorf("amount=%.0f: non-ACCOUNT_CREDIT candidate found after ACCOUNT_CREDIT at position %d", amt, i)
+				t.Errorf("amount=%s: non-ACCOUNT_CREDIT candidate found after ACCOUNT_CREDIT at position %d", amt, i)
 			}
 		}
 	}
@@ -1358,7 +1365,7 @@ func TestBuildReasoning(t *testing.T) {
 				Currency:      model.CurrencyBRL,
 				PaymentMethod: model.MethodPIX,
 				ProcessorID:   "paybr",
-				Amount:        200.0,
+				Amount:        money.FromFloat(200.0),
 				Timestamp:     now.Add(-2 * time.Hour),
 				Settled:       false,
 			},
@@ -1372,7 +1379,7 @@ func TestBuildReasoning(t *testing.T) {
 				Currency:      model.CurrencyBRL,
 				PaymentMethod: model.MethodPIX,
 				ProcessorID:   "paybr",
-				Amount:        200.0,
+				Amount:        money.FromFloat(200.0),
 				Timestamp:     now.Add(-48 * time.Hour),
 				Settled:       true,
 			},
@@ -1386,7 +1393,7 @@ func TestBuildReasoning(t *testing.T) {
 				Currency:      model.CurrencyMXN,
 				PaymentMethod: model.MethodOXXO,
 				ProcessorID:   "mexpay",
-				Amount:        1000.0,
+				Amount:        money.FromFloat(1000.0),
 				Timestamp:     now.Add(-5 * 24 * time.Hour),
 				Settled:       true,
 			},
@@ -1400,7 +1407,7 @@ func TestBuildReasoning(t *testing.T) {
 				Currency:      "USD",
 				PaymentMethod: "CRYPTO",
 				ProcessorID:   "nonexistent",
-				Amount:        100.0,
+				Amount:        money.FromFloat(100.0),
 				Timestamp:     now.Add(-1 * time.Hour),
 				Settled:       false,
 			},
@@ -1436,17 +1443,17 @@ func TestBuildReasoning_AllCandidatesHaveNonEmptyReasoning(t *testing.T) {
 	txs := []model.Transaction{
 		{
 			ID: "tx-r1", Country: model.CountryBR, Currency: model.CurrencyBRL,
-			PaymentMethod: model.MethodPIX, ProcessorID: "paybr", Amount: 200.0,
+			PaymentMethod: model.MethodPIX, ProcessorID: "paybr", Amount: money.FromFloat(200.0),
 			Timestamp: now.Add(-2 * time.Hour), Settled: false,
 		},
 		{
 			ID: "tx-r2", Country: model.CountryMX, Currency: model.CurrencyMXN,
-			PaymentMethod: model.MethodOXXO, ProcessorID: "mexpay", Amount: 500.0,
+			PaymentMethod: model.MethodOXXO, ProcessorID: "mexpay", Amount: money.FromFloat(500.0),
 			Timestamp: now.Add(-5 * 24 * time.Hour), Settled: true,
 		},
 		{
 			ID: "tx-r3", Country: model.CountryCO, Currency: model.CurrencyCOP,
-			PaymentMethod: model.MethodPSE, ProcessorID: "colpay", Amount: 100000.0,
+			PaymentMethod: model.MethodPSE, ProcessorID: "colpay", Amount: money.FromFloat(100000.0),
 			Timestamp: now.Add(-3 * time.Hour), Settled: false,
 		},
 	}
@@ -1486,11 +1493,11 @@ func TestBuildReasoning_ReversalFormat(t *testing.T) {
 		Currency:      model.CurrencyBRL,
 		PaymentMethod: model.MethodPIX,
 		ProcessorID:   "paybr",
-		Amount:        500.0,
+		Amount:        money.FromFloat(500.0),
 		Timestamp:     now.Add(-2 * time.Hour),
 	}
 
-	reasoning := buildReasoning(tx, proc, path, fee, 0, 0)
+	reasoning := buildReasoning(i18n.For(i18n.English), tx, proc, path, fee, 0, 0)
 
 	if !strings.Contains(reasoning, "Free reversal") {
 		t.Errorf("reversal reasoning should contain 'Free reversal', got %q", reasoning)
@@ -1506,9 +1513,9 @@ func TestBuildReasoning_SameMethodFormat(t *testing.T) {
 	proc := testProcessorPayBR()
 	fee := model.RefundMethodFee{
 		Method:     model.RefundSameMethod,
-		BaseFee:    0.5,
+		BaseFee:    money.FromFloat(0.5),
 		PercentFee: 0.005,
-		MinFee:     0.75,
+		MinFee:     money.FromFloat(0.75),
 		MaxFee:     0,
 	}
 	path := rules.EligiblePath{
@@ -1521,10 +1528,10 @@ func TestBuildReasoning_SameMethodFormat(t *testing.T) {
 		Currency:      model.CurrencyBRL,
 		PaymentMethod: model.MethodPIX,
 		ProcessorID:   "paybr",
-		Amount:        200.0,
+		Amount:        money.FromFloat(200.0),
 	}
 
-	reasoning := buildReasoning(tx, proc, path, fee, 1.50, 1)
+	reasoning := buildReasoning(i18n.For(i18n.English), tx, proc, path, fee, money.FromFloat(1.50), 1)
 
 	if !strings.Contains(reasoning, "PIX-to-PIX") {
 		t.Errorf("same-method reasoning should contain 'PIX-to-PIX', got %q", reasoning)
@@ -1546,10 +1553,10 @@ func TestBuildReasoning_BankTransferFormat(t *testing.T) {
 	proc := testProcessorPayBR()
 	fee := model.RefundMethodFee{
 		Method:     model.RefundBankTransfer,
-		BaseFee:    1.0,
+		BaseFee:    money.FromFloat(1.0),
 		PercentFee: 0.015,
-		MinFee:     1.5,
-		MaxFee:     100.0,
+		MinFee:     money.FromFloat(1.5),
+		MaxFee:     money.FromFloat(100.0),
 	}
 	path := rules.EligiblePath{
 		Method: model.RefundBankTransfer,
@@ -1561,10 +1568,10 @@ func TestBuildReasoning_BankTransferFormat(t *testing.T) {
 		Currency:      model.CurrencyBRL,
 		PaymentMethod: model.MethodPIX,
 		ProcessorID:   "paybr",
-		Amount:        1000.0,
+		Amount:        money.FromFloat(1000.0),
 	}
 
-	reasoning := buildReasoning(tx, proc, path, fee, 16.0, 2)
+	reasoning := buildReasoning(i18n.For(i18n.English), tx, proc, path, fee, 16.0, 2)
 
 	if !strings.Contains(reasoning, "bank transfer") {
 		t.Errorf("bank transfer reasoning should contain 'bank transfer', got %q", reasoning)
@@ -1580,9 +1587,9 @@ func TestBuildReasoning_InstantProcessing(t *testing.T) {
 	proc := testProcessorQuickRefund()
 	fee := model.RefundMethodFee{
 		Method:     model.RefundSameMethod,
-		BaseFee:    3.0,
+		BaseFee:    money.FromFloat(3.0),
 		PercentFee: 0.03,
-		MinFee:     4.5,
+		MinFee:     money.FromFloat(4.5),
 	}
 	path := rules.EligiblePath{
 		Method: model.RefundSameMethod,
@@ -1594,10 +1601,10 @@ func TestBuildReasoning_InstantProcessing(t *testing.T) {
 		Currency:      model.CurrencyBRL,
 		PaymentMethod: model.MethodPIX,
 		ProcessorID:   "quickrefund",
-		Amount:        100.0,
+		Amount:        money.FromFloat(100.0),
 	}
 
-	reasoning := buildReasoning(tx, proc, path, fee, 6.0, 0)
+	reasoning := buildReasoning(i18n.For(i18n.English), tx, proc, path, fee, 6.0, 0)
 
 	if !strings.Contains(reasoning, "instant") {
 		t.Errorf("zero-day processing should say 'instant', got %q", reasoning)
@@ -1610,22 +1617,22 @@ func TestBuildReasoning_CostDescriptions(t *testing.T) {
 	proc := testProcessorPayBR()
 	tx := model.Transaction{
 		ID: "tx-cost-desc", Country: model.CountryBR, Currency: model.CurrencyBRL,
-		PaymentMethod: model.MethodPIX, ProcessorID: "paybr", Amount: 200.0,
+		PaymentMethod: model.MethodPIX, ProcessorID: "paybr", Amount: money.FromFloat(200.0),
 	}
 	path := rules.EligiblePath{Method: model.RefundSameMethod, Reason: "test"}
 
 	tests := []struct {
 		name           string
 		fee            model.RefundMethodFee
-		cost           float64
+		cost           money.Amount
 		wantSubstrings []string
 	}{
 		{
 			name: "base plus percent",
 			fee: model.RefundMethodFee{
-				Method: model.RefundSameMethod, BaseFee: 0.5, PercentFee: 0.005,
+				Method: model.RefundSameMethod, BaseFee: money.FromFloat(0.5), PercentFee: 0.005,
 			},
-			cost:           1.50,
+			cost:           money.FromFloat(1.50),
 			wantSubstrings: []string{"0.50 base", "0.5%", "1.50"},
 		},
 		{
@@ -1633,15 +1640,15 @@ func TestBuildReasoning_CostDescriptions(t *testing.T) {
 			fee: model.RefundMethodFee{
 				Method: model.RefundSameMethod, BaseFee: 0, PercentFee: 0.015,
 			},
-			cost:           3.0,
+			cost:           money.FromFloat(3.0),
 			wantSubstrings: []string{"1.5%", "3.00"},
 		},
 		{
 			name: "flat fee only",
 			fee: model.RefundMethodFee{
-				Method: model.RefundSameMethod, BaseFee: 5.0, PercentFee: 0,
+				Method: model.RefundSameMethod, BaseFee: money.FromFloat(5.0), PercentFee: 0,
 			},
-			cost:           5.0,
+			cost:           money.FromFloat(5.0),
 			wantSubstrings: []string{"5.00 BRL"},
 		},
 	}
@@ -1649,7 +1656,7 @@ func TestBuildReasoning_CostDescriptions(t *testing.T) {
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			t.Parallel()
-			reasoning := buildReasoning(tx, proc, path, tt.fee, tt.cost, 1)
+			reasoning := buildReasoning(i18n.For(i18n.English), tx, proc, path, tt.fee, tt.cost, 1)
 			for _, substr := range tt.wantSubstrings {
 				if !strings.Contains(reasoning, substr) {
 					t.Errorf("reasoning %q does not contain %q", reasoning, substr)
@@ -1671,7 +1678,7 @@ func TestSelectRoute_NaiveCostUsesOriginalProcessor(t *testing.T) {
 		Currency:      model.CurrencyBRL,
 		PaymentMethod: model.MethodPIX,
 		ProcessorID:   "quickrefund",
-		Amount:        200.0,
+		Amount:        money.FromFloat(200.0),
 		Timestamp:     now.Add(-48 * time.Hour),
 		Settled:       true,
 	}
@@ -1684,8 +1691,8 @@ func TestSelectRoute_NaiveCostUsesOriginalProcessor(t *testing.T) {
 	}
 	quickRefundSameMethod = math.Round(quickRefundSameMethod*100) / 100
 
-	if !almostEqual(result.NaiveCost, quickRefundSameMethod) {
-		t.Errorf("NaiveCost = %.2f, want %.2f (naive cost through quickrefund same-method)",
+	if !almostEqual(result.NaiveCost.Float64(), quickRefundSameMethod) {
+		t.Errorf("NaiveCost = %s, want %.2f (naive cost through quickrefund same-method)",
 			result.NaiveCost, quickRefundSameMethod)
 	}
 }
@@ -1702,7 +1709,7 @@ func TestSelectRoute_ProcessorNamePopulated(t *testing.T) {
 		Currency:      model.CurrencyBRL,
 		PaymentMethod: model.MethodPIX,
 		ProcessorID:   "paybr",
-		Amount:        200.0,
+		Amount:        money.FromFloat(200.0),
 		Timestamp:     now.Add(-48 * time.Hour),
 		Settled:       true,
 	}
@@ -1732,7 +1739,7 @@ func TestSelectRoute_CreditCardBR_UnsettledFreshGetsReversal(t *testing.T) {
 		Currency:      model.CurrencyBRL,
 		PaymentMethod: model.MethodCreditCard,
 		ProcessorID:   "paybr",
-		Amount:        1000.0,
+		Amount:        money.FromFloat(1000.0),
 		Timestamp:     now.Add(-3 * time.Hour),
 		Settled:       false,
 	}
@@ -1743,7 +1750,7 @@ func TestSelectRoute_CreditCardBR_UnsettledFreshGetsReversal(t *testing.T) {
 		t.Errorf("Selected.RefundMethod = %s, want REVERSAL for fresh unsettled CC", result.Selected.RefundMethod)
 	}
 	if result.Selected.EstimatedCost != 0 {
-		t.Errorf("Reversal cost = %.2f, want 0", result.Selected.EstimatedCost)
+		t.Errorf("Reversal cost = %s, want 0", result.Selected.EstimatedCost)
 	}
 }
 
@@ -1759,7 +1766,7 @@ func TestSelectRoute_BoletoBR_NoReversalOrSameMethod(t *testing.T) {
 		Currency:      model.CurrencyBRL,
 		PaymentMethod: model.MethodBoleto,
 		ProcessorID:   "paybr",
-		Amount:        300.0,
+		Amount:        money.FromFloat(300.0),
 		Timestamp:     now.Add(-5 * 24 * time.Hour),
 		Settled:       true,
 	}
@@ -1793,7 +1800,7 @@ func TestSelectRoute_LargeAmount_MaxFeeCap(t *testing.T) {
 		Currency:      model.CurrencyBRL,
 		PaymentMethod: model.MethodCreditCard,
 		ProcessorID:   "paybr",
-		Amount:        50000.0,
+		Amount:        money.FromFloat(50000.0),
 		Timestamp:     now.Add(-48 * time.Hour),
 		Settled:       true,
 	}
@@ -1802,9 +1809,9 @@ func TestSelectRoute_LargeAmount_MaxFeeCap(t *testing.T) {
 
 	all := append([]model.RefundCandidate{result.Selected}, result.Alternatives...)
 
-	paybr_sm_cap := 150.0
-	paybr_bt_cap := 100.0
-	globalpay_cap := 200.0
+	paybr_sm_cap := money.FromFloat(150.0)
+	paybr_bt_cap := money.FromFloat(100.0)
+	globalpay_cap := money.FromFloat(200.0)
 
 	for _, c := range all {
 		if c.RefundMethod == model.RefundAccountCredit {
@@ -1813,21 +1820,21 @@ func TestSelectRoute_LargeAmount_MaxFeeCap(t *testing.T) {
 		switch c.ProcessorID {
 		case "paybr":
 			if c.RefundMethod == model.RefundSameMethod && c.EstimatedCost > paybr_sm_cap {
-				t.Errorf("paybr SAME_METHOD cost %.2f exceeds cap %.2f", c.EstimatedCost, paybr_sm_cap)
+				t.Errorf("paybr SAME_METHOD cost %s exceeds cap %s", c.EstimatedCost, paybr_sm_cap)
 			}
 			if c.RefundMethod == model.RefundBankTransfer && c.EstimatedCost > paybr_bt_cap {
-				t.Errorf("paybr BANK_TRANSFER cost %.2f exceeds cap %.2f", c.EstimatedCost, paybr_bt_cap)
+				t.Errorf("paybr BANK_TRANSFER cost %s exceeds cap %s", c.EstimatedCost, paybr_bt_cap)
 			}
 		case "globalpay":
 			if c.EstimatedCost > globalpay_cap {
-				t.Errorf("globalpay %s cost %.2f exceeds cap %.2f", c.RefundMethod, c.EstimatedCost, globalpay_cap)
+				t.Errorf("globalpay %s cost %s exceeds cap %s", c.RefundMethod, c.EstimatedCost, globalpay_cap)
 			}
 		case "valueproc":
-			if c.RefundMethod == model.RefundSameMethod && c.EstimatedCost > 80.0 {
-				t.Errorf("valueproc SAME_METHOD cost %.2f exceeds cap 80.0", c.EstimatedCost)
+			if c.RefundMethod == model.RefundSameMethod && c.EstimatedCost > money.FromFloat(80.0) {
+				t.Errorf("valueproc SAME_METHOD cost %s exceeds cap 80.0", c.EstimatedCost)
 			}
-			if c.RefundMethod == model.RefundBankTransfer && c.EstimatedCost > 100.0 {
-				t.Errorf("valueproc BANK_TRANSFER cost %.2f exceeds cap 100.0", c.EstimatedCost)
+			if c.RefundMethod == model.RefundBankTransfer && c.EstimatedCost > money.FromFloat(100.0) {
+				t.Errorf("valueproc BANK_TRANSFER cost %s exceeds cap 100.0", c.EstimatedCost)
 			}
 		}
 	}
@@ -1845,7 +1852,7 @@ func TestSelectRoute_EligiblePath_AccountCreditExplicitInRules(t *testing.T) {
 		Currency:      model.CurrencyMXN,
 		PaymentMethod: model.MethodOXXO,
 		ProcessorID:   "mexpay",
-		Amount:        500.0,
+		Amount:        money.FromFloat(500.0),
 		Timestamp:     now.Add(-5 * 24 * time.Hour),
 		Settled:       true,
 	}
@@ -1864,7 +1871,7 @@ func TestSelectRoute_EligiblePath_AccountCreditExplicitInRules(t *testing.T) {
 				t.Errorf("ACCOUNT_CREDIT ProcessorName = %s, want 'Account Credit'", c.ProcessorName)
 			}
 			if c.EstimatedCost != 0 {
-				t.Errorf("ACCOUNT_CREDIT cost = %.2f, want 0", c.EstimatedCost)
+				t.Errorf("ACCOUNT_CREDIT cost = %s, want 0", c.EstimatedCost)
 			}
 			if c.ProcessingDays != 0 {
 				t.Errorf("ACCOUNT_CREDIT ProcessingDays = %d, want 0", c.ProcessingDays)
@@ -1888,7 +1895,7 @@ func TestSelectRoute_PSE_CO_SettledGetsMultipleCandidates(t *testing.T) {
 		Currency:      model.CurrencyCOP,
 		PaymentMethod: model.MethodPSE,
 		ProcessorID:   "colpay",
-		Amount:        100000.0,
+		Amount:        money.FromFloat(100000.0),
 		Timestamp:     now.Add(-10 * 24 * time.Hour),
 		Settled:       true,
 	}
@@ -1921,7 +1928,7 @@ func TestSelectRoute_ResultStructure(t *testing.T) {
 		Currency:      model.CurrencyBRL,
 		PaymentMethod: model.MethodPIX,
 		ProcessorID:   "paybr",
-		Amount:        500.0,
+		Amount:        money.FromFloat(500.0),
 		Timestamp:     now.Add(-2 * time.Hour),
 		Settled:       false,
 	}
@@ -1941,7 +1948,7 @@ func TestSelectRoute_ResultStructure(t *testing.T) {
 		t.Error("Selected.Reasoning is empty")
 	}
 	if result.Selected.EstimatedCost < 0 {
-		t.Errorf("Selected.EstimatedCost = %.2f, want >= 0", result.Selected.EstimatedCost)
+		t.Errorf("Selected.EstimatedCost = %s, want >= 0", result.Selected.EstimatedCost)
 	}
 	if result.Selected.ProcessingDays < 0 {
 		t.Errorf("Selected.ProcessingDays = %d, want >= 0", result.Selected.ProcessingDays)
@@ -1978,3 +1985,977 @@ func TestNewRouter_EmptyInputs(t *testing.T) {
 		t.Error("RuleIndex is nil even with nil rules")
 	}
 }
+
+func TestSelectRoute_SkipsUnavailableProcessor(t *testing.T) {
+	t.Parallel()
+
+	now := time.Date(2025, 6, 15, 12, 0, 0, 0, time.UTC)
+	procs := allProcessors()
+	r := NewRouter(procs, allCompatRules())
+	r.Quota = quota.NewTracker(procs)
+	r.Quota.SetOverrides(map[string]model.ProcessorOverride{
+		"paybr": {AtCapacity: boolPtr(true)},
+	})
+
+	tx := model.Transaction{
+		ID:            "tx-pix-quota",
+		Country:       model.CountryBR,
+		Currency:      model.CurrencyBRL,
+		PaymentMethod: model.MethodPIX,
+		ProcessorID:   "globalpay",
+		Amount:        money.FromFloat(320.0),
+		Timestamp:     now.Add(-48 * time.Hour),
+		Settled:       true,
+	}
+
+	result := r.SelectRoute(tx, now)
+
+	if result.Selected.ProcessorID != "valueproc" {
+		t.Errorf("Selected.ProcessorID = %s, want valueproc", result.Selected.ProcessorID)
+	}
+	if !strings.Contains(result.Selected.Reasoning, "skipped due to processor availability") {
+		t.Errorf("Selected.Reasoning = %q, want mention of skipped options", result.Selected.Reasoning)
+	}
+	for _, alt := range result.Alternatives {
+		if alt.ProcessorID == "paybr" {
+			t.Errorf("unavailable processor paybr listed in Alternatives: %+v", alt)
+		}
+	}
+	if len(result.Unavailable) == 0 {
+		t.Fatal("Unavailable is empty, want paybr candidates")
+	}
+	for _, u := range result.Unavailable {
+		if u.ProcessorID != "paybr" {
+			t.Errorf("Unavailable contains %s, want only paybr", u.ProcessorID)
+		}
+		if u.UnavailableReason == "" {
+			t.Errorf("Unavailable[%s/%s].UnavailableReason is empty", u.ProcessorID, u.RefundMethod)
+		}
+	}
+}
+
+func TestSelectRoute_DoesNotConsumeQuota(t *testing.T) {
+	t.Parallel()
+
+	now := time.Date(2025, 6, 15, 12, 0, 0, 0, time.UTC)
+	procs := allProcessors()
+	r := NewRouter(procs, allCompatRules())
+	r.Quota = quota.NewTracker(procs)
+
+	tx := model.Transaction{
+		ID: "tx-read-only", Country: model.CountryBR, Currency: model.CurrencyBRL,
+		PaymentMethod: model.MethodPIX, ProcessorID: "paybr", Amount: money.FromFloat(200.0),
+		Timestamp: now.Add(-48 * time.Hour), Settled: true,
+	}
+	r.SelectRoute(tx, now)
+
+	for _, s := range r.Quota.Status(now) {
+		if s.UsedToday != 0 {
+			t.Errorf("processor %s UsedToday = %d after SelectRoute, want 0", s.ProcessorID, s.UsedToday)
+		}
+	}
+}
+
+func TestCommitRoute_ConsumesQuotaAndFallsThrough(t *testing.T) {
+	t.Parallel()
+
+	now := time.Date(2025, 6, 15, 12, 0, 0, 0, time.UTC)
+	procs := allProcessors()
+	for i := range procs {
+		if procs[i].ID == "paybr" {
+			procs[i].DailyQuota = 1
+		}
+	}
+	r := NewRouter(procs, allCompatRules())
+	r.Quota = quota.NewTracker(procs)
+
+	tx := model.Transaction{
+		ID: "tx-commit", Country: model.CountryBR, Currency: model.CurrencyBRL,
+		PaymentMethod: model.MethodPIX, ProcessorID: "globalpay", Amount: money.FromFloat(320.0),
+		Timestamp: now.Add(-48 * time.Hour), Settled: true,
+	}
+
+	first := r.CommitRoute(tx, now)
+	if first.Selected.ProcessorID != "paybr" {
+		t.Fatalf("first Selected.ProcessorID = %s, want paybr", first.Selected.ProcessorID)
+	}
+	if ok, _ := r.Quota.IsAvailable("paybr", now); ok {
+		t.Error("paybr still available after consuming its only slot")
+	}
+
+	second := r.CommitRoute(tx, now)
+	if second.Selected.ProcessorID == "paybr" {
+		t.Error("second CommitRoute selected paybr despite exhausted quota")
+	}
+	if len(second.Unavailable) == 0 {
+		t.Error("second CommitRoute has no Unavailable candidates")
+	}
+	if second.Unavailable[0].UnavailableReason == "" {
+		t.Error("Unavailable[0].UnavailableReason is empty")
+	}
+}
+
+func TestCommitRoute_AllUnavailableFallsBackToAccountCredit(t *testing.T) {
+	t.Parallel()
+
+	now := time.Date(2025, 6, 15, 12, 0, 0, 0, time.UTC)
+	procs := allProcessors()
+	r := NewRouter(procs, allCompatRules())
+	r.Quota = quota.NewTracker(procs)
+
+	overrides := make(map[string]model.ProcessorOverride)
+	for _, p := range procs {
+		overrides[p.ID] = model.ProcessorOverride{Available: boolPtr(false)}
+	}
+	r.Quota.SetOverrides(overrides)
+
+	tx := model.Transaction{
+		ID: "tx-all-down", Country: model.CountryBR, Currency: model.CurrencyBRL,
+		PaymentMethod: model.MethodPIX, ProcessorID: "paybr", Amount: money.FromFloat(200.0),
+		Timestamp: now.Add(-48 * time.Hour), Settled: true,
+	}
+
+	result := r.CommitRoute(tx, now)
+	if result.Selected.RefundMethod != model.RefundAccountCredit {
+		t.Errorf("Selected.RefundMethod = %s, want ACCOUNT_CREDIT", result.Selected.RefundMethod)
+	}
+	if len(result.Alternatives) != 0 {
+		t.Errorf("len(Alternatives) = %d, want 0", len(result.Alternatives))
+	}
+}
+
+func TestSelectRouteWithOptions_PartialRefundAmount(t *testing.T) {
+	t.Parallel()
+
+	now := time.Date(2025, 6, 15, 12, 0, 0, 0, time.UTC)
+	r := NewRouter(allProcessors(), allCompatRules())
+
+	tx := model.Transaction{
+		ID: "tx-partial", Country: model.CountryBR, Currency: model.CurrencyBRL,
+		PaymentMethod: model.MethodPIX, ProcessorID: "paybr", Amount: money.FromFloat(500.0),
+		Timestamp: now.Add(-48 * time.Hour), Settled: true,
+	}
+
+	full := r.SelectRoute(tx, now)
+	partial := r.SelectRouteWithOptions(tx, RouteOptions{RefundAmount: money.FromFloat(100.0)}, now)
+
+	if !almostEqual(full.RefundAmount.Float64(), 500.0) {
+		t.Errorf("full RefundAmount = %s, want 500.00", full.RefundAmount)
+	}
+	if !almostEqual(partial.RefundAmount.Float64(), 100.0) {
+		t.Errorf("partial RefundAmount = %s, want 100.00", partial.RefundAmount)
+	}
+	if partial.Selected.EstimatedCost > full.Selected.EstimatedCost {
+		t.Errorf("partial cost %s > full cost %s", partial.Selected.EstimatedCost, full.Selected.EstimatedCost)
+	}
+}
+
+func TestReload_SwapsProcessorsAndRules(t *testing.T) {
+	t.Parallel()
+
+	now := time.Date(2025, 6, 15, 12, 0, 0, 0, time.UTC)
+	r := NewRouter(allProcessors(), allCompatRules())
+
+	tx := model.Transaction{
+		ID: "tx-reload", Country: model.CountryBR, Currency: model.CurrencyBRL,
+		PaymentMethod: model.MethodPIX, ProcessorID: "globalpay", Amount: money.FromFloat(320.0),
+		Timestamp: now.Add(-48 * time.Hour), Settled: true,
+	}
+	if got := r.SelectRoute(tx, now).Selected.ProcessorID; got != "paybr" {
+		t.Fatalf("before reload Selected.ProcessorID = %s, want paybr", got)
+	}
+
+	var procs []model.Processor
+	for _, p := range allProcessors() {
+		if p.ID != "paybr" {
+			procs = append(procs, p)
+		}
+	}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 50; j++ {
+				r.SelectRoute(tx, now)
+			}
+		}()
+	}
+	r.Reload(procs, allCompatRules())
+	wg.Wait()
+
+	result := r.SelectRoute(tx, now)
+	if result.Selected.ProcessorID == "paybr" {
+		t.Error("removed processor paybr still selected after reload")
+	}
+	for _, alt := range result.Alternatives {
+		if alt.ProcessorID == "paybr" {
+			t.Errorf("removed processor paybr listed in Alternatives after reload")
+		}
+	}
+}
+
+func TestSelectRouteWithOptions_PricingTime(t *testing.T) {
+	t.Parallel()
+
+	now := time.Date(2025, 6, 15, 12, 0, 0, 0, time.UTC)
+	cutover := time.Date(2025, 6, 1, 0, 0, 0, 0, time.UTC)
+
+	procs := allProcessors()
+	for i := range procs {
+		if procs[i].ID != "paybr" {
+			continue
+		}
+		for j := range procs[i].RefundFees {
+			fee := procs[i].RefundFees[j]
+			if fee.Method == model.RefundSameMethod {
+				fee.BaseFee += money.FromFloat(1.0)
+				fee.EffectiveFrom = &cutover
+				procs[i].RefundFees = append(procs[i].RefundFees, fee)
+				break
+			}
+		}
+	}
+	r := NewRouter(procs, allCompatRules())
+
+	tx := model.Transaction{
+		ID: "tx-pricing", Country: model.CountryBR, Currency: model.CurrencyBRL,
+		PaymentMethod: model.MethodPIX, ProcessorID: "paybr", Amount: money.FromFloat(320.0),
+		Timestamp: time.Date(2025, 5, 10, 0, 0, 0, 0, time.UTC), Settled: true,
+	}
+
+	current := r.SelectRoute(tx, now)
+	atTx := r.SelectRouteWithOptions(tx, RouteOptions{PricingTime: tx.Timestamp}, now)
+
+	if current.NaiveCost-atTx.NaiveCost != money.FromFloat(1.0) {
+		t.Errorf("NaiveCost current %s vs at transaction %s, want 1.00 difference", current.NaiveCost, atTx.NaiveCost)
+	}
+	if atTx.Selected.ProcessorID != "paybr" {
+		t.Errorf("at transaction time Selected.ProcessorID = %s, want paybr", atTx.Selected.ProcessorID)
+	}
+}
+
+func TestSelectRoute_TierReasoning(t *testing.T) {
+	t.Parallel()
+
+	now := time.Date(2025, 6, 15, 12, 0, 0, 0, time.UTC)
+	procs := allProcessors()
+	for i := range procs {
+		if procs[i].ID != "paybr" {
+			continue
+		}
+		for j := range procs[i].RefundFees {
+			if procs[i].RefundFees[j].Method == model.RefundSameMethod {
+				procs[i].RefundFees[j].AmountTiers = []model.AmountTier{{Above: money.FromFloat(1000), PercentFee: 0.001}}
+				procs[i].RefundFees[j].VolumeTiers = []model.VolumeTier{{MinMonthlyRefunds: 2, Discount: 0.5}}
+			}
+		}
+	}
+	r := NewRouter(procs, allCompatRules())
+	r.Quota = quota.NewTracker(procs)
+
+	tx := model.Transaction{
+		ID: "tx-tiers", Country: model.CountryBR, Currency: model.CurrencyBRL,
+		PaymentMethod: model.MethodPIX, ProcessorID: "paybr", Amount: money.FromFloat(2000.0),
+		Timestamp: now.Add(-48 * time.Hour), Settled: true,
+	}
+
+	before := r.SelectRoute(tx, now)
+	if before.Selected.ProcessorID != "paybr" {
+		t.Fatalf("Selected.ProcessorID = %s, want paybr", before.Selected.ProcessorID)
+	}
+	if !strings.Contains(before.Selected.Reasoning, "amount tier above 1000.00") {
+		t.Errorf("Reasoning = %q, want amount tier note", before.Selected.Reasoning)
+	}
+	if strings.Contains(before.Selected.Reasoning, "volume tier") {
+		t.Errorf("Reasoning = %q, want no volume tier before any volume", before.Selected.Reasoning)
+	}
+
+	r.Quota.Consume("paybr", now)
+	r.Quota.Consume("paybr", now)
+
+	after := r.SelectRoute(tx, now)
+	if !strings.Contains(after.Selected.Reasoning, "volume tier 2+ refunds/month applied") {
+		t.Errorf("Reasoning = %q, want volume tier note", after.Selected.Reasoning)
+	}
+	if after.Selected.EstimatedCost >= before.Selected.EstimatedCost && before.Selected.EstimatedCost > 0 {
+		t.Errorf("EstimatedCost with volume discount %s, want below %s", after.Selected.EstimatedCost, before.Selected.EstimatedCost)
+	}
+}
+
+func TestSelectRouteWithOptions_VolumeTierAtPricingTime(t *testing.T) {
+	t.Parallel()
+
+	now := time.Date(2025, 6, 15, 12, 0, 0, 0, time.UTC)
+	procs := allProcessors()
+	for i := range procs {
+		if procs[i].ID != "paybr" {
+			continue
+		}
+		for j := range procs[i].RefundFees {
+			procs[i].RefundFees[j].VolumeTiers = []model.VolumeTier{{MinMonthlyRefunds: 2, Discount: 0.5}}
+		}
+	}
+	r := NewRouter(procs, allCompatRules())
+	r.Quota = quota.NewTracker(procs)
+	r.Quota.Consume("paybr", now.Add(-time.Hour))
+	r.Quota.Consume("paybr", now.Add(-time.Hour))
+
+	tx := model.Transaction{
+		ID: "tx-volume-history", Country: model.CountryBR, Currency: model.CurrencyBRL,
+		PaymentMethod: model.MethodPIX, ProcessorID: "paybr", Amount: money.FromFloat(320.0),
+		Timestamp: time.Date(2025, 5, 10, 0, 0, 0, 0, time.UTC), Settled: true,
+	}
+
+	current := r.SelectRoute(tx, now)
+	atTx := r.SelectRouteWithOptions(tx, RouteOptions{PricingTime: tx.Timestamp}, now)
+
+	if !strings.Contains(current.Selected.Reasoning, "volume tier 2+ refunds/month applied") {
+		t.Errorf("current Reasoning = %q, want this month's volume tier", current.Selected.Reasoning)
+	}
+	if strings.Contains(atTx.Selected.Reasoning, "volume tier") {
+		t.Errorf("at transaction time Reasoning = %q, want no tier from June's volume on a May refund", atTx.Selected.Reasoning)
+	}
+	if atTx.NaiveCost <= current.NaiveCost {
+		t.Errorf("NaiveCost at transaction time %s, want above the discounted current %s", atTx.NaiveCost, current.NaiveCost)
+	}
+	if earlier := r.SelectRouteWithOptions(tx, RouteOptions{PricingTime: now.Add(-2 * time.Hour)}, now); strings.Contains(earlier.Selected.Reasoning, "volume tier") {
+		t.Errorf("priced before this month's refunds Reasoning = %q, want no volume tier", earlier.Selected.Reasoning)
+	}
+}
+
+func TestSelectRoute_LandedCostBreakdown(t *testing.T) {
+	t.Parallel()
+
+	now := time.Date(2025, 6, 15, 12, 0, 0, 0, time.UTC)
+	r := NewRouter(allProcessors(), allCompatRules())
+	r.Taxes = map[model.Country][]model.TaxRule{
+		model.CountryBR: {{Name: "IOF", Rate: 0.01, RefundMethods: []model.RefundMethod{model.RefundSameMethod}}},
+	}
+
+	tx := model.Transaction{
+		ID: "tx-landed", Country: model.CountryBR, Currency: model.CurrencyBRL,
+		PaymentMethod: model.MethodPIX, ProcessorID: "paybr", Amount: money.FromFloat(200.0),
+		Timestamp: now.Add(-48 * time.Hour), Settled: true,
+	}
+
+	result := r.SelectRoute(tx, now)
+	if result.Selected.RefundMethod != model.RefundBankTransfer || result.Selected.CostBreakdown.Tax != 0 {
+		t.Errorf("Selected = %s %s, want an untaxed BANK_TRANSFER once SAME_METHOD carries IOF",
+			result.Selected.ProcessorID, result.Selected.RefundMethod)
+	}
+
+	var paybr *model.RefundCandidate
+	for i, c := range result.Alternatives {
+		if c.ProcessorID == "paybr" && c.RefundMethod == model.RefundSameMethod {
+			paybr = &result.Alternatives[i]
+		}
+	}
+	if paybr == nil {
+		t.Fatal("expected paybr SAME_METHOD among alternatives")
+	}
+	b := paybr.CostBreakdown
+	if b.Fee != money.FromFloat(1.5) || b.Tax != money.FromFloat(2) || b.Total != money.FromFloat(3.5) {
+		t.Errorf("CostBreakdown = %+v, want fee 1.50 + tax 2.00", b)
+	}
+	if paybr.EstimatedCost != b.Total {
+		t.Errorf("EstimatedCost = %s, want landed total %s", paybr.EstimatedCost, b.Total)
+	}
+	if !strings.Contains(paybr.Reasoning, "landed cost 3.50 BRL incl. IOF 2.00") {
+		t.Errorf("Reasoning = %q, want landed cost note", paybr.Reasoning)
+	}
+	if result.NaiveCost != money.FromFloat(3.5) {
+		t.Errorf("NaiveCost = %s, want 3.50 including tax", result.NaiveCost)
+	}
+}
+
+func TestSelectRoute_StructuredBreakdown(t *testing.T) {
+	t.Parallel()
+
+	now := time.Date(2025, 6, 15, 12, 0, 0, 0, time.UTC)
+	procs := allProcessors()
+	r := NewRouter(procs, allCompatRules())
+
+	tx := model.Transaction{
+		ID: "tx-structured", Country: model.CountryBR, Currency: model.CurrencyBRL,
+		PaymentMethod: model.MethodPIX, ProcessorID: "paybr", Amount: money.FromFloat(200.0),
+		Timestamp: now.Add(-48 * time.Hour), Settled: true,
+	}
+
+	sel := r.SelectRoute(tx, now).Selected
+	if sel.ProcessorID != "paybr" || sel.RefundMethod != model.RefundSameMethod {
+		t.Fatalf("Selected = %s %s, want paybr SAME_METHOD", sel.ProcessorID, sel.RefundMethod)
+	}
+	b := sel.CostBreakdown
+	if b.BaseFee != money.FromFloat(0.5) || b.PercentComponent != money.FromFloat(1.0) || b.Clamp != "" || b.Fee != money.FromFloat(1.5) {
+		t.Errorf("CostBreakdown = %+v, want 0.50 base + 1.00 percent, unclamped", b)
+	}
+	wantCodes := []model.ReasonCode{model.ReasonRuleAllowed, model.ReasonWithinWindow}
+	if !slices.Equal(sel.Eligibility.ReasonCodes, wantCodes) {
+		t.Errorf("ReasonCodes = %v, want %v", sel.Eligibility.ReasonCodes, wantCodes)
+	}
+	if w := sel.Eligibility.Window; w == nil || w.Used != 2 || w.Remaining != 88 {
+		t.Errorf("Window = %+v, want 2 of 90 days used", sel.Eligibility.Window)
+	}
+
+	small := tx
+	small.Amount = money.FromFloat(10.0)
+	if got := r.SelectRoute(small, now).Selected.CostBreakdown.Clamp; got != model.ClampMin {
+		t.Errorf("Clamp = %q, want min for a fee below min_fee", got)
+	}
+
+	r.Quota = quota.NewTracker(procs)
+	r.Quota.SetOverrides(map[string]model.ProcessorOverride{"paybr": {AtCapacity: boolPtr(true)}})
+	result := r.SelectRoute(tx, now)
+	if !slices.Contains(result.Selected.Eligibility.ReasonCodes, model.ReasonHigherRankedUnavailable) {
+		t.Errorf("ReasonCodes = %v, want HIGHER_RANKED_UNAVAILABLE after skipping paybr", result.Selected.Eligibility.ReasonCodes)
+	}
+	for _, c := range result.Alternatives {
+		if slices.Contains(c.Eligibility.ReasonCodes, model.ReasonHigherRankedUnavailable) {
+			t.Errorf("%s %s shares the selected candidate's reason codes", c.ProcessorID, c.RefundMethod)
+		}
+	}
+}
+
+func TestSelectRoute_LocalizedReasoning(t *testing.T) {
+	t.Parallel()
+
+	now := time.Date(2025, 6, 15, 12, 0, 0, 0, time.UTC)
+	r := NewRouter(allProcessors(), allCompatRules())
+
+	tx := model.Transaction{
+		ID: "tx-locale", Country: model.CountryBR, Currency: model.CurrencyBRL,
+		PaymentMethod: model.MethodPIX, ProcessorID: "paybr", Amount: money.FromFloat(1200.0),
+		Timestamp: now.Add(-48 * time.Hour), Settled: true,
+	}
+
+	en := r.SelectRoute(tx, now).Selected.Reasoning
+	pt := r.SelectRouteWithOptions(tx, RouteOptions{Locale: i18n.PortugueseBR}, now).Selected.Reasoning
+
+	if want := "PIX-to-PIX via PayBR: 0.50 base + 0.5% = 6.50 BRL, 1 day processing; Within SAME_METHOD window (2 of 90 days used, 88 remaining)"; en != want {
+		t.Errorf("English reasoning = %q, want %q", en, want)
+	}
+	if want := "PIX para PIX via PayBR: 0,50 de base + 0,5% = R$ 6,50, processamento em 1 dia; Dentro do prazo de SAME_METHOD (2 de 90 dias usados, 88 restantes)"; pt != want {
+		t.Errorf("pt-BR reasoning = %q, want %q", pt, want)
+	}
+}
+
+func TestSelectRoute_ProcessorScopedRule(t *testing.T) {
+	t.Parallel()
+
+	now := time.Date(2025, 6, 15, 12, 0, 0, 0, time.UTC)
+	compat := append(allCompatRules(), model.CompatibilityRule{
+		OriginalMethod: model.MethodPIX,
+		Country:        model.CountryBR,
+		ProcessorID:    "paybr",
+		AllowedRefunds: []model.AllowedRefund{
+			{Method: model.RefundSameMethod, MaxAgeDays: 120},
+			{Method: model.RefundBankTransfer},
+		},
+	})
+	r := NewRouter(allProcessors(), compat)
+
+	tx := model.Transaction{
+		ID: "tx-scoped", Country: model.CountryBR, Currency: model.CurrencyBRL,
+		PaymentMethod: model.MethodPIX, ProcessorID: "globalpay", Amount: money.FromFloat(320.0),
+		Timestamp: now.Add(-100 * 24 * time.Hour), Settled: true,
+	}
+
+	result := r.SelectRoute(tx, now)
+	if result.Selected.ProcessorID != "paybr" || result.Selected.RefundMethod != model.RefundSameMethod {
+		t.Fatalf("Selected = %s %s, want paybr SAME_METHOD under its 120-day rule", result.Selected.ProcessorID, result.Selected.RefundMethod)
+	}
+	if !slices.Contains(result.Selected.Eligibility.ReasonCodes, model.ReasonProcessorRule) {
+		t.Errorf("ReasonCodes = %v, want PROCESSOR_RULE", result.Selected.Eligibility.ReasonCodes)
+	}
+	for _, c := range result.Alternatives {
+		if c.RefundMethod == model.RefundSameMethod {
+			t.Errorf("%s offered SAME_METHOD past the generic 90-day window", c.ProcessorID)
+		}
+	}
+}
+
+func TestSelectRoute_ReversalPolicies(t *testing.T) {
+	t.Parallel()
+
+	now := time.Date(2025, 6, 16, 3, 0, 0, 0, time.UTC)
+	procs := allProcessors()
+	for i := range procs {
+		if procs[i].ID == "paybr" {
+			procs[i].ReversalWindow = &model.ReversalPolicy{Cutoff: "23:00", TimeZone: "America/Sao_Paulo"}
+		}
+	}
+	r := NewRouter(procs, allCompatRules())
+
+	tx := model.Transaction{
+		ID: "tx-cutoff", Country: model.CountryBR, Currency: model.CurrencyBRL,
+		PaymentMethod: model.MethodCreditCard, ProcessorID: "paybr", Amount: money.FromFloat(500.0),
+		Timestamp: now.Add(-15 * time.Hour),
+	}
+
+	result := r.SelectRoute(tx, now)
+	if result.Selected.RefundMethod != model.RefundReversal || result.Selected.ProcessorID == "paybr" {
+		t.Fatalf("Selected = %s %s, want a REVERSAL outside paybr", result.Selected.ProcessorID, result.Selected.RefundMethod)
+	}
+	for _, c := range append([]model.RefundCandidate{result.Selected}, result.Alternatives...) {
+		if c.ProcessorID == "paybr" && c.RefundMethod == model.RefundReversal {
+			t.Errorf("paybr offered REVERSAL after its 23:00 Sao Paulo cutoff")
+		}
+	}
+
+	tx.Timestamp = now.Add(-30 * time.Hour)
+	if got := r.SelectRoute(tx, now).Selected.RefundMethod; got == model.RefundReversal {
+		t.Fatalf("Selected = %s at 30 hours, want no reversal under the default policy", got)
+	}
+	r.SetReversalPolicies(map[model.Country]*model.ReversalPolicy{model.CountryBR: {Hours: 48}})
+	selected := r.SelectRoute(tx, now).Selected
+	if selected.RefundMethod != model.RefundReversal || selected.Eligibility.Window.Limit != 48 {
+		t.Errorf("Selected = %s %s window %+v, want REVERSAL under the 48 hour BR policy", selected.ProcessorID, selected.RefundMethod, selected.Eligibility.Window)
+	}
+}
+
+func TestSelectRoute_ExpectedCompletion(t *testing.T) {
+	t.Parallel()
+
+	friday := time.Date(2026, 2, 13, 15, 0, 0, 0, time.UTC)
+	cal, err := calendar.New("America/Sao_Paulo", []string{"2026-02-16", "2026-02-17"})
+	if err != nil {
+		t.Fatalf("calendar.New() error = %v", err)
+	}
+	r := NewRouter(allProcessors(), allCompatRules())
+
+	tx := model.Transaction{
+		ID: "tx-carnaval", Country: model.CountryBR, Currency: model.CurrencyBRL,
+		PaymentMethod: model.MethodBoleto, ProcessorID: "paybr", Amount: money.FromFloat(200.0),
+		Timestamp: friday.Add(-72 * time.Hour), Settled: true,
+	}
+
+	before := r.SelectRoute(tx, friday).Selected
+	if want := friday.AddDate(0, 0, before.ProcessingDays); !before.ExpectedCompletion.Equal(want) {
+		t.Errorf("ExpectedCompletion without calendar = %v, want %v", before.ExpectedCompletion, want)
+	}
+
+	r.SetCalendars(map[model.Country]*calendar.Calendar{model.CountryBR: cal})
+	result := r.SelectRoute(tx, friday)
+	want := map[int]time.Time{
+		0: friday,
+		1: time.Date(2026, 2, 18, 15, 0, 0, 0, time.UTC),
+		2: time.Date(2026, 2, 19, 15, 0, 0, 0, time.UTC),
+		3: time.Date(2026, 2, 20, 15, 0, 0, 0, time.UTC),
+		5: time.Date(2026, 2, 24, 15, 0, 0, 0, time.UTC),
+	}
+	for _, c := range append([]model.RefundCandidate{result.Selected}, result.Alternatives...) {
+		w, ok := want[c.ProcessingDays]
+		if !ok {
+			t.Fatalf("%s %s has unexpected ProcessingDays %d", c.ProcessorID, c.RefundMethod, c.ProcessingDays)
+		}
+		if !c.ExpectedCompletion.Equal(w) {
+			t.Errorf("%s %s (%d days) ExpectedCompletion = %v, want %v after the weekend and Carnaval", c.ProcessorID, c.RefundMethod, c.ProcessingDays, c.ExpectedCompletion, w)
+		}
+	}
+}
+
+func TestCommitRoute_QuotaLimits(t *testing.T) {
+	t.Parallel()
+
+	now := time.Date(2025, 6, 15, 12, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name      string
+		limit     model.QuotaLimit
+		wantLabel string
+		wantReset time.Time
+	}{
+		{
+			name:      "hourly",
+			limit:     model.QuotaLimit{Period: model.QuotaPeriodHour, Limit: 1},
+			wantLabel: "Hourly quota",
+			wantReset: time.Date(2025, 6, 15, 13, 0, 0, 0, time.UTC),
+		},
+		{
+			name:      "daily in local time zone",
+			limit:     model.QuotaLimit{Period: model.QuotaPeriodDay, Limit: 1, TimeZone: "America/Sao_Paulo"},
+			wantLabel: "Daily quota",
+			wantReset: time.Date(2025, 6, 16, 3, 0, 0, 0, time.UTC),
+		},
+		{
+			name:      "monthly",
+			limit:     model.QuotaLimit{Period: model.QuotaPeriodMonth, Limit: 1},
+			wantLabel: "Monthly quota",
+			wantReset: time.Date(2025, 7, 1, 0, 0, 0, 0, time.UTC),
+		},
+		{
+			name:      "rolling window",
+			limit:     model.QuotaLimit{Period: model.QuotaPeriodRolling, Limit: 1, Window: "30m"},
+			wantLabel: "Rolling 30m quota",
+			wantReset: time.Date(2025, 6, 15, 12, 31, 0, 0, time.UTC),
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			procs := allProcessors()
+			for i := range procs {
+				if procs[i].ID == "paybr" {
+					procs[i].DailyQuota = 0
+					procs[i].QuotaLimits = []model.QuotaLimit{tc.limit}
+				}
+			}
+			r := NewRouter(procs, allCompatRules())
+			r.Quota = quota.NewTracker(procs)
+
+			tx := model.Transaction{
+				ID: "tx-limits", Country: model.CountryBR, Currency: model.CurrencyBRL,
+				PaymentMethod: model.MethodPIX, ProcessorID: "globalpay", Amount: money.FromFloat(320.0),
+				Timestamp: now.Add(-48 * time.Hour), Settled: true,
+			}
+			if got := r.CommitRoute(tx, now).Selected.ProcessorID; got != "paybr" {
+				t.Fatalf("Selected.ProcessorID = %s, want paybr", got)
+			}
+
+			a := r.Quota.Availability("paybr", now.Add(10*time.Minute))
+			if a.Available {
+				t.Fatal("paybr still available after consuming its only slot")
+			}
+			if !a.NextReset.Equal(tc.wantReset) {
+				t.Errorf("NextReset = %v, want %v", a.NextReset, tc.wantReset)
+			}
+			if want := tc.wantLabel + " exhausted: 1/1 used; resets at " + tc.wantReset.Format(time.RFC3339); a.Reason != want {
+				t.Errorf("Reason = %q, want %q", a.Reason, want)
+			}
+			if ok, reason := r.Quota.IsAvailable("paybr", tc.wantReset); !ok {
+				t.Errorf("IsAvailable at NextReset = false (%s), want true", reason)
+			}
+
+			for _, s := range r.Quota.Status(now) {
+				if s.ProcessorID != "paybr" {
+					continue
+				}
+				if len(s.Limits) != 1 || s.Limits[0].Used != 1 || s.Limits[0].Remaining == nil || *s.Limits[0].Remaining != 0 {
+					t.Errorf("Status Limits = %+v, want one limit with 1 used and 0 remaining", s.Limits)
+				}
+				if !s.NextReset.Equal(tc.wantReset) {
+					t.Errorf("Status NextReset = %v, want %v", s.NextReset, tc.wantReset)
+				}
+			}
+		})
+	}
+}
+
+func TestCommitRoute_VolumeAndMethodLimits(t *testing.T) {
+	t.Parallel()
+
+	now := time.Date(2025, 6, 15, 12, 0, 0, 0, time.UTC)
+	resets := "resets at 2025-06-16T03:00:00Z"
+	tests := []struct {
+		name       string
+		limit      model.QuotaLimit
+		amounts    []float64
+		wantPaybr  []bool
+		wantReason string
+	}{
+		{
+			name:       "volume cap skips refunds that don't fit",
+			limit:      model.QuotaLimit{Period: model.QuotaPeriodDay, Amount: money.FromFloat(500), Currency: model.CurrencyBRL, Method: model.RefundSameMethod, TimeZone: "America/Sao_Paulo"},
+			amounts:    []float64{320, 320, 180},
+			wantPaybr:  []bool{true, false, true},
+			wantReason: "Daily SAME_METHOD volume cap reached: 320.00 of 500.00 BRL used, refund of 320.00 BRL doesn't fit; " + resets,
+		},
+		{
+			name:       "refund larger than the cap",
+			limit:      model.QuotaLimit{Period: model.QuotaPeriodDay, Amount: money.FromFloat(500), Currency: model.CurrencyBRL, TimeZone: "America/Sao_Paulo"},
+			amounts:    []float64{600},
+			wantPaybr:  []bool{false},
+			wantReason: "Daily volume cap is 500.00 BRL; refund of 600.00 BRL exceeds it",
+		},
+		{
+			name:      "cap in another currency doesn't apply",
+			limit:     model.QuotaLimit{Period: model.QuotaPeriodDay, Amount: money.FromFloat(100), Currency: model.CurrencyUSD},
+			amounts:   []float64{320, 320},
+			wantPaybr: []bool{true, true},
+		},
+		{
+			name:       "per-method count limit",
+			limit:      model.QuotaLimit{Period: model.QuotaPeriodDay, Limit: 1, Method: model.RefundSameMethod, TimeZone: "America/Sao_Paulo"},
+			amounts:    []float64{320, 320},
+			wantPaybr:  []bool{true, false},
+			wantReason: "Daily SAME_METHOD quota exhausted: 1/1 used; " + resets,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			procs := allProcessors()
+			for i := range procs {
+				if procs[i].ID == "paybr" {
+					procs[i].QuotaLimits = []model.QuotaLimit{tc.limit}
+				}
+			}
+			r := NewRouter(procs, allCompatRules())
+			r.Quota = quota.NewTracker(procs)
+
+			tx := model.Transaction{
+				ID: "tx-volume", Country: model.CountryBR, Currency: model.CurrencyBRL,
+				PaymentMethod: model.MethodPIX, ProcessorID: "globalpay", Amount: money.FromFloat(1000.0),
+				Timestamp: now.Add(-48 * time.Hour), Settled: true,
+			}
+			var reason string
+			for i, amount := range tc.amounts {
+				result := r.CommitRouteWithOptions(tx, RouteOptions{RefundAmount: money.FromFloat(amount)}, now)
+				if got := result.Selected.ProcessorID == "paybr"; got != tc.wantPaybr[i] {
+					t.Fatalf("refund %d of %.2f: selected %s %s, want paybr = %v", i, amount, result.Selected.ProcessorID, result.Selected.RefundMethod, tc.wantPaybr[i])
+				}
+				for _, c := range result.Unavailable {
+					if c.ProcessorID == "paybr" {
+						reason = c.UnavailableReason
+					}
+				}
+			}
+			if reason != tc.wantReason {
+				t.Errorf("paybr UnavailableReason = %q, want %q", reason, tc.wantReason)
+			}
+			if ok, reason := r.Quota.IsAvailable("paybr", now); !ok {
+				t.Errorf("IsAvailable(paybr) = false (%s), want true: method and currency limits don't block the processor", reason)
+			}
+		})
+	}
+}
+
+func TestReserveRoute_ReleaseAndExpiry(t *testing.T) {
+	t.Parallel()
+
+	now := time.Date(2025, 6, 15, 12, 0, 0, 0, time.UTC)
+	procs := allProcessors()
+	for i := range procs {
+		if procs[i].ID == "paybr" {
+			procs[i].DailyQuota = 1
+		}
+	}
+	r := NewRouter(procs, allCompatRules())
+	r.Quota = quota.NewTracker(procs)
+	r.Quota.ReservationTTL = time.Minute
+
+	tx := model.Transaction{
+		ID: "tx-reserve", Country: model.CountryBR, Currency: model.CurrencyBRL,
+		PaymentMethod: model.MethodPIX, ProcessorID: "globalpay", Amount: money.FromFloat(320.0),
+		Timestamp: now.Add(-48 * time.Hour), Settled: true,
+	}
+
+	first := r.ReserveRouteWithOptions(tx, RouteOptions{}, now)
+	if first.Selected.ProcessorID != "paybr" || first.Reservation == "" {
+		t.Fatalf("Selected = %s with reservation %q, want paybr with a reservation", first.Selected.ProcessorID, first.Reservation)
+	}
+	if ok, _ := r.Quota.IsAvailable("paybr", now); ok {
+		t.Error("paybr available while its only slot is reserved")
+	}
+	if err := r.Quota.Release(first.Reservation, now); err != nil {
+		t.Fatalf("Release() error = %v", err)
+	}
+	if ok, reason := r.Quota.IsAvailable("paybr", now); !ok {
+		t.Errorf("paybr unavailable after release: %s", reason)
+	}
+
+	second := r.ReserveRouteWithOptions(tx, RouteOptions{}, now)
+	if second.Selected.ProcessorID != "paybr" {
+		t.Fatalf("second Selected = %s, want paybr", second.Selected.ProcessorID)
+	}
+	later := now.Add(2 * time.Minute)
+	if ok, reason := r.Quota.IsAvailable("paybr", later); !ok {
+		t.Errorf("paybr unavailable after the reservation expired: %s", reason)
+	}
+	if err := r.Quota.Release(second.Reservation, later); err != nil {
+		t.Errorf("Release(expired) error = %v, want nil for a slot already freed", err)
+	}
+	if err := r.Quota.Release(second.Reservation, later); !errors.Is(err, quota.ErrReservationNotFound) {
+		t.Errorf("second Release(expired) error = %v, want ErrReservationNotFound", err)
+	}
+
+	third := r.ReserveRouteWithOptions(tx, RouteOptions{}, later)
+	if err := r.Quota.Commit(third.Reservation, later); err != nil {
+		t.Fatalf("Commit() error = %v", err)
+	}
+	if ok, _ := r.Quota.IsAvailable("paybr", later.Add(time.Hour)); ok {
+		t.Error("paybr available after its committed slot, want the commit to survive expiry")
+	}
+}
+
+func TestReserveCandidate(t *testing.T) {
+	t.Parallel()
+
+	now := time.Date(2025, 6, 15, 12, 0, 0, 0, time.UTC)
+	procs := allProcessors()
+	for i := range procs {
+		if procs[i].ID == "valueproc" {
+			procs[i].DailyQuota = 1
+		}
+	}
+	r := NewRouter(procs, allCompatRules())
+	r.Quota = quota.NewTracker(procs)
+	r.Quota.SetOverrides(map[string]model.ProcessorOverride{"quickrefund": {Available: boolPtr(false)}})
+
+	tx := model.Transaction{
+		ID: "tx-candidate", Country: model.CountryBR, Currency: model.CurrencyBRL,
+		PaymentMethod: model.MethodPIX, ProcessorID: "globalpay", Amount: money.FromFloat(320.0),
+		Timestamp: now.Add(-48 * time.Hour), Settled: true,
+	}
+	valueproc := model.RefundCandidate{ProcessorID: "valueproc", RefundMethod: model.RefundSameMethod}
+
+	route, err := r.ReserveCandidateWithOptions(tx, valueproc, RouteOptions{}, now)
+	if err != nil {
+		t.Fatalf("ReserveCandidateWithOptions() error = %v", err)
+	}
+	if route.Selected.ProcessorID != "valueproc" || route.Reservation == "" {
+		t.Fatalf("Selected = %s with reservation %q, want valueproc with a reservation", route.Selected.ProcessorID, route.Reservation)
+	}
+	if route.Savings != route.NaiveCost-route.Selected.EstimatedCost {
+		t.Errorf("Savings = %s, want savings against the chosen candidate", route.Savings)
+	}
+	for _, alt := range route.Alternatives {
+		if alt.ProcessorID == "valueproc" && alt.RefundMethod == model.RefundSameMethod {
+			t.Error("chosen candidate also listed as an alternative")
+		}
+	}
+	if ok, _ := r.Quota.IsAvailable("valueproc", now); ok {
+		t.Error("valueproc available while its only slot is reserved")
+	}
+
+	tests := []struct {
+		name string
+		want model.RefundCandidate
+		err  error
+	}{
+		{"exhausted by the reservation", valueproc, ErrCandidateUnavailable},
+		{"disabled processor", model.RefundCandidate{ProcessorID: "quickrefund", RefundMethod: model.RefundSameMethod}, ErrCandidateUnavailable},
+		{"not eligible", model.RefundCandidate{ProcessorID: "mexpay", RefundMethod: model.RefundSameMethod}, ErrCandidateNotEligible},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			route, err := r.ReserveCandidateWithOptions(tx, tt.want, RouteOptions{}, now)
+			if !errors.Is(err, tt.err) {
+				t.Errorf("error = %v, want %v", err, tt.err)
+			}
+			if route.Reservation != "" {
+				t.Errorf("Reservation = %q, want none", route.Reservation)
+			}
+		})
+	}
+}
+
+func TestApply_SwapsConfigTogether(t *testing.T) {
+	t.Parallel()
+
+	now := time.Date(2025, 6, 15, 12, 0, 0, 0, time.UTC)
+	tx := model.Transaction{
+		ID: "tx-apply", Country: model.CountryBR, Currency: model.CurrencyBRL,
+		PaymentMethod: model.MethodPIX, ProcessorID: "globalpay", Amount: money.FromFloat(320.0),
+		Timestamp: now.Add(-48 * time.Hour), Settled: true,
+	}
+
+	var withoutPayBR []model.Processor
+	for _, p := range allProcessors() {
+		if p.ID != "paybr" {
+			withoutPayBR = append(withoutPayBR, p)
+		}
+	}
+	untaxed := Config{Processors: allProcessors(), Rules: allCompatRules()}
+	taxed := Config{
+		Processors: withoutPayBR,
+		Rules:      allCompatRules(),
+		Scorers:    map[model.RoutingStrategy]Scorer{model.StrategyBalanced: BalancedScorer{DayValues: map[model.Currency]float64{model.CurrencyBRL: 7}}},
+		Taxes:      map[model.Country][]model.TaxRule{model.CountryBR: {{Name: "IOF", Rate: 0.01}}},
+	}
+
+	r := NewRouter(allProcessors(), allCompatRules())
+	done := make(chan struct{})
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for {
+				select {
+				case <-done:
+					return
+				default:
+				}
+				result := r.SelectRouteWithOptions(tx, RouteOptions{Strategy: model.StrategyBalanced}, now)
+				routesPayBR := result.Selected.ProcessorID == "paybr"
+				for _, alt := range result.Alternatives {
+					routesPayBR = routesPayBR || alt.ProcessorID == "paybr"
+				}
+				if isTaxed := result.Selected.CostBreakdown.Tax != 0; routesPayBR == isTaxed {
+					t.Errorf("route mixes config versions: paybr listed %v, taxed %v", routesPayBR, isTaxed)
+					return
+				}
+			}
+		}()
+	}
+	for i := 0; i < 50; i++ {
+		if i%2 == 0 {
+			r.Apply(taxed)
+		} else {
+			r.Apply(untaxed)
+		}
+	}
+	close(done)
+	wg.Wait()
+
+	r.Apply(taxed)
+	if b, ok := r.snapshot().scorers[model.StrategyBalanced].(BalancedScorer); !ok || b.DayValues[model.CurrencyBRL] != 7 {
+		t.Errorf("balanced scorer = %+v, want the applied day values", r.snapshot().scorers[model.StrategyBalanced])
+	}
+	for _, s := range []model.RoutingStrategy{model.StrategyCheapest, model.StrategyFastest, model.StrategyCustomerFirst} {
+		if !r.HasStrategy(s) {
+			t.Errorf("HasStrategy(%s) = false after Apply, want scorers not in the config kept", s)
+		}
+	}
+}
+
+func TestSelectRoute_DaysOfWeekInCountryTimeZone(t *testing.T) {
+	t.Parallel()
+
+	compat := allCompatRules()
+	for i := range compat {
+		if compat[i].OriginalMethod == model.MethodOXXO && compat[i].Country == model.CountryMX {
+			compat[i].AllowedRefunds = []model.AllowedRefund{
+				{Method: model.RefundBankTransfer, When: &model.RuleCondition{DaysOfWeek: []string{"SUN"}}},
+				{Method: model.RefundAccountCredit},
+			}
+		}
+	}
+	cal, err := calendar.New("America/Mexico_City", nil)
+	if err != nil {
+		t.Fatalf("calendar.New() error = %v", err)
+	}
+
+	sundayNight := time.Date(2025, 6, 16, 2, 0, 0, 0, time.UTC)
+	tx := model.Transaction{
+		ID: "tx-sunday", Country: model.CountryMX, Currency: model.CurrencyMXN,
+		PaymentMethod: model.MethodOXXO, ProcessorID: "mexpay", Amount: money.FromFloat(500.0),
+		Timestamp: sundayNight.Add(-48 * time.Hour), Settled: true,
+	}
+
+	tests := []struct {
+		name      string
+		calendars map[model.Country]*calendar.Calendar
+		want      model.RefundMethod
+	}{
+		{"UTC without a calendar", nil, model.RefundAccountCredit},
+		{"local day with a calendar", map[model.Country]*calendar.Calendar{model.CountryMX: cal}, model.RefundBankTransfer},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			r := NewRouter(allProcessors(), compat)
+			r.SetCalendars(tt.calendars)
+			if got := r.SelectRoute(tx, sundayNight).Selected.RefundMethod; got != tt.want {
+				t.Errorf("Selected.RefundMethod = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,89 @@
+package router
+
+import (
+	"github.com/ivanjtm/YunoChallenge/internal/model"
+)
+
+type Scorer interface {
+	Score(tx model.Transaction, c model.RefundCandidate) float64
+}
+
+type ScorerFunc func(tx model.Transaction, c model.RefundCandidate) float64
+
+func (f ScorerFunc) Score(tx model.Transaction, c model.RefundCandidate) float64 {
+	return f(tx, c)
+}
+
+func CheapestScorer() Scorer {
+	return ScorerFunc(func(_ model.Transaction, c model.RefundCandidate) float64 {
+		return c.EstimatedCost.Float64()
+	})
+}
+
+func FastestScorer() Scorer {
+	return ScorerFunc(func(_ model.Transaction, c model.RefundCandidate) float64 {
+		return float64(c.ProcessingDays)
+	})
+}
+
+type BalancedScorer struct {
+	DayValue  float64
+	DayValues map[model.Currency]float64
+}
+
+func (s BalancedScorer) Score(tx model.Transaction, c model.RefundCandidate) float64 {
+	dayValue := s.DayValue
+	if dayValue <= 0 {
+		dayValue = s.DayValues[tx.Currency]
+	}
+	return c.EstimatedCost.Float64() + float64(c.ProcessingDays)*dayValue
+}
+
+func CustomerFirstScorer() Scorer {
+	return ScorerFunc(func(_ model.Transaction, c model.RefundCandidate) float64 {
+		score := float64(c.ProcessingDays)
+		if c.RefundMethod != model.RefundReversal && c.RefundMethod != model.RefundSameMethod {
+			score += 0.5
+		}
+		return score
+	})
+}
+
+func defaultScorers() map[model.RoutingStrategy]Scorer {
+	return map[model.RoutingStrategy]Scorer{
+		model.StrategyCheapest:      CheapestScorer(),
+		model.StrategyFastest:       FastestScorer(),
+		model.StrategyBalanced:      BalancedScorer{},
+		model.StrategyCustomerFirst: CustomerFirstScorer(),
+	}
+}
+
+func (r *Router) HasStrategy(s model.RoutingStrategy) bool {
+	if s == "" {
+		return true
+	}
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	_, ok := r.Scorers[s]
+	return ok
+}
+
+func (c routingConfig) scorer(opts RouteOptions) (model.RoutingStrategy, Scorer) {
+	strategy := opts.Strategy
+	if strategy == "" {
+		strategy = c.defaultStrategy
+	}
+	if strategy == "" {
+		strategy = model.StrategyCheapest
+	}
+
+	s, ok := c.scorers[strategy]
+	if !ok {
+		return model.StrategyCheapest, CheapestScorer()
+	}
+	if b, ok := s.(BalancedScorer); ok && opts.DayValue > 0 {
+		b.DayValue = opts.DayValue
+		s = b
+	}
+	return strategy, s
+}
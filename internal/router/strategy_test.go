@@ -0,0 +1,128 @@
+package router
+
+import (
+	"testing"
+	"time"
+
+	"github.com/ivanjtm/YunoChallenge/internal/model"
+	"github.com/ivanjtm/YunoChallenge/internal/money"
+)
+
+func TestSelectRoute_Strategies(t *testing.T) {
+	t.Parallel()
+
+	now := time.Date(2025, 6, 15, 12, 0, 0, 0, time.UTC)
+	r := NewRouter(allProcessors(), allCompatRules())
+
+	tx := model.Transaction{
+		ID: "tx-strategy", Country: model.CountryMX, Currency: model.CurrencyMXN,
+		PaymentMethod: model.MethodCreditCard, ProcessorID: "mexpay", Amount: money.FromFloat(1000.0),
+		Timestamp: now.Add(-48 * time.Hour), Settled: true,
+	}
+
+	tests := []struct {
+		name          string
+		opts          RouteOptions
+		wantStrategy  model.RoutingStrategy
+		wantProcessor string
+		wantMethod    model.RefundMethod
+	}{
+		{"default is cheapest", RouteOptions{}, model.StrategyCheapest, "valueproc", model.RefundSameMethod},
+		{"cheapest", RouteOptions{Strategy: model.StrategyCheapest}, model.StrategyCheapest, "valueproc", model.RefundSameMethod},
+		{"fastest", RouteOptions{Strategy: model.StrategyFastest}, model.StrategyFastest, "quickrefund", model.RefundSameMethod},
+		{"balanced without configured day values", RouteOptions{Strategy: model.StrategyBalanced}, model.StrategyBalanced, "valueproc", model.RefundSameMethod},
+		{"balanced with high day value", RouteOptions{Strategy: model.StrategyBalanced, DayValue: 10}, model.StrategyBalanced, "mexpay", model.RefundBankTransfer},
+		{"customer first", RouteOptions{Strategy: model.StrategyCustomerFirst}, model.StrategyCustomerFirst, "quickrefund", model.RefundSameMethod},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			result := r.SelectRouteWithOptions(tx, tt.opts, now)
+			if result.Strategy != tt.wantStrategy {
+				t.Errorf("Strategy = %s, want %s", result.Strategy, tt.wantStrategy)
+			}
+			if result.Selected.ProcessorID != tt.wantProcessor || result.Selected.RefundMethod != tt.wantMethod {
+				t.Errorf("Selected = %s/%s, want %s/%s", result.Selected.ProcessorID, result.Selected.RefundMethod, tt.wantProcessor, tt.wantMethod)
+			}
+		})
+	}
+}
+
+func TestBalancedScorer_DayValues(t *testing.T) {
+	t.Parallel()
+
+	c := model.RefundCandidate{ProcessingDays: 3, EstimatedCost: money.FromFloat(20)}
+	configured := BalancedScorer{DayValues: map[model.Currency]float64{model.CurrencyMXN: 3.5, "CLP": 180}}
+
+	tests := []struct {
+		name     string
+		scorer   BalancedScorer
+		currency model.Currency
+		want     float64
+	}{
+		{"configured currency", configured, model.CurrencyMXN, 30.5},
+		{"currency added by config", configured, "CLP", 560},
+		{"currency without a day value", configured, model.CurrencyBRL, 20},
+		{"router default has no day values", defaultScorers()[model.StrategyBalanced].(BalancedScorer), model.CurrencyMXN, 20},
+		{"request day value wins", BalancedScorer{DayValue: 10, DayValues: configured.DayValues}, "CLP", 50},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			if got := tt.scorer.Score(model.Transaction{Currency: tt.currency}, c); got != tt.want {
+				t.Errorf("Score() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCustomerFirstScorer_PrefersOriginalInstrumentAtEqualSpeed(t *testing.T) {
+	t.Parallel()
+
+	s := CustomerFirstScorer()
+	tx := model.Transaction{Currency: model.CurrencyMXN}
+	same := model.RefundCandidate{RefundMethod: model.RefundSameMethod, ProcessingDays: 1, EstimatedCost: money.FromFloat(35)}
+	bank := model.RefundCandidate{RefundMethod: model.RefundBankTransfer, ProcessingDays: 1, EstimatedCost: money.FromFloat(20)}
+	slower := model.RefundCandidate{RefundMethod: model.RefundSameMethod, ProcessingDays: 2, EstimatedCost: money.FromFloat(10)}
+
+	if s.Score(tx, same) >= s.Score(tx, bank) {
+		t.Errorf("same-method score %.2f not below bank transfer score %.2f", s.Score(tx, same), s.Score(tx, bank))
+	}
+	if s.Score(tx, bank) >= s.Score(tx, slower) {
+		t.Errorf("1-day bank transfer score %.2f not below 2-day same-method score %.2f", s.Score(tx, bank), s.Score(tx, slower))
+	}
+}
+
+func TestSelectRoute_CustomScorerAndAccountCreditLast(t *testing.T) {
+	t.Parallel()
+
+	now := time.Date(2025, 6, 15, 12, 0, 0, 0, time.UTC)
+	r := NewRouter(allProcessors(), allCompatRules())
+	r.Scorers["prefer_globalpay"] = ScorerFunc(func(_ model.Transaction, c model.RefundCandidate) float64 {
+		if c.ProcessorID == "globalpay" {
+			return 0
+		}
+		return 1
+	})
+
+	tx := model.Transaction{
+		ID: "tx-custom", Country: model.CountryBR, Currency: model.CurrencyBRL,
+		PaymentMethod: model.MethodPIX, ProcessorID: "paybr", Amount: money.FromFloat(320.0),
+		Timestamp: now.Add(-48 * time.Hour), Settled: true,
+	}
+
+	if !r.HasStrategy("prefer_globalpay") || r.HasStrategy("unknown") {
+		t.Fatal("HasStrategy does not reflect registered scorers")
+	}
+
+	result := r.SelectRouteWithOptions(tx, RouteOptions{Strategy: "prefer_globalpay"}, now)
+	if result.Selected.ProcessorID != "globalpay" {
+		t.Errorf("Selected.ProcessorID = %s, want globalpay", result.Selected.ProcessorID)
+	}
+	for i, alt := range result.Alternatives {
+		if alt.RefundMethod == model.RefundAccountCredit && i != len(result.Alternatives)-1 {
+			t.Errorf("account credit at position %d of %d, want last", i, len(result.Alternatives))
+		}
+	}
+}
@@ -3,7 +3,8 @@ package rules
 import "github.com/ivanjtm/YunoChallenge/internal/model"
 
 type RuleIndex struct {
-	index map[string]model.CompatibilityRule
+	index  map[string]model.CompatibilityRule
+	scoped bool
 }
 
 func NewRuleIndex(rules []model.CompatibilityRule) *RuleIndex {
@@ -12,6 +13,10 @@ func NewRuleIndex(rules []model.CompatibilityRule) *RuleIndex {
 	}
 	for _, r := range rules {
 		k := key(r.OriginalMethod, r.Country)
+		if r.ProcessorID != "" {
+			k = processorKey(r.OriginalMethod, r.Country, r.ProcessorID)
+			idx.scoped = true
+		}
 		idx.index[k] = r
 	}
 	return idx
@@ -21,6 +26,10 @@ func key(method model.PaymentMethod, country model.Country) string {
 	return string(method) + ":" + string(country)
 }
 
+func processorKey(method model.PaymentMethod, country model.Country, processorID string) string {
+	return key(method, country) + ":" + processorID
+}
+
 func (ri *RuleIndex) Lookup(method model.PaymentMethod, country model.Country) *model.CompatibilityRule {
 	k := key(method, country)
 	rule, ok := ri.index[k]
@@ -30,6 +39,17 @@ func (ri *RuleIndex) Lookup(method model.PaymentMethod, country model.Country) *
 	return &rule
 }
 
+func (ri *RuleIndex) LookupProcessor(method model.PaymentMethod, country model.Country, processorID string) *model.CompatibilityRule {
+	if !ri.scoped {
+		return nil
+	}
+	rule, ok := ri.index[processorKey(method, country, processorID)]
+	if !ok {
+		return nil
+	}
+	return &rule
+}
+
 func (ri *RuleIndex) AllowedRefundMethods(method model.PaymentMethod, country model.Country) []model.AllowedRefund {
 	rule := ri.Lookup(method, country)
 	if rule == nil {
@@ -37,3 +57,23 @@ func (ri *RuleIndex) AllowedRefundMethods(method model.PaymentMethod, country mo
 	}
 	return rule.AllowedRefunds
 }
+
+func (ri *RuleIndex) AllowedRefundMethodsFor(method model.PaymentMethod, country model.Country, processorID string) []model.AllowedRefund {
+	if rule := ri.LookupProcessor(method, country, processorID); rule != nil {
+		return rule.AllowedRefunds
+	}
+	return ri.AllowedRefundMethods(method, country)
+}
+
+func (ri *RuleIndex) AllowsSelfRefund(method model.PaymentMethod, country model.Country) bool {
+	rule := ri.Lookup(method, country)
+	if rule == nil {
+		return true
+	}
+	for _, ar := range rule.AllowedRefunds {
+		if ar.Method == model.RefundSameMethod || ar.Method == model.RefundReversal {
+			return true
+		}
+	}
+	return false
+}
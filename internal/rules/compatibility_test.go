@@ -686,3 +686,32 @@ func TestLookup_ReturnedRuleIsIndependent(t *testing.T) {
 		t.Error("both lookups should return equivalent data")
 	}
 }
+
+func TestAllowsSelfRefund(t *testing.T) {
+	t.Parallel()
+
+	idx := NewRuleIndex(allRules())
+
+	tests := []struct {
+		name    string
+		method  model.PaymentMethod
+		country model.Country
+		want    bool
+	}{
+		{"PIX BR", model.MethodPIX, model.CountryBR, true},
+		{"credit card MX", model.MethodCreditCard, model.CountryMX, true},
+		{"OXXO MX", model.MethodOXXO, model.CountryMX, false},
+		{"BOLETO BR", model.MethodBoleto, model.CountryBR, false},
+		{"EFECTY CO", model.MethodEfecty, model.CountryCO, false},
+		{"no rule configured", "YAPE", "PE", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			if got := idx.AllowsSelfRefund(tt.method, tt.country); got != tt.want {
+				t.Errorf("AllowsSelfRefund(%s, %s) = %v, want %v", tt.method, tt.country, got, tt.want)
+			}
+		})
+	}
+}
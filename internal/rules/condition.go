@@ -0,0 +1,124 @@
+package rules
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/ivanjtm/YunoChallenge/internal/model"
+)
+
+var weekdays = map[string]time.Weekday{
+	"SUN": time.Sunday,
+	"MON": time.Monday,
+	"TUE": time.Tuesday,
+	"WED": time.Wednesday,
+	"THU": time.Thursday,
+	"FRI": time.Friday,
+	"SAT": time.Saturday,
+}
+
+func ParseWeekday(s string) (time.Weekday, bool) {
+	d, ok := weekdays[strings.ToUpper(s)]
+	return d, ok
+}
+
+func Matches(c model.RuleCondition, tx model.Transaction, now time.Time) bool {
+	if a := c.Amount; a != nil {
+		if a.Min > 0 && tx.Amount < a.Min {
+			return false
+		}
+		if a.Max > 0 && tx.Amount > a.Max {
+			return false
+		}
+	}
+	for attr, values := range c.Customer {
+		v, ok := tx.Customer[attr]
+		if !ok || !slices.Contains(values, v) {
+			return false
+		}
+	}
+	if len(c.Processors) > 0 && !slices.Contains(c.Processors, tx.ProcessorID) {
+		return false
+	}
+	if c.Settled != nil && *c.Settled != tx.Settled {
+		return false
+	}
+	if len(c.DaysOfWeek) > 0 {
+		today := now.Weekday()
+		if !slices.ContainsFunc(c.DaysOfWeek, func(s string) bool {
+			d, ok := ParseWeekday(s)
+			return ok && d == today
+		}) {
+			return false
+		}
+	}
+	for _, sub := range c.All {
+		if !Matches(sub, tx, now) {
+			return false
+		}
+	}
+	if len(c.Any) > 0 && !slices.ContainsFunc(c.Any, func(sub model.RuleCondition) bool {
+		return Matches(sub, tx, now)
+	}) {
+		return false
+	}
+	if c.Not != nil && Matches(*c.Not, tx, now) {
+		return false
+	}
+	return true
+}
+
+func ValidateCondition(c model.RuleCondition, knownProcessor func(string) bool) error {
+	if c.Amount == nil && len(c.Customer) == 0 && len(c.Processors) == 0 && c.Settled == nil &&
+		len(c.DaysOfWeek) == 0 && len(c.All) == 0 && len(c.Any) == 0 && c.Not == nil {
+		return errors.New("empty condition")
+	}
+	if a := c.Amount; a != nil {
+		if a.Min < 0 || a.Max < 0 {
+			return errors.New("amount bounds must not be negative")
+		}
+		if a.Min == 0 && a.Max == 0 {
+			return errors.New("amount needs a min or a max")
+		}
+		if a.Max > 0 && a.Min > a.Max {
+			return fmt.Errorf("amount min %s is above max %s", a.Min, a.Max)
+		}
+	}
+	for attr, values := range c.Customer {
+		if attr == "" {
+			return errors.New("customer attribute name is empty")
+		}
+		if len(values) == 0 || slices.Contains(values, "") {
+			return fmt.Errorf("customer attribute %q needs non-empty values", attr)
+		}
+	}
+	for _, id := range c.Processors {
+		if !knownProcessor(id) {
+			return fmt.Errorf("unknown processor %q", id)
+		}
+	}
+	for _, d := range c.DaysOfWeek {
+		if _, ok := ParseWeekday(d); !ok {
+			return fmt.Errorf("unknown day of week %q (want MON..SUN)", d)
+		}
+	}
+	for i, sub := range c.All {
+		if err := ValidateCondition(sub, knownProcessor); err != nil {
+			return fmt.Errorf("all[%d]: %w", i, err)
+		}
+	}
+	for i, sub := range c.Any {
+		if err := ValidateCondition(sub, knownProcessor); err != nil {
+			return fmt.Errorf("any[%d]: %w", i, err)
+		}
+	}
+	if c.Not != nil {
+		if err := ValidateCondition(*c.Not, knownProcessor); err != nil {
+			return fmt.Errorf("not: %w", err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,157 @@
+package rules
+
+import (
+	"testing"
+	"time"
+
+	"github.com/ivanjtm/YunoChallenge/internal/model"
+	"github.com/ivanjtm/YunoChallenge/internal/money"
+)
+
+func amountRange(min, max float64) *model.AmountRange {
+	return &model.AmountRange{Min: money.FromFloat(min), Max: money.FromFloat(max)}
+}
+
+func TestMatches(t *testing.T) {
+	t.Parallel()
+
+	monday := time.Date(2025, 6, 16, 12, 0, 0, 0, time.UTC)
+	tx := model.Transaction{
+		ProcessorID: "mexpay",
+		Amount:      money.FromFloat(60000),
+		Settled:     true,
+		Customer:    map[string]string{"card_type": "prepaid", "segment": "vip"},
+	}
+
+	tests := []struct {
+		name string
+		cond model.RuleCondition
+		want bool
+	}{
+		{"amount within range", model.RuleCondition{Amount: amountRange(50000, 100000)}, true},
+		{"amount max is inclusive", model.RuleCondition{Amount: amountRange(0, 60000)}, true},
+		{"amount above max", model.RuleCondition{Amount: amountRange(0, 50000)}, false},
+		{"amount below min", model.RuleCondition{Amount: amountRange(70000, 0)}, false},
+		{"customer attribute matches", model.RuleCondition{Customer: map[string][]string{"card_type": {"debit", "prepaid"}}}, true},
+		{"customer attribute differs", model.RuleCondition{Customer: map[string][]string{"segment": {"standard"}}}, false},
+		{"customer attribute missing", model.RuleCondition{Customer: map[string][]string{"bin_country": {"MX"}}}, false},
+		{"processor listed", model.RuleCondition{Processors: []string{"globalpay", "mexpay"}}, true},
+		{"processor not listed", model.RuleCondition{Processors: []string{"globalpay"}}, false},
+		{"settled", model.RuleCondition{Settled: boolPtr(true)}, true},
+		{"unsettled required", model.RuleCondition{Settled: boolPtr(false)}, false},
+		{"weekday matches", model.RuleCondition{DaysOfWeek: []string{"mon", "TUE"}}, true},
+		{"weekend only", model.RuleCondition{DaysOfWeek: []string{"SAT", "SUN"}}, false},
+		{"fields are combined with and", model.RuleCondition{Settled: boolPtr(true), Processors: []string{"globalpay"}}, false},
+		{"all", model.RuleCondition{All: []model.RuleCondition{{Settled: boolPtr(true)}, {Amount: amountRange(1000, 0)}}}, true},
+		{"all with one failing", model.RuleCondition{All: []model.RuleCondition{{Settled: boolPtr(true)}, {Amount: amountRange(0, 1000)}}}, false},
+		{"any", model.RuleCondition{Any: []model.RuleCondition{{Settled: boolPtr(false)}, {Processors: []string{"mexpay"}}}}, true},
+		{"any with none matching", model.RuleCondition{Any: []model.RuleCondition{{Settled: boolPtr(false)}, {Processors: []string{"paybr"}}}}, false},
+		{"not", model.RuleCondition{Not: &model.RuleCondition{Customer: map[string][]string{"card_type": {"prepaid"}}}}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			if got := Matches(tt.cond, tx, monday); got != tt.want {
+				t.Errorf("Matches() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMatches_DaysOfWeekUsesLocalDay(t *testing.T) {
+	t.Parallel()
+
+	mexico, err := time.LoadLocation("America/Mexico_City")
+	if err != nil {
+		t.Fatalf("LoadLocation() error = %v", err)
+	}
+	mondayUTC := time.Date(2025, 6, 16, 2, 0, 0, 0, time.UTC)
+	sunday := model.RuleCondition{DaysOfWeek: []string{"SUN"}}
+
+	tests := []struct {
+		name string
+		now  time.Time
+		want bool
+	}{
+		{"UTC day", mondayUTC, false},
+		{"same instant in Mexico City", mondayUTC.In(mexico), true},
+		{"after local midnight", mondayUTC.Add(4 * time.Hour).In(mexico), false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			if got := Matches(sunday, model.Transaction{}, tt.now); got != tt.want {
+				t.Errorf("Matches() at %s = %v, want %v", tt.now, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestValidateCondition(t *testing.T) {
+	t.Parallel()
+
+	known := func(id string) bool { return id == "mexpay" }
+
+	tests := []struct {
+		name    string
+		cond    model.RuleCondition
+		wantErr bool
+	}{
+		{"valid amount", model.RuleCondition{Amount: amountRange(0, 50000)}, false},
+		{"valid nested", model.RuleCondition{Any: []model.RuleCondition{{Processors: []string{"mexpay"}}, {DaysOfWeek: []string{"FRI"}}}}, false},
+		{"empty", model.RuleCondition{}, true},
+		{"empty amount", model.RuleCondition{Amount: amountRange(0, 0)}, true},
+		{"negative amount", model.RuleCondition{Amount: amountRange(-1, 0)}, true},
+		{"min above max", model.RuleCondition{Amount: amountRange(100, 50)}, true},
+		{"customer without values", model.RuleCondition{Customer: map[string][]string{"card_type": {}}}, true},
+		{"customer with empty value", model.RuleCondition{Customer: map[string][]string{"card_type": {""}}}, true},
+		{"unknown processor", model.RuleCondition{Processors: []string{"nope"}}, true},
+		{"unknown day", model.RuleCondition{DaysOfWeek: []string{"Funday"}}, true},
+		{"invalid inside not", model.RuleCondition{Not: &model.RuleCondition{}}, true},
+		{"invalid inside all", model.RuleCondition{All: []model.RuleCondition{{Settled: boolPtr(true)}, {}}}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			err := ValidateCondition(tt.cond, known)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("ValidateCondition() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestFindEligiblePaths_Conditions(t *testing.T) {
+	t.Parallel()
+
+	now := time.Date(2025, 6, 15, 12, 0, 0, 0, time.UTC)
+	under50k := &model.RuleCondition{Amount: amountRange(0, 50000)}
+	idx := NewRuleIndex([]model.CompatibilityRule{{
+		OriginalMethod: model.MethodSPEI,
+		Country:        model.CountryMX,
+		AllowedRefunds: []model.AllowedRefund{
+			{Method: model.RefundSameMethod, When: under50k},
+			{Method: model.RefundBankTransfer},
+		},
+	}})
+
+	tx := model.Transaction{
+		Country: model.CountryMX, PaymentMethod: model.MethodSPEI,
+		Timestamp: now.Add(-48 * time.Hour), Settled: true, Amount: money.FromFloat(20000),
+	}
+	small := FindEligiblePaths(tx, idx, now)
+	if len(small) != 2 || small[0].Method != model.RefundSameMethod {
+		t.Fatalf("FindEligiblePaths(20000) = %+v, want SAME_METHOD and BANK_TRANSFER", small)
+	}
+	if small[0].Codes[1] != model.ReasonConditionMet {
+		t.Errorf("SAME_METHOD codes = %v, want CONDITION_MET after RULE_ALLOWED", small[0].Codes)
+	}
+
+	tx.Amount = money.FromFloat(60000)
+	large := FindEligiblePaths(tx, idx, now)
+	if len(large) != 1 || large[0].Method != model.RefundBankTransfer {
+		t.Errorf("FindEligiblePaths(60000) = %+v, want only BANK_TRANSFER", large)
+	}
+}
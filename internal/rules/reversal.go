@@ -0,0 +1,126 @@
+package rules
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/ivanjtm/YunoChallenge/internal/model"
+)
+
+const (
+	DefaultReversalHours        = 24
+	DefaultReversalWarningHours = 6
+)
+
+func DefaultReversalPolicy() model.ReversalPolicy {
+	return model.ReversalPolicy{Hours: DefaultReversalHours, WarningHours: DefaultReversalWarningHours}
+}
+
+func ResolveReversalPolicy(layers ...*model.ReversalPolicy) model.ReversalPolicy {
+	p := DefaultReversalPolicy()
+	for _, l := range layers {
+		if l == nil {
+			continue
+		}
+		if l.Hours > 0 {
+			p.Hours = l.Hours
+		}
+		if l.Cutoff != "" {
+			p.Cutoff = l.Cutoff
+		}
+		if l.TimeZone != "" {
+			p.TimeZone = l.TimeZone
+		}
+		if l.WarningHours > 0 {
+			p.WarningHours = l.WarningHours
+		}
+	}
+	return p
+}
+
+func ReversalDeadline(tx model.Transaction, p model.ReversalPolicy) (deadline time.Time, byCutoff bool) {
+	deadline = tx.Timestamp.Add(time.Duration(p.Hours) * time.Hour)
+	if next, ok := nextCutoff(tx.Timestamp, p); ok && next.Before(deadline) {
+		return next, true
+	}
+	return deadline, false
+}
+
+func ValidateReversalPolicy(p model.ReversalPolicy) error {
+	if p.Hours < 0 || p.WarningHours < 0 {
+		return errors.New("hours and warning_hours must not be negative")
+	}
+	if p.Hours > 0 && p.WarningHours >= p.Hours {
+		return fmt.Errorf("warning_hours %d must be below hours %d", p.WarningHours, p.Hours)
+	}
+	if p.Cutoff != "" {
+		if _, _, err := parseCutoff(p.Cutoff); err != nil {
+			return err
+		}
+	}
+	if _, err := location(p.TimeZone); err != nil {
+		return fmt.Errorf("unknown time_zone %q", p.TimeZone)
+	}
+	return nil
+}
+
+func capToRule(p model.ReversalPolicy, maxAgeDays int) model.ReversalPolicy {
+	if maxAgeDays > 0 && maxAgeDays*24 < p.Hours {
+		p.Hours = maxAgeDays * 24
+	}
+	return p
+}
+
+func nextCutoff(after time.Time, p model.ReversalPolicy) (time.Time, bool) {
+	if p.Cutoff == "" {
+		return time.Time{}, false
+	}
+	hour, minute, err := parseCutoff(p.Cutoff)
+	if err != nil {
+		return time.Time{}, false
+	}
+	loc, err := location(p.TimeZone)
+	if err != nil {
+		return time.Time{}, false
+	}
+	local := after.In(loc)
+	next := time.Date(local.Year(), local.Month(), local.Day(), hour, minute, 0, 0, loc)
+	if !next.After(local) {
+		next = time.Date(local.Year(), local.Month(), local.Day()+1, hour, minute, 0, 0, loc)
+	}
+	return next.In(after.Location()), true
+}
+
+func parseCutoff(s string) (hour, minute int, err error) {
+	t, err := time.Parse("15:04", s)
+	if err != nil {
+		return 0, 0, fmt.Errorf("cutoff %q is not HH:MM", s)
+	}
+	return t.Hour(), t.Minute(), nil
+}
+
+var locations sync.Map
+
+func location(name string) (*time.Location, error) {
+	if name == "" {
+		return time.UTC, nil
+	}
+	if l, ok := locations.Load(name); ok {
+		return l.(*time.Location), nil
+	}
+	l, err := time.LoadLocation(name)
+	if err != nil {
+		return nil, err
+	}
+	locations.Store(name, l)
+	return l, nil
+}
+
+func zoneName(p model.ReversalPolicy) string {
+	if p.TimeZone == "" {
+		return "UTC"
+	}
+	return p.TimeZone
+}
@@ -0,0 +1,250 @@
+package rules
+
+import (
+	"testing"
+	"time"
+
+	"github.com/ivanjtm/YunoChallenge/internal/i18n"
+	"github.com/ivanjtm/YunoChallenge/internal/model"
+)
+
+func TestResolveReversalPolicy(t *testing.T) {
+	t.Parallel()
+
+	country := &model.ReversalPolicy{Hours: 48, TimeZone: "America/Bogota"}
+	proc := &model.ReversalPolicy{Cutoff: "22:00", WarningHours: 3}
+
+	got := ResolveReversalPolicy(country, nil, proc)
+	want := model.ReversalPolicy{Hours: 48, Cutoff: "22:00", TimeZone: "America/Bogota", WarningHours: 3}
+	if got != want {
+		t.Errorf("ResolveReversalPolicy() = %+v, want %+v", got, want)
+	}
+	if got := ResolveReversalPolicy(); got != DefaultReversalPolicy() {
+		t.Errorf("ResolveReversalPolicy() without layers = %+v, want default", got)
+	}
+}
+
+func TestReversalDeadline(t *testing.T) {
+	t.Parallel()
+
+	beforeDST := time.Date(2025, 3, 8, 23, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name         string
+		ts           time.Time
+		policy       model.ReversalPolicy
+		wantDeadline time.Time
+		wantCutoff   bool
+	}{
+		{
+			name:         "default 24 hours",
+			ts:           beforeDST,
+			policy:       DefaultReversalPolicy(),
+			wantDeadline: beforeDST.Add(24 * time.Hour),
+		},
+		{
+			name:         "configured hours",
+			ts:           beforeDST,
+			policy:       model.ReversalPolicy{Hours: 48},
+			wantDeadline: beforeDST.Add(48 * time.Hour),
+		},
+		{
+			name:         "cutoff later the same local day",
+			ts:           time.Date(2025, 6, 15, 12, 0, 0, 0, time.UTC),
+			policy:       model.ReversalPolicy{Hours: 24, Cutoff: "23:00", TimeZone: "America/Sao_Paulo"},
+			wantDeadline: time.Date(2025, 6, 16, 2, 0, 0, 0, time.UTC),
+			wantCutoff:   true,
+		},
+		{
+			name:         "cutoff already passed rolls to next local day",
+			ts:           time.Date(2025, 6, 16, 2, 30, 0, 0, time.UTC),
+			policy:       model.ReversalPolicy{Hours: 48, Cutoff: "23:00", TimeZone: "America/Sao_Paulo"},
+			wantDeadline: time.Date(2025, 6, 17, 2, 0, 0, 0, time.UTC),
+			wantCutoff:   true,
+		},
+		{
+			name:         "hours shorter than cutoff",
+			ts:           time.Date(2025, 6, 15, 12, 0, 0, 0, time.UTC),
+			policy:       model.ReversalPolicy{Hours: 6, Cutoff: "23:00", TimeZone: "America/Sao_Paulo"},
+			wantDeadline: time.Date(2025, 6, 15, 18, 0, 0, 0, time.UTC),
+		},
+		{
+			name:         "cutoff follows daylight saving change",
+			ts:           beforeDST,
+			policy:       model.ReversalPolicy{Hours: 48, Cutoff: "17:00", TimeZone: "America/New_York"},
+			wantDeadline: time.Date(2025, 3, 9, 21, 0, 0, 0, time.UTC),
+			wantCutoff:   true,
+		},
+		{
+			name:         "cutoff without time zone is UTC",
+			ts:           beforeDST,
+			policy:       model.ReversalPolicy{Hours: 24, Cutoff: "21:00"},
+			wantDeadline: time.Date(2025, 3, 9, 21, 0, 0, 0, time.UTC),
+			wantCutoff:   true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			deadline, byCutoff := ReversalDeadline(model.Transaction{Timestamp: tt.ts}, tt.policy)
+			if !deadline.Equal(tt.wantDeadline) || byCutoff != tt.wantCutoff {
+				t.Errorf("ReversalDeadline() = %v, %v; want %v, %v", deadline, byCutoff, tt.wantDeadline, tt.wantCutoff)
+			}
+		})
+	}
+}
+
+func TestValidateReversalPolicy(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		policy  model.ReversalPolicy
+		wantErr bool
+	}{
+		{"default", DefaultReversalPolicy(), false},
+		{"cutoff with zone", model.ReversalPolicy{Hours: 24, Cutoff: "18:30", TimeZone: "America/Mexico_City"}, false},
+		{"negative hours", model.ReversalPolicy{Hours: -1}, true},
+		{"warning not below hours", model.ReversalPolicy{Hours: 6, WarningHours: 6}, true},
+		{"malformed cutoff", model.ReversalPolicy{Cutoff: "25:00"}, true},
+		{"unknown time zone", model.ReversalPolicy{Cutoff: "18:00", TimeZone: "Mars/Olympus"}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			err := ValidateReversalPolicy(tt.policy)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("ValidateReversalPolicy() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestFindEligiblePathsFor_ReversalPolicy(t *testing.T) {
+	t.Parallel()
+
+	now := time.Date(2025, 6, 16, 3, 0, 0, 0, time.UTC)
+	tx := model.Transaction{
+		Country:       model.CountryBR,
+		PaymentMethod: model.MethodCreditCard,
+		Timestamp:     now.Add(-15 * time.Hour),
+	}
+	idx := NewRuleIndex(allRules())
+	cutoff := model.ReversalPolicy{Hours: 24, Cutoff: "23:00", TimeZone: "America/Sao_Paulo", WarningHours: 6}
+
+	if _, ok := findMethod(FindEligiblePaths(tx, idx, now), model.RefundReversal); !ok {
+		t.Fatal("default policy should allow reversal 15 hours in")
+	}
+	if _, ok := findMethod(FindEligiblePathsFor(tx, idx, cutoff, now), model.RefundReversal); ok {
+		t.Error("cutoff policy should close reversal at 23:00 Sao Paulo")
+	}
+	ok, msg := ReversalEligibilityFor(tx, cutoff, now)
+	if ok || msg.Key != i18n.KeyReversalCutoffPassed {
+		t.Errorf("ReversalEligibilityFor() = %v, %s; want false, %s", ok, msg.Key, i18n.KeyReversalCutoffPassed)
+	}
+
+	long := model.ReversalPolicy{Hours: 72, WarningHours: 6}
+	tx.Timestamp = now.Add(-30 * time.Hour)
+	path, ok := findMethod(FindEligiblePathsFor(tx, idx, long, now), model.RefundReversal)
+	if !ok {
+		t.Fatal("72 hour policy should allow reversal 30 hours in")
+	}
+	if path.Window.Limit != 72 || path.Window.Remaining != 42 {
+		t.Errorf("reversal window = %+v, want limit 72 remaining 42", path.Window)
+	}
+}
+
+func TestTimeSensitiveWindows_ReversalPolicy(t *testing.T) {
+	t.Parallel()
+
+	now := time.Date(2025, 6, 16, 0, 0, 0, 0, time.UTC)
+	tx := model.Transaction{
+		ID:            "tx-cutoff",
+		Country:       model.CountryBR,
+		PaymentMethod: model.MethodCreditCard,
+		Timestamp:     now.Add(-10 * time.Hour),
+	}
+	idx := NewRuleIndex(allRules())
+
+	if flags := TimeSensitiveWindows(tx, idx, now, 0); len(flags) != 0 {
+		t.Errorf("default policy flags = %+v, want none 10 hours in", flags)
+	}
+
+	cutoff := model.ReversalPolicy{Hours: 24, Cutoff: "23:00", TimeZone: "America/Sao_Paulo", WarningHours: 6}
+	flags := LocalizedTimeSensitiveWindows(tx, idx, cutoff, now, 0, i18n.For(i18n.English))
+	if len(flags) != 1 || flags[0].WindowType != "REVERSAL_CUTOFF" {
+		t.Fatalf("cutoff policy flags = %+v, want one REVERSAL_CUTOFF", flags)
+	}
+	if want := time.Date(2025, 6, 16, 2, 0, 0, 0, time.UTC); !flags[0].ExpiresAt.Equal(want) {
+		t.Errorf("ExpiresAt = %v, want %v", flags[0].ExpiresAt, want)
+	}
+
+	hours := model.ReversalPolicy{Hours: 12, WarningHours: 4}
+	flags = LocalizedTimeSensitiveWindows(tx, idx, hours, now, 0, i18n.For(i18n.English))
+	if len(flags) != 1 || flags[0].WindowType != "REVERSAL_12H" {
+		t.Errorf("12 hour policy flags = %+v, want one REVERSAL_12H", flags)
+	}
+}
+
+func TestTimeSensitiveWindows_ShortReversalWindow(t *testing.T) {
+	t.Parallel()
+
+	saoPaulo, err := time.LoadLocation("America/Sao_Paulo")
+	if err != nil {
+		t.Fatalf("LoadLocation() error = %v", err)
+	}
+	local := func(h, m int) time.Time { return time.Date(2025, 6, 16, h, m, 0, 0, saoPaulo) }
+	cutoff := model.ReversalPolicy{Hours: 24, Cutoff: "23:00", TimeZone: "America/Sao_Paulo", WarningHours: 6}
+	hours := model.ReversalPolicy{Hours: 12, WarningHours: 4}
+	idx := NewRuleIndex(allRules())
+
+	tests := []struct {
+		name     string
+		policy   model.ReversalPolicy
+		paidAt   time.Time
+		now      time.Time
+		wantType string
+		wantMsg  string
+	}{
+		{"15h cutoff window before the threshold", cutoff, local(8, 0), local(16, 59), "", ""},
+		{"15h cutoff window at the threshold", cutoff, local(8, 0), local(17, 0), "REVERSAL_CUTOFF", "Free reversal window closes in 6.0 hours"},
+		{"15h cutoff window near the cutoff", cutoff, local(8, 0), local(22, 30), "REVERSAL_CUTOFF", "Free reversal window closes in 0.5 hours"},
+		{"15h cutoff window closed", cutoff, local(8, 0), local(23, 0), "", ""},
+		{"3h cutoff window flagged at once", cutoff, local(20, 0), local(20, 0), "REVERSAL_CUTOFF", "Free reversal window closes in 3.0 hours"},
+		{"12h window before the threshold", hours, local(8, 0), local(15, 59), "", ""},
+		{"12h window at the threshold", hours, local(8, 0), local(16, 0), "REVERSAL_12H", "Free reversal window closes in 4.0 hours"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			tx := model.Transaction{
+				ID:            "tx-short-window",
+				Country:       model.CountryBR,
+				PaymentMethod: model.MethodCreditCard,
+				Timestamp:     tt.paidAt,
+			}
+			flags := LocalizedTimeSensitiveWindows(tx, idx, tt.policy, tt.now, 0, i18n.For(i18n.English))
+			if tt.wantType == "" {
+				if len(flags) != 0 {
+					t.Errorf("flags = %+v, want none", flags)
+				}
+				return
+			}
+			if len(flags) != 1 || flags[0].WindowType != tt.wantType || flags[0].Message != tt.wantMsg {
+				t.Errorf("flags = %+v, want %s %q", flags, tt.wantType, tt.wantMsg)
+			}
+		})
+	}
+}
+
+func findMethod(paths []EligiblePath, method model.RefundMethod) (EligiblePath, bool) {
+	for _, p := range paths {
+		if p.Method == method {
+			return p, true
+		}
+	}
+	return EligiblePath{}, false
+}
@@ -2,30 +2,64 @@ package rules
 
 import (
 	"fmt"
+	"slices"
 	"time"
 
+	"github.com/ivanjtm/YunoChallenge/internal/i18n"
 	"github.com/ivanjtm/YunoChallenge/internal/model"
 )
 
 type EligiblePath struct {
-	Method model.RefundMethod
-	Reason string
+	Method  model.RefundMethod
+	Reason  string
+	Message i18n.Message
+	Codes   []model.ReasonCode
+	Window  *model.RuleWindow
+}
+
+func newPath(method model.RefundMethod, msg i18n.Message, codes []model.ReasonCode, window *model.RuleWindow) EligiblePath {
+	return EligiblePath{Method: method, Reason: msg.String(), Message: msg, Codes: codes, Window: window}
 }
 
 func FindEligiblePaths(tx model.Transaction, ruleIndex *RuleIndex, now time.Time) []EligiblePath {
+	return FindEligiblePathsFor(tx, ruleIndex, DefaultReversalPolicy(), now)
+}
+
+func FindEligiblePathsFor(tx model.Transaction, ruleIndex *RuleIndex, policy model.ReversalPolicy, now time.Time) []EligiblePath {
 	allowed := ruleIndex.AllowedRefundMethods(tx.PaymentMethod, tx.Country)
 	if len(allowed) == 0 {
 		return []EligiblePath{
-			{Method: model.RefundAccountCredit, Reason: "No compatibility rules found; only account credit available"},
+			newPath(model.RefundAccountCredit, i18n.Msg(i18n.KeyNoRules), []model.ReasonCode{model.ReasonNoRules}, nil),
 		}
 	}
+	return eligiblePaths(tx, allowed, []model.ReasonCode{model.ReasonRuleAllowed}, policy, now)
+}
 
+func FindProcessorPaths(tx model.Transaction, ruleIndex *RuleIndex, processorID string, policy model.ReversalPolicy, now time.Time) ([]EligiblePath, bool) {
+	rule := ruleIndex.LookupProcessor(tx.PaymentMethod, tx.Country, processorID)
+	if rule == nil {
+		return nil, false
+	}
+	return eligiblePaths(tx, rule.AllowedRefunds, []model.ReasonCode{model.ReasonRuleAllowed, model.ReasonProcessorRule}, policy, now), true
+}
+
+func eligiblePaths(tx model.Transaction, allowed []model.AllowedRefund, ruleCodes []model.ReasonCode, policy model.ReversalPolicy, now time.Time) []EligiblePath {
 	var paths []EligiblePath
 	for _, ar := range allowed {
+		codes := slices.Clone(ruleCodes)
+		if ar.When != nil {
+			if !Matches(*ar.When, tx, now) {
+				continue
+			}
+			codes = append(codes, model.ReasonConditionMet)
+		}
+
 		switch ar.Method {
 		case model.RefundReversal:
-			if ok, reason := IsReversalEligible(tx, now); ok {
-				paths = append(paths, EligiblePath{Method: ar.Method, Reason: reason})
+			p := capToRule(policy, ar.MaxAgeDays)
+			if ok, msg := ReversalEligibilityFor(tx, p, now); ok {
+				codes = append(codes, model.ReasonUnsettled, model.ReasonReversalWindow)
+				paths = append(paths, newPath(ar.Method, msg, codes, ReversalWindowFor(tx, p, now)))
 			}
 		default:
 			if ar.RequireSettled != nil {
@@ -35,38 +69,54 @@ func FindEligiblePaths(tx model.Transaction, ruleIndex *RuleIndex, now time.Time
 				if !*ar.RequireSettled && tx.Settled {
 					continue
 				}
+				if tx.Settled {
+					codes = append(codes, model.ReasonSettled)
+				} else {
+					codes = append(codes, model.ReasonUnsettled)
+				}
 			}
-			if ok, reason := IsWithinTimeWindow(tx, ar, now); ok {
-				paths = append(paths, EligiblePath{Method: ar.Method, Reason: reason})
+			if ok, msg := TimeWindowEligibility(tx, ar, now); ok {
+				window := AgeWindow(tx, ar, now)
+				if window == nil {
+					codes = append(codes, model.ReasonNoTimeLimit)
+				} else {
+					codes = append(codes, model.ReasonWithinWindow)
+				}
+				paths = append(paths, newPath(ar.Method, msg, codes, window))
 			}
 		}
 	}
 
 	if len(paths) == 0 {
-		paths = append(paths, EligiblePath{
-			Method: model.RefundAccountCredit,
-			Reason: "No eligible refund methods; falling back to account credit",
-		})
+		paths = append(paths, newPath(model.RefundAccountCredit, i18n.Msg(i18n.KeyNoEligibleMethods), []model.ReasonCode{model.ReasonNoEligibleMethods}, nil))
 	}
 
 	return paths
 }
 
 func TimeSensitiveWindows(tx model.Transaction, ruleIndex *RuleIndex, now time.Time, thresholdDays int) []model.TimeSensitiveFlag {
-	allowed := ruleIndex.AllowedRefundMethods(tx.PaymentMethod, tx.Country)
+	return LocalizedTimeSensitiveWindows(tx, ruleIndex, DefaultReversalPolicy(), now, thresholdDays, i18n.For(i18n.English))
+}
+
+func LocalizedTimeSensitiveWindows(tx model.Transaction, ruleIndex *RuleIndex, policy model.ReversalPolicy, now time.Time, thresholdDays int, p i18n.Printer) []model.TimeSensitiveFlag {
+	allowed := ruleIndex.AllowedRefundMethodsFor(tx.PaymentMethod, tx.Country, tx.ProcessorID)
 	var flags []model.TimeSensitiveFlag
 
 	for _, ar := range allowed {
+		if ar.When != nil && !Matches(*ar.When, tx, now) {
+			continue
+		}
 		if ar.Method == model.RefundReversal {
-			hoursSince := now.Sub(tx.Timestamp).Hours()
-			if !tx.Settled && hoursSince >= 18 && hoursSince < 24 {
-				hoursLeft := 24 - hoursSince
+			rp := capToRule(policy, ar.MaxAgeDays)
+			deadline, byCutoff := ReversalDeadline(tx, rp)
+			hoursLeft := deadline.Sub(now).Hours()
+			if !tx.Settled && hoursLeft > 0 && hoursLeft <= float64(rp.WarningHours) {
 				flags = append(flags, model.TimeSensitiveFlag{
 					TransactionID: tx.ID,
-					WindowType:    "REVERSAL_24H",
-					ExpiresAt:     tx.Timestamp.Add(24 * time.Hour),
+					WindowType:    reversalWindowName(rp, byCutoff),
+					ExpiresAt:     deadline,
 					DaysRemaining: 0,
-					Message:       fmt.Sprintf("Free reversal window closes in %.1f hours", hoursLeft),
+					Message:       p.Sprintf(i18n.KeyReversalWindowClosing, i18n.Decimal{Value: hoursLeft, Places: 1}),
 				})
 			}
 			continue
@@ -82,13 +132,20 @@ func TimeSensitiveWindows(tx model.Transaction, ruleIndex *RuleIndex, now time.T
 				WindowType:    windowName,
 				ExpiresAt:     tx.Timestamp.AddDate(0, 0, ar.MaxAgeDays),
 				DaysRemaining: remaining,
-				Message:       fmt.Sprintf("%s refund window expires in %d days. After expiry, more expensive alternatives required.", windowName, remaining),
+				Message:       p.Sprintf(i18n.KeyWindowClosing, windowName, remaining),
 			})
 		}
 	}
 	return flags
 }
 
+func reversalWindowName(p model.ReversalPolicy, byCutoff bool) string {
+	if byCutoff {
+		return "REVERSAL_CUTOFF"
+	}
+	return fmt.Sprintf("REVERSAL_%dH", p.Hours)
+}
+
 func windowTypeName(method model.PaymentMethod, ar model.AllowedRefund) string {
 	return fmt.Sprintf("%s_%s_%dD", method, ar.Method, ar.MaxAgeDays)
 }
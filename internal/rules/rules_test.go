@@ -1,6 +1,7 @@
 package rules
 
 import (
+	"slices"
 	"testing"
 	"time"
 
@@ -623,6 +624,58 @@ func TestTimeSensitiveWindows_MethodExpiresAt(t *testing.T) {
 	}
 }
 
+func TestTimeSensitiveWindows_ProcessorRule(t *testing.T) {
+	t.Parallel()
+
+	now := time.Date(2025, 6, 15, 12, 0, 0, 0, time.UTC)
+	scoped := model.CompatibilityRule{
+		OriginalMethod: model.MethodPIX,
+		Country:        model.CountryBR,
+		ProcessorID:    "paybr",
+		AllowedRefunds: []model.AllowedRefund{
+			{Method: model.RefundSameMethod, MaxAgeDays: 120},
+			{Method: model.RefundBankTransfer},
+		},
+	}
+	idx := NewRuleIndex(append(allRules(), scoped))
+
+	tests := []struct {
+		name      string
+		processor string
+		age       int
+		wantType  string
+		wantDays  int
+	}{
+		{"scoped rule past the generic window", "paybr", 115, "PIX_SAME_METHOD_120D", 5},
+		{"scoped rule not closing yet", "paybr", 85, "", 0},
+		{"other processors use the generic rule", "globalpay", 85, "PIX_SAME_METHOD_90D", 5},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			tx := model.Transaction{
+				ID:            "tx-scoped",
+				Country:       model.CountryBR,
+				PaymentMethod: model.MethodPIX,
+				ProcessorID:   tt.processor,
+				Timestamp:     now.Add(-time.Duration(tt.age) * 24 * time.Hour),
+				Settled:       true,
+			}
+			flags := TimeSensitiveWindows(tx, idx, now, 7)
+			if tt.wantType == "" {
+				if len(flags) != 0 {
+					t.Errorf("flags = %+v, want none", flags)
+				}
+				return
+			}
+			if len(flags) != 1 || flags[0].WindowType != tt.wantType || flags[0].DaysRemaining != tt.wantDays {
+				t.Errorf("flags = %+v, want %s with %d days left", flags, tt.wantType, tt.wantDays)
+			}
+		})
+	}
+}
+
 func TestWindowTypeName(t *testing.T) {
 	t.Parallel()
 
@@ -668,3 +721,96 @@ func TestWindowTypeName(t *testing.T) {
 		})
 	}
 }
+
+func TestFindEligiblePaths_ReasonCodesAndWindow(t *testing.T) {
+	t.Parallel()
+
+	now := time.Date(2025, 6, 15, 12, 0, 0, 0, time.UTC)
+	idx := NewRuleIndex(allRules())
+
+	tx := model.Transaction{
+		ID:            "tx-codes",
+		Country:       model.CountryBR,
+		PaymentMethod: model.MethodPIX,
+		Timestamp:     now.Add(-6 * time.Hour),
+		Settled:       false,
+	}
+
+	byMethod := make(map[model.RefundMethod]EligiblePath)
+	for _, p := range FindEligiblePaths(tx, idx, now) {
+		byMethod[p.Method] = p
+	}
+
+	rev, ok := byMethod[model.RefundReversal]
+	if !ok {
+		t.Fatal("expected a REVERSAL path")
+	}
+	if !slices.Contains(rev.Codes, model.ReasonReversalWindow) || !slices.Contains(rev.Codes, model.ReasonUnsettled) {
+		t.Errorf("REVERSAL codes = %v, want unsettled and reversal window", rev.Codes)
+	}
+	if w := rev.Window; w == nil || w.Unit != model.WindowHours || w.Used != 6 || w.Remaining != 18 || !w.ExpiresAt.Equal(tx.Timestamp.Add(24*time.Hour)) {
+		t.Errorf("REVERSAL window = %+v, want 6 of 24 hours used", rev.Window)
+	}
+
+	same, ok := byMethod[model.RefundSameMethod]
+	if !ok {
+		t.Fatal("expected a SAME_METHOD path")
+	}
+	if !slices.Contains(same.Codes, model.ReasonRuleAllowed) || !slices.Contains(same.Codes, model.ReasonWithinWindow) {
+		t.Errorf("SAME_METHOD codes = %v, want rule allowed and within window", same.Codes)
+	}
+	if w := same.Window; w == nil || w.Unit != model.WindowDays || w.Limit != 90 || w.Used != 0 || w.Remaining != 90 {
+		t.Errorf("SAME_METHOD window = %+v, want 0 of 90 days used", same.Window)
+	}
+
+	none := FindEligiblePaths(model.Transaction{Country: model.CountryBR, PaymentMethod: "UNKNOWN"}, idx, now)
+	if len(none) != 1 || !slices.Equal(none[0].Codes, []model.ReasonCode{model.ReasonNoRules}) || none[0].Window != nil {
+		t.Errorf("FindEligiblePaths(no rules) = %+v, want NO_RULES account credit", none)
+	}
+}
+
+func TestFindProcessorPaths(t *testing.T) {
+	t.Parallel()
+
+	now := time.Date(2025, 6, 15, 12, 0, 0, 0, time.UTC)
+	scoped := model.CompatibilityRule{
+		OriginalMethod: model.MethodPIX,
+		Country:        model.CountryBR,
+		ProcessorID:    "paybr",
+		AllowedRefunds: []model.AllowedRefund{
+			{Method: model.RefundSameMethod, MaxAgeDays: 120},
+			{Method: model.RefundBankTransfer},
+		},
+	}
+	idx := NewRuleIndex(append(allRules(), scoped))
+
+	tx := model.Transaction{
+		Country:       model.CountryBR,
+		PaymentMethod: model.MethodPIX,
+		Timestamp:     now.Add(-100 * 24 * time.Hour),
+		Settled:       true,
+	}
+
+	generic := FindEligiblePaths(tx, idx, now)
+	if len(generic) != 1 || generic[0].Method != model.RefundBankTransfer {
+		t.Errorf("generic paths = %+v, want only BANK_TRANSFER past 90 days", generic)
+	}
+
+	paths, ok := FindProcessorPaths(tx, idx, "paybr", DefaultReversalPolicy(), now)
+	if !ok {
+		t.Fatal("FindProcessorPaths(paybr) found no processor rule")
+	}
+	if len(paths) != 2 || paths[0].Method != model.RefundSameMethod {
+		t.Fatalf("paybr paths = %+v, want SAME_METHOD within 120 days and BANK_TRANSFER", paths)
+	}
+	if !slices.Equal(paths[0].Codes, []model.ReasonCode{model.ReasonRuleAllowed, model.ReasonProcessorRule, model.ReasonWithinWindow}) {
+		t.Errorf("paybr SAME_METHOD codes = %v", paths[0].Codes)
+	}
+
+	if _, ok := FindProcessorPaths(tx, idx, "globalpay", DefaultReversalPolicy(), now); ok {
+		t.Error("FindProcessorPaths(globalpay) ok = true, want false without a scoped rule")
+	}
+	if rule := idx.Lookup(model.MethodPIX, model.CountryBR); rule == nil || rule.ProcessorID != "" {
+		t.Errorf("Lookup() = %+v, want the generic rule", rule)
+	}
+}
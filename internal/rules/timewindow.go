@@ -1,40 +1,96 @@
 package rules
 
 import (
-	"fmt"
 	"math"
 	"time"
 
+	"github.com/ivanjtm/YunoChallenge/internal/i18n"
 	"github.com/ivanjtm/YunoChallenge/internal/model"
 )
 
 func IsReversalEligible(tx model.Transaction, now time.Time) (eligible bool, reason string) {
+	eligible, msg := ReversalEligibility(tx, now)
+	return eligible, msg.String()
+}
+
+func ReversalEligibility(tx model.Transaction, now time.Time) (bool, i18n.Message) {
+	return ReversalEligibilityFor(tx, DefaultReversalPolicy(), now)
+}
+
+func ReversalEligibilityFor(tx model.Transaction, p model.ReversalPolicy, now time.Time) (bool, i18n.Message) {
 	hoursSince := now.Sub(tx.Timestamp).Hours()
 	if tx.Settled {
-		return false, "Transaction already settled; reversal not available"
+		return false, i18n.Msg(i18n.KeyAlreadySettled)
 	}
-	if hoursSince >= 24 {
-		return false, fmt.Sprintf("Transaction is %.0f hours old; reversal requires < 24 hours", hoursSince)
+	deadline, byCutoff := ReversalDeadline(tx, p)
+	if !now.Before(deadline) {
+		if byCutoff {
+			return false, i18n.Msg(i18n.KeyReversalCutoffPassed, i18n.Decimal{Value: hoursSince, Places: 0}, p.Cutoff, zoneName(p))
+		}
+		return false, i18n.Msg(i18n.KeyReversalWindowExpired, i18n.Decimal{Value: hoursSince, Places: 0}, p.Hours)
 	}
-	return true, fmt.Sprintf("Transaction is %.1f hours old and unsettled; free reversal available", hoursSince)
+	return true, i18n.Msg(i18n.KeyReversalWindow, i18n.Decimal{Value: hoursSince, Places: 1})
 }
 
 func IsWithinTimeWindow(tx model.Transaction, allowed model.AllowedRefund, now time.Time) (eligible bool, reason string) {
+	eligible, msg := TimeWindowEligibility(tx, allowed, now)
+	return eligible, msg.String()
+}
+
+func TimeWindowEligibility(tx model.Transaction, allowed model.AllowedRefund, now time.Time) (bool, i18n.Message) {
 	if allowed.MaxAgeDays == 0 {
-		return true, "No time limit for this refund method"
+		return true, i18n.Msg(i18n.KeyNoTimeLimit)
 	}
-	daysSince := int(math.Floor(now.Sub(tx.Timestamp).Hours() / 24))
+	daysSince := ageDays(tx, now)
 	if daysSince > allowed.MaxAgeDays {
-		return false, fmt.Sprintf("Transaction is %d days old; %s window is %d days", daysSince, allowed.Method, allowed.MaxAgeDays)
+		return false, i18n.Msg(i18n.KeyWindowExpired, daysSince, allowed.Method, allowed.MaxAgeDays)
 	}
 	remaining := allowed.MaxAgeDays - daysSince
-	return true, fmt.Sprintf("Within %s window (%d of %d days used, %d remaining)", allowed.Method, daysSince, allowed.MaxAgeDays, remaining)
+	return true, i18n.Msg(i18n.KeyWithinWindow, allowed.Method, daysSince, allowed.MaxAgeDays, remaining)
 }
 
 func DaysUntilExpiry(tx model.Transaction, allowed model.AllowedRefund, now time.Time) int {
 	if allowed.MaxAgeDays == 0 {
 		return -1
 	}
-	daysSince := int(math.Floor(now.Sub(tx.Timestamp).Hours() / 24))
-	return allowed.MaxAgeDays - daysSince
+	return allowed.MaxAgeDays - ageDays(tx, now)
+}
+
+func ReversalWindow(tx model.Transaction, now time.Time) *model.RuleWindow {
+	return ReversalWindowFor(tx, DefaultReversalPolicy(), now)
+}
+
+func ReversalWindowFor(tx model.Transaction, p model.ReversalPolicy, now time.Time) *model.RuleWindow {
+	deadline, _ := ReversalDeadline(tx, p)
+	limit := roundHours(deadline.Sub(tx.Timestamp).Hours())
+	used := roundHours(now.Sub(tx.Timestamp).Hours())
+	return &model.RuleWindow{
+		Unit:      model.WindowHours,
+		Limit:     limit,
+		Used:      used,
+		Remaining: roundHours(limit - used),
+		ExpiresAt: deadline,
+	}
+}
+
+func AgeWindow(tx model.Transaction, allowed model.AllowedRefund, now time.Time) *model.RuleWindow {
+	if allowed.MaxAgeDays == 0 {
+		return nil
+	}
+	used := ageDays(tx, now)
+	return &model.RuleWindow{
+		Unit:      model.WindowDays,
+		Limit:     float64(allowed.MaxAgeDays),
+		Used:      float64(used),
+		Remaining: float64(allowed.MaxAgeDays - used),
+		ExpiresAt: tx.Timestamp.AddDate(0, 0, allowed.MaxAgeDays),
+	}
+}
+
+func roundHours(h float64) float64 {
+	return math.Round(h*10) / 10
+}
+
+func ageDays(tx model.Transaction, now time.Time) int {
+	return int(math.Floor(now.Sub(tx.Timestamp).Hours() / 24))
 }
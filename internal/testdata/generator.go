@@ -9,6 +9,7 @@ import (
 	"time"
 
 	"github.com/ivanjtm/YunoChallenge/internal/model"
+	"github.com/ivanjtm/YunoChallenge/internal/money"
 )
 
 func weightedPick[T any](rng *rand.Rand, items []T, weights []float64) T {
@@ -28,179 +29,194 @@ func weightedPick[T any](rng *rand.Rand, items []T, weights []float64) T {
 }
 
 type countryInfo struct {
-	code     model.Country
-	currency model.Currency
+	code          model.Country
+	currency      model.Currency
+	methods       []model.PaymentMethod
+	methodWeights []float64
+	processors    []string
+	procWeights   []float64
 }
 
-func GenerateTransactions(count int, now time.Time) []model.Transaction {
+type amountParams struct {
+	mu       float64
+	sigma    float64
+	min      float64
+	max      float64
+	decimals int
+}
+
+func GenerateTransactions(count int, now time.Time, markets model.MarketConfig) []model.Transaction {
 	txns := make([]model.Transaction, 0, count)
 
 	edges := []model.Transaction{
 		{
 			ID: "txn_edge_001", Country: model.CountryBR, Currency: model.CurrencyBRL,
 			PaymentMethod: model.MethodPIX, ProcessorID: "paybr",
-			Amount: 250, Timestamp: now.Add(-30 * time.Minute), Settled: false,
+			Amount: money.FromFloat(250), Timestamp: now.Add(-30 * time.Minute), Settled: false,
 		},
 		{
 			ID: "txn_edge_002", Country: model.CountryMX, Currency: model.CurrencyMXN,
 			PaymentMethod: model.MethodCreditCard, ProcessorID: "mexpay",
-			Amount: 1500, Timestamp: now.Add(-45 * time.Minute), Settled: false,
+			Amount: money.FromFloat(1500), Timestamp: now.Add(-45 * time.Minute), Settled: false,
 		},
 		{
 			ID: "txn_edge_003", Country: model.CountryCO, Currency: model.CurrencyCOP,
 			PaymentMethod: model.MethodPSE, ProcessorID: "colpay",
-			Amount: 200000, Timestamp: now.Add(-20 * time.Minute), Settled: false,
+			Amount: money.FromFloat(200000), Timestamp: now.Add(-20 * time.Minute), Settled: false,
 		},
 		{
 			ID: "txn_edge_004", Country: model.CountryMX, Currency: model.CurrencyMXN,
 			PaymentMethod: model.MethodCreditCard, ProcessorID: "mexpay",
-			Amount: 800, Timestamp: now.Add(-12 * time.Hour), Settled: true,
+			Amount: money.FromFloat(800), Timestamp: now.Add(-12 * time.Hour), Settled: true,
 		},
 		{
 			ID: "txn_edge_005", Country: model.CountryBR, Currency: model.CurrencyBRL,
 			PaymentMethod: model.MethodPIX, ProcessorID: "paybr",
-			Amount: 450, Timestamp: now.Add(-86 * 24 * time.Hour), Settled: true,
+			Amount: money.FromFloat(450), Timestamp: now.Add(-86 * 24 * time.Hour), Settled: true,
 		},
 		{
 			ID: "txn_edge_006", Country: model.CountryBR, Currency: model.CurrencyBRL,
 			PaymentMethod: model.MethodPIX, ProcessorID: "globalpay",
-			Amount: 320, Timestamp: now.Add(-88 * 24 * time.Hour), Settled: true,
+			Amount: money.FromFloat(320), Timestamp: now.Add(-88 * 24 * time.Hour), Settled: true,
 		},
 		{
 			ID: "txn_edge_007", Country: model.CountryBR, Currency: model.CurrencyBRL,
 			PaymentMethod: model.MethodPIX, ProcessorID: "quickrefund",
-			Amount: 180, Timestamp: now.Add(-89 * 24 * time.Hour), Settled: true,
+			Amount: money.FromFloat(180), Timestamp: now.Add(-89 * 24 * time.Hour), Settled: true,
 		},
 		{
 			ID: "txn_edge_008", Country: model.CountryCO, Currency: model.CurrencyCOP,
 			PaymentMethod: model.MethodPSE, ProcessorID: "colpay",
-			Amount: 350000, Timestamp: now.Add(-57 * 24 * time.Hour), Settled: true,
+			Amount: money.FromFloat(350000), Timestamp: now.Add(-57 * 24 * time.Hour), Settled: true,
 		},
 		{
 			ID: "txn_edge_009", Country: model.CountryCO, Currency: model.CurrencyCOP,
 			PaymentMethod: model.MethodPSE, ProcessorID: "globalpay",
-			Amount: 180000, Timestamp: now.Add(-59 * 24 * time.Hour), Settled: true,
+			Amount: money.FromFloat(180000), Timestamp: now.Add(-59 * 24 * time.Hour), Settled: true,
 		},
 		{
 			ID: "txn_edge_010", Country: model.CountryMX, Currency: model.CurrencyMXN,
 			PaymentMethod: model.MethodOXXO, ProcessorID: "mexpay",
-			Amount: 2500, Timestamp: now.Add(-45 * 24 * time.Hour), Settled: true,
+			Amount: money.FromFloat(2500), Timestamp: now.Add(-45 * 24 * time.Hour), Settled: true,
 		},
 		{
 			ID: "txn_edge_011", Country: model.CountryMX, Currency: model.CurrencyMXN,
 			PaymentMethod: model.MethodOXXO, ProcessorID: "globalpay",
-			Amount: 800, Timestamp: now.Add(-30 * 24 * time.Hour), Settled: true,
+			Amount: money.FromFloat(800), Timestamp: now.Add(-30 * 24 * time.Hour), Settled: true,
 		},
 		{
 			ID: "txn_edge_012", Country: model.CountryMX, Currency: model.CurrencyMXN,
 			PaymentMethod: model.MethodOXXO, ProcessorID: "mexpay",
-			Amount: 3200, Timestamp: now.Add(-100 * 24 * time.Hour), Settled: true,
+			Amount: money.FromFloat(3200), Timestamp: now.Add(-100 * 24 * time.Hour), Settled: true,
 		},
 		{
 			ID: "txn_edge_013", Country: model.CountryBR, Currency: model.CurrencyBRL,
 			PaymentMethod: model.MethodBoleto, ProcessorID: "paybr",
-			Amount: 600, Timestamp: now.Add(-60 * 24 * time.Hour), Settled: true,
+			Amount: money.FromFloat(600), Timestamp: now.Add(-60 * 24 * time.Hour), Settled: true,
 		},
 		{
 			ID: "txn_edge_014", Country: model.CountryBR, Currency: model.CurrencyBRL,
 			PaymentMethod: model.MethodBoleto, ProcessorID: "globalpay",
-			Amount: 150, Timestamp: now.Add(-20 * 24 * time.Hour), Settled: true,
+			Amount: money.FromFloat(150), Timestamp: now.Add(-20 * 24 * time.Hour), Settled: true,
 		},
 		{
 			ID: "txn_edge_015", Country: model.CountryCO, Currency: model.CurrencyCOP,
 			PaymentMethod: model.MethodEfecty, ProcessorID: "colpay",
-			Amount: 450000, Timestamp: now.Add(-40 * 24 * time.Hour), Settled: true,
+			Amount: money.FromFloat(450000), Timestamp: now.Add(-40 * 24 * time.Hour), Settled: true,
 		},
 		{
 			ID: "txn_edge_016", Country: model.CountryCO, Currency: model.CurrencyCOP,
 			PaymentMethod: model.MethodEfecty, ProcessorID: "globalpay",
-			Amount: 120000, Timestamp: now.Add(-15 * 24 * time.Hour), Settled: true,
+			Amount: money.FromFloat(120000), Timestamp: now.Add(-15 * 24 * time.Hour), Settled: true,
 		},
 		{
 			ID: "txn_edge_017", Country: model.CountryBR, Currency: model.CurrencyBRL,
 			PaymentMethod: model.MethodCreditCard, ProcessorID: "quickrefund",
-			Amount: 4800, Timestamp: now.Add(-10 * 24 * time.Hour), Settled: true,
+			Amount: money.FromFloat(4800), Timestamp: now.Add(-10 * 24 * time.Hour), Settled: true,
 		},
 		{
 			ID: "txn_edge_018", Country: model.CountryMX, Currency: model.CurrencyMXN,
 			PaymentMethod: model.MethodCreditCard, ProcessorID: "mexpay",
-			Amount: 14000, Timestamp: now.Add(-5 * 24 * time.Hour), Settled: true,
+			Amount: money.FromFloat(14000), Timestamp: now.Add(-5 * 24 * time.Hour), Settled: true,
 		},
 		{
 			ID: "txn_edge_019", Country: model.CountryCO, Currency: model.CurrencyCOP,
 			PaymentMethod: model.MethodCreditCard, ProcessorID: "colpay",
-			Amount: 4800000, Timestamp: now.Add(-8 * 24 * time.Hour), Settled: true,
+			Amount: money.FromFloat(4800000), Timestamp: now.Add(-8 * 24 * time.Hour), Settled: true,
 		},
 		{
 			ID: "txn_edge_020", Country: model.CountryBR, Currency: model.CurrencyBRL,
 			PaymentMethod: model.MethodPIX, ProcessorID: "paybr",
-			Amount: 15, Timestamp: now.Add(-3 * 24 * time.Hour), Settled: true,
+			Amount: money.FromFloat(15), Timestamp: now.Add(-3 * 24 * time.Hour), Settled: true,
 		},
 		{
 			ID: "txn_edge_021", Country: model.CountryMX, Currency: model.CurrencyMXN,
 			PaymentMethod: model.MethodSPEI, ProcessorID: "mexpay",
-			Amount: 50, Timestamp: now.Add(-2 * 24 * time.Hour), Settled: true,
+			Amount: money.FromFloat(50), Timestamp: now.Add(-2 * 24 * time.Hour), Settled: true,
 		},
 		{
 			ID: "txn_edge_022", Country: model.CountryBR, Currency: model.CurrencyBRL,
 			PaymentMethod: model.MethodCreditCard, ProcessorID: "paybr",
-			Amount: 500, Timestamp: now.Add(-176 * 24 * time.Hour), Settled: true,
+			Amount: money.FromFloat(500), Timestamp: now.Add(-176 * 24 * time.Hour), Settled: true,
 		},
 		{
 			ID: "txn_edge_023", Country: model.CountryMX, Currency: model.CurrencyMXN,
 			PaymentMethod: model.MethodCreditCard, ProcessorID: "globalpay",
-			Amount: 2000, Timestamp: now.Add(-179 * 24 * time.Hour), Settled: true,
+			Amount: money.FromFloat(2000), Timestamp: now.Add(-179 * 24 * time.Hour), Settled: true,
 		},
 	}
 
-	txns = append(txns, edges...)
-
-	rng := rand.New(rand.NewSource(42))
-
-	countries := []countryInfo{
-		{model.CountryBR, model.CurrencyBRL},
-		{model.CountryMX, model.CurrencyMXN},
-		{model.CountryCO, model.CurrencyCOP},
-	}
-	countryWeights := []float64{0.45, 0.35, 0.20}
-
-	paymentMethods := map[model.Country][]model.PaymentMethod{
-		model.CountryBR: {model.MethodPIX, model.MethodCreditCard, model.MethodBoleto},
-		model.CountryMX: {model.MethodCreditCard, model.MethodOXXO, model.MethodSPEI},
-		model.CountryCO: {model.MethodCreditCard, model.MethodPSE, model.MethodEfecty},
+	defined := make(map[model.Country]bool)
+	for _, c := range markets.Countries {
+		defined[c.Code] = true
 	}
-	paymentWeights := map[model.Country][]float64{
-		model.CountryBR: {0.50, 0.35, 0.15},
-		model.CountryMX: {0.40, 0.30, 0.30},
-		model.CountryCO: {0.40, 0.35, 0.25},
+	for _, tx := range edges {
+		if defined[tx.Country] {
+			txns = append(txns, tx)
+		}
 	}
 
-	processors := map[model.Country][]string{
-		model.CountryBR: {"paybr", "globalpay", "quickrefund", "valueproc"},
-		model.CountryMX: {"mexpay", "globalpay", "quickrefund", "valueproc"},
-		model.CountryCO: {"colpay", "globalpay", "valueproc"},
-	}
-	processorWeights := map[model.Country][]float64{
-		model.CountryBR: {0.50, 0.20, 0.15, 0.15},
-		model.CountryMX: {0.45, 0.20, 0.20, 0.15},
-		model.CountryCO: {0.50, 0.30, 0.20},
-	}
+	rng := rand.New(rand.NewSource(42))
 
-	type amountParams struct {
-		mu       float64
-		sigma    float64
-		min      float64
-		max      float64
-		decimals int
+	amountCfg := make(map[model.Currency]amountParams)
+	for _, c := range markets.Currencies {
+		if c.TestData == nil {
+			continue
+		}
+		amountCfg[c.Code] = amountParams{
+			mu:       math.Log(c.TestData.TypicalAmount.Float64()),
+			sigma:    1.0,
+			min:      c.TestData.MinAmount.Float64(),
+			max:      c.TestData.MaxAmount.Float64(),
+			decimals: c.MinorUnits,
+		}
 	}
-	amountCfg := map[model.Currency]amountParams{
-		model.CurrencyBRL: {mu: math.Log(150), sigma: 1.0, min: 15.0, max: 5000.0, decimals: 2},
-		model.CurrencyMXN: {mu: math.Log(500), sigma: 1.0, min: 50.0, max: 15000.0, decimals: 2},
-		model.CurrencyCOP: {mu: math.Log(150000), sigma: 1.0, min: 10000.0, max: 5000000.0, decimals: 0},
+
+	var countries []countryInfo
+	var countryWeights []float64
+	for _, c := range markets.Countries {
+		td := c.TestData
+		if td == nil || td.Weight <= 0 || len(td.PaymentMethods) == 0 || len(td.Processors) == 0 {
+			continue
+		}
+		if _, ok := amountCfg[c.Currency]; !ok {
+			continue
+		}
+		ci := countryInfo{code: c.Code, currency: c.Currency}
+		for _, wm := range td.PaymentMethods {
+			ci.methods = append(ci.methods, wm.Method)
+			ci.methodWeights = append(ci.methodWeights, wm.Weight)
+		}
+		for _, wp := range td.Processors {
+			ci.processors = append(ci.processors, wp.ProcessorID)
+			ci.procWeights = append(ci.procWeights, wp.Weight)
+		}
+		countries = append(countries, ci)
+		countryWeights = append(countryWeights, td.Weight)
 	}
 
-	remaining := count - len(edges)
-	if remaining < 0 {
+	remaining := count - len(txns)
+	if remaining < 0 || len(countries) == 0 {
 		remaining = 0
 	}
 
@@ -209,8 +225,8 @@ func GenerateTransactions(count int, now time.Time) []model.Transaction {
 		cc := ci.code
 		cur := ci.currency
 
-		pm := weightedPick(rng, paymentMethods[cc], paymentWeights[cc])
-		proc := weightedPick(rng, processors[cc], processorWeights[cc])
+		pm := weightedPick(rng, ci.methods, ci.methodWeights)
+		proc := weightedPick(rng, ci.processors, ci.procWeights)
 
 		cfg := amountCfg[cur]
 		raw := math.Exp(rng.NormFloat64()*cfg.sigma + cfg.mu)
@@ -220,11 +236,8 @@ func GenerateTransactions(count int, now time.Time) []model.Transaction {
 		if raw > cfg.max {
 			raw = cfg.max
 		}
-		if cfg.decimals == 2 {
-			raw = math.Round(raw*100) / 100
-		} else {
-			raw = math.Round(raw)
-		}
+		scale := math.Pow(10, float64(cfg.decimals))
+		raw = math.Round(raw*scale) / scale
 
 		ts := now.Add(-time.Duration(rng.Intn(180*24)) * time.Hour)
 
@@ -242,7 +255,7 @@ func GenerateTransactions(count int, now time.Time) []model.Transaction {
 			Currency:      cur,
 			PaymentMethod: pm,
 			ProcessorID:   proc,
-			Amount:        raw,
+			Amount:        money.FromFloat(raw),
 			Timestamp:     ts,
 			Settled:       settled,
 			CustomerID:    fmt.Sprintf("cust_%05d", rng.Intn(50000)),
@@ -252,8 +265,8 @@ func GenerateTransactions(count int, now time.Time) []model.Transaction {
 	return txns
 }
 
-func GenerateAndSave(path string, count int, now time.Time) error {
-	txns := GenerateTransactions(count, now)
+func GenerateAndSave(path string, count int, now time.Time, markets model.MarketConfig) error {
+	txns := GenerateTransactions(count, now, markets)
 
 	data, err := json.MarshalIndent(txns, "", "  ")
 	if err != nil {
@@ -0,0 +1,98 @@
+package testdata
+
+import (
+	"math"
+	"reflect"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ivanjtm/YunoChallenge/internal/config"
+	"github.com/ivanjtm/YunoChallenge/internal/model"
+)
+
+func TestGenerateTransactions_FollowsMarkets(t *testing.T) {
+	t.Parallel()
+
+	cfg, err := config.Load("../config/testdata/processors.json", "../config/testdata/rules.json", "../config/testdata/markets.json")
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	now := time.Date(2025, 6, 15, 12, 0, 0, 0, time.UTC)
+	txns := GenerateTransactions(500, now, cfg.Markets)
+
+	if len(txns) != 500 {
+		t.Fatalf("generated %d transactions, want 500", len(txns))
+	}
+	if !reflect.DeepEqual(txns, GenerateTransactions(500, now, cfg.Markets)) {
+		t.Error("GenerateTransactions() is not deterministic")
+	}
+
+	perCountry := make(map[model.Country]int)
+	for _, tx := range txns {
+		perCountry[tx.Country]++
+		c, ok := cfg.CountryByCode(tx.Country)
+		if !ok {
+			t.Errorf("%s in country %s, which the markets don't define", tx.ID, tx.Country)
+			continue
+		}
+		if tx.Currency != c.Currency {
+			t.Errorf("%s in %s, want the country's currency %s", tx.ID, tx.Currency, c.Currency)
+		}
+		if tx.Timestamp.After(now) {
+			t.Errorf("%s at %s, after now", tx.ID, tx.Timestamp)
+		}
+
+		for _, cur := range cfg.Markets.Currencies {
+			if cur.Code != tx.Currency || cur.TestData == nil {
+				continue
+			}
+			if tx.Amount < cur.TestData.MinAmount || tx.Amount > cur.TestData.MaxAmount {
+				t.Errorf("%s amount %s outside %s test_data range %s-%s", tx.ID, tx.Amount, cur.Code, cur.TestData.MinAmount, cur.TestData.MaxAmount)
+			}
+			if step := int64(math.Pow10(4 - cur.MinorUnits)); int64(tx.Amount)%step != 0 {
+				t.Errorf("%s amount %s has more than %d decimals", tx.ID, tx.Amount, cur.MinorUnits)
+			}
+		}
+
+		if strings.HasPrefix(tx.ID, "txn_edge_") {
+			continue
+		}
+		if !slices.ContainsFunc(c.TestData.PaymentMethods, func(w model.WeightedPaymentMethod) bool { return w.Method == tx.PaymentMethod }) {
+			t.Errorf("%s paid with %s, not one of %s's test_data methods", tx.ID, tx.PaymentMethod, tx.Country)
+		}
+		if !slices.ContainsFunc(c.TestData.Processors, func(w model.WeightedProcessor) bool { return w.ProcessorID == tx.ProcessorID }) {
+			t.Errorf("%s on %s, not one of %s's test_data processors", tx.ID, tx.ProcessorID, tx.Country)
+		}
+	}
+
+	if perCountry["CL"] < 150 || perCountry["BR"] < 150 {
+		t.Errorf("per country = %v, want both even-weighted countries well represented", perCountry)
+	}
+	for _, code := range []model.Country{model.CountryMX, model.CountryCO} {
+		if perCountry[code] > 0 {
+			t.Errorf("%d transactions in %s, want none for a country the markets leave out", perCountry[code], code)
+		}
+	}
+}
+
+func TestGenerateTransactions_SkipsCountriesWithoutTestData(t *testing.T) {
+	t.Parallel()
+
+	now := time.Date(2025, 6, 15, 12, 0, 0, 0, time.UTC)
+	markets := model.MarketConfig{
+		Currencies: []model.CurrencyConfig{{Code: model.CurrencyBRL, MinorUnits: 2}},
+		Countries:  []model.CountryConfig{{Code: model.CountryBR, Currency: model.CurrencyBRL}},
+	}
+
+	txns := GenerateTransactions(50, now, markets)
+	if len(txns) == 0 || len(txns) == 50 {
+		t.Fatalf("generated %d transactions, want only the BR edge cases", len(txns))
+	}
+	for _, tx := range txns {
+		if tx.Country != model.CountryBR || !slices.Contains([]string{"paybr", "globalpay", "quickrefund"}, tx.ProcessorID) {
+			t.Errorf("%s = %s on %s, want a fixed BR edge case", tx.ID, tx.Country, tx.ProcessorID)
+		}
+	}
+}
@@ -1,15 +1,29 @@
 package main
 
 import (
+	"context"
+	"database/sql"
 	"fmt"
 	"log"
 	"net/http"
+	"net/http/httptest"
 	"os"
+	"os/signal"
+	"strings"
+	"syscall"
 	"time"
+	_ "time/tzdata"
+
+	_ "github.com/lib/pq"
 
 	internalconfig "github.com/ivanjtm/YunoChallenge/internal/config"
+	"github.com/ivanjtm/YunoChallenge/internal/fx"
 	"github.com/ivanjtm/YunoChallenge/internal/handler"
+	"github.com/ivanjtm/YunoChallenge/internal/model"
+	"github.com/ivanjtm/YunoChallenge/internal/money"
+	"github.com/ivanjtm/YunoChallenge/internal/processor"
 	"github.com/ivanjtm/YunoChallenge/internal/quota"
+	"github.com/ivanjtm/YunoChallenge/internal/refund"
 	"github.com/ivanjtm/YunoChallenge/internal/router"
 	"github.com/ivanjtm/YunoChallenge/internal/testdata"
 )
@@ -20,38 +34,114 @@ func main() {
 		port = "8080"
 	}
 
+	log.Println("Loading configuration...")
+	cfg, err := internalconfig.Load("config/processors.json", "config/rules.json", "config/markets.json")
+	if err != nil {
+		log.Fatalf("Failed to load configuration: %v", err)
+	}
+	rounding, err := money.ParseRoundingMode(os.Getenv("ROUNDING_MODE"))
+	if err != nil {
+		log.Fatalf("Invalid ROUNDING_MODE: %v", err)
+	}
+	money.SetRoundingMode(rounding)
+	money.SetMinorUnits(cfg.MinorUnits())
+
 	txnPath := "data/transactions.json"
 	if _, err := os.Stat(txnPath); os.IsNotExist(err) {
 		log.Println("Generating test transaction data...")
-		if err := testdata.GenerateAndSave(txnPath, 200, time.Now()); err != nil {
+		if err := testdata.GenerateAndSave(txnPath, 200, time.Now(), cfg.Markets); err != nil {
 			log.Fatalf("Failed to generate test data: %v", err)
 		}
 		log.Println("Generated 200 test transactions at", txnPath)
 	}
+	if err := cfg.LoadTransactions(txnPath); err != nil {
+		log.Fatalf("Failed to load configuration: %v", err)
+	}
+	log.Printf("Loaded %d countries, %d payment methods, %d processors, %d rules, %d transactions",
+		len(cfg.Markets.Countries), len(cfg.Markets.PaymentMethods), len(cfg.Processors), len(cfg.Rules), len(cfg.Transactions))
 
-	log.Println("Loading configuration...")
-	cfg, err := internalconfig.LoadWithTransactions("config/processors.json", "config/rules.json", txnPath)
+	clients, err := newProcessorClients()
 	if err != nil {
-		log.Fatalf("Failed to load configuration: %v", err)
+		log.Fatalf("Invalid processor configuration: %v", err)
+	}
+	if err := clients.Check(cfg); err != nil {
+		log.Fatalf("Invalid processor configuration: %v", err)
 	}
-	log.Printf("Loaded %d processors, %d rules, %d transactions", len(cfg.Processors), len(cfg.Rules), len(cfg.Transactions))
 
+	quotaTracker, err := newQuotaTracker(cfg.Processors)
+	if err != nil {
+		log.Fatalf("Failed to open quota store: %v", err)
+	}
+	quotaTracker.ReservationTTL = durationEnv("QUOTA_RESERVATION_TTL", quota.DefaultReservationTTL)
 	routerEngine := router.NewRouter(cfg.Processors, cfg.Rules)
-	quotaTracker := quota.NewTracker(cfg.Processors)
+	routerEngine.Quota = quotaTracker
+	routerEngine.Apply(routerConfig(cfg))
 
-	healthH := &handler.HealthHandler{Config: cfg}
-	refundH := &handler.RefundHandler{Router: routerEngine}
+	defer clients.Close()
+	clients.Sync(cfg.Processors)
+
+	watcher := internalconfig.NewWatcher(cfg, "config/processors.json", "config/rules.json", "config/markets.json")
+	watcher.Validate = clients.Check
+	watcher.OnReload = func(next *internalconfig.AppConfig) {
+		money.SetMinorUnits(next.MinorUnits())
+		clients.Sync(next.Processors)
+		quotaTracker.SetProcessors(next.Processors)
+		routerEngine.Apply(routerConfig(next))
+		log.Printf("Reloaded configuration %s: %d processors, %d rules", next.Version, len(next.Processors), len(next.Rules))
+	}
+	watcher.OnError = func(err error) {
+		log.Printf("Config reload failed, keeping version %s: %v", watcher.Current().Version, err)
+	}
+	if interval := reloadInterval(); interval > 0 {
+		go watcher.Poll(context.Background(), interval)
+	}
+	hup := make(chan os.Signal, 1)
+	signal.Notify(hup, syscall.SIGHUP)
+	go func() {
+		for range hup {
+			log.Println("SIGHUP received, reloading configuration...")
+			watcher.Reload()
+		}
+	}()
+
+	fxProvider, err := fx.NewFileProvider("config/fx_rates.json")
+	if err != nil {
+		log.Fatalf("Failed to load FX rates: %v", err)
+	}
+	routerEngine.FX = fxProvider
+	if cur := os.Getenv("REPORTING_CURRENCY"); cur != "" {
+		routerEngine.ReportingCurrency = model.Currency(cur)
+	}
+
+	refundStore, err := refund.NewFileStore("data/refunds.json")
+	if err != nil {
+		log.Fatalf("Failed to open refund store: %v", err)
+	}
+	refundService := refund.NewService(refundStore, clients.reg, quotaTracker)
+
+	healthH := &handler.HealthHandler{Configs: watcher}
+	refundH := &handler.RefundHandler{Router: routerEngine, Refunds: refundService}
 	batchH := &handler.BatchHandler{Router: routerEngine}
 	quotaH := &handler.QuotaHandler{Tracker: quotaTracker}
-	historicalH := &handler.HistoricalHandler{Router: routerEngine}
+	historicalH := &handler.HistoricalHandler{Router: routerEngine, Configs: watcher}
+	refundsH := &handler.RefundsHandler{Router: routerEngine, Refunds: refundService}
+
+	idempotent := handler.IdempotencyMiddleware(handler.NewIdempotencyStore(24 * time.Hour))
 
 	mux := http.NewServeMux()
 	mux.HandleFunc("GET /api/v1/health", healthH.Handle)
-	mux.HandleFunc("POST /api/v1/refund", refundH.Handle)
-	mux.HandleFunc("POST /api/v1/refund/batch", batchH.Handle)
+	mux.Handle("POST /api/v1/refund", idempotent(http.HandlerFunc(refundH.Handle)))
+	mux.Handle("POST /api/v1/refund/batch", idempotent(http.HandlerFunc(batchH.Handle)))
 	mux.HandleFunc("POST /api/v1/simulation/quota", quotaH.Set)
 	mux.HandleFunc("DELETE /api/v1/simulation/quota", quotaH.Reset)
 	mux.HandleFunc("POST /api/v1/analysis/historical", historicalH.Handle)
+	mux.Handle("POST /api/v1/refunds", idempotent(http.HandlerFunc(refundsH.Create)))
+	mux.HandleFunc("GET /api/v1/refunds", refundsH.List)
+	mux.HandleFunc("GET /api/v1/refunds/{id}", refundsH.Get)
+	mux.HandleFunc("POST /api/v1/refunds/{id}/cancel", refundsH.Cancel)
+	mux.HandleFunc("POST /api/v1/refunds/{id}/refresh", refundsH.Refresh)
+	mux.HandleFunc("POST /api/v1/refunds/{id}/status", refundsH.UpdateStatus)
+	mux.HandleFunc("GET /api/v1/transactions/{id}/refunds", refundsH.Summary)
 
 	srv := handler.Chain(mux,
 		handler.RecoveryMiddleware,
@@ -68,8 +158,160 @@ func main() {
 	log.Printf("  POST /api/v1/simulation/quota")
 	log.Printf("  DELETE /api/v1/simulation/quota")
 	log.Printf("  POST /api/v1/analysis/historical")
+	log.Printf("  POST /api/v1/refunds")
+	log.Printf("  GET  /api/v1/refunds")
+	log.Printf("  GET  /api/v1/refunds/{id}")
+	log.Printf("  POST /api/v1/refunds/{id}/cancel")
+	log.Printf("  POST /api/v1/refunds/{id}/refresh")
+	log.Printf("  POST /api/v1/refunds/{id}/status")
+	log.Printf("  GET  /api/v1/transactions/{id}/refunds")
 
 	if err := http.ListenAndServe(addr, srv); err != nil {
 		log.Fatalf("Server failed: %v", err)
 	}
 }
+
+func reloadInterval() time.Duration {
+	return durationEnv("CONFIG_RELOAD_INTERVAL", 30*time.Second)
+}
+
+func durationEnv(name string, def time.Duration) time.Duration {
+	v := os.Getenv(name)
+	if v == "" {
+		return def
+	}
+	d, err := time.ParseDuration(v)
+	if err != nil {
+		log.Fatalf("Invalid %s %q: %v", name, v, err)
+	}
+	return d
+}
+
+func newQuotaTracker(processors []model.Processor) (*quota.Tracker, error) {
+	var store quota.Store
+	var where string
+	switch mode := os.Getenv("QUOTA_STORE"); mode {
+	case "memory":
+		return quota.NewTracker(processors), nil
+	case "", "file":
+		fs, err := quota.NewFileStore("data/quota.log", quota.Horizon(processors, time.Now()))
+		if err != nil {
+			return nil, err
+		}
+		store, where = fs, "data/quota.log"
+	case "sql":
+		driver := os.Getenv("QUOTA_SQL_DRIVER")
+		if driver == "" {
+			driver = "postgres"
+		}
+		dsn := os.Getenv("QUOTA_SQL_DSN")
+		if dsn == "" {
+			return nil, fmt.Errorf("QUOTA_STORE=sql needs QUOTA_SQL_DSN")
+		}
+		db, err := sql.Open(driver, dsn)
+		if err != nil {
+			return nil, fmt.Errorf("open quota database: %w", err)
+		}
+		ss, err := quota.NewSQLStore(db)
+		if err != nil {
+			db.Close()
+			return nil, err
+		}
+		store, where = ss, driver+" database"
+	default:
+		return nil, fmt.Errorf("unknown QUOTA_STORE %q (want file, sql or memory)", mode)
+	}
+
+	tracker, err := quota.NewPersistentTracker(processors, store, time.Now())
+	if err != nil {
+		return nil, err
+	}
+	tracker.OnSyncError = func(err error) {
+		log.Printf("Quota sync failed: %v", err)
+	}
+	if interval := durationEnv("QUOTA_SYNC_INTERVAL", 10*time.Second); interval > 0 {
+		go tracker.Poll(context.Background(), interval)
+	}
+	log.Printf("Quota usage persisted to %s", where)
+	return tracker, nil
+}
+
+func routerConfig(cfg *internalconfig.AppConfig) router.Config {
+	return router.Config{
+		Processors:       cfg.Processors,
+		Rules:            cfg.Rules,
+		Scorers:          map[model.RoutingStrategy]router.Scorer{model.StrategyBalanced: router.BalancedScorer{DayValues: cfg.DayValues()}},
+		Taxes:            cfg.Taxes(),
+		ReversalPolicies: cfg.ReversalPolicies(),
+		Calendars:        cfg.Calendars(),
+	}
+}
+
+type processorClients struct {
+	mockMode  bool
+	reg       *processor.Registry
+	endpoints map[string]string
+	mock      *processor.Mock
+	mockSrv   *httptest.Server
+}
+
+func newProcessorClients() (*processorClients, error) {
+	c := &processorClients{reg: processor.NewRegistry(), endpoints: make(map[string]string)}
+	switch mode := os.Getenv("PROCESSOR_MODE"); mode {
+	case "", "live":
+	case "mock":
+		c.mockMode = true
+	default:
+		return nil, fmt.Errorf("unknown PROCESSOR_MODE %q (want live or mock)", mode)
+	}
+	return c, nil
+}
+
+func (c *processorClients) Check(cfg *internalconfig.AppConfig) error {
+	if c.mockMode {
+		return nil
+	}
+	var missing []string
+	for _, p := range cfg.Processors {
+		if p.Endpoint == "" {
+			missing = append(missing, p.ID)
+		}
+	}
+	if len(missing) > 0 {
+		return fmt.Errorf("processors without an endpoint: %s (set PROCESSOR_MODE=mock to use the built-in mock)", strings.Join(missing, ", "))
+	}
+	return nil
+}
+
+func (c *processorClients) Sync(processors []model.Processor) {
+	const timeout = 10 * time.Second
+
+	var mocked []string
+	for _, p := range processors {
+		if endpoint, ok := c.endpoints[p.ID]; ok && endpoint == p.Endpoint {
+			continue
+		}
+		c.endpoints[p.ID] = p.Endpoint
+		if p.Endpoint != "" {
+			c.reg.Register(p.ID, processor.NewHTTPClient(p.ID, p.Endpoint, timeout))
+			continue
+		}
+		if c.mockSrv == nil {
+			c.mock = processor.NewMock(make(map[string]processor.MockProfile), 42)
+			c.mockSrv = httptest.NewServer(c.mock)
+			log.Printf("Mock processor server running at %s", c.mockSrv.URL)
+		}
+		c.mock.SetProfile(p.ID, processor.DefaultMockProfiles()[p.ID])
+		c.reg.Register(p.ID, processor.NewHTTPClient(p.ID, c.mockSrv.URL+"/"+p.ID, timeout))
+		mocked = append(mocked, p.ID)
+	}
+	if len(mocked) > 0 {
+		log.Printf("Mocking processors: %s", strings.Join(mocked, ", "))
+	}
+}
+
+func (c *processorClients) Close() {
+	if c.mockSrv != nil {
+		c.mockSrv.Close()
+	}
+}